@@ -33,6 +33,8 @@ import (
 	"syscall"
 	"time"
 
+	_ "github.com/jackc/pgx/v5/stdlib" // driver "pgx" untuk VECTOR_STORE=pgvector
+
 	"mcp-oilgas/internal/app"
 	"mcp-oilgas/internal/middleware"
 )
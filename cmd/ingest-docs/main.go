@@ -3,45 +3,49 @@
 package main
 
 import (
-	"bytes"
 	"context"
 	"database/sql"
-	"encoding/json"
 	"flag"
 	"fmt"
-	"io"
-	"net/http"
 	"os"
 	"strings"
 	"time"
 
 	_ "github.com/go-sql-driver/mysql"
-)
 
-type embeddingReq struct {
-	Model string   `json:"model"`
-	Input []string `json:"input"`
-}
-type embeddingRes struct {
-	Data []struct {
-		Embedding []float32 `json:"embedding"`
-	} `json:"data"`
-}
+	"mcp-oilgas/internal/llm"
+	"mcp-oilgas/internal/repositories/embeddings"
+	"mcp-oilgas/pkg/vector"
+)
 
 func main() {
-	var dsn, model, where string
+	var dsn, provider, model, version, baseURL, where, storage string
 	var batch int
 	flag.StringVar(&dsn, "dsn", "mcpuser:secret@tcp(mysql:3306)/mcp?parseTime=true&multiStatements=true", "MySQL DSN")
-	flag.StringVar(&model, "model", "text-embedding-3-small", "OpenAI embeddings model")
+	flag.StringVar(&provider, "provider", "", "embeddings provider: openai|local (default: EMBED_PROVIDER / openai; local needs no network)")
+	flag.StringVar(&model, "model", "", "embeddings model (default: OPENAI_EMBED_MODEL / text-embedding-3-small, local-ngram-v1 for local)")
+	flag.StringVar(&version, "version", embeddings.ModelFromEnv().Version, "embedding version label stored per chunk (default: EMBED_VERSION)")
+	flag.StringVar(&baseURL, "base-url", "", "OpenAI-compatible embeddings endpoint (default: EMBED_BASE_URL / OPENAI_BASE_URL)")
 	flag.IntVar(&batch, "batch", 128, "batch size")
-	flag.StringVar(&where, "where", "embedding IS NULL", "extra WHERE filter for selection")
+	flag.StringVar(&where, "where", "embedding IS NULL AND embedding_bin IS NULL", "extra WHERE filter for selection")
+	flag.StringVar(&storage, "storage", embeddings.StorageFromEnv(), "embedding storage format: f32|i8|json (default: EMBED_STORAGE / f32)")
 	flag.Parse()
+	if !vector.ValidFormat(storage) {
+		must(fmt.Errorf("invalid -storage %q", storage))
+	}
 
-	apiKey := os.Getenv("OPENAI_API_KEY")
-	if apiKey == "" {
-		fmt.Println("OPENAI_API_KEY empty")
-		os.Exit(1)
+	if provider != "" {
+		os.Setenv("EMBED_PROVIDER", provider) // default model & dimensi mengikuti provider
+	}
+	cfg := llm.ConfigFromEnv()
+	if model != "" {
+		cfg.EmbedModel = model
 	}
+	if baseURL != "" {
+		cfg.EmbedBaseURL = baseURL
+	}
+	embedder, err := llm.NewEmbedder(cfg)
+	must(err)
 
 	db, err := sql.Open("mysql", dsn)
 	must(err)
@@ -75,12 +79,12 @@ func main() {
 			return
 		}
 
-		// call OpenAI
+		// call embeddings provider
 		inputs := make([]string, len(batchRows))
 		for i, r := range batchRows {
 			inputs[i] = r.text
 		}
-		embeds, err := fetchEmbeddings(ctx, apiKey, model, inputs)
+		embeds, err := embedder.Embed(ctx, inputs)
 		must(err)
 		if len(embeds) != len(batchRows) {
 			must(fmt.Errorf("embedding count mismatch %d != %d", len(embeds), len(batchRows)))
@@ -88,10 +92,13 @@ func main() {
 
 		// save
 		tx, err := db.BeginTx(ctx, nil); must(err)
-		stmt, err := tx.PrepareContext(ctx, `UPDATE doc_chunks SET embedding = ? WHERE id = ?`); must(err)
+		stmt, err := tx.PrepareContext(ctx, `UPDATE doc_chunks SET `+embeddings.SetColumns+` WHERE id = ?`); must(err)
 		for i, r := range batchRows {
-			js, _ := json.Marshal(embeds[i])
-			if _, err := stmt.ExecContext(ctx, string(js), r.id); err != nil {
+			v, err := embeddings.Encode(embeds[i], embeddings.Model{Name: embedder.EmbedModel(), Version: version}, storage)
+			if err != nil {
+				_ = tx.Rollback(); must(err)
+			}
+			if _, err := stmt.ExecContext(ctx, v.JSON, v.Bin, v.Model, v.Dim, v.Version, r.id); err != nil {
 				_ = tx.Rollback(); must(err)
 			}
 		}
@@ -104,31 +111,6 @@ func main() {
 	}
 }
 
-func fetchEmbeddings(ctx context.Context, apiKey, model string, input []string) ([][]float32, error) {
-	reqBody, _ := json.Marshal(embeddingReq{Model: model, Input: input})
-	req, _ := http.NewRequestWithContext(ctx, "POST", "https://api.openai.com/v1/embeddings", bytes.NewReader(reqBody))
-	req.Header.Set("Authorization", "Bearer "+apiKey)
-	req.Header.Set("Content-Type", "application/json")
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-	if resp.StatusCode/100 != 2 {
-		b, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("openai error: %s", string(b))
-	}
-	var out embeddingRes
-	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
-		return nil, err
-	}
-	vecs := make([][]float32, len(out.Data))
-	for i := range out.Data {
-		vecs[i] = out.Data[i].Embedding
-	}
-	return vecs, nil
-}
-
 func must(err error) {
 	if err != nil {
 		fmt.Fprintln(os.Stderr, "ERR:", err)
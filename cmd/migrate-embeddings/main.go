@@ -0,0 +1,180 @@
+// cmd/migrate-embeddings/main.go
+// Konversi doc_chunks.embedding (array JSON) → embedding_bin (float32 / int8 terkuantisasi) per batch.
+// Aman dihentikan & dijalankan ulang: baris yang sudah berformat target dilewati.
+//
+//	go run ./cmd/migrate-embeddings -format f32            # JSON → f32, kolom JSON dikosongkan
+//	go run ./cmd/migrate-embeddings -format i8 -reencode   # termasuk baris biner f32 → i8
+//	go run ./cmd/migrate-embeddings -dry-run               # hanya hitung
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	_ "github.com/go-sql-driver/mysql"
+
+	"mcp-oilgas/internal/llm"
+	"mcp-oilgas/internal/repositories/embeddings"
+	"mcp-oilgas/pkg/vector"
+)
+
+func main() {
+	var (
+		dsn, format, model string
+		batch              int
+		keepJSON, reencode bool
+		dryRun             bool
+		pause              time.Duration
+	)
+	flag.StringVar(&dsn, "dsn", envOr("DB_DSN", "mcpuser:secret@tcp(mysql:3306)/mcp?parseTime=true&multiStatements=true"), "MySQL DSN")
+	flag.StringVar(&format, "format", embeddings.StorageFromEnv(), "target format: f32|i8 (default: EMBED_STORAGE / f32)")
+	flag.StringVar(&model, "model", llm.ConfigFromEnv().EmbedModel, "model dicatat untuk baris tanpa embedding_model")
+	flag.IntVar(&batch, "batch", 500, "rows per transaction")
+	flag.BoolVar(&keepJSON, "keep-json", false, "jangan kosongkan kolom JSON setelah konversi")
+	flag.BoolVar(&reencode, "reencode", false, "konversi juga baris biner yang formatnya berbeda dari -format")
+	flag.BoolVar(&dryRun, "dry-run", false, "hanya hitung baris yang akan dikonversi")
+	flag.DurationVar(&pause, "pause", 100*time.Millisecond, "jeda antar batch (mengurangi beban DB)")
+	flag.Parse()
+
+	if format != vector.FormatF32 && format != vector.FormatI8 {
+		fail(fmt.Errorf("-format must be f32 or i8, got %q", format))
+	}
+	if batch <= 0 {
+		batch = 500
+	}
+
+	db, err := sql.Open("mysql", dsn)
+	if err != nil {
+		fail(err)
+	}
+	defer db.Close()
+	ctx := context.Background()
+
+	// baris JSON yang belum punya BLOB; dengan -reencode juga BLOB berformat lain (byte pertama = tag format)
+	tag := "01"
+	if format == vector.FormatI8 {
+		tag = "02"
+	}
+	pending := `(embedding_bin IS NULL AND embedding IS NOT NULL)`
+	if reencode {
+		pending = `(` + pending + ` OR (embedding_bin IS NOT NULL AND HEX(LEFT(embedding_bin, 1)) <> '` + tag + `'))`
+	}
+
+	var total int
+	if err := db.QueryRowContext(ctx, `SELECT COUNT(*) FROM doc_chunks WHERE `+pending).Scan(&total); err != nil {
+		fail(err)
+	}
+	fmt.Printf("pending: %d rows (format=%s, keep-json=%v)\n", total, format, keepJSON)
+	if dryRun || total == 0 {
+		return
+	}
+
+	set := `embedding_bin = ?, embedding_dim = ?, embedding_model = COALESCE(embedding_model, ?)`
+	if !keepJSON {
+		set += `, embedding = NULL`
+	}
+
+	var (
+		lastID                  int64
+		done, skipped           int
+		bytesBefore, bytesAfter int64
+		started                 = time.Now()
+	)
+	for {
+		rows, err := db.QueryContext(ctx, `
+			SELECT id, `+embeddings.Columns+` FROM doc_chunks
+			 WHERE id > ? AND `+pending+`
+			 ORDER BY id LIMIT ?`, lastID, batch)
+		if err != nil {
+			fail(err)
+		}
+		type item struct {
+			id  int64
+			bin []byte
+			dim int
+		}
+		var items []item
+		n := 0
+		for rows.Next() {
+			var (
+				id       int64
+				bin, raw []byte
+			)
+			if err := rows.Scan(&id, &bin, &raw); err != nil {
+				fail(err)
+			}
+			n++
+			lastID = id
+			vec, err := embeddings.Decode(bin, raw)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "skip id=%d: %v\n", id, err)
+				skipped++
+				continue
+			}
+			enc, _ := vector.Encode(vec, format)
+			bytesBefore += int64(len(raw) + len(bin))
+			bytesAfter += int64(len(enc))
+			items = append(items, item{id, enc, len(vec)})
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			fail(err)
+		}
+		if n == 0 {
+			break
+		}
+
+		tx, err := db.BeginTx(ctx, nil)
+		if err != nil {
+			fail(err)
+		}
+		stmt, err := tx.PrepareContext(ctx, `UPDATE doc_chunks SET `+set+` WHERE id = ?`)
+		if err != nil {
+			_ = tx.Rollback()
+			fail(err)
+		}
+		for _, it := range items {
+			if _, err := stmt.ExecContext(ctx, it.bin, it.dim, nullable(model), it.id); err != nil {
+				_ = tx.Rollback()
+				fail(fmt.Errorf("update id=%d: %w", it.id, err))
+			}
+		}
+		stmt.Close()
+		if err := tx.Commit(); err != nil {
+			fail(err)
+		}
+		done += len(items)
+		fmt.Printf("converted %d/%d (last id %d)\n", done, total, lastID)
+		time.Sleep(pause)
+	}
+
+	ratio := 0.0
+	if bytesBefore > 0 {
+		ratio = float64(bytesAfter) / float64(bytesBefore)
+	}
+	fmt.Printf("done: %d converted, %d skipped in %s; %d → %d bytes (%.0f%%)\n",
+		done, skipped, time.Since(started).Round(time.Second), bytesBefore, bytesAfter, ratio*100)
+}
+
+func nullable(s string) any {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+func envOr(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, "ERR:", err)
+	os.Exit(1)
+}
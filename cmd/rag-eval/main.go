@@ -0,0 +1,302 @@
+// cmd/rag-eval/main.go
+// Evaluasi kualitas retrieval atas dataset berlabel (query → chunk relevan): membandingkan BM25 saja,
+// cosine saja, hybrid dengan beberapa alpha/fusi (mysql.RAGRepo) dan prefilter search.RAGRepo, lalu
+// melaporkan recall@k, MRR dan nDCG@k sebagai tabel + JSON (untuk dilacak dari waktu ke waktu).
+//
+//	go run ./cmd/rag-eval -dataset configs/rag_eval.example.jsonl
+//	go run ./cmd/rag-eval -dataset evals/hse.jsonl -alphas 0.4,0.6,0.8 -prefilter 100,200 -ann \
+//	    -json evals/last.json -history evals/history.jsonl
+//	go run ./cmd/rag-eval -dataset evals/hse.jsonl -lexical -bm25-only   # FULLTEXT vs indeks BM25 Go
+//
+// Format dataset: lihat internal/rageval. Nilai terbaik dipasang lewat RAG_ALPHA / RAG_PREFILTER / RAG_FUSION
+// / LEXICAL_SEARCH.
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	_ "github.com/go-sql-driver/mysql"
+
+	"mcp-oilgas/internal/analysis"
+	"mcp-oilgas/internal/lexindex"
+	"mcp-oilgas/internal/llm"
+	"mcp-oilgas/internal/rageval"
+	mysqlrepo "mcp-oilgas/internal/repositories/mysql"
+	"mcp-oilgas/internal/repositories/search"
+	"mcp-oilgas/internal/vectorindex"
+)
+
+func main() {
+	var (
+		dsn, dataset, provider   string
+		ksFlag, alphas, prefs    string
+		only, jsonOut, history   string
+		rrf, ann, perQuery, bm25 bool
+		lexical                  bool
+		timeout                  time.Duration
+	)
+	flag.StringVar(&dsn, "dsn", envOr("DB_DSN", "mcpuser:secret@tcp(mysql:3306)/mcp?parseTime=true&multiStatements=true"), "MySQL DSN")
+	flag.StringVar(&dataset, "dataset", "", "dataset berlabel (JSONL atau array JSON)")
+	flag.StringVar(&provider, "provider", "", "provider embedding query: openai|local (default EMBED_PROVIDER; harus sama dengan saat ingest)")
+	flag.StringVar(&ksFlag, "k", "1,3,5,10", "cut-off recall/nDCG")
+	flag.StringVar(&alphas, "alphas", "0.3,0.5,0.6,0.7,0.8", "alpha hybrid linear yang dibandingkan (bobot BM25)")
+	flag.StringVar(&prefs, "prefilter", "50,100,200", "ukuran prefilter BM25 search.RAGRepo yang dibandingkan (kosong = lewati)")
+	flag.BoolVar(&rrf, "rrf", true, "sertakan fusi rrf & weighted_rrf (alpha RAG_ALPHA)")
+	flag.BoolVar(&ann, "ann", false, "bangun indeks ANN in-process (seperti API) sebagai sumber kandidat semantik")
+	flag.BoolVar(&lexical, "lexical", false, "bangun indeks BM25 Go (analyzer Indonesia/Inggris) dan bandingkan LEXICAL_SEARCH=go|both")
+	flag.BoolVar(&bm25, "bm25-only", false, "hanya BM25 (tanpa embedder)")
+	flag.StringVar(&only, "only", "", "hanya konfigurasi yang namanya mengandung salah satu substring (dipisah koma)")
+	flag.StringVar(&jsonOut, "json", "", "tulis laporan JSON ke file (\"-\" = stdout)")
+	flag.StringVar(&history, "history", "", "tambahkan laporan sebagai satu baris JSON ke file riwayat")
+	flag.BoolVar(&perQuery, "per-query", false, "sertakan hasil per query di JSON")
+	flag.DurationVar(&timeout, "timeout", 30*time.Second, "batas waktu per query")
+	flag.Parse()
+
+	if dataset == "" {
+		fail(fmt.Errorf("-dataset is required"))
+	}
+	cases, err := rageval.LoadDataset(dataset)
+	if err != nil {
+		fail(err)
+	}
+	ks, err := parseInts(ksFlag)
+	if err != nil || len(ks) == 0 {
+		fail(fmt.Errorf("invalid -k %q", ksFlag))
+	}
+
+	db, err := sql.Open("mysql", dsn)
+	if err != nil {
+		fail(err)
+	}
+	defer db.Close()
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if err := db.PingContext(ctx); err != nil {
+		fail(err)
+	}
+
+	repo := &mysqlrepo.RAGRepo{DB: db}
+	cfgs := []rageval.Config{{Name: "mysql/bm25", Params: map[string]any{"alpha": 1.0}, Retrieve: rageval.BM25(repo)}}
+	env := map[string]any{"rag_alpha": mysqlrepo.AlphaFromEnv(), "rag_prefilter": search.PrefilterFromEnv()}
+
+	// repo per mode leksikal (fulltext = repo di atas)
+	var lexRepos []*mysqlrepo.RAGRepo
+	if lexical {
+		an, err := analysis.New(analysis.ConfigFromEnv())
+		if err != nil {
+			fail(fmt.Errorf("analyzer: %w", err))
+		}
+		lx := lexindex.New(db, lexindex.ConfigFromEnv(), an)
+		log.Printf("building lexical index ...")
+		res, err := lx.Sync(ctx)
+		if err != nil {
+			fail(fmt.Errorf("lexical index: %w", err))
+		}
+		log.Printf("lexical index ready: %+v", res)
+		for _, m := range []string{mysqlrepo.LexicalGo, mysqlrepo.LexicalBoth} {
+			lr := &mysqlrepo.RAGRepo{DB: db, Lexical: lx, LexicalMode: m}
+			lexRepos = append(lexRepos, lr)
+			cfgs = append(cfgs, rageval.Config{Name: "mysql/bm25-" + m, Params: map[string]any{"alpha": 1.0, "lexical": m},
+				Retrieve: rageval.BM25(lr)})
+		}
+		env["lexical"] = true
+	}
+
+	if !bm25 {
+		if provider != "" {
+			os.Setenv("EMBED_PROVIDER", provider) // default model & dimensi (EMBED_DIM) mengikuti provider
+		}
+		e, err := llm.NewEmbedder(llm.ConfigFromEnv())
+		if err != nil {
+			fail(fmt.Errorf("embedder: %w (use -bm25-only to skip dense configs)", err))
+		}
+		emb := rageval.NewCachedEmbedder(e)
+		env["embed_model"] = emb.EmbedModel()
+
+		if ann {
+			idx := vectorindex.New(db, vectorindex.ConfigFromEnv())
+			log.Printf("building ANN index ...")
+			res, err := idx.Sync(ctx)
+			if err != nil {
+				fail(fmt.Errorf("ann index: %w", err))
+			}
+			log.Printf("ANN index ready: %+v", res)
+			repo.ANN = idx
+			for _, lr := range lexRepos {
+				lr.ANN = idx
+			}
+			env["ann"] = true
+		}
+
+		linear := mysqlrepo.HybridOptions{Fusion: mysqlrepo.FusionLinear}
+		cfgs = append(cfgs, rageval.Config{Name: "mysql/cosine", Params: map[string]any{"alpha": 0.0},
+			Retrieve: rageval.Hybrid(repo, emb, 0, linear)})
+		as, err := parseFloats(alphas)
+		if err != nil {
+			fail(fmt.Errorf("invalid -alphas: %w", err))
+		}
+		for _, a := range as {
+			cfgs = append(cfgs, rageval.Config{Name: fmt.Sprintf("mysql/hybrid a=%.2f", a),
+				Params: map[string]any{"alpha": a, "fusion": mysqlrepo.FusionLinear}, Retrieve: rageval.Hybrid(repo, emb, a, linear)})
+		}
+		for _, lr := range lexRepos {
+			a := mysqlrepo.AlphaFromEnv()
+			cfgs = append(cfgs, rageval.Config{Name: fmt.Sprintf("mysql/hybrid-%s a=%.2f", lr.LexicalMode, a),
+				Params:   map[string]any{"alpha": a, "fusion": mysqlrepo.FusionLinear, "lexical": lr.LexicalMode},
+				Retrieve: rageval.Hybrid(lr, emb, a, linear)})
+		}
+		if rrf {
+			a := mysqlrepo.AlphaFromEnv()
+			for _, f := range []string{mysqlrepo.FusionRRF, mysqlrepo.FusionWeightedRRF} {
+				cfgs = append(cfgs, rageval.Config{Name: fmt.Sprintf("mysql/%s a=%.2f", f, a),
+					Params:   map[string]any{"alpha": a, "fusion": f},
+					Retrieve: rageval.Hybrid(repo, emb, a, mysqlrepo.HybridOptions{Fusion: f})})
+			}
+		}
+		ps, err := parseInts(prefs)
+		if err != nil {
+			fail(fmt.Errorf("invalid -prefilter: %w", err))
+		}
+		for _, p := range ps {
+			cfgs = append(cfgs, rageval.Config{Name: fmt.Sprintf("search/prefilter=%d", p),
+				Params: map[string]any{"prefilter": p}, Retrieve: rageval.Search(search.NewRAGRepo(db, emb, p))})
+		}
+	}
+	cfgs = filterConfigs(cfgs, only)
+	if len(cfgs) == 0 {
+		fail(fmt.Errorf("no configuration matches -only %q", only))
+	}
+
+	log.Printf("evaluating %d cases × %d configs", len(cases), len(cfgs))
+	rep := rageval.Evaluate(ctx, cases, cfgs, rageval.Options{Ks: ks, PerQuery: perQuery, Timeout: timeout})
+	rep.Dataset, rep.Env = filepath.Base(dataset), env
+
+	out := os.Stdout
+	if jsonOut == "-" {
+		out = os.Stderr // stdout khusus JSON
+	}
+	if err := rep.WriteTable(out); err != nil {
+		fail(err)
+	}
+	if best, ok := rep.Best(); ok {
+		fmt.Fprintf(out, "\nbest (nDCG@%d): %s\n", rep.Ks[len(rep.Ks)-1], best.Name)
+	}
+	if jsonOut != "" {
+		if err := writeJSON(jsonOut, rep); err != nil {
+			fail(err)
+		}
+	}
+	if history != "" {
+		if err := appendHistory(history, rep); err != nil {
+			fail(err)
+		}
+	}
+}
+
+func filterConfigs(cfgs []rageval.Config, only string) []rageval.Config {
+	if strings.TrimSpace(only) == "" {
+		return cfgs
+	}
+	var out []rageval.Config
+	for _, c := range cfgs {
+		for _, s := range strings.Split(only, ",") {
+			if s = strings.TrimSpace(s); s != "" && strings.Contains(c.Name, s) {
+				out = append(out, c)
+				break
+			}
+		}
+	}
+	return out
+}
+
+func writeJSON(path string, rep rageval.Report) error {
+	b, err := json.MarshalIndent(rep, "", "  ")
+	if err != nil {
+		return err
+	}
+	if path == "-" {
+		_, err = fmt.Println(string(b))
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(b, '\n'), 0o644)
+}
+
+// appendHistory menambahkan ringkasan (tanpa hasil per query) sebagai satu baris JSONL.
+func appendHistory(path string, rep rageval.Report) error {
+	for i := range rep.Results {
+		rep.Results[i].PerQuery = nil
+	}
+	b, err := json.Marshal(rep)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(b, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func parseInts(s string) ([]int, error) {
+	var out []int
+	for _, p := range strings.Split(s, ",") {
+		if p = strings.TrimSpace(p); p == "" {
+			continue
+		}
+		n, err := strconv.Atoi(p)
+		if err != nil || n <= 0 {
+			return nil, fmt.Errorf("invalid value %q", p)
+		}
+		out = append(out, n)
+	}
+	return out, nil
+}
+
+func parseFloats(s string) ([]float64, error) {
+	var out []float64
+	for _, p := range strings.Split(s, ",") {
+		if p = strings.TrimSpace(p); p == "" {
+			continue
+		}
+		f, err := strconv.ParseFloat(p, 64)
+		if err != nil || f < 0 || f > 1 {
+			return nil, fmt.Errorf("invalid value %q (0..1)", p)
+		}
+		out = append(out, f)
+	}
+	return out, nil
+}
+
+func envOr(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, "ERR:", err)
+	os.Exit(1)
+}
@@ -0,0 +1,153 @@
+// cmd/reembed/main.go
+// Re-embedding doc_chunks ke model embedding baru melalui kolom bayangan embedding_next_*.
+// Job bisa dihentikan (Ctrl+C) dan dilanjutkan: checkpoint disimpan di tabel reembed_jobs.
+//
+//	go run ./cmd/reembed -model text-embedding-3-large -version v2 -dim 3072 -rps 5
+//	go run ./cmd/reembed -model text-embedding-3-large -version v2 -status
+//	go run ./cmd/reembed -model text-embedding-3-large -version v2 -cutover
+//
+// Selama migrasi query tetap memakai kolom aktif; EMBED_READ=next + EMBED_DIM baru membaca vektor
+// bayangan (dual-read). Setelah -cutover kembalikan EMBED_READ=current dan set OPENAI_EMBED_MODEL,
+// EMBED_VERSION, EMBED_DIM ke model baru.
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	_ "github.com/go-sql-driver/mysql"
+
+	"mcp-oilgas/internal/llm"
+	"mcp-oilgas/internal/reembed"
+	"mcp-oilgas/internal/repositories/embeddings"
+	"mcp-oilgas/pkg/vector"
+)
+
+func main() {
+	var (
+		dsn, provider   string
+		baseURL, apiKey string
+		cfg             reembed.Config
+		cutover, force  bool
+		status          bool
+	)
+	def := embeddings.ModelFromEnv()
+	flag.StringVar(&provider, "provider", llm.ConfigFromEnv().EmbedProvider, "provider embedding target: openai|local")
+	flag.StringVar(&dsn, "dsn", envOr("DB_DSN", "mcpuser:secret@tcp(mysql:3306)/mcp?parseTime=true&multiStatements=true"), "MySQL DSN")
+	flag.StringVar(&cfg.Model.Name, "model", def.Name, "model embedding target")
+	flag.StringVar(&cfg.Model.Version, "version", def.Version, "label versi model target")
+	flag.IntVar(&cfg.Dim, "dim", 0, "dimensi yang diharapkan (0 = ikuti hasil batch pertama)")
+	flag.StringVar(&baseURL, "base-url", os.Getenv("EMBED_BASE_URL"), "endpoint embedding kompatibel OpenAI (opsional)")
+	flag.StringVar(&apiKey, "api-key", os.Getenv("EMBED_API_KEY"), "API key endpoint embedding (default OPENAI_API_KEY)")
+	flag.StringVar(&cfg.Storage, "storage", embeddings.StorageFromEnv(), "format vektor: f32|i8")
+	flag.StringVar(&cfg.Name, "job", "", "nama job/checkpoint (default model@version)")
+	flag.IntVar(&cfg.Batch, "batch", 64, "chunk per panggilan embedding")
+	flag.IntVar(&cfg.Concurrency, "concurrency", 2, "worker paralel")
+	flag.Float64Var(&cfg.RPS, "rps", 0, "maksimal panggilan embedding per detik (0 = tanpa batas)")
+	flag.IntVar(&cfg.Retries, "retries", 5, "percobaan per batch (backoff eksponensial)")
+	flag.StringVar(&cfg.Where, "where", "", "filter SQL tambahan, mis. \"doc_id IN (SELECT id FROM documents WHERE collection='hse')\"")
+	flag.BoolVar(&cfg.Reset, "reset", false, "abaikan checkpoint, mulai dari id 0 (memproses ulang batch yang gagal)")
+	flag.BoolVar(&cutover, "cutover", false, "pindahkan vektor bayangan ke kolom aktif")
+	flag.BoolVar(&force, "force", false, "cutover walaupun masih ada chunk pending")
+	flag.BoolVar(&status, "status", false, "tampilkan cakupan model & checkpoint lalu keluar")
+	flag.Parse()
+
+	if cfg.Storage != vector.FormatF32 && cfg.Storage != vector.FormatI8 {
+		fail(fmt.Errorf("-storage must be f32 or i8, got %q", cfg.Storage))
+	}
+	if provider == llm.ProviderLocal && !flagSet("model") {
+		cfg.Model.Name = llm.LocalModel
+	}
+	if cfg.Model.Name == "" {
+		fail(fmt.Errorf("-model is required"))
+	}
+
+	db, err := sql.Open("mysql", dsn)
+	if err != nil {
+		fail(err)
+	}
+	defer db.Close()
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	r := &reembed.Runner{DB: db, Cfg: cfg, Logf: log.Printf}
+	switch {
+	case status:
+		printStatus(ctx, db, r)
+		return
+	case cutover:
+		n, err := r.Cutover(ctx, force)
+		if err != nil {
+			fail(err)
+		}
+		fmt.Printf("cutover: %d chunks now use %s; set OPENAI_EMBED_MODEL/EMBED_VERSION/EMBED_DIM and EMBED_READ=current\n", n, cfg.Model)
+		return
+	}
+
+	lc := llm.ConfigFromEnv()
+	lc.EmbedProvider, lc.EmbedModel = provider, cfg.Model.Name
+	lc.EmbedBaseURL, lc.EmbedAPIKey = baseURL, apiKey
+	if cfg.Dim > 0 {
+		lc.EmbedDim = cfg.Dim
+	} else if provider == llm.ProviderLocal {
+		lc.EmbedDim = llm.DefaultLocalDim
+	}
+	emb, err := llm.NewEmbedder(lc)
+	if err != nil {
+		fail(err)
+	}
+	r.Embedder = emb
+
+	p, err := r.Run(ctx)
+	fmt.Printf("last id %d: %d embedded, %d failed, %d pending\n", p.LastID, p.Processed, p.Failed, p.Pending)
+	if err != nil {
+		fail(err)
+	}
+}
+
+func printStatus(ctx context.Context, db *sql.DB, r *reembed.Runner) {
+	cov, err := reembed.Coverage(ctx, db)
+	if err != nil {
+		fail(err)
+	}
+	for _, c := range cov {
+		fmt.Printf("%-8s %-32s %-10s dim=%-5d %d chunks\n", c.Column, c.Model, c.Version, c.Dim, c.Chunks)
+	}
+	jobs, err := reembed.Jobs(ctx, db)
+	if err != nil {
+		fail(err)
+	}
+	for _, j := range jobs {
+		fmt.Printf("job %s: %s@%s status=%s last_id=%d processed=%d failed=%d %s\n",
+			j.Name, j.Model, j.Version, j.Status, j.LastID, j.Processed, j.Failed, j.LastError)
+	}
+	n, err := r.Pending(ctx)
+	if err != nil {
+		fail(err)
+	}
+	fmt.Printf("pending for %s: %d\n", r.Cfg.Model, n)
+}
+
+func flagSet(name string) bool {
+	set := false
+	flag.Visit(func(f *flag.Flag) { set = set || f.Name == name })
+	return set
+}
+
+func envOr(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, "ERR:", err)
+	os.Exit(1)
+}
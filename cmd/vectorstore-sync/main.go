@@ -0,0 +1,123 @@
+// cmd/vectorstore-sync/main.go
+// Menyalin chunk aktif doc_chunks (MySQL, hasil cmd/worker / cmd/ingest-docs) ke vector store
+// PostgreSQL + pgvector (VECTOR_STORE=pgvector), dengan ID yang sama sehingga sitasi tetap konsisten.
+// Default inkremental (mulai setelah id terbesar di Postgres); -full menyalin ulang semuanya
+// (memperbarui baris yang ada) dan -prune menghapus baris yang sudah tidak aktif di MySQL.
+//
+//	go run ./cmd/vectorstore-sync -pg "postgres://mcp:secret@pg:5432/mcp" -prune
+//
+// Driver Postgres "pgx" (github.com/jackc/pgx/v5/stdlib) sudah terdaftar di binary ini dan cmd/api;
+// -driver / PGVECTOR_DRIVER hanya perlu diubah bila memakai driver database/sql lain.
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	_ "github.com/go-sql-driver/mysql"
+	_ "github.com/jackc/pgx/v5/stdlib"
+
+	"mcp-oilgas/internal/analysis"
+	"mcp-oilgas/internal/vectorstore"
+)
+
+func main() {
+	cfg := vectorstore.ConfigFromEnv()
+	var (
+		dsn         string
+		batch       int
+		full, prune bool
+	)
+	flag.StringVar(&dsn, "dsn", envOr("DB_DSN", "mcpuser:secret@tcp(mysql:3306)/mcp?parseTime=true&multiStatements=true"), "MySQL DSN (sumber)")
+	flag.StringVar(&cfg.PGDSN, "pg", cfg.PGDSN, "PostgreSQL DSN (tujuan, default PGVECTOR_DSN)")
+	flag.StringVar(&cfg.PGDriver, "driver", cfg.PGDriver, "nama driver database/sql Postgres (default PGVECTOR_DRIVER)")
+	flag.StringVar(&cfg.PGTable, "table", cfg.PGTable, "tabel tujuan (default PGVECTOR_TABLE)")
+	flag.IntVar(&batch, "batch", 500, "chunk per batch")
+	flag.BoolVar(&full, "full", false, "salin ulang semua chunk (bukan hanya id baru)")
+	flag.BoolVar(&prune, "prune", false, "hapus chunk di Postgres yang tidak aktif lagi di MySQL")
+	flag.Parse()
+
+	cfg.Backend = vectorstore.BackendPGVector
+	if err := cfg.Validate(); err != nil {
+		fail(err)
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	db, err := sql.Open("mysql", dsn)
+	if err != nil {
+		fail(err)
+	}
+	defer db.Close()
+	if err := db.PingContext(ctx); err != nil {
+		fail(err)
+	}
+	an, err := analysis.New(analysis.ConfigFromEnv())
+	if err != nil {
+		fail(fmt.Errorf("analyzer: %w", err))
+	}
+	pg, err := vectorstore.OpenPGVector(ctx, cfg, an)
+	if err != nil {
+		fail(err)
+	}
+	defer pg.DB.Close()
+	src := vectorstore.NewMySQL(db, nil)
+
+	var after int64
+	if !full {
+		if after, err = pg.MaxID(ctx); err != nil {
+			fail(err)
+		}
+	}
+	t0 := time.Now()
+	n, last, err := vectorstore.Copy(ctx, src, pg, after, batch)
+	log.Printf("copied %d chunks (id %d..%d) in %s", n, after, last, time.Since(t0).Round(time.Millisecond))
+	if err != nil {
+		fail(err)
+	}
+
+	if prune {
+		live, err := src.LiveIDs(ctx)
+		if err != nil {
+			fail(err)
+		}
+		ids, err := pg.IDs(ctx)
+		if err != nil {
+			fail(err)
+		}
+		var stale []int64
+		for _, id := range ids {
+			if !live[id] {
+				stale = append(stale, id)
+			}
+		}
+		pruned := len(stale)
+		for len(stale) > 0 {
+			k := min(batch, len(stale))
+			if err := pg.Delete(ctx, stale[:k]...); err != nil {
+				fail(err)
+			}
+			stale = stale[k:]
+		}
+		log.Printf("pruned %d chunks", pruned)
+	}
+}
+
+func envOr(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, "ERR:", err)
+	os.Exit(1)
+}
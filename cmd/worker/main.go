@@ -1,16 +1,60 @@
 // cmd/worker/main.go
+// Worker ingest dokumen: memproses antrian ingest_jobs (upload dari /admin/docs/upload).
 package main
 
 import (
+	"context"
+	"database/sql"
 	"log"
+	"os"
+	"os/signal"
+	"syscall"
 	"time"
+
+	_ "github.com/go-sql-driver/mysql"
+
+	"mcp-oilgas/internal/ingest"
+	"mcp-oilgas/internal/llm"
+	"mcp-oilgas/internal/redact"
 )
 
 func main() {
+	redact.InstallLogFilter()
 	log.Println("Worker started...")
-	for {
-		// placeholder job
-		log.Println("Worker heartbeat")
-		time.Sleep(30 * time.Second)
+
+	dsn := os.Getenv("DB_DSN")
+	if dsn == "" {
+		dsn = os.Getenv("DB_DSN_DOCKER")
+	}
+	if dsn == "" {
+		log.Fatal("DB_DSN/DB_DSN_DOCKER empty")
+	}
+	db, err := sql.Open("mysql", dsn)
+	if err != nil {
+		log.Fatalf("open mysql: %v", err)
 	}
+	defer db.Close()
+	for i := 0; ; i++ {
+		if err = db.Ping(); err == nil {
+			break
+		}
+		if i >= 30 {
+			log.Fatalf("mysql not ready: %v", err)
+		}
+		time.Sleep(2 * time.Second)
+	}
+
+	// Tanpa OPENAI_API_KEY chunk tetap disimpan (embedding NULL, bisa diisi cmd/ingest-docs)
+	var embedder llm.Embedder
+	if e, err := llm.SharedEmbedder(); err != nil {
+		log.Printf("[WARN] embeddings disabled: %v", err)
+	} else {
+		embedder = e
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	p := &ingest.Pipeline{DB: db, Embedder: embedder, Config: ingest.ConfigFromEnv()}
+	ingest.WorkerFromEnv(ingest.NewJobStore(db), p).Run(ctx)
 }
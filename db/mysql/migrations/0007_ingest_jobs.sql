@@ -0,0 +1,23 @@
+-- 0007_ingest_jobs.sql
+-- Antrian job ingest dokumen (upload admin → worker: ekstrak, chunk, embed, simpan doc_chunks)
+
+CREATE TABLE IF NOT EXISTS ingest_jobs (
+  id          BIGINT AUTO_INCREMENT PRIMARY KEY,
+  filename    VARCHAR(255) NOT NULL,
+  path        TEXT         NOT NULL,
+  doc_id      VARCHAR(64)  NOT NULL,
+  title       VARCHAR(255) NOT NULL DEFAULT '',
+  status      VARCHAR(16)  NOT NULL DEFAULT 'queued',  -- queued|running|done|failed
+  stage       VARCHAR(16)  NULL,                       -- extract|chunk|embed|store
+  attempts    INT          NOT NULL DEFAULT 0,
+  error       TEXT         NULL,
+  pages       INT          NOT NULL DEFAULT 0,
+  chunks      INT          NOT NULL DEFAULT 0,
+  worker_id   VARCHAR(128) NULL,
+  created_at  DATETIME     NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  started_at  DATETIME     NULL,
+  finished_at DATETIME     NULL,
+  updated_at  DATETIME     NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  KEY idx_ingest_jobs_status (status, id),
+  KEY idx_ingest_jobs_doc (doc_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,12 @@
+-- 0008_chunk_offsets.sql
+-- Posisi chunk di teks dokumen (offset rune, halaman digabung "\f") untuk sitasi span,
+-- urutan chunk & heading section; koleksi (konfigurasi chunking) per job ingest.
+
+ALTER TABLE doc_chunks
+  ADD COLUMN chunk_index INT          NULL AFTER page_no,
+  ADD COLUMN char_start  INT          NULL AFTER chunk_index,
+  ADD COLUMN char_end    INT          NULL AFTER char_start,
+  ADD COLUMN section     VARCHAR(255) NULL AFTER char_end;
+
+ALTER TABLE ingest_jobs
+  ADD COLUMN collection VARCHAR(64) NOT NULL DEFAULT 'default' AFTER title;
@@ -0,0 +1,38 @@
+-- 0009_documents.sql
+-- Metadata per dokumen untuk filter retrieval (jenis, aset, sumur, area, revisi, tanggal efektif,
+-- bahasa, tag). doc_chunks.doc_id merujuk documents.doc_id.
+
+CREATE TABLE IF NOT EXISTS documents (
+  doc_id         VARCHAR(64)  PRIMARY KEY,
+  title          VARCHAR(255) NOT NULL DEFAULT '',
+  url            TEXT         NULL,
+  collection     VARCHAR(64)  NOT NULL DEFAULT 'default',
+  doc_type       VARCHAR(32)  NULL,   -- sop|manual|report|permit|...
+  asset_id       VARCHAR(64)  NULL,
+  well_id        VARCHAR(64)  NULL,
+  area           VARCHAR(64)  NULL,
+  revision       VARCHAR(32)  NULL,
+  effective_date DATE         NULL,
+  lang           VARCHAR(8)   NULL,
+  tags           JSON         NULL,   -- array string huruf kecil
+  created_at     DATETIME     NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at     DATETIME     NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  KEY idx_documents_type_area (doc_type, area),
+  KEY idx_documents_well (well_id),
+  KEY idx_documents_asset (asset_id),
+  KEY idx_documents_effective (effective_date),
+  KEY idx_documents_collection (collection)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE INDEX idx_doc_chunks_doc ON doc_chunks (doc_id);
+
+-- Dokumen yang sudah ada di doc_chunks (tanpa metadata; isi via PUT /admin/documents/{doc_id})
+INSERT IGNORE INTO documents (doc_id, title, url)
+SELECT doc_id, COALESCE(MAX(title), ''), MAX(url)
+  FROM doc_chunks
+ WHERE doc_id IS NOT NULL AND doc_id <> ''
+ GROUP BY doc_id;
+
+-- Metadata dari form upload (diterapkan worker ke documents saat job selesai)
+ALTER TABLE ingest_jobs
+  ADD COLUMN meta JSON NULL AFTER collection;
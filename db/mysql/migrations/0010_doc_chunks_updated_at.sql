@@ -0,0 +1,7 @@
+-- 0010_doc_chunks_updated_at.sql
+-- Waktu perubahan chunk untuk sinkronisasi inkremental indeks vektor in-process
+-- (internal/vectorindex): embedding yang diisi/diubah di tempat ikut terdeteksi.
+
+ALTER TABLE doc_chunks
+  ADD COLUMN updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  ADD INDEX idx_doc_chunks_updated (updated_at);
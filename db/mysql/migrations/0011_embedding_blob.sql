@@ -0,0 +1,9 @@
+-- 0011_embedding_blob.sql
+-- Embedding biner (pkg/vector: f32 atau i8 terkuantisasi) menggantikan array JSON di doc_chunks.embedding,
+-- beserta model & dimensi per baris. Kolom JSON tetap ada selama transisi; isi embedding_bin dari
+-- baris lama dengan: go run ./cmd/migrate-embeddings
+
+ALTER TABLE doc_chunks
+  ADD COLUMN embedding_bin   MEDIUMBLOB        NULL AFTER embedding,
+  ADD COLUMN embedding_model VARCHAR(64)       NULL AFTER embedding_bin,
+  ADD COLUMN embedding_dim   SMALLINT UNSIGNED NULL AFTER embedding_model;
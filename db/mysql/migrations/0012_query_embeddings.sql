@@ -0,0 +1,13 @@
+-- 0012_query_embeddings.sql
+-- Cache persisten embedding query untuk /rag/search_v2 (internal/queryembed).
+-- Hanya hash query (sha256 dari model + teks) yang disimpan, bukan teksnya.
+
+CREATE TABLE IF NOT EXISTS query_embeddings (
+  model      VARCHAR(64)       NOT NULL,
+  query_hash BINARY(32)        NOT NULL,
+  dim        SMALLINT UNSIGNED NOT NULL,
+  embedding  MEDIUMBLOB        NOT NULL,  -- pkg/vector f32
+  created_at TIMESTAMP         NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (model, query_hash),
+  KEY idx_query_embeddings_created (created_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,29 @@
+-- 0013_embedding_versions.sql
+-- Migrasi model embedding tanpa menghapus data:
+--   - embedding_version: label versi embedder (EMBED_VERSION) di samping embedding_model
+--   - embedding_next_*: kolom bayangan yang diisi cmd/reembed untuk model baru; query tetap memakai
+--     kolom aktif (EMBED_READ=current) atau membaca keduanya (EMBED_READ=next) sampai cutover
+--   - reembed_jobs: checkpoint job re-embedding agar bisa dilanjutkan setelah berhenti
+
+ALTER TABLE doc_chunks
+  ADD COLUMN embedding_version      VARCHAR(64)       NULL AFTER embedding_dim,
+  ADD COLUMN embedding_next_bin     MEDIUMBLOB        NULL AFTER embedding_version,
+  ADD COLUMN embedding_next_model   VARCHAR(64)       NULL AFTER embedding_next_bin,
+  ADD COLUMN embedding_next_version VARCHAR(64)       NULL AFTER embedding_next_model,
+  ADD COLUMN embedding_next_dim     SMALLINT UNSIGNED NULL AFTER embedding_next_version,
+  ADD KEY idx_doc_chunks_embedding_next (embedding_next_model, embedding_next_version);
+
+CREATE TABLE IF NOT EXISTS reembed_jobs (
+  name       VARCHAR(128)      NOT NULL,
+  model      VARCHAR(64)       NOT NULL,
+  version    VARCHAR(64)       NOT NULL DEFAULT '',
+  dim        SMALLINT UNSIGNED NULL,
+  last_id    BIGINT            NOT NULL DEFAULT 0,  -- semua chunk dengan id <= last_id sudah diproses
+  processed  BIGINT            NOT NULL DEFAULT 0,
+  failed     BIGINT            NOT NULL DEFAULT 0,
+  status     VARCHAR(16)       NOT NULL DEFAULT 'running',  -- running|done|failed|cutover
+  last_error TEXT              NULL,
+  started_at TIMESTAMP         NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP         NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  PRIMARY KEY (name)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
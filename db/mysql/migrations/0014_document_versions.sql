@@ -0,0 +1,47 @@
+-- 0014_document_versions.sql
+-- Versi dokumen, dedup berdasarkan hash isi & penghapusan:
+--   - documents.content_hash/current_version: sha256 file versi aktif; upload isi identik dilewati
+--   - doc_chunks.doc_version/superseded: chunk revisi lama tetap disimpan (DOC_KEEP_VERSIONS) tetapi
+--     retrieval hanya memakai chunk superseded = 0 kecuali filter version/revision diminta
+--   - deleted_at: soft delete (dokumen & chunk disembunyikan dari retrieval, bisa di-restore)
+--   - document_versions: riwayat upload per dokumen
+
+ALTER TABLE documents
+  ADD COLUMN content_hash    CHAR(64) NULL AFTER tags,
+  ADD COLUMN current_version INT      NOT NULL DEFAULT 1 AFTER content_hash,
+  ADD COLUMN deleted_at      DATETIME NULL AFTER current_version,
+  ADD KEY idx_documents_hash (content_hash);
+
+ALTER TABLE doc_chunks
+  ADD COLUMN doc_version INT        NOT NULL DEFAULT 1 AFTER doc_id,
+  ADD COLUMN superseded  TINYINT(1) NOT NULL DEFAULT 0 AFTER doc_version,
+  ADD COLUMN deleted_at  TIMESTAMP  NULL AFTER superseded,
+  ADD KEY idx_doc_chunks_doc_version (doc_id, doc_version);
+
+CREATE TABLE IF NOT EXISTS document_versions (
+  doc_id        VARCHAR(64)  NOT NULL,
+  version       INT          NOT NULL,
+  content_hash  CHAR(64)     NULL,
+  filename      VARCHAR(255) NOT NULL DEFAULT '',
+  title         VARCHAR(255) NOT NULL DEFAULT '',
+  revision      VARCHAR(32)  NULL,   -- label revisi dari metadata upload (mis. "Rev C")
+  chunks        INT          NOT NULL DEFAULT 0,
+  job_id        BIGINT       NULL,
+  created_at    DATETIME     NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  superseded_at DATETIME     NULL,
+  PRIMARY KEY (doc_id, version),
+  KEY idx_document_versions_hash (content_hash),
+  KEY idx_document_versions_revision (doc_id, revision)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- Dokumen yang sudah ada = versi 1
+INSERT IGNORE INTO document_versions (doc_id, version, title, revision, chunks)
+SELECT d.doc_id, 1, d.title, d.revision, (SELECT COUNT(*) FROM doc_chunks c WHERE c.doc_id = d.doc_id)
+  FROM documents d;
+
+-- Hasil dedup/versi per job ingest; force = proses ulang walau isi identik
+ALTER TABLE ingest_jobs
+  ADD COLUMN force_reingest TINYINT(1) NOT NULL DEFAULT 0 AFTER meta,
+  ADD COLUMN content_hash   CHAR(64)   NULL AFTER force_reingest,
+  ADD COLUMN doc_version    INT        NULL AFTER content_hash,
+  ADD COLUMN duplicate_of   VARCHAR(64) NULL AFTER doc_version;
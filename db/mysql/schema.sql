@@ -4,3 +4,11 @@
 SOURCE /docker-entrypoint-initdb.d/migrations/0001_init.sql;
 SOURCE /docker-entrypoint-initdb.d/migrations/0002_indexes.sql;
 SOURCE /docker-entrypoint-initdb.d/migrations/0003_sample_data.sql;
+SOURCE /docker-entrypoint-initdb.d/migrations/0007_ingest_jobs.sql;
+SOURCE /docker-entrypoint-initdb.d/migrations/0008_chunk_offsets.sql;
+SOURCE /docker-entrypoint-initdb.d/migrations/0009_documents.sql;
+SOURCE /docker-entrypoint-initdb.d/migrations/0010_doc_chunks_updated_at.sql;
+SOURCE /docker-entrypoint-initdb.d/migrations/0011_embedding_blob.sql;
+SOURCE /docker-entrypoint-initdb.d/migrations/0012_query_embeddings.sql;
+SOURCE /docker-entrypoint-initdb.d/migrations/0013_embedding_versions.sql;
+SOURCE /docker-entrypoint-initdb.d/migrations/0014_document_versions.sql;
@@ -0,0 +1,28 @@
+-- db/postgres/vectorstore.sql
+-- Skema vector store PostgreSQL + pgvector (VECTOR_STORE=pgvector, internal/vectorstore/pgvector.go).
+-- Diisi dari doc_chunks MySQL oleh cmd/vectorstore-sync (id chunk dipertahankan).
+-- Sesuaikan vector(N) dengan EMBED_DIM (default 1536; 512 untuk EMBED_PROVIDER=local).
+
+CREATE EXTENSION IF NOT EXISTS vector;
+
+CREATE TABLE IF NOT EXISTS rag_chunks (
+  id           BIGSERIAL PRIMARY KEY,
+  doc_id       VARCHAR(128) NOT NULL,
+  doc_version  INT          NOT NULL DEFAULT 1,
+  title        TEXT         NOT NULL DEFAULT '',
+  url          TEXT         NOT NULL DEFAULT '',
+  snippet      TEXT         NOT NULL,
+  section      TEXT         NOT NULL DEFAULT '',
+  page_no      INT          NOT NULL DEFAULT 0,
+  chunk_index  INT          NOT NULL DEFAULT 0,
+  char_start   INT          NULL,
+  char_end     INT          NULL,
+  meta         JSONB        NULL,                   -- metadata dokumen (documents.Document) untuk filter
+  lex          TEXT         NOT NULL DEFAULT '',    -- term analyzer (stem ID/EN, tanpa stopword)
+  tsv          TSVECTOR GENERATED ALWAYS AS (to_tsvector('simple', coalesce(lex, ''))) STORED,
+  embedding    vector(1536) NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_rag_chunks_doc ON rag_chunks (doc_id, doc_version);
+CREATE INDEX IF NOT EXISTS idx_rag_chunks_tsv ON rag_chunks USING GIN (tsv);
+CREATE INDEX IF NOT EXISTS idx_rag_chunks_embedding ON rag_chunks USING hnsw (embedding vector_cosine_ops);
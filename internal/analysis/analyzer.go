@@ -0,0 +1,166 @@
+// internal/analysis/analyzer.go
+// Analyzer teks tingkat aplikasi untuk pencarian leksikal: tokenisasi, stopword Indonesia & Inggris,
+// stemming (StemID + StemEN) dan ekspansi sinonim di sisi query. FULLTEXT MySQL (NATURAL LANGUAGE
+// MODE) tidak mengenal imbuhan bahasa Indonesia sehingga "pemeriksaan", "diperiksa" dan "periksa"
+// dianggap kata berbeda; indeks yang dibangun dengan Analyzer (internal/lexindex) menyamakannya.
+package analysis
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Config dibaca dari env SEARCH_*.
+type Config struct {
+	Stemming      bool
+	Stopwords     bool
+	SynonymsFile  string
+	SynonymWeight float64 // bobot term hasil ekspansi sinonim relatif terhadap term query asli
+}
+
+// ConfigFromEnv: SEARCH_STEMMING (on|off, default on), SEARCH_STOPWORDS (on|off, default on),
+// SEARCH_SYNONYMS_FILE (default configs/synonyms.json), SEARCH_SYNONYM_WEIGHT (default 0.5).
+func ConfigFromEnv() Config {
+	cfg := Config{
+		Stemming:      !isOff(os.Getenv("SEARCH_STEMMING")),
+		Stopwords:     !isOff(os.Getenv("SEARCH_STOPWORDS")),
+		SynonymsFile:  "configs/synonyms.json",
+		SynonymWeight: 0.5,
+	}
+	if v, ok := os.LookupEnv("SEARCH_SYNONYMS_FILE"); ok {
+		cfg.SynonymsFile = strings.TrimSpace(v)
+	}
+	if f, err := strconv.ParseFloat(os.Getenv("SEARCH_SYNONYM_WEIGHT"), 64); err == nil && f >= 0 && f <= 1 {
+		cfg.SynonymWeight = f
+	}
+	return cfg
+}
+
+func isOff(v string) bool {
+	switch strings.ToLower(strings.TrimSpace(v)) {
+	case "off", "false", "0", "no":
+		return true
+	}
+	return false
+}
+
+// Term adalah satu term query beserta bobotnya.
+type Term struct {
+	Text   string  `json:"text"`
+	Weight float64 `json:"weight"`
+}
+
+// Analyzer aman dipakai bersamaan (read-only setelah New).
+type Analyzer struct {
+	cfg Config
+	syn Synonyms
+}
+
+// New membuat Analyzer dengan sinonim bawaan + file cfg.SynonymsFile.
+func New(cfg Config) (*Analyzer, error) {
+	extra, err := LoadSynonyms(cfg.SynonymsFile)
+	if err != nil {
+		return nil, err
+	}
+	return &Analyzer{cfg: cfg, syn: BuiltinSynonyms().Merge(extra)}, nil
+}
+
+// Default: Analyzer dengan konfigurasi bawaan dan sinonim bawaan saja.
+func Default() *Analyzer {
+	return &Analyzer{
+		cfg: Config{Stemming: true, Stopwords: true, SynonymWeight: 0.5},
+		syn: BuiltinSynonyms(),
+	}
+}
+
+// Stems mengembalikan token itu sendiri ditambah bentuk dasar kandidatnya (huruf kecil). Token
+// pendek atau mengandung angka (h2s, k3) tidak diubah. Bahasa tidak ditebak per kata: kandidat
+// StemID dan StemEN digabung; karena indeks & query memakai fungsi yang sama, kandidat yang
+// "salah" hanya menambah term, tidak menghilangkan kecocokan, dan bentuk permukaan yang persis
+// sama tetap mendapat skor lebih tinggi.
+func (a *Analyzer) Stems(w string) []string {
+	if !a.cfg.Stemming || len(w) <= 3 || strings.IndexFunc(w, unicode.IsDigit) >= 0 {
+		return []string{w}
+	}
+	out := append([]string{w}, StemID(w)...)
+	if en := StemEN(w); en != w {
+		out = append(out, en)
+	}
+	return dedupe(out)
+}
+
+// Terms mengembalikan term indeks untuk teks dokumen (satu entri per kemunculan per stem).
+func (a *Analyzer) Terms(text string) []string {
+	var out []string
+	for _, w := range tokenize(text) {
+		if a.cfg.Stopwords && IsStopword(w) {
+			continue
+		}
+		out = append(out, a.Stems(w)...)
+	}
+	return out
+}
+
+// QueryTerms menganalisis query: term asli berbobot 1 (dibagi rata antar kandidat stem satu kata),
+// ditambah padanan sinonim (frasa terpanjang dulu, maksimal 3 kata) berbobot cfg.SynonymWeight.
+// Term yang sama digabung dengan bobot terbesar.
+func (a *Analyzer) QueryTerms(text string) []Term {
+	weights := map[string]float64{}
+	var order []string
+	put := func(t string, w float64) {
+		if old, ok := weights[t]; !ok {
+			order = append(order, t)
+			weights[t] = w
+		} else if w > old {
+			weights[t] = w
+		}
+	}
+	addWord := func(w string, weight float64) {
+		if a.cfg.Stopwords && IsStopword(w) {
+			return
+		}
+		stems := a.Stems(w)
+		for _, s := range stems {
+			put(s, weight/float64(len(stems)))
+		}
+	}
+
+	ws := tokenize(text)
+	for _, w := range ws {
+		addWord(w, 1)
+	}
+	if a.cfg.SynonymWeight > 0 && len(a.syn) > 0 {
+		for i := 0; i < len(ws); {
+			n := 0
+			for size := min(3, len(ws)-i); size >= 1; size-- {
+				exp, ok := a.syn[strings.Join(ws[i:i+size], " ")]
+				if !ok {
+					continue
+				}
+				for _, e := range exp {
+					for _, w := range tokenize(e) {
+						addWord(w, a.cfg.SynonymWeight)
+					}
+				}
+				n = size
+				break
+			}
+			i += max(n, 1)
+		}
+	}
+
+	out := make([]Term, 0, len(order))
+	for _, t := range order {
+		out = append(out, Term{Text: t, Weight: weights[t]})
+	}
+	return out
+}
+
+// tokenize: huruf kecil, dipisah di non huruf/angka (sama dengan queryrewrite).
+func tokenize(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
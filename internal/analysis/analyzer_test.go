@@ -0,0 +1,77 @@
+// internal/analysis/analyzer_test.go
+
+package analysis_test
+
+import (
+	"slices"
+	"testing"
+
+	"mcp-oilgas/internal/analysis"
+)
+
+func shareStem(a *analysis.Analyzer, x, y string) bool {
+	for _, s := range a.Stems(x) {
+		if slices.Contains(a.Stems(y), s) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestStemsMatchInflectedForms(t *testing.T) {
+	a := analysis.Default()
+	pairs := [][2]string{
+		{"pemeriksaan", "periksa"},
+		{"diperiksa", "periksa"},
+		{"pengeboran", "pemboran"},
+		{"mengebor", "bor"},
+		{"kebocorannya", "bocor"},
+		{"menangani", "penanganan"},
+		{"memasang", "pasang"},
+		{"perbaikan", "memperbaiki"},
+		{"dilakukan", "lakukan"},
+		{"tekanan", "menekan"},
+		{"valves", "valve"},
+		{"drilling", "drilled"},
+	}
+	for _, p := range pairs {
+		if !shareStem(a, p[0], p[1]) {
+			t.Fatalf("%q %v and %q %v share no stem", p[0], a.Stems(p[0]), p[1], a.Stems(p[1]))
+		}
+	}
+	if got := a.Stems("h2s"); len(got) != 1 || got[0] != "h2s" {
+		t.Fatalf("tokens with digits must not be stemmed: %v", got)
+	}
+}
+
+func TestTermsDropStopwords(t *testing.T) {
+	a := analysis.Default()
+	for _, term := range a.Terms("Prosedur yang harus dilakukan untuk the valve") {
+		switch term {
+		case "yang", "harus", "untuk", "the":
+			t.Fatalf("stopword %q indexed", term)
+		}
+	}
+}
+
+func TestQueryTermsSynonyms(t *testing.T) {
+	a := analysis.Default()
+	weights := map[string]float64{}
+	for _, term := range a.QueryTerms("kebocoran katup") {
+		weights[term.Text] = term.Weight
+	}
+	if weights["valve"] == 0 || weights["leak"] == 0 {
+		t.Fatalf("expected synonym expansion, got %v", weights)
+	}
+	if weights["valve"] >= weights["katup"] {
+		t.Fatalf("synonym weight %v must be below original %v", weights["valve"], weights["katup"])
+	}
+	// frasa: "alat pelindung diri" → apd / ppe
+	found := false
+	for _, term := range a.QueryTerms("alat pelindung diri") {
+		found = found || term.Text == "ppe"
+	}
+	if !found {
+		t.Fatalf("phrase synonym not expanded")
+	}
+}
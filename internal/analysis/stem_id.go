@@ -0,0 +1,159 @@
+// internal/analysis/stem_id.go
+package analysis
+
+import "strings"
+
+// StemID adalah stemmer bahasa Indonesia berbasis aturan (turunan Nazief-Adriani / ECS tanpa kamus):
+//
+//  1. partikel      -lah -kah -tah -pun
+//  2. posesif       -nya -ku -mu
+//  3. derivasional  -kan, -an, -i (-an/-i hanya bila berimbuhan awalan, atau -an pada kata >= 6 huruf)
+//  4. awalan        di- ke- se- ber- ter- per- me(N)- pe(N)- be- pe-, paling banyak 2 lapis
+//
+// Tanpa kamus, peluluhan nasal ambigu (mengirim → kirim / irim, menulis → tulis / nulis) sehingga
+// StemID mengembalikan semua kandidat akar; indeks & query memakai fungsi yang sama sehingga bentuk
+// dasar dan bentuk berimbuhan tetap bertemu. Kata <= 3 huruf tidak diubah.
+func StemID(w string) []string {
+	if len([]rune(w)) <= 3 {
+		return []string{w}
+	}
+	prefixed := len(stripPrefixID(w)) > 0
+	w = trimSuffixID(w, []string{"lah", "kah", "tah", "pun"})
+	w = trimSuffixID(w, []string{"nya", "ku", "mu"})
+	// sufiks derivasional; bila pemotongan belum pasti (tanpa awalan, atau -kan yang bisa juga -an
+	// seperti perbaikan → baik, atau bagian akar seperti menekan → tekan) semua bentuk dipertahankan
+	cur := []string{w}
+	switch {
+	case strings.HasSuffix(w, "kan") && validRoot(w[:len(w)-3]):
+		cur = []string{w[:len(w)-3]}
+		if prefixed {
+			cur = append(cur, w[:len(w)-2], w)
+		}
+	case strings.HasSuffix(w, "an") && prefixed && validRoot(w[:len(w)-2]):
+		cur = []string{w[:len(w)-2]}
+	case strings.HasSuffix(w, "an") && len(w) >= 6 && validRoot(w[:len(w)-2]):
+		cur = []string{w, w[:len(w)-2]} // tekanan → tekan, tetapi tangan tetap tangan
+	case strings.HasSuffix(w, "i") && prefixed && validRoot(w[:len(w)-1]):
+		cur = []string{w[:len(w)-1]}
+	}
+
+	for pass := 0; pass < 2; pass++ {
+		var next []string
+		changed := false
+		for _, c := range cur {
+			roots := stripPrefixID(c)
+			if len(roots) == 0 {
+				next = append(next, c)
+				continue
+			}
+			changed = true
+			next = append(next, roots...)
+		}
+		cur = dedupe(next)
+		if !changed {
+			break
+		}
+	}
+	return cur
+}
+
+func trimSuffixID(w string, sfx []string) string {
+	for _, s := range sfx {
+		if strings.HasSuffix(w, s) && validRoot(w[:len(w)-len(s)]) {
+			return w[:len(w)-len(s)]
+		}
+	}
+	return w
+}
+
+// stripPrefixID mengembalikan kandidat akar setelah satu awalan dilepas (nil bila tidak ada awalan).
+func stripPrefixID(w string) []string {
+	var out []string
+	add := func(roots ...string) {
+		for _, r := range roots {
+			if validRoot(r) {
+				out = append(out, r)
+			}
+		}
+	}
+	switch {
+	case strings.HasPrefix(w, "meng"), strings.HasPrefix(w, "peng"):
+		r := w[4:]
+		switch {
+		case strings.HasPrefix(r, "e") && validRoot(r[1:]): // menge-/penge- + kata bersuku satu (mengebor)
+			add(r[1:], r)
+		case startsVowel(r):
+			add(r, "k"+r) // mengambil → ambil; mengirim → kirim
+		default:
+			add(r) // menggali, menghitung
+		}
+	case strings.HasPrefix(w, "meny"), strings.HasPrefix(w, "peny"):
+		add("s" + w[4:]) // menyapu → sapu
+	case strings.HasPrefix(w, "mem"), strings.HasPrefix(w, "pem"):
+		r := w[3:]
+		if startsVowel(r) {
+			add("p"+r, "m"+r) // memakai → pakai; memasak → masak
+		} else {
+			add(r) // membuat, memperbaiki
+		}
+	case strings.HasPrefix(w, "men"), strings.HasPrefix(w, "pen"):
+		r := w[3:]
+		if startsVowel(r) {
+			add("t"+r, "n"+r) // menulis → tulis; menikah → nikah
+		} else {
+			add(r) // mendapat, mencari
+		}
+	case strings.HasPrefix(w, "me") && len(w) > 2 && strings.ContainsRune("lrwy", rune(w[2])):
+		add(w[2:]) // melihat, merawat
+	case w == "belajar", w == "pelajar":
+		add("ajar")
+	case strings.HasPrefix(w, "ber"), strings.HasPrefix(w, "ter"), strings.HasPrefix(w, "per"):
+		r := w[3:]
+		if startsVowel(r) {
+			add(r, w[2:]) // berangkat → angkat; beracun → racun
+		} else {
+			add(r)
+		}
+	case strings.HasPrefix(w, "be"), strings.HasPrefix(w, "pe"), strings.HasPrefix(w, "te"):
+		if len(w) > 3 && !startsVowel(w[2:]) && strings.HasPrefix(w[3:], "er") {
+			add(w[2:]) // bekerja → kerja; pekerja → kerja
+		} else if strings.HasPrefix(w, "pe") && len(w) > 2 && strings.ContainsRune("lrwy", rune(w[2])) {
+			add(w[2:]) // pelatih → latih
+		}
+	case strings.HasPrefix(w, "di"), strings.HasPrefix(w, "ke"), strings.HasPrefix(w, "se"):
+		add(w[2:])
+	}
+	return out
+}
+
+func startsVowel(s string) bool {
+	return s != "" && strings.ContainsRune("aiueo", rune(s[0]))
+}
+
+// validRoot: akar minimal 3 huruf, mengandung vokal, dan tidak diawali gugus konsonan yang
+// asing bagi bahasa Indonesia (mencegah "sensor" → "nsor").
+func validRoot(r string) bool {
+	if len(r) < 3 || !strings.ContainsAny(r, "aiueo") {
+		return false
+	}
+	if !startsVowel(r) && len(r) > 1 && !startsVowel(r[1:]) {
+		switch r[:2] {
+		case "pr", "tr", "kr", "gr", "br", "dr", "fr", "st", "sp", "sk", "sl", "pl", "kl", "bl", "gl", "fl", "ny", "ng", "sy", "kh":
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func dedupe(ss []string) []string {
+	seen := make(map[string]bool, len(ss))
+	out := ss[:0]
+	for _, s := range ss {
+		if !seen[s] {
+			seen[s] = true
+			out = append(out, s)
+		}
+	}
+	return out
+}
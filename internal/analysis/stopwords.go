@@ -0,0 +1,37 @@
+// internal/analysis/stopwords.go
+package analysis
+
+// stopwordsID: kata fungsi bahasa Indonesia (kata ganti, kata depan, kata sambung, partikel)
+// yang tidak membawa makna pencarian.
+var stopwordsID = setOf(`
+ada adalah adanya agar akan akankah aku anda antara apa apakah apabila atas atau bagai bagaimana
+bagi bahkan bahwa baik banyak beberapa begitu belum benar berapa bila bisa boleh bukan dalam
+dan dapat dari daripada demikian dengan di dia dirinya dong hal hanya harus hingga ia ialah ini
+itu jadi jika juga justru kalau kami kamu kan karena ke kemudian kenapa kepada ketika kita lagi
+lain lalu maka mana masih mau melainkan mereka meski misalnya mungkin namun nanti oleh pada
+para per perlu pula saat saja sambil sampai sangat saya se sebagai sebelum sedang sedangkan
+sehingga sekali sekitar selain selama seluruh semua sendiri seperti serta sesudah setelah
+setiap suatu sudah supaya tanpa tapi telah tentang tersebut tetapi tiap untuk walaupun yaitu
+yakni yang
+`)
+
+// stopwordsEN: kata fungsi bahasa Inggris.
+var stopwordsEN = setOf(`
+a about above after again against all also am an and any are as at be because been before being
+below between both but by can could did do does doing down during each few for from further had
+has have having he her here hers him his how i if in into is it its itself just me more most my
+no nor not of off on once only or other our out over own same she should so some such than that
+the their them then there these they this those through to too under until up very was we were
+what when where which while who whom why will with would you your
+`)
+
+// IsStopword melaporkan apakah w (huruf kecil) termasuk stopword Indonesia atau Inggris.
+func IsStopword(w string) bool { return stopwordsID[w] || stopwordsEN[w] }
+
+func setOf(s string) map[string]bool {
+	m := map[string]bool{}
+	for _, w := range tokenize(s) {
+		m[w] = true
+	}
+	return m
+}
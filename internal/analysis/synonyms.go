@@ -0,0 +1,113 @@
+// internal/analysis/synonyms.go
+package analysis
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Synonyms memetakan istilah (huruf kecil, boleh frasa sampai 3 kata) ke padanannya. Berbeda dengan
+// glosarium queryrewrite (satu arah, untuk query ke MySQL), sinonim di sini dua arah: setiap anggota
+// grup mengekspansi ke anggota lain.
+type Synonyms map[string][]string
+
+// builtinSynonyms: grup padanan Indonesia ↔ Inggris untuk istilah operasi migas & HSE yang umum.
+var builtinSynonyms = [][]string{
+	{"pengeboran", "pemboran", "drilling"},
+	{"sumur", "well"},
+	{"katup", "valve"},
+	{"pompa", "pump"},
+	{"tekanan", "pressure"},
+	{"suhu", "temperatur", "temperature"},
+	{"kebocoran", "bocor", "leak"},
+	{"tumpahan", "spill"},
+	{"pipa", "pipeline"},
+	{"perawatan", "pemeliharaan", "maintenance"},
+	{"inspeksi", "pemeriksaan", "inspection"},
+	{"darurat", "emergency"},
+	{"kebakaran", "fire"},
+	{"keselamatan", "safety"},
+	{"pelatihan", "training"},
+	{"prosedur", "procedure"},
+	{"izin kerja", "permit to work", "ptw"},
+	{"apd", "alat pelindung diri", "ppe", "personal protective equipment"},
+	{"semburan liar", "blowout"},
+	{"kontrol sumur", "well control"},
+	{"ruang terbatas", "confined space"},
+	{"lumpur pemboran", "drilling fluid", "drilling mud"},
+	{"h2s", "hidrogen sulfida", "hydrogen sulfide"},
+	{"pemasok", "vendor", "supplier"},
+}
+
+// BuiltinSynonyms mengembalikan salinan tabel sinonim bawaan.
+func BuiltinSynonyms() Synonyms {
+	s := Synonyms{}
+	for _, g := range builtinSynonyms {
+		s.addGroup(g)
+	}
+	return s
+}
+
+// LoadSynonyms membaca sinonim tambahan dari file JSON {"istilah": ["padanan", ...]}; setiap entri
+// diperlakukan sebagai satu grup dua arah. File tidak ada = tabel kosong.
+func LoadSynonyms(path string) (Synonyms, error) {
+	if path == "" {
+		return nil, nil
+	}
+	b, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var raw map[string][]string
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	s := Synonyms{}
+	for k, v := range raw {
+		s.addGroup(append([]string{k}, v...))
+	}
+	return s, nil
+}
+
+// Merge menggabungkan padanan other ke s (tanpa duplikat).
+func (s Synonyms) Merge(other Synonyms) Synonyms {
+	out := make(Synonyms, len(s)+len(other))
+	for _, src := range []Synonyms{s, other} {
+		for k, v := range src {
+			for _, e := range v {
+				out.add(k, e)
+			}
+		}
+	}
+	return out
+}
+
+func (s Synonyms) addGroup(g []string) {
+	terms := make([]string, 0, len(g))
+	for _, t := range g {
+		if t = strings.Join(tokenize(t), " "); t != "" {
+			terms = append(terms, t)
+		}
+	}
+	for _, a := range terms {
+		for _, b := range terms {
+			if a != b {
+				s.add(a, b)
+			}
+		}
+	}
+}
+
+func (s Synonyms) add(k, v string) {
+	for _, e := range s[k] {
+		if e == v {
+			return
+		}
+	}
+	s[k] = append(s[k], v)
+}
@@ -0,0 +1,310 @@
+// internal/answercache/cache.go
+// Cache jawaban semantik: pertanyaan yang mirip (cosine embedding ≥ threshold) dengan
+// hasil tool yang sama (fingerprint) dilayani dari cache tanpa memanggil planner & synthesizer.
+//
+// Alur di /chat/stream:
+//  1. Embed pertanyaan → Lookup kandidat (scope: bahasa|mode|versi prompt) yang token
+//     kuncinya (angka, ID seperti A-12/PO-2025, nama bulan) sama persis.
+//  2. Jalankan ulang routes kandidat → Fingerprint harus sama dengan saat disimpan.
+//  3. Miss → pipeline normal, lalu Store bila jawaban lolos verifikasi.
+//
+// Entri juga dibuang saat tabel sumbernya berubah (lihat WatchTables).
+package answercache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"mcp-oilgas/internal/llm"
+	"mcp-oilgas/internal/mcp"
+	"mcp-oilgas/pkg/vector"
+)
+
+// Config mengatur perilaku cache.
+type Config struct {
+	Enabled       bool
+	Threshold     float64       // cosine minimal untuk dianggap pertanyaan yang sama
+	TTL           time.Duration // umur maksimum entri
+	MaxEntries    int           // entri tertua dibuang bila penuh
+	WatchInterval time.Duration // interval polling perubahan tabel
+}
+
+// ConfigFromEnv membaca ANSWER_CACHE_ENABLED, ANSWER_CACHE_THRESHOLD, ANSWER_CACHE_TTL (detik),
+// ANSWER_CACHE_MAX_ENTRIES, ANSWER_CACHE_WATCH_INTERVAL (detik).
+func ConfigFromEnv() Config {
+	cfg := Config{
+		Enabled:       true,
+		Threshold:     0.92,
+		TTL:           6 * time.Hour,
+		MaxEntries:    500,
+		WatchInterval: 30 * time.Second,
+	}
+	if v := strings.TrimSpace(os.Getenv("ANSWER_CACHE_ENABLED")); v != "" {
+		cfg.Enabled = v == "1" || strings.EqualFold(v, "true")
+	}
+	if f, err := strconv.ParseFloat(os.Getenv("ANSWER_CACHE_THRESHOLD"), 64); err == nil && f > 0 && f <= 1 {
+		cfg.Threshold = f
+	}
+	if n, err := strconv.Atoi(os.Getenv("ANSWER_CACHE_TTL")); err == nil && n > 0 {
+		cfg.TTL = time.Duration(n) * time.Second
+	}
+	if n, err := strconv.Atoi(os.Getenv("ANSWER_CACHE_MAX_ENTRIES")); err == nil && n > 0 {
+		cfg.MaxEntries = n
+	}
+	if n, err := strconv.Atoi(os.Getenv("ANSWER_CACHE_WATCH_INTERVAL")); err == nil && n > 0 {
+		cfg.WatchInterval = time.Duration(n) * time.Second
+	}
+	return cfg
+}
+
+// Query adalah pertanyaan yang sudah dinormalisasi (+ embedding bila tersedia).
+type Query struct {
+	Text   string
+	Norm   string
+	Keys   string    // token kunci (lihat keyTokens)
+	Vector []float32 // nil → hanya cocok bila Norm identik
+}
+
+// Entry adalah satu jawaban yang di-cache.
+type Entry struct {
+	ID          string
+	Scope       string
+	Question    string
+	Norm        string
+	Keys        string
+	Vector      []float32
+	Routes      []mcp.Route // routes yang dieksekusi (diputar ulang untuk cek fingerprint)
+	Fingerprint string
+	Tables      []string
+	Answer      string
+	CreatedAt   time.Time
+	Hits        int
+}
+
+// Cache adalah cache jawaban in-memory (thread-safe).
+type Cache struct {
+	mu      sync.Mutex
+	cfg     Config
+	emb     llm.Embedder
+	entries []*Entry
+	tables  map[string]string // versi tabel terakhir yang dilihat watcher
+}
+
+// New membuat Cache. emb boleh nil (pencocokan teks ternormalisasi saja).
+func New(cfg Config, emb llm.Embedder) *Cache {
+	if cfg.Threshold <= 0 {
+		cfg.Threshold = 0.92
+	}
+	if cfg.MaxEntries <= 0 {
+		cfg.MaxEntries = 500
+	}
+	return &Cache{cfg: cfg, emb: emb, tables: map[string]string{}}
+}
+
+// Config mengembalikan konfigurasi aktif.
+func (c *Cache) Config() Config { return c.cfg }
+
+// Embed menormalisasi pertanyaan dan menghitung embedding-nya. Gagal embed tidak fatal:
+// Query tetap dikembalikan tanpa Vector.
+func (c *Cache) Embed(ctx context.Context, question string) Query {
+	q := Query{Text: question, Norm: normalize(question)}
+	q.Keys = keyTokens(q.Norm)
+	if c.emb == nil || q.Norm == "" {
+		return q
+	}
+	vecs, err := c.emb.Embed(ctx, []string{q.Norm})
+	if err == nil && len(vecs) == 1 && len(vecs[0]) > 0 {
+		q.Vector = vecs[0]
+	}
+	return q
+}
+
+// Lookup mencari entri paling mirip pada scope yang sama. Mengembalikan nil bila
+// tidak ada yang melewati threshold. Kandidat dengan token kunci berbeda dilewati: "top 3 PO"
+// vs "top 5 PO" atau sumur/bulan lain bisa mirip secara embedding tetapi butuh jawaban lain.
+func (c *Cache) Lookup(q Query, scope string) (*Entry, float64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	var best *Entry
+	bestSim := 0.0
+	for _, e := range c.entries {
+		if e.Scope != scope || e.Keys != q.Keys || (c.cfg.TTL > 0 && now.Sub(e.CreatedAt) > c.cfg.TTL) {
+			continue
+		}
+		sim := similarity(q, e)
+		if sim > bestSim {
+			best, bestSim = e, sim
+		}
+	}
+	if best == nil || bestSim < c.cfg.Threshold {
+		return nil, bestSim
+	}
+	best.Hits++
+	return best, bestSim
+}
+
+// Store menyimpan jawaban. Hasil tool yang error tidak di-cache karena sifatnya sementara.
+func (c *Cache) Store(q Query, scope string, sources []mcp.ExecResult, answer string) *Entry {
+	if strings.TrimSpace(answer) == "" || q.Norm == "" {
+		return nil
+	}
+	routes := make([]mcp.Route, 0, len(sources))
+	for _, s := range sources {
+		if s.Error != "" {
+			return nil
+		}
+		routes = append(routes, s.Route)
+	}
+	e := &Entry{
+		Scope:       scope,
+		Question:    q.Text,
+		Norm:        q.Norm,
+		Keys:        keyTokens(q.Norm),
+		Vector:      q.Vector,
+		Routes:      routes,
+		Fingerprint: Fingerprint(sources),
+		Tables:      TablesFor(routes),
+		Answer:      answer,
+		CreatedAt:   time.Now(),
+	}
+	sum := sha256.Sum256([]byte(scope + "\n" + q.Norm + "\n" + e.Fingerprint))
+	e.ID = hex.EncodeToString(sum[:])[:16]
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	kept := c.entries[:0]
+	for _, old := range c.entries {
+		if old.Scope == scope && old.Norm == e.Norm {
+			continue // ganti jawaban lama untuk pertanyaan yang sama
+		}
+		kept = append(kept, old)
+	}
+	c.entries = append(kept, e)
+	if over := len(c.entries) - c.cfg.MaxEntries; over > 0 {
+		c.entries = append([]*Entry(nil), c.entries[over:]...)
+	}
+	return e
+}
+
+// Invalidate membuang satu entri.
+func (c *Cache) Invalidate(id string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	kept := c.entries[:0]
+	for _, e := range c.entries {
+		if e.ID != id {
+			kept = append(kept, e)
+		}
+	}
+	c.entries = kept
+}
+
+// InvalidateTables membuang entri yang bergantung pada salah satu tabel; mengembalikan jumlahnya.
+func (c *Cache) InvalidateTables(tables ...string) int {
+	if len(tables) == 0 {
+		return 0
+	}
+	changed := map[string]bool{}
+	for _, t := range tables {
+		changed[t] = true
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	kept := c.entries[:0]
+	n := 0
+	for _, e := range c.entries {
+		drop := false
+		for _, t := range e.Tables {
+			if changed[t] {
+				drop = true
+				break
+			}
+		}
+		if drop {
+			n++
+			continue
+		}
+		kept = append(kept, e)
+	}
+	c.entries = kept
+	return n
+}
+
+// Len mengembalikan jumlah entri.
+func (c *Cache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.entries)
+}
+
+// Fingerprint adalah hash hasil tool (route + data) dalam urutan eksekusi.
+func Fingerprint(sources []mcp.ExecResult) string {
+	h := sha256.New()
+	for _, s := range sources {
+		b, _ := json.Marshal(struct {
+			Kind   mcp.RouteKind   `json:"k"`
+			Tool   string          `json:"t,omitempty"`
+			Params json.RawMessage `json:"p,omitempty"`
+			Query  string          `json:"q,omitempty"`
+			Data   any             `json:"d,omitempty"`
+			Error  string          `json:"e,omitempty"`
+		}{s.Route.Kind, s.Route.Tool, s.Route.Params, s.Route.Query, s.Data, s.Error})
+		h.Write(b)
+		h.Write([]byte{'\n'})
+	}
+	return hex.EncodeToString(h.Sum(nil))[:32]
+}
+
+func similarity(q Query, e *Entry) float64 {
+	if q.Norm == e.Norm {
+		return 1
+	}
+	return vector.Cosine(q.Vector, e.Vector)
+}
+
+// normalize: huruf kecil, buang tanda baca di ujung & spasi berlebih.
+func normalize(s string) string {
+	s = strings.ToLower(strings.Join(strings.Fields(s), " "))
+	return strings.Trim(s, " ?!.,;:")
+}
+
+// months: nama bulan id/en (lengkap & singkatan) yang membedakan pertanyaan walau tanpa angka.
+var months = map[string]bool{}
+
+func init() {
+	for _, m := range strings.Fields(`januari februari maret april mei juni juli agustus september oktober november desember
+		january february march may june july august october december
+		jan feb mar apr jun jul agu aug sep okt oct nov des dec`) {
+		months[m] = true
+	}
+}
+
+// keyTokens mengembalikan token pembeda pertanyaan ternormalisasi, terurut & unik: token yang
+// mengandung angka (top-N, tahun, ID sumur/PO/WO) dan nama bulan.
+func keyTokens(norm string) string {
+	seen := map[string]bool{}
+	// "-" dipertahankan agar ID seperti a-12 / po-20250001 tetap satu token (a-12 ≠ b-12)
+	for _, t := range strings.FieldsFunc(norm, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '-' }) {
+		t = strings.Trim(t, "-")
+		if months[t] || strings.IndexFunc(t, unicode.IsDigit) >= 0 {
+			seen[t] = true
+		}
+	}
+	keys := make([]string, 0, len(seen))
+	for t := range seen {
+		keys = append(keys, t)
+	}
+	sort.Strings(keys)
+	return strings.Join(keys, " ")
+}
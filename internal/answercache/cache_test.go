@@ -0,0 +1,76 @@
+package answercache_test
+
+import (
+	"context"
+	"testing"
+
+	"mcp-oilgas/internal/answercache"
+	"mcp-oilgas/internal/llm"
+	"mcp-oilgas/internal/mcp"
+)
+
+func sources(amount float64) []mcp.ExecResult {
+	return []mcp.ExecResult{{
+		Route: mcp.Route{Kind: mcp.RouteMCP, Tool: "get_po_top_amount"},
+		Data:  map[string]any{"items": []any{map[string]any{"po_number": "PO-20250001", "amount": amount}}},
+	}}
+}
+
+func TestLookupSimilarQuestion(t *testing.T) {
+	c := answercache.New(answercache.Config{Threshold: 0.85}, &llm.Fake{Dim: 256})
+	ctx := context.Background()
+
+	c.Store(c.Embed(ctx, "Top 3 PO by amount?"), "en", sources(100), "PO-20250001 is the largest.")
+
+	e, sim := c.Lookup(c.Embed(ctx, "top 3 po amount"), "en")
+	if e == nil || sim < 0.85 {
+		t.Fatalf("expected hit, sim=%v", sim)
+	}
+	if e.Fingerprint != answercache.Fingerprint(sources(100)) {
+		t.Fatalf("fingerprint mismatch for same data")
+	}
+	if e.Fingerprint == answercache.Fingerprint(sources(101)) {
+		t.Fatalf("fingerprint must change when data changes")
+	}
+	if e, _ := c.Lookup(c.Embed(ctx, "npt bulan ini"), "en"); e != nil {
+		t.Fatalf("unexpected hit for different question")
+	}
+	if e, _ := c.Lookup(c.Embed(ctx, "top 3 po by amount"), "id"); e != nil {
+		t.Fatalf("scope must separate entries")
+	}
+}
+
+func TestInvalidateTables(t *testing.T) {
+	c := answercache.New(answercache.Config{}, nil)
+	ctx := context.Background()
+	c.Store(c.Embed(ctx, "top 3 po by amount"), "id", sources(1), "jawaban")
+	if c.Len() != 1 {
+		t.Fatalf("want 1 entry, got %d", c.Len())
+	}
+	if n := c.InvalidateTables("work_orders"); n != 0 {
+		t.Fatalf("unrelated table invalidated %d entries", n)
+	}
+	if n := c.InvalidateTables("purchase_orders"); n != 1 || c.Len() != 0 {
+		t.Fatalf("want entry dropped, n=%d len=%d", n, c.Len())
+	}
+}
+
+// Pertanyaan yang mirip secara embedding tetapi beda angka/ID/bulan tidak boleh memakai jawaban lama.
+func TestLookupRequiresSameKeyTokens(t *testing.T) {
+	c := answercache.New(answercache.Config{Threshold: 0.3}, &llm.Fake{Dim: 256})
+	ctx := context.Background()
+	c.Store(c.Embed(ctx, "top 3 PO terbesar"), "id", sources(1), "jawaban top 3")
+	c.Store(c.Embed(ctx, "NPT sumur A-12 bulan Januari 2025"), "id", sources(2), "jawaban A-12")
+
+	for _, q := range []string{"top 5 PO terbesar", "NPT sumur B-12 bulan Januari 2025", "NPT sumur A-12 bulan Februari 2025"} {
+		if e, sim := c.Lookup(c.Embed(ctx, q), "id"); e != nil {
+			t.Fatalf("%q served stale answer %q (sim=%.2f)", q, e.Answer, sim)
+		}
+	}
+	if e, _ := c.Lookup(c.Embed(ctx, "PO terbesar top 3"), "id"); e == nil || e.Answer != "jawaban top 3" {
+		t.Fatalf("same key tokens should still hit: %+v", e)
+	}
+	if e, _ := c.Lookup(c.Embed(ctx, "berapa NPT sumur A-12 januari 2025"), "id"); e == nil || e.Answer != "jawaban A-12" {
+		t.Fatalf("same well & month should still hit: %+v", e)
+	}
+}
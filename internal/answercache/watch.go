@@ -0,0 +1,147 @@
+// internal/answercache/watch.go
+// Pemetaan tool → tabel sumber & watcher perubahan tabel (information_schema.TABLES.UPDATE_TIME).
+package answercache
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"mcp-oilgas/internal/mcp"
+)
+
+// ToolTables memetakan tool MCP ke tabel yang dibacanya.
+var ToolTables = map[string][]string{
+	"get_timeseries":                 {"ts_value", "ts_signal"},
+	"detect_anomalies_and_correlate": {"ts_value", "ts_signal"},
+	"get_drilling_events":            {"drilling_events"},
+	"drilling_events:list":           {"drilling_events"},
+	"summarize_npt_events":           {"drilling_events"},
+	"get_po_status":                  {"purchase_orders"},
+	"get_po_vendor_compare":          {"purchase_orders"},
+	"get_po_vendor_summary":          {"purchase_orders"},
+	"get_po_top_amount":              {"purchase_orders"},
+	"get_production":                 {"prod_allocation_daily"},
+	"search_work_orders":             {"work_orders"},
+	"answer_with_docs":               {"doc_chunks"},
+}
+
+// TablesFor mengembalikan tabel unik yang dibaca routes (RAG → doc_chunks).
+func TablesFor(routes []mcp.Route) []string {
+	set := map[string]struct{}{}
+	for _, r := range routes {
+		if r.Kind == mcp.RouteRAG {
+			set["doc_chunks"] = struct{}{}
+			continue
+		}
+		for _, t := range ToolTables[r.Tool] {
+			set[t] = struct{}{}
+		}
+	}
+	out := make([]string, 0, len(set))
+	for t := range set {
+		out = append(out, t)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// WatchTables mem-polling UPDATE_TIME tabel sumber dan membuang entri yang bergantung pada
+// tabel yang berubah. Berjalan sampai ctx selesai; panggil sebagai goroutine.
+func (c *Cache) WatchTables(ctx context.Context, db *sql.DB) {
+	if db == nil {
+		return
+	}
+	interval := c.cfg.WatchInterval
+	if interval <= 0 {
+		interval = 30 * time.Second
+	}
+	tables := watchedTables()
+
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		if changed, err := c.pollTables(ctx, db, tables); err != nil {
+			log.Printf("[answercache] watch tables: %v", err)
+		} else if len(changed) > 0 {
+			n := c.InvalidateTables(changed...)
+			log.Printf("[answercache] tables changed %v → %d entries invalidated", changed, n)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// pollTables membandingkan versi tabel dengan poll sebelumnya. Poll pertama hanya
+// mencatat baseline.
+func (c *Cache) pollTables(ctx context.Context, db *sql.DB, tables []string) ([]string, error) {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	// MySQL 8 meng-cache statistik information_schema (default 24 jam); matikan untuk sesi ini.
+	// Di MySQL 5.7 variabel ini tidak ada dan UPDATE_TIME memang tidak di-cache.
+	_, _ = conn.ExecContext(ctx, "SET SESSION information_schema_stats_expiry = 0")
+
+	args := make([]any, len(tables))
+	for i, t := range tables {
+		args[i] = t
+	}
+	q := `SELECT TABLE_NAME, COALESCE(UPDATE_TIME, CREATE_TIME)
+		FROM information_schema.TABLES
+		WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME IN (?` + strings.Repeat(",?", len(tables)-1) + `)`
+	rows, err := conn.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	now := map[string]string{}
+	for rows.Next() {
+		var (
+			name string
+			ts   sql.NullString
+		)
+		if err := rows.Scan(&name, &ts); err != nil {
+			return nil, err
+		}
+		now[name] = ts.String
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var changed []string
+	for name, ver := range now {
+		if old, ok := c.tables[name]; ok && old != ver {
+			changed = append(changed, name)
+		}
+		c.tables[name] = ver
+	}
+	sort.Strings(changed)
+	return changed, nil
+}
+
+func watchedTables() []string {
+	set := map[string]struct{}{"doc_chunks": {}}
+	for _, ts := range ToolTables {
+		for _, t := range ts {
+			set[t] = struct{}{}
+		}
+	}
+	out := make([]string, 0, len(set))
+	for t := range set {
+		out = append(out, t)
+	}
+	sort.Strings(out)
+	return out
+}
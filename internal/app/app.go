@@ -16,13 +16,24 @@ import (
 	_ "github.com/go-sql-driver/mysql"
 	"github.com/gorilla/mux"
 
+	"mcp-oilgas/internal/analysis"
+	"mcp-oilgas/internal/answercache"
 	hh "mcp-oilgas/internal/handlers/http"
 	mcphandlers "mcp-oilgas/internal/handlers/mcp"
 	ragh "mcp-oilgas/internal/handlers/rag" // RAG hybrid (BM25 + cosine)
+	"mcp-oilgas/internal/ingest"
+	"mcp-oilgas/internal/lexindex"
+	"mcp-oilgas/internal/llm"
 	"mcp-oilgas/internal/mcp"
+	"mcp-oilgas/internal/queryembed"
+	"mcp-oilgas/internal/queryrewrite"
+	"mcp-oilgas/internal/redact"
+	"mcp-oilgas/internal/repositories/documents"
 	mysqlrepo "mcp-oilgas/internal/repositories/mysql"
 	searchrepo "mcp-oilgas/internal/repositories/search"
-	"mcp-oilgas/pkg/vector"
+	"mcp-oilgas/internal/rerank"
+	"mcp-oilgas/internal/vectorindex"
+	"mcp-oilgas/internal/vectorstore"
 )
 
 
@@ -33,6 +44,9 @@ type App struct {
 
 // New membuat instance App + registrasi semua routes (HTTP & MCP)
 func New() *App {
+	// Redaksi PII/secret di semua log standar (REDACT_LOGS)
+	redact.InstallLogFilter()
+
 	r := mux.NewRouter()
 
 	// === init DB ===
@@ -88,32 +102,95 @@ func New() *App {
 		log.Printf("[WARN] DB_DSN/DB_DSN_DOCKER empty; skipping DB init")
 	}
 
+	// ==== Embedder bersama (internal/llm) untuk RAG repo & cache jawaban ====
+	embedder, embErr := llm.SharedEmbedder()
+	if embErr != nil {
+		log.Printf("[WARN] init embeddings client: %v", embErr)
+	}
+
+	// ==== Reranker opsional setelah retrieval (RERANK=lexical|llm|http) ====
+	rrCfg := rerank.ConfigFromEnv()
+	reranker, rrErr := rerank.New(rrCfg)
+	if rrErr != nil {
+		log.Printf("[WARN] init reranker: %v", rrErr)
+	}
+
 	// ==== Inisialisasi RAG repo untuk /ask & SSE (pipeline existing) ====
+	// Backend vector store (VECTOR_STORE=mysql|memory|pgvector); nil = repo MySQL yang ada
+	store := openVectorStore(db)
 	var ragRepo searchrepo.RAGRepo
-	if db != nil {
-		embedClient, e := vector.NewOpenAIClientFromEnv()
-		if e != nil {
-			log.Printf("[WARN] init embeddings client: %v", e)
-		} else {
-			ragRepo = searchrepo.NewRAGRepo(db, embedClient, "text-embedding-3-small", 200)
-		}
+	if store != nil && embedder != nil {
+		ragRepo = searchrepo.WithReranker(vectorstore.Retriever{Store: store, Embedder: embedder}, reranker, rrCfg)
+	} else if db != nil && embedder != nil {
+		ragRepo = searchrepo.WithReranker(searchrepo.NewRAGRepo(db, embedder, searchrepo.PrefilterFromEnv()), reranker, rrCfg)
 	}
 	// share ke SSE handler (opsional)
 	hh.SetRAGRepo(ragRepo)
 
+	// ==== Cache jawaban semantik untuk /chat/stream ====
+	if cacheCfg := answercache.ConfigFromEnv(); cacheCfg.Enabled {
+		ac := answercache.New(cacheCfg, embedder)
+		hh.SetAnswerCache(ac)
+		if db != nil {
+			go ac.WatchTables(context.Background(), db)
+		}
+	}
+
+	// ==== Antrian ingest dokumen (diproses cmd/worker) ====
+	if db != nil {
+		hh.SetIngestJobs(ingest.NewJobStore(db))
+		hh.SetDocuments(&documents.Repo{DB: db})
+		hh.SetVectorStore(store)
+	}
+
 	// ---- HTTP routes (UI/API biasa) ----
 	RegisterRoutesWithDeps(r, RegisterDeps{RAGRepo: ragRepo})
 
 	// ---- RAG Hybrid (BM25 + Cosine) terhadap doc_chunks.embedding (JSON) ----
 	// Endpoint ini langsung memakai repo MySQL-native tanpa memanggil OpenAI di query-time.
 	if db != nil {
-		rv2 := &ragh.HandlerV2{RAG: &mysqlrepo.RAGRepo{DB: db}}
+		rv2 := &ragh.HandlerV2{Reranker: reranker, RerankConfig: rrCfg}
+		if store != nil {
+			// backend vector store menggantikan repo MySQL: indeks ANN & BM25 in-process tidak dipakai
+			rv2.RAG = vectorstore.RAG{Store: store}
+		} else {
+			ragV2Repo := &mysqlrepo.RAGRepo{DB: db}
+			// Indeks ANN in-process atas seluruh embedding chunk (dibangun di background, sync berkala)
+			if vcfg := vectorindex.ConfigFromEnv(); vcfg.Enabled {
+				idx := vectorindex.New(db, vcfg)
+				go idx.Start(context.Background())
+				ragV2Repo.ANN = idx
+				hh.SetVectorIndex(idx)
+			}
+			// Indeks BM25 in-process dengan analyzer Indonesia/Inggris (LEXICAL_SEARCH=go|both)
+			if mode := mysqlrepo.LexicalModeFromEnv(); mode != mysqlrepo.LexicalFullText {
+				if an, err := analysis.New(analysis.ConfigFromEnv()); err != nil {
+					log.Printf("[WARN] init analyzer: %v; lexical search tetap FULLTEXT", err)
+				} else {
+					lx := lexindex.New(db, lexindex.ConfigFromEnv(), an)
+					go lx.Start(context.Background())
+					ragV2Repo.Lexical, ragV2Repo.LexicalMode = lx, mode
+					hh.SetLexicalIndex(lx)
+				}
+			}
+			rv2.RAG = ragV2Repo
+		}
+		// Query rewriting / multi-query (QUERY_REWRITE=glossary|llm)
+		if qr, err := queryrewrite.New(queryrewrite.ConfigFromEnv()); err != nil {
+			log.Printf("[WARN] init query rewriter: %v", err)
+		} else if qr != nil {
+			rv2.Rewriter = qr
+		}
+		// Embedding query di server (LRU + cache MySQL, timeout → BM25 saja)
+		if qcfg := queryembed.ConfigFromEnv(); qcfg.Enabled && embedder != nil {
+			rv2.Embedder = queryembed.New(embedder, db, qcfg)
+		}
 
 		// GET untuk debug (pakai ?q=...), POST untuk payload JSON {query, query_embedding, top_k, alpha}
 		r.HandleFunc("/rag/search_v2", rv2.SearchV2).Methods(http.MethodGet, http.MethodPost)
 
 		// Wire "answer_with_docs" agar auto-retrieve via hybrid /rag/search_v2 (in-process)
-		mcphandlers.RegisterRetriever(func(ctx context.Context, q string, topK int) ([]mcphandlers.DocChunkRef, error) {
+		mcphandlers.RegisterRetriever(func(ctx context.Context, q string, topK int, f documents.Filter, opt mysqlrepo.HybridOptions) ([]mcphandlers.DocChunkRef, error) {
 			if topK <= 0 || topK > 50 {
 				topK = 10
 			}
@@ -121,8 +198,12 @@ func New() *App {
 			payload := map[string]any{
 				"query": q,
 				"top_k": topK,
-				"alpha": 0.6, // BM25:cosine blend; sama seperti yang dipakai di normalizer
+				"alpha": mysqlrepo.AlphaFromEnv(), // BM25:cosine blend (RAG_ALPHA); sama seperti yang dipakai di normalizer
+			}
+			if !f.Empty() {
+				payload["filters"] = f
 			}
+			mcp.AddFusionParams(payload, opt)
 			b, _ := json.Marshal(payload)
 
 			// re-use handler SearchV2 in-process (tanpa HTTP nyata)
@@ -137,31 +218,36 @@ func New() *App {
 
 			// Bentuk respons SearchV2 -> ubah ke DocChunkRef utk answer_with_docs
 			// ✅ Decode ke objek dengan field retrieved_chunks (sesuai output /rag/search_v2)
-var resp struct {
-    RetrievedChunks []struct {
-        DocID   string `json:"doc_id"`
-        Title   string `json:"title"`
-        URL     string `json:"url"`
-        Snippet string `json:"snippet"`
-        PageNo  int    `json:"page_no"`
-    } `json:"retrieved_chunks"`
-}
-if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
-    return nil, fmt.Errorf("decode rag response: %w; body=%s", err, rr.Body.String())
-}
-
-out := make([]mcphandlers.DocChunkRef, 0, len(resp.RetrievedChunks))
-for _, h := range resp.RetrievedChunks {
-    out = append(out, mcphandlers.DocChunkRef{
-        DocID:   h.DocID,
-        Title:   h.Title,
-        URL:     h.URL,
-        Snippet: h.Snippet,
-        PageNo:  h.PageNo,
-    })
-}
-return out, nil
+			var resp struct {
+				RetrievedChunks []struct {
+					ChunkID   int64  `json:"chunk_id"`
+					DocID     string `json:"doc_id"`
+					Title     string `json:"title"`
+					URL       string `json:"url"`
+					Snippet   string `json:"snippet"`
+					PageNo    int    `json:"page_no"`
+					CharStart int    `json:"char_start"`
+					CharEnd   int    `json:"char_end"`
+				} `json:"retrieved_chunks"`
+			}
+			if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+				return nil, fmt.Errorf("decode rag response: %w; body=%s", err, rr.Body.String())
+			}
 
+			out := make([]mcphandlers.DocChunkRef, 0, len(resp.RetrievedChunks))
+			for _, h := range resp.RetrievedChunks {
+				out = append(out, mcphandlers.DocChunkRef{
+					ChunkID:   h.ChunkID,
+					DocID:     h.DocID,
+					Title:     h.Title,
+					URL:       h.URL,
+					Snippet:   h.Snippet,
+					PageNo:    h.PageNo,
+					CharStart: h.CharStart,
+					CharEnd:   h.CharEnd,
+				})
+			}
+			return out, nil
 		})
 	}
 
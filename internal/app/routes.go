@@ -78,4 +78,19 @@ func RegisterRoutesWithDeps(r *mux.Router, deps RegisterDeps) {
 	adminJWT.Use(middleware.AdminJWTAuth)
 	adminJWT.HandleFunc("/docs", hh.AdminListDocs).Methods(http.MethodGet)
 	adminJWT.HandleFunc("/docs/upload", hh.AdminUploadDoc).Methods(http.MethodPost)
+	adminJWT.HandleFunc("/ingest/jobs", hh.AdminListIngestJobs).Methods(http.MethodGet)
+	adminJWT.HandleFunc("/ingest/jobs/{id:[0-9]+}", hh.AdminGetIngestJob).Methods(http.MethodGet)
+	adminJWT.HandleFunc("/documents", hh.AdminListDocuments).Methods(http.MethodGet)
+	adminJWT.HandleFunc("/documents/{doc_id}", hh.AdminGetDocument).Methods(http.MethodGet)
+	adminJWT.HandleFunc("/documents/{doc_id}", hh.AdminUpdateDocument).Methods(http.MethodPut)
+	adminJWT.HandleFunc("/documents/{doc_id}", hh.AdminDeleteDocument).Methods(http.MethodDelete)
+	adminJWT.HandleFunc("/documents/{doc_id}/restore", hh.AdminRestoreDocument).Methods(http.MethodPost)
+	adminJWT.HandleFunc("/documents/{doc_id}/versions", hh.AdminListDocumentVersions).Methods(http.MethodGet)
+	adminJWT.HandleFunc("/documents/{doc_id}/versions/{version:[0-9]+}/activate", hh.AdminActivateDocumentVersion).Methods(http.MethodPost)
+	adminJWT.HandleFunc("/vector-index", hh.AdminVectorIndexStats).Methods(http.MethodGet)
+	adminJWT.HandleFunc("/vector-index/sync", hh.AdminVectorIndexSync).Methods(http.MethodPost)
+	adminJWT.HandleFunc("/lexical-index", hh.AdminLexicalIndexStats).Methods(http.MethodGet)
+	adminJWT.HandleFunc("/lexical-index/sync", hh.AdminLexicalIndexSync).Methods(http.MethodPost)
+	adminJWT.HandleFunc("/prompts", hh.AdminListPrompts).Methods(http.MethodGet)
+	adminJWT.HandleFunc("/prompts/reload", hh.AdminReloadPrompts).Methods(http.MethodPost)
 }
@@ -0,0 +1,50 @@
+// internal/app/vectorstore.go
+package app
+
+import (
+	"context"
+	"database/sql"
+	"log"
+
+	"mcp-oilgas/internal/analysis"
+	"mcp-oilgas/internal/vectorstore"
+)
+
+// openVectorStore membuka backend VECTOR_STORE selain mysql; nil = pakai repo MySQL apa adanya.
+// memory dimuat dari doc_chunks lalu disinkronkan berkala di background (VECTOR_STORE_SYNC_INTERVAL);
+// pgvector diisi cmd/vectorstore-sync.
+func openVectorStore(db *sql.DB) vectorstore.Store {
+	cfg := vectorstore.ConfigFromEnv()
+	if cfg.Backend == vectorstore.BackendMySQL {
+		return nil
+	}
+	if err := cfg.Validate(); err != nil {
+		log.Printf("[WARN] %v; using mysql", err)
+		return nil
+	}
+	an, err := analysis.New(analysis.ConfigFromEnv())
+	if err != nil {
+		log.Printf("[WARN] init analyzer: %v; using built-in synonyms only", err)
+		an = analysis.Default()
+	}
+	switch cfg.Backend {
+	case vectorstore.BackendMemory:
+		if db == nil {
+			log.Printf("[WARN] vector store memory needs MySQL as source; using mysql")
+			return nil
+		}
+		mem := vectorstore.NewMemory(an)
+		sync := &vectorstore.MemorySync{Src: vectorstore.NewMySQL(db, nil), Dst: mem, Interval: cfg.SyncInterval}
+		go sync.Start(context.Background())
+		return mem
+	case vectorstore.BackendPGVector:
+		pg, err := vectorstore.OpenPGVector(context.Background(), cfg, an)
+		if err != nil {
+			log.Printf("[WARN] vector store pgvector: %v; using mysql", err)
+			return nil
+		}
+		log.Printf("[vectorstore] pgvector: table %s", pg.Table)
+		return pg
+	}
+	return nil
+}
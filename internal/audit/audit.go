@@ -0,0 +1,137 @@
+// internal/audit/audit.go
+// Audit log JSONL append-only (satu event per baris) untuk jejak keamanan & akses data.
+//
+// Stream yang dikenal (path dari env, folder dibuat otomatis):
+//   - Security():   AUDIT_SECURITY   (default logs/audit/security.log)
+//   - ToolCalls():  AUDIT_TOOL_CALLS (default logs/audit/tool_calls.log)
+//
+// Bila file tidak bisa dibuka, event ditulis ke log standar agar tidak hilang.
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"mcp-oilgas/internal/redact"
+)
+
+// Logger menulis event sebagai satu baris JSON; aman dipakai konkuren.
+type Logger struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// New membungkus writer apa pun (mis. bytes.Buffer di test).
+func New(w io.Writer) *Logger { return &Logger{w: w} }
+
+// Open membuka (append) file audit di path; folder induk dibuat bila belum ada.
+func Open(path string) (*Logger, error) {
+	if dir := filepath.Dir(path); dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, err
+		}
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o640)
+	if err != nil {
+		return nil, err
+	}
+	return &Logger{w: f}, nil
+}
+
+// Record menulis satu event. Field "ts" & "event" selalu diisi; request_id diambil dari ctx bila ada.
+func (l *Logger) Record(ctx context.Context, event string, fields map[string]any) {
+	if l == nil {
+		return
+	}
+	rec := make(map[string]any, len(fields)+3)
+	for k, v := range fields {
+		rec[k] = v
+	}
+	rec["ts"] = time.Now().UTC().Format(time.RFC3339Nano)
+	rec["event"] = event
+	if id := RequestID(ctx); id != "" {
+		rec["request_id"] = id
+	}
+	b, err := json.Marshal(rec)
+	if err != nil {
+		log.Printf("[audit] marshal %s: %v", event, err)
+		return
+	}
+	b = []byte(redact.Log(string(b)))
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.w == nil {
+		log.Printf("[audit] %s", b)
+		return
+	}
+	if _, err := l.w.Write(append(b, '\n')); err != nil {
+		log.Printf("[audit] write %s: %v", event, err)
+	}
+}
+
+// ----------------- Stream bawaan -----------------
+
+var (
+	streamsMu sync.Mutex
+	streams   = map[string]*Logger{}
+)
+
+func stream(envKey, def string) *Logger {
+	streamsMu.Lock()
+	defer streamsMu.Unlock()
+	if l, ok := streams[envKey]; ok {
+		return l
+	}
+	path := strings.TrimSpace(os.Getenv(envKey))
+	if path == "" {
+		path = def
+	}
+	l, err := Open(path)
+	if err != nil {
+		log.Printf("[audit] open %s (%s): %v; fallback ke log standar", envKey, path, err)
+		l = &Logger{}
+	}
+	streams[envKey] = l
+	return l
+}
+
+// Security: event keamanan (mis. indikasi prompt injection pada dokumen/tool output).
+func Security() *Logger { return stream("AUDIT_SECURITY", "logs/audit/security.log") }
+
+// ToolCalls: jejak pemanggilan tool MCP.
+func ToolCalls() *Logger { return stream("AUDIT_TOOL_CALLS", "logs/audit/tool_calls.log") }
+
+// SetSecurity mengganti stream Security (dipakai test / wiring khusus).
+func SetSecurity(l *Logger) {
+	streamsMu.Lock()
+	streams["AUDIT_SECURITY"] = l
+	streamsMu.Unlock()
+}
+
+// ----------------- Request ID di context -----------------
+
+type ctxKey struct{}
+
+// WithRequestID menyimpan X-Request-ID di ctx agar ikut tercatat di event audit.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	if id == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, ctxKey{}, id)
+}
+
+// RequestID mengambil request id dari ctx ("" bila tidak ada).
+func RequestID(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	id, _ := ctx.Value(ctxKey{}).(string)
+	return id
+}
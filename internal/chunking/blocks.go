@@ -0,0 +1,141 @@
+// internal/chunking/blocks.go
+// Deteksi struktur teks hasil ekstraksi: heading, paragraf, dan tabel (baris ber-pipe/tab).
+package chunking
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+const (
+	blockPara = iota
+	blockHeading
+	blockTable
+)
+
+// block adalah rentang byte [start, end) di teks dokumen.
+type block struct {
+	start, end int
+	kind       int
+}
+
+var (
+	reMDHeading  = regexp.MustCompile(`^#{1,6}\s+\S`)
+	reNumHeading = regexp.MustCompile(`^(\d+(\.\d+)+\.?|BAB\s+[IVXLC]+\.?)\s+\p{Lu}`)
+	reTableSep   = regexp.MustCompile(`^[\s|:+\-]+$`)
+)
+
+// parseBlocks mengelompokkan baris doc[from:to] menjadi blok. Baris kosong dan "\f"
+// selalu mengakhiri blok; heading selalu menjadi blok sendiri.
+func parseBlocks(doc string, from, to int, tables bool) []block {
+	var (
+		out []block
+		cur = block{start: -1}
+	)
+	closeCur := func() {
+		if cur.start < 0 {
+			return
+		}
+		if cur.kind == blockTable && !strings.Contains(doc[cur.start:cur.end], "\n") {
+			cur.kind = blockPara // satu baris ber-pipe belum tentu tabel
+		}
+		out = append(out, cur)
+		cur = block{start: -1}
+	}
+
+	for pos := from; pos < to; {
+		end := strings.IndexAny(doc[pos:to], "\n\f")
+		brk := byte(0)
+		if end < 0 {
+			end = to
+		} else {
+			end += pos
+			brk = doc[end]
+		}
+		line := doc[pos:end]
+		switch {
+		case strings.TrimSpace(line) == "":
+			closeCur()
+		case tables && isTableRow(line):
+			if cur.start >= 0 && cur.kind != blockTable {
+				closeCur()
+			}
+			if cur.start < 0 {
+				cur = block{start: pos, kind: blockTable}
+			}
+			cur.end = end
+		case isHeading(line):
+			closeCur()
+			out = append(out, block{start: pos, end: end, kind: blockHeading})
+		default:
+			if cur.start >= 0 && cur.kind != blockPara {
+				closeCur()
+			}
+			if cur.start < 0 {
+				cur = block{start: pos, kind: blockPara}
+			}
+			cur.end = end
+		}
+		if brk == '\f' {
+			closeCur()
+		}
+		pos = end + 1
+	}
+	closeCur()
+	return out
+}
+
+// isHeading: heading markdown, bernomor bertingkat ("2.1 Prosedur", "BAB II ..."), atau baris pendek
+// huruf kapital. Butir daftar "1. Tutup valve" sengaja tidak dianggap heading.
+func isHeading(line string) bool {
+	t := strings.TrimSpace(line)
+	if reMDHeading.MatchString(t) {
+		return true
+	}
+	n := runes(t)
+	if n > 100 || strings.HasSuffix(t, ".") || strings.HasSuffix(t, ",") || strings.HasSuffix(t, ";") {
+		return false
+	}
+	if reNumHeading.MatchString(t) && len(strings.Fields(t)) <= 10 {
+		return true
+	}
+	if n > 80 {
+		return false
+	}
+	letters := 0
+	for _, r := range t {
+		if unicode.IsLetter(r) {
+			if !unicode.IsUpper(r) {
+				return false
+			}
+			letters++
+		}
+	}
+	return letters >= 4
+}
+
+// headingText membuang penanda markdown dan titik-titik daftar isi dari heading.
+func headingText(line string) string {
+	t := strings.TrimLeft(strings.TrimSpace(line), "#")
+	return strings.TrimSpace(strings.TrimRight(t, ".:·… \t"))
+}
+
+// isTableRow: minimal dua pipe (tabel markdown/ASCII) atau sel dipisah tab.
+func isTableRow(line string) bool {
+	if strings.Count(line, "|") >= 2 {
+		return true
+	}
+	cells := 0
+	for _, c := range strings.Split(line, "\t") {
+		if strings.TrimSpace(c) != "" {
+			cells++
+		}
+	}
+	return cells >= 2
+}
+
+// isTableSeparator: baris pemisah header markdown (|---|:---:|).
+func isTableSeparator(line string) bool {
+	return strings.Contains(line, "-") && reTableSep.MatchString(line)
+}
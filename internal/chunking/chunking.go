@@ -0,0 +1,221 @@
+// internal/chunking/chunking.go
+// Pemecahan dokumen menjadi chunk untuk RAG. Strategi:
+//
+//	fixed     → jendela token tetap dengan overlap (struktur diabaikan)
+//	recursive → heading → paragraf/tabel → baris → kalimat → kata (default)
+//	page      → seperti recursive, tetapi chunk tidak pernah melewati batas halaman (PDF)
+//
+// Setiap chunk adalah potongan persis dari teks dokumen (halaman digabung dengan "\f")
+// beserta offset rune [Start, End), sehingga sitasi bisa menunjuk span yang tepat.
+package chunking
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Strategi chunking.
+const (
+	StrategyFixed     = "fixed"
+	StrategyRecursive = "recursive"
+	StrategyPage      = "page"
+)
+
+// PageBreak memisahkan halaman di teks dokumen acuan offset.
+const PageBreak = "\f"
+
+// Config mengatur satu strategi chunking.
+type Config struct {
+	Strategy string `json:"strategy,omitempty"`
+	Size     int    `json:"size,omitempty"`    // fixed: token; recursive/page: rune
+	Overlap  int    `json:"overlap,omitempty"` // satuan sama dengan Size; negatif = tanpa overlap
+	// PlainTables = true memperlakukan tabel sebagai paragraf biasa. Default (false): tabel
+	// tidak dipotong di tengah baris; tabel besar dipecah per baris dengan header diulang.
+	PlainTables bool `json:"plain_tables,omitempty"`
+}
+
+// Batas bawah Size: chunk lebih kecil tidak berguna untuk retrieval, dan ukuran 1-2 membuat
+// anggaran tabel (Size dikurangi header) habis sehingga splitter tidak bisa maju.
+const (
+	minRunes  = 64 // recursive/page
+	minTokens = 16 // fixed
+)
+
+// DefaultConfig: recursive 1200 rune, overlap 150, tabel utuh.
+func DefaultConfig() Config {
+	return Config{Strategy: StrategyRecursive, Size: 1200, Overlap: 150}
+}
+
+func (c Config) normalized() Config {
+	switch c.Strategy {
+	case StrategyFixed, StrategyPage:
+	default:
+		c.Strategy = StrategyRecursive
+	}
+	if c.Size <= 0 {
+		c.Size = 1200
+		if c.Strategy == StrategyFixed {
+			c.Size = 256
+		}
+	}
+	if c.Strategy == StrategyFixed {
+		c.Size = max(c.Size, minTokens)
+	} else {
+		c.Size = max(c.Size, minRunes)
+	}
+	switch {
+	case c.Overlap < 0:
+		c.Overlap = 0
+	case c.Overlap >= c.Size/2:
+		c.Overlap = c.Size / 8
+	}
+	return c
+}
+
+// Page adalah teks satu halaman (No mulai dari 1).
+type Page struct {
+	No   int
+	Text string
+}
+
+// Chunk adalah satu potongan dokumen. Text == []rune(Join(pages))[Start:End].
+type Chunk struct {
+	Index   int    `json:"index"`
+	Text    string `json:"text"`
+	Page    int    `json:"page"`     // halaman tempat chunk dimulai
+	PageEnd int    `json:"page_end"` // halaman tempat chunk berakhir (> Page bila melewati batas halaman)
+	Start   int    `json:"start"`    // offset rune (inklusif) di teks dokumen
+	End     int    `json:"end"`      // offset rune (eksklusif)
+	Heading string `json:"heading,omitempty"`
+	// Context berisi header tabel untuk chunk lanjutan tabel (tidak termasuk Text/offset).
+	Context string `json:"context,omitempty"`
+	Table   bool   `json:"table,omitempty"`
+}
+
+// EmbedText menyusun teks untuk embedding: judul dokumen, heading, header tabel, lalu isi chunk.
+func (c Chunk) EmbedText(title string) string {
+	parts := make([]string, 0, 4)
+	for _, s := range []string{title, c.Heading, c.Context, c.Text} {
+		if s = strings.TrimSpace(s); s != "" {
+			parts = append(parts, s)
+		}
+	}
+	return strings.Join(parts, "\n")
+}
+
+// Join menyusun teks dokumen acuan offset (halaman digabung PageBreak).
+func Join(pages []Page) string {
+	texts := make([]string, len(pages))
+	for i, p := range pages {
+		texts[i] = p.Text
+	}
+	return strings.Join(texts, PageBreak)
+}
+
+// span adalah rentang byte di teks dokumen sebelum dikonversi menjadi Chunk.
+type span struct {
+	start, end int
+	heading    string
+	context    string
+	table      bool
+}
+
+// Split memecah halaman-halaman menurut cfg.
+func Split(pages []Page, cfg Config) []Chunk {
+	cfg = cfg.normalized()
+	doc := Join(pages)
+
+	// byte awal tiap halaman di doc
+	starts := make([]int, len(pages))
+	off := 0
+	for i, p := range pages {
+		starts[i] = off
+		off += len(p.Text) + len(PageBreak)
+	}
+
+	var spans []span
+	switch cfg.Strategy {
+	case StrategyFixed:
+		spans = fixedSpans(doc, cfg.Size, cfg.Overlap)
+	case StrategyPage:
+		for i, p := range pages {
+			blocks := parseBlocks(doc, starts[i], starts[i]+len(p.Text), !cfg.PlainTables)
+			spans = append(spans, pack(doc, blocks, cfg.Size, cfg.Overlap)...)
+		}
+	default:
+		spans = pack(doc, parseBlocks(doc, 0, len(doc), !cfg.PlainTables), cfg.Size, cfg.Overlap)
+	}
+
+	pageAt := func(b int) int {
+		i := sort.SearchInts(starts, b+1) - 1
+		if i < 0 || i >= len(pages) {
+			return 0
+		}
+		return pages[i].No
+	}
+
+	var (
+		out    []Chunk
+		rs, re runeCounter
+	)
+	rs.s, re.s = doc, doc
+	for _, sp := range spans {
+		s, e := trimSpan(doc, sp.start, sp.end)
+		if s >= e {
+			continue
+		}
+		if n := len(out); n > 0 && out[n-1].Text == doc[s:e] && out[n-1].Start == rs.at(s) {
+			continue
+		}
+		out = append(out, Chunk{
+			Index:   len(out),
+			Text:    doc[s:e],
+			Page:    pageAt(s),
+			PageEnd: pageAt(e - 1),
+			Start:   rs.at(s),
+			End:     re.at(e),
+			Heading: sp.heading,
+			Context: sp.context,
+			Table:   sp.table,
+		})
+	}
+	return out
+}
+
+// runeCounter mengonversi offset byte → offset rune secara inkremental
+// (offset yang diminta umumnya naik monoton).
+type runeCounter struct {
+	s    string
+	b, r int
+}
+
+func (c *runeCounter) at(b int) int {
+	if b < c.b {
+		c.b, c.r = 0, 0
+	}
+	c.r += utf8.RuneCountInString(c.s[c.b:b])
+	c.b = b
+	return c.r
+}
+
+func trimSpan(doc string, s, e int) (int, int) {
+	for s < e {
+		r, sz := utf8.DecodeRuneInString(doc[s:e])
+		if !unicode.IsSpace(r) {
+			break
+		}
+		s += sz
+	}
+	for e > s {
+		r, sz := utf8.DecodeLastRuneInString(doc[s:e])
+		if !unicode.IsSpace(r) {
+			break
+		}
+		e -= sz
+	}
+	return s, e
+}
+
+func runes(s string) int { return utf8.RuneCountInString(s) }
@@ -0,0 +1,168 @@
+// internal/chunking/chunking_test.go
+
+package chunking_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"mcp-oilgas/internal/chunking"
+)
+
+func samplePages() []chunking.Page {
+	var p1 []string
+	for i := 0; i < 30; i++ {
+		p1 = append(p1, fmt.Sprintf("Langkah %d: periksa tekanan casing dan catat laju gas sebelum membuka choke.", i))
+	}
+	return []chunking.Page{
+		{No: 1, Text: "# Prosedur Well Control\n\n" + strings.Join(p1[:15], " ") + "\n\n2.1 Deteksi Kick\n\n" + strings.Join(p1[15:], " ")},
+		{No: 2, Text: "LAMPIRAN DATA\n\n| Sumur | NPT (jam) |\n|---|---|\n| A-12 | 14 |\n| B-03 | 6 |\n\nCatatan penutup — ☑ selesai."},
+	}
+}
+
+// Text setiap chunk harus sama persis dengan span [Start,End) di teks dokumen.
+func checkOffsets(t *testing.T, pages []chunking.Page, chunks []chunking.Chunk) {
+	t.Helper()
+	doc := []rune(chunking.Join(pages))
+	for _, c := range chunks {
+		if c.Start < 0 || c.End > len(doc) || string(doc[c.Start:c.End]) != c.Text {
+			t.Fatalf("chunk %d offsets [%d,%d) do not match text %q", c.Index, c.Start, c.End, c.Text)
+		}
+	}
+}
+
+func TestRecursiveHeadingsOverlapAndOffsets(t *testing.T) {
+	pages := samplePages()
+	chunks := chunking.Split(pages, chunking.Config{Strategy: chunking.StrategyRecursive, Size: 400, Overlap: 80})
+	checkOffsets(t, pages, chunks)
+	if len(chunks) < 4 {
+		t.Fatalf("expected several chunks, got %d", len(chunks))
+	}
+	sawSection, sawOverlap := false, false
+	for i, c := range chunks {
+		if n := len([]rune(c.Text)); n > 400 {
+			t.Fatalf("chunk %d too long: %d", i, n)
+		}
+		if strings.HasPrefix(c.Text, "2.1 Deteksi Kick") {
+			sawSection = c.Heading == "2.1 Deteksi Kick"
+		}
+		if i > 0 && c.Start < chunks[i-1].End && c.Heading == chunks[i-1].Heading {
+			sawOverlap = true
+		}
+	}
+	if !sawSection {
+		t.Fatalf("expected a chunk to start at heading 2.1: %+v", chunks)
+	}
+	if !sawOverlap {
+		t.Fatalf("expected overlapping chunks within a section")
+	}
+	if chunks[0].Heading != "Prosedur Well Control" || chunks[0].Page != 1 {
+		t.Fatalf("first chunk: %+v", chunks[0])
+	}
+}
+
+func TestPageStrategyNeverCrossesPages(t *testing.T) {
+	pages := []chunking.Page{{No: 1, Text: "awal dokumen pendek"}, {No: 2, Text: "halaman dua juga pendek"}}
+	rec := chunking.Split(pages, chunking.Config{Strategy: chunking.StrategyRecursive, Size: 200})
+	if len(rec) != 1 || rec[0].Page != 1 || rec[0].PageEnd != 2 {
+		t.Fatalf("recursive should merge short pages: %+v", rec)
+	}
+	pg := chunking.Split(pages, chunking.Config{Strategy: chunking.StrategyPage, Size: 200})
+	checkOffsets(t, pages, pg)
+	if len(pg) != 2 || pg[1].Page != 2 || pg[1].PageEnd != 2 || pg[1].Start != len([]rune(pages[0].Text))+1 {
+		t.Fatalf("page strategy: %+v", pg)
+	}
+}
+
+func TestFixedTokenWindows(t *testing.T) {
+	words := make([]string, 100)
+	for i := range words {
+		words[i] = fmt.Sprintf("w%d", i)
+	}
+	pages := []chunking.Page{{No: 1, Text: strings.Join(words, " ")}}
+	chunks := chunking.Split(pages, chunking.Config{Strategy: chunking.StrategyFixed, Size: 40, Overlap: 10})
+	checkOffsets(t, pages, chunks)
+	if len(chunks) != 3 {
+		t.Fatalf("expected 3 windows (0-40, 30-70, 60-100), got %d", len(chunks))
+	}
+	if !strings.HasPrefix(chunks[1].Text, "w30 ") || !strings.HasSuffix(chunks[1].Text, " w69") {
+		t.Fatalf("window 2: %q", chunks[1].Text)
+	}
+}
+
+func TestTablesKeptWholeOrSplitWithHeader(t *testing.T) {
+	pages := samplePages()
+	chunks := chunking.Split(pages, chunking.Config{Size: 400, Overlap: 80})
+	found := false
+	for _, c := range chunks {
+		if strings.Contains(c.Text, "| Sumur |") {
+			found = c.Table && strings.Contains(c.Text, "| B-03 | 6 |")
+		}
+	}
+	if !found {
+		t.Fatalf("small table should stay in one chunk: %+v", chunks)
+	}
+
+	rows := []string{"| Vendor | PO | Status |", "|---|---|---|"}
+	for i := 0; i < 40; i++ {
+		rows = append(rows, fmt.Sprintf("| PT Vendor %02d | PO-2024-%03d | delivered |", i, i))
+	}
+	big := []chunking.Page{{No: 1, Text: strings.Join(rows, "\n")}}
+	chunks = chunking.Split(big, chunking.Config{Size: 300, Overlap: 50})
+	checkOffsets(t, big, chunks)
+	if len(chunks) < 3 {
+		t.Fatalf("expected table to be split, got %d chunks", len(chunks))
+	}
+	for i, c := range chunks {
+		if !c.Table || !strings.HasPrefix(c.Text, "| ") || !strings.HasSuffix(c.Text, "|") {
+			t.Fatalf("table chunk %d cut mid-row: %q", i, c.Text)
+		}
+		if i > 0 && c.Context != "| Vendor | PO | Status |\n|---|---|---|" {
+			t.Fatalf("continuation chunk %d missing header context: %q", i, c.Context)
+		}
+		if i > 0 && !strings.Contains(c.EmbedText("PO"), "| Vendor |") {
+			t.Fatalf("embed text should include header")
+		}
+	}
+}
+
+func TestCollectionsInheritDefault(t *testing.T) {
+	cs := chunking.Collections{
+		"default": {Strategy: chunking.StrategyRecursive, Size: 1000, Overlap: 100},
+		"sop":     {Strategy: chunking.StrategyPage},
+		"logs":    {Strategy: chunking.StrategyFixed, Size: 128},
+	}
+	if c := cs.For("sop"); c.Size != 1000 || c.Overlap != 100 || c.Strategy != chunking.StrategyPage {
+		t.Fatalf("sop: %+v", c)
+	}
+	if c := cs.For("logs"); c.Size != 128 || c.Overlap != 0 {
+		t.Fatalf("fixed must not inherit rune sizes: %+v", c)
+	}
+	if c := cs.For("unknown"); c.Size != 1000 {
+		t.Fatalf("unknown: %+v", c)
+	}
+	if chunking.ValidCollection("../etc") || !chunking.ValidCollection("sop_2024") {
+		t.Fatalf("ValidCollection")
+	}
+}
+
+// Size sangat kecil (INGEST_CHUNK_SIZE=1, konfigurasi koleksi) harus tetap selesai, tanpa chunk kosong.
+func TestTinySizesTerminate(t *testing.T) {
+	pages := samplePages()
+	pages = append(pages, chunking.Page{No: 3, Text: "| Kolom header yang sangat panjang sekali | Nilai |\n|---|---|\n| A | 1 |\n| B | 2 |"})
+	for _, strategy := range []string{chunking.StrategyRecursive, chunking.StrategyPage, chunking.StrategyFixed} {
+		for size := 1; size <= 4; size++ {
+			chunks := chunking.Split(pages, chunking.Config{Strategy: strategy, Size: size, Overlap: size})
+			if len(chunks) == 0 {
+				t.Fatalf("%s size %d: no chunks", strategy, size)
+			}
+			checkOffsets(t, pages, chunks)
+			for _, c := range chunks {
+				if strings.TrimSpace(c.Text) == "" {
+					t.Fatalf("%s size %d: empty chunk %d", strategy, size, c.Index)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,124 @@
+// internal/chunking/collections.go
+// Konfigurasi chunking per koleksi dokumen dari CHUNKING_CONFIG_FILE (default configs/chunking.json):
+//
+//	{"default": {"strategy": "recursive", "size": 1200, "overlap": 150},
+//	 "sop":     {"strategy": "page"},
+//	 "logs":    {"strategy": "fixed", "size": 256, "overlap": 32}}
+//
+// Field kosong di koleksi mewarisi "default"; koleksi tak dikenal memakai "default".
+package chunking
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultCollection dipakai bila upload tidak menyebut koleksi.
+const DefaultCollection = "default"
+
+var reCollection = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,63}$`)
+
+// ValidCollection memeriksa nama koleksi (huruf kecil, angka, _ dan -, maks. 64).
+func ValidCollection(name string) bool { return reCollection.MatchString(name) }
+
+// Collections memetakan nama koleksi → Config.
+type Collections map[string]Config
+
+// For mengembalikan konfigurasi efektif koleksi name.
+func (cs Collections) For(name string) Config {
+	base := cs[DefaultCollection]
+	c, ok := cs[strings.ToLower(strings.TrimSpace(name))]
+	if !ok {
+		return base
+	}
+	if c.Strategy == "" {
+		c.Strategy = base.Strategy
+	}
+	// ukuran hanya diwarisi bila satuannya sama (token untuk fixed, rune untuk lainnya)
+	if (c.Strategy == StrategyFixed) == (base.Strategy == StrategyFixed) {
+		if c.Size == 0 {
+			c.Size = base.Size
+		}
+		if c.Overlap == 0 {
+			c.Overlap = base.Overlap
+		}
+	}
+	return c
+}
+
+// LoadCollections membaca file konfigurasi; file tidak ada → (nil, nil).
+func LoadCollections(path string) (Collections, error) {
+	if path == "" {
+		return nil, nil
+	}
+	b, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var cs Collections
+	if err := json.Unmarshal(b, &cs); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	for name := range cs {
+		if !ValidCollection(name) {
+			return nil, fmt.Errorf("parse %s: invalid collection name %q", path, name)
+		}
+	}
+	return cs, nil
+}
+
+// ConfigFromEnv membentuk konfigurasi "default" dari INGEST_CHUNK_STRATEGY,
+// INGEST_CHUNK_SIZE dan INGEST_CHUNK_OVERLAP.
+func ConfigFromEnv() Config {
+	cfg := DefaultConfig()
+	if s := strings.ToLower(strings.TrimSpace(os.Getenv("INGEST_CHUNK_STRATEGY"))); s != "" {
+		cfg.Strategy = s
+		if s == StrategyFixed {
+			cfg.Size, cfg.Overlap = 256, 32
+		}
+	}
+	if n, err := strconv.Atoi(os.Getenv("INGEST_CHUNK_SIZE")); err == nil && n > 0 {
+		cfg.Size = n
+	}
+	if n, err := strconv.Atoi(os.Getenv("INGEST_CHUNK_OVERLAP")); err == nil && n >= 0 {
+		cfg.Overlap = n
+	}
+	return cfg
+}
+
+var (
+	defaultOnce sync.Once
+	defaultCs   Collections
+)
+
+// Default memuat konfigurasi koleksi sekali (file + env untuk "default").
+func Default() Collections {
+	defaultOnce.Do(func() {
+		path := os.Getenv("CHUNKING_CONFIG_FILE")
+		if path == "" {
+			path = "configs/chunking.json"
+		}
+		cs, err := LoadCollections(path)
+		if err != nil {
+			log.Printf("[chunking] %v; memakai konfigurasi default", err)
+			cs = nil
+		}
+		if cs == nil {
+			cs = Collections{}
+		}
+		if _, ok := cs[DefaultCollection]; !ok {
+			cs[DefaultCollection] = ConfigFromEnv()
+		}
+		defaultCs = cs
+	})
+	return defaultCs
+}
@@ -0,0 +1,28 @@
+// internal/chunking/fixed.go
+// Strategi fixed: jendela Size token dengan Overlap token. Token ≈ kata/angka/tanda baca
+// (pendekatan kasar tokenizer BPE, cukup untuk menjaga chunk di bawah batas model embedding).
+package chunking
+
+import "regexp"
+
+var reToken = regexp.MustCompile(`[\p{L}\p{N}_]+|[^\s\p{L}\p{N}_]`)
+
+func fixedSpans(doc string, size, overlap int) []span {
+	toks := reToken.FindAllStringIndex(doc, -1)
+	step := size - overlap
+	if step <= 0 {
+		step = size
+	}
+	var out []span
+	for i := 0; i < len(toks); i += step {
+		j := i + size
+		if j > len(toks) {
+			j = len(toks)
+		}
+		out = append(out, span{start: toks[i][0], end: toks[j-1][1]})
+		if j == len(toks) {
+			break
+		}
+	}
+	return out
+}
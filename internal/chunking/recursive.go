@@ -0,0 +1,250 @@
+// internal/chunking/recursive.go
+// Strategi recursive: blok (heading/paragraf/tabel) dipecah bertahap baris → kalimat → kata
+// sampai muat, lalu digabung kembali secara greedy sampai Size rune dengan overlap di batas kata.
+package chunking
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+var (
+	reSentenceEnd = regexp.MustCompile(`[.!?;:]["')\]]?\s+`)
+	reWordSpan    = regexp.MustCompile(`\S+`)
+)
+
+// unit adalah potongan terkecil yang tidak dipecah lagi saat packing.
+type unit struct {
+	start, end int
+	table      bool   // tabel utuh: overlap chunk berikutnya tidak boleh mulai di dalamnya
+	piece      bool   // potongan tabel besar: selalu menjadi chunk sendiri
+	context    string // header tabel untuk potongan lanjutan
+}
+
+// pack menyusun chunk dari blok-blok satu rentang dokumen.
+func pack(doc string, blocks []block, size, overlap int) []span {
+	var (
+		out      []span
+		cs       = -1 // awal chunk berjalan (byte); -1 = kosong
+		ce, n    int  // akhir (byte) & panjang (rune) chunk berjalan
+		floor    int  // overlap tidak boleh mundur melewati posisi ini
+		heading  string
+		chHead   string
+		hasTable bool
+	)
+	emit := func() {
+		if cs >= 0 {
+			out = append(out, span{start: cs, end: ce, heading: chHead, table: hasTable})
+		}
+	}
+	begin := func(s, e int) {
+		cs, ce, n = s, e, runes(doc[s:e])
+		chHead, hasTable = heading, false
+	}
+	add := func(u unit) {
+		switch {
+		case cs < 0:
+			begin(u.start, u.end)
+		case n+runes(doc[ce:u.end]) <= size:
+			n += runes(doc[ce:u.end])
+			ce = u.end
+		default:
+			emit()
+			if ns := overlapStart(doc, cs, ce, overlap, floor); ns >= 0 && runes(doc[ns:u.end]) <= size {
+				begin(ns, u.end)
+			} else {
+				begin(u.start, u.end)
+			}
+		}
+		if u.table {
+			hasTable = true
+			floor = u.end
+		}
+	}
+
+	for _, b := range blocks {
+		if b.kind == blockHeading {
+			// section baru memulai chunk baru, kecuali chunk berjalan masih sangat pendek
+			if cs >= 0 && n >= size/4 {
+				emit()
+				cs = -1
+			}
+			heading = headingText(doc[b.start:b.end])
+			if cs >= 0 && chHead == "" {
+				chHead = heading
+			}
+			floor = b.start
+		}
+		for _, u := range blockUnits(doc, b, size) {
+			if u.piece {
+				emit()
+				cs = -1
+				out = append(out, span{start: u.start, end: u.end, heading: heading, context: u.context, table: true})
+				floor = u.end
+				continue
+			}
+			add(u)
+		}
+	}
+	emit()
+	return out
+}
+
+// blockUnits memecah satu blok menjadi unit yang masing-masing <= size rune.
+func blockUnits(doc string, b block, size int) []unit {
+	switch {
+	case b.kind == blockTable && runes(doc[b.start:b.end]) <= size:
+		return []unit{{start: b.start, end: b.end, table: true}}
+	case b.kind == blockTable:
+		return tablePieces(doc, b, size)
+	}
+	return splitRange(doc, b.start, b.end, size, 0)
+}
+
+// splitRange memecah doc[s:e] bertingkat: 0 baris, 1 kalimat, 2 kata, 3 paksa per rune.
+func splitRange(doc string, s, e, size, level int) []unit {
+	if s, e = trimSpan(doc, s, e); s >= e {
+		return nil
+	}
+	if runes(doc[s:e]) <= size {
+		return []unit{{start: s, end: e}}
+	}
+
+	var cuts []int // awal potongan berikutnya
+	switch level {
+	case 0:
+		for i := s; i < e; i++ {
+			if doc[i] == '\n' {
+				cuts = append(cuts, i+1)
+			}
+		}
+	case 1:
+		for _, loc := range reSentenceEnd.FindAllStringIndex(doc[s:e], -1) {
+			cuts = append(cuts, s+loc[1])
+		}
+	case 2:
+		var out []unit
+		for _, loc := range reWordSpan.FindAllStringIndex(doc[s:e], -1) {
+			out = append(out, splitRange(doc, s+loc[0], s+loc[1], size, 3)...)
+		}
+		return out
+	default:
+		var out []unit
+		for p := s; p < e; {
+			q, k := p, 0
+			for q < e && (k < size || q == p) { // minimal satu rune per potongan
+				_, sz := utf8.DecodeRuneInString(doc[q:e])
+				q += sz
+				k++
+			}
+			out = append(out, unit{start: p, end: q})
+			p = q
+		}
+		return out
+	}
+
+	var out []unit
+	prev := s
+	for _, c := range append(cuts, e) {
+		if c > prev {
+			out = append(out, splitRange(doc, prev, c, size, level+1)...)
+		}
+		prev = c
+	}
+	return out
+}
+
+// tablePieces memecah tabel besar per baris; potongan lanjutan membawa header (baris pertama
+// + pemisah markdown bila ada) sebagai context agar tiap chunk tetap bisa dibaca sendiri.
+func tablePieces(doc string, b block, size int) []unit {
+	type row struct{ start, end int }
+	var rows []row
+	for p := b.start; p < b.end; {
+		e := strings.IndexByte(doc[p:b.end], '\n')
+		if e < 0 {
+			e = b.end
+		} else {
+			e += p
+		}
+		if strings.TrimSpace(doc[p:e]) != "" {
+			rows = append(rows, row{p, e})
+		}
+		p = e + 1
+	}
+	hdrEnd := rows[0].end
+	if len(rows) > 1 && isTableSeparator(doc[rows[1].start:rows[1].end]) {
+		hdrEnd = rows[1].end
+	}
+	header := strings.TrimSpace(doc[b.start:hdrEnd])
+	budget := size - runes(header) - 1
+	if budget < size/2 {
+		budget = size / 2
+	}
+	budget = max(budget, 1)
+
+	var (
+		out []unit
+		ps  = -1
+		pe  int
+	)
+	flush := func() {
+		if ps >= 0 {
+			u := unit{start: ps, end: pe, table: true, piece: true}
+			if ps > b.start {
+				u.context = header
+			}
+			out = append(out, u)
+		}
+		ps = -1
+	}
+	for _, r := range rows {
+		limit := budget
+		if ps == b.start {
+			limit = size // potongan pertama sudah memuat header sendiri
+		}
+		if ps >= 0 && runes(doc[ps:r.end]) > limit {
+			flush()
+		}
+		if ps < 0 && runes(doc[r.start:r.end]) > budget {
+			// satu baris lebih panjang dari anggaran → pecah per kata
+			for _, u := range splitRange(doc, r.start, r.end, budget, 2) {
+				out = append(out, unit{start: u.start, end: u.end, table: true, piece: true, context: header})
+			}
+			continue
+		}
+		if ps < 0 {
+			ps = r.start
+		}
+		pe = r.end
+	}
+	flush()
+	return out
+}
+
+// overlapStart mencari awal kata di ekor doc[cs:ce] sepanjang <= overlap rune (dan >= floor).
+// -1 bila tidak ada overlap yang layak.
+func overlapStart(doc string, cs, ce, overlap, floor int) int {
+	if overlap <= 0 {
+		return -1
+	}
+	lo, k := ce, 0
+	for lo > cs && k < overlap {
+		_, sz := utf8.DecodeLastRuneInString(doc[cs:lo])
+		lo -= sz
+		k++
+	}
+	if lo < floor {
+		lo = floor
+	}
+	for p := lo; p < ce; {
+		r, sz := utf8.DecodeRuneInString(doc[p:ce])
+		prev, _ := utf8.DecodeLastRuneInString(doc[:p])
+		if p > cs && !unicode.IsSpace(r) && unicode.IsSpace(prev) {
+			return p
+		}
+		p += sz
+	}
+	return -1
+}
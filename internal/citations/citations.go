@@ -0,0 +1,384 @@
+// internal/citations/citations.go
+// Sitasi terstruktur untuk jawaban berbasis dokumen: setiap chunk sumber menjadi Citation (judul, URL,
+// halaman, offset karakter, span yang di-highlight) dan setiap kalimat jawaban ditautkan ke chunk
+// pendukungnya.
+//
+// Penautan kalimat → chunk:
+//  1. penanda eksplisit dari LLM: "[2]" (nomor snippet di prompt) atau citation key "DOC-1#p3";
+//  2. bila tidak ada penanda: kecocokan leksikal (porsi kata isi kalimat yang muncul di chunk
+//     >= Options.MinOverlap), maksimal Options.MaxPerSentence chunk.
+//
+// Semua offset dalam rune (sama dengan doc_chunks.char_start/char_end), [start, end).
+package citations
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"mcp-oilgas/internal/analysis"
+)
+
+// Source adalah satu chunk yang diberikan ke generator jawaban (urutan = nomor [n] di prompt).
+type Source struct {
+	Key       string // citation key, mis. "DOC-1#p3"
+	ChunkID   int64
+	DocID     string
+	Title     string
+	URL       string
+	Snippet   string
+	Page      int
+	CharStart int // offset snippet di teks dokumen (CharEnd <= CharStart = tidak diketahui)
+	CharEnd   int
+}
+
+// Span adalah potongan snippet yang cocok dengan pertanyaan / kalimat jawaban.
+type Span struct {
+	Start    int    `json:"start"` // offset di snippet
+	End      int    `json:"end"`
+	Text     string `json:"text"`
+	DocStart *int   `json:"doc_start,omitempty"` // offset di teks dokumen (bila snippet = teks chunk utuh)
+	DocEnd   *int   `json:"doc_end,omitempty"`
+}
+
+// Citation adalah sitasi terstruktur untuk satu chunk sumber.
+type Citation struct {
+	N          int    `json:"n"` // nomor snippet di prompt ("[n]")
+	Key        string `json:"key"`
+	ChunkID    int64  `json:"chunk_id,omitempty"`
+	DocID      string `json:"doc_id"`
+	Title      string `json:"title,omitempty"`
+	URL        string `json:"url,omitempty"`
+	PageNo     int    `json:"page_no,omitempty"`
+	CharStart  *int   `json:"char_start,omitempty"`
+	CharEnd    *int   `json:"char_end,omitempty"`
+	Highlights []Span `json:"highlights,omitempty"`
+	Used       bool   `json:"used"` // ditautkan ke minimal satu kalimat jawaban
+}
+
+// Sentence adalah satu kalimat jawaban beserta chunk pendukungnya.
+type Sentence struct {
+	Text      string  `json:"text"`
+	Start     int     `json:"start"` // offset di jawaban
+	End       int     `json:"end"`
+	Citations []int   `json:"citations,omitempty"` // Citation.N
+	ChunkIDs  []int64 `json:"chunk_ids,omitempty"`
+	MatchedBy string  `json:"matched_by,omitempty"` // marker|lexical
+}
+
+// Options mengatur penautan & highlight.
+type Options struct {
+	Highlight      bool    // isi Citation.Highlights
+	MinOverlap     float64 // default 0.35
+	MaxPerSentence int     // default 3
+	MaxHighlights  int     // per sitasi, default 12
+}
+
+func (o Options) withDefaults() Options {
+	if o.MinOverlap <= 0 {
+		o.MinOverlap = 0.35
+	}
+	if o.MaxPerSentence <= 0 {
+		o.MaxPerSentence = 3
+	}
+	if o.MaxHighlights <= 0 {
+		o.MaxHighlights = 12
+	}
+	return o
+}
+
+// Build menyusun sitasi terstruktur & penautan kalimat untuk answer yang dihasilkan dari srcs.
+func Build(question, answer string, srcs []Source, opt Options) ([]Citation, []Sentence) {
+	opt = opt.withDefaults()
+	cits := make([]Citation, len(srcs))
+	srcTerms := make([]map[string]bool, len(srcs))
+	for i, s := range srcs {
+		cits[i] = Citation{N: i + 1, Key: s.Key, ChunkID: s.ChunkID, DocID: s.DocID, Title: s.Title, URL: s.URL, PageNo: s.Page}
+		if s.CharEnd > s.CharStart {
+			start, end := s.CharStart, s.CharEnd
+			cits[i].CharStart, cits[i].CharEnd = &start, &end
+		}
+		srcTerms[i] = termSet(s.Snippet)
+	}
+
+	sents := splitSentences(answer)
+	linkedTerms := make([]map[string]bool, len(srcs))
+	for si := range sents {
+		s := &sents[si]
+		idx := markers(s.Text, srcs)
+		s.MatchedBy = "marker"
+		if len(idx) == 0 {
+			idx = lexical(words(s.Text), srcTerms, opt)
+			s.MatchedBy = "lexical"
+		}
+		if len(idx) == 0 {
+			s.MatchedBy = ""
+			continue
+		}
+		for _, i := range idx {
+			s.Citations = append(s.Citations, i+1)
+			if srcs[i].ChunkID > 0 {
+				s.ChunkIDs = append(s.ChunkIDs, srcs[i].ChunkID)
+			}
+			cits[i].Used = true
+			if linkedTerms[i] == nil {
+				linkedTerms[i] = map[string]bool{}
+			}
+			for t := range termSet(s.Text) {
+				linkedTerms[i][t] = true
+			}
+		}
+	}
+
+	if opt.Highlight {
+		qTerms := termSet(question)
+		for i, s := range srcs {
+			terms := map[string]bool{}
+			for t := range qTerms {
+				terms[t] = true
+			}
+			for t := range linkedTerms[i] {
+				terms[t] = true
+			}
+			cits[i].Highlights = highlight(s, terms, opt.MaxHighlights)
+		}
+	}
+	return cits, sents
+}
+
+// ======= Kalimat =======
+
+var reMarker = regexp.MustCompile(`\[(\d{1,3})\]`)
+
+// markers mengembalikan indeks sumber yang dirujuk eksplisit oleh kalimat ("[n]" atau citation key).
+func markers(text string, srcs []Source) []int {
+	seen := map[int]bool{}
+	var out []int
+	add := func(i int) {
+		if i >= 0 && i < len(srcs) && !seen[i] {
+			seen[i] = true
+			out = append(out, i)
+		}
+	}
+	for _, m := range reMarker.FindAllStringSubmatch(text, -1) {
+		n, _ := strconv.Atoi(m[1])
+		add(n - 1)
+	}
+	for i, s := range srcs {
+		if s.Key != "" && strings.Contains(text, s.Key) {
+			add(i)
+		}
+	}
+	return out
+}
+
+// lexical memilih sumber dengan porsi kata isi kalimat tertinggi (>= MinOverlap).
+func lexical(sent []token, srcTerms []map[string]bool, opt Options) []int {
+	if len(sent) == 0 {
+		return nil
+	}
+	type cand struct {
+		i       int
+		overlap float64
+	}
+	var cs []cand
+	for i, terms := range srcTerms {
+		hit := 0
+		for _, tk := range sent {
+			if tk.in(terms) {
+				hit++
+			}
+		}
+		if ov := float64(hit) / float64(len(sent)); ov >= opt.MinOverlap {
+			cs = append(cs, cand{i, ov})
+		}
+	}
+	sort.SliceStable(cs, func(a, b int) bool { return cs[a].overlap > cs[b].overlap })
+	if len(cs) > opt.MaxPerSentence {
+		cs = cs[:opt.MaxPerSentence]
+	}
+	out := make([]int, len(cs))
+	for k, c := range cs {
+		out[k] = c.i
+	}
+	sort.Ints(out)
+	return out
+}
+
+// splitSentences memecah jawaban per kalimat (. ! ? diikuti spasi, atau baris baru). Penanda sitasi
+// yang berdiri sendiri setelah titik ("... ditutup. [1]") digabung ke kalimat sebelumnya.
+func splitSentences(answer string) []Sentence {
+	r := []rune(answer)
+	var out []Sentence
+	emit := func(start, end int) {
+		for start < end && unicode.IsSpace(r[start]) {
+			start++
+		}
+		for end > start && unicode.IsSpace(r[end-1]) {
+			end--
+		}
+		if start >= end {
+			return
+		}
+		text := string(r[start:end])
+		if n := len(out); n > 0 && !hasLetter(reMarker.ReplaceAllString(text, "")) {
+			out[n-1].End = end
+			out[n-1].Text = string(r[out[n-1].Start:end])
+			return
+		}
+		if !hasLetter(text) {
+			return
+		}
+		out = append(out, Sentence{Text: text, Start: start, End: end})
+	}
+	start := 0
+	for i := 0; i < len(r); i++ {
+		switch {
+		case r[i] == '\n':
+			emit(start, i)
+			start = i + 1
+		case (r[i] == '.' || r[i] == '!' || r[i] == '?') && (i+1 == len(r) || unicode.IsSpace(r[i+1])):
+			emit(start, i+1)
+			start = i + 1
+		}
+	}
+	emit(start, len(r))
+	return out
+}
+
+func hasLetter(s string) bool {
+	for _, c := range s {
+		if unicode.IsLetter(c) {
+			return true
+		}
+	}
+	return false
+}
+
+// ======= Highlight =======
+
+// highlight menandai token snippet yang termasuk terms; token bertanda yang berdekatan
+// (lihat joinable) digabung menjadi satu span.
+func highlight(s Source, terms map[string]bool, max int) []Span {
+	if len(terms) == 0 {
+		return nil
+	}
+	r := []rune(s.Snippet)
+	// offset dokumen hanya valid bila snippet adalah teks chunk utuh (tidak dinetralkan/dipotong)
+	withDoc := s.CharEnd > s.CharStart && s.CharEnd-s.CharStart == len(r)
+	var spans []Span
+	for _, tk := range tokens(r) {
+		if !tk.in(terms) {
+			continue
+		}
+		if n := len(spans); n > 0 && joinable(r[spans[n-1].End:tk.start]) {
+			spans[n-1].End = tk.end
+			continue
+		}
+		spans = append(spans, Span{Start: tk.start, End: tk.end})
+	}
+	if len(spans) > max {
+		spans = spans[:max]
+	}
+	for i := range spans {
+		sp := &spans[i]
+		sp.Text = string(r[sp.Start:sp.End])
+		if withDoc {
+			ds, de := s.CharStart+sp.Start, s.CharStart+sp.End
+			sp.DocStart, sp.DocEnd = &ds, &de
+		}
+	}
+	return spans
+}
+
+// joinable: celah antar-token hanya spasi/tanda hubung dan paling banyak satu kata pendek
+// (mis. "shut-in pressure", "tekanan di casing").
+func joinable(gap []rune) bool {
+	if len(gap) == 0 || len(gap) > 5 {
+		return false
+	}
+	words, inWord := 0, false
+	for _, c := range gap {
+		switch {
+		case c == ' ' || c == '-':
+			inWord = false
+		case unicode.IsLetter(c):
+			if !inWord {
+				words++
+			}
+			inWord = true
+		default:
+			return false
+		}
+	}
+	return words <= 1
+}
+
+// ======= Token =======
+
+// analyzer sama dengan yang dipakai pencarian leksikal (internal/lexindex) sehingga kata isi,
+// stopword dan stem yang dicocokkan di sini konsisten dengan hasil retrieval.
+var analyzer = analysis.Default()
+
+type token struct {
+	stems      []string // kata (huruf kecil) + bentuk dasarnya, lihat analysis.Analyzer.Stems
+	start, end int
+}
+
+// in melaporkan apakah salah satu stem token ada di terms.
+func (tk token) in(terms map[string]bool) bool {
+	for _, s := range tk.stems {
+		if terms[s] {
+			return true
+		}
+	}
+	return false
+}
+
+// tokens mengembalikan kata isi (huruf/angka, >= 3 rune, bukan stopword) beserta offset rune.
+func tokens(r []rune) []token {
+	var out []token
+	for i := 0; i < len(r); {
+		if !isWord(r[i]) {
+			i++
+			continue
+		}
+		j := i
+		for j < len(r) && isWord(r[j]) {
+			j++
+		}
+		if w := strings.ToLower(string(r[i:j])); j-i >= 3 && !analysis.IsStopword(w) {
+			out = append(out, token{stems: analyzer.Stems(w), start: i, end: j})
+		}
+		i = j
+	}
+	return out
+}
+
+// termSet mengembalikan semua stem kata isi text.
+func termSet(text string) map[string]bool {
+	out := map[string]bool{}
+	for _, tk := range tokens([]rune(text)) {
+		for _, s := range tk.stems {
+			out[s] = true
+		}
+	}
+	return out
+}
+
+// words mengembalikan kata isi unik text (satu entri per kata, bukan per stem) untuk menghitung overlap.
+func words(text string) []token {
+	seen := map[string]bool{}
+	var out []token
+	for _, tk := range tokens([]rune(text)) {
+		if !seen[tk.stems[0]] {
+			seen[tk.stems[0]] = true
+			out = append(out, tk)
+		}
+	}
+	return out
+}
+
+func isWord(c rune) bool { return unicode.IsLetter(c) || unicode.IsDigit(c) }
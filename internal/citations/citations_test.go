@@ -0,0 +1,77 @@
+// internal/citations/citations_test.go
+
+package citations_test
+
+import (
+	"reflect"
+	"testing"
+
+	"mcp-oilgas/internal/citations"
+)
+
+func sources() []citations.Source {
+	return []citations.Source{
+		{Key: "SOP-7#p2", ChunkID: 41, DocID: "SOP-7", Title: "Well Control SOP", Page: 2, CharStart: 100, CharEnd: 159,
+			Snippet: "Saat kick terdeteksi, tutup BOP dan catat shut-in pressure."},
+		{Key: "HSE-1#p1", ChunkID: 42, DocID: "HSE-1", Title: "H2S Manual", Page: 1,
+			Snippet: "Gas H2S di atas 10 ppm mewajibkan evakuasi ke muster point."},
+	}
+}
+
+// Penanda [n] dari LLM menautkan kalimat ke chunk yang dirujuk, termasuk penanda setelah titik.
+func TestBuildLinksMarkers(t *testing.T) {
+	answer := "Tutup BOP segera setelah kick terdeteksi [1]. Evakuasi bila H2S melebihi 10 ppm. [2]"
+	_, sents := citations.Build("apa tindakan saat kick?", answer, sources(), citations.Options{})
+	if len(sents) != 2 {
+		t.Fatalf("expected 2 sentences, got %+v", sents)
+	}
+	if !reflect.DeepEqual(sents[0].ChunkIDs, []int64{41}) || sents[0].MatchedBy != "marker" {
+		t.Fatalf("sentence 0 should cite chunk 41: %+v", sents[0])
+	}
+	if !reflect.DeepEqual(sents[1].Citations, []int{2}) || sents[1].Text != "Evakuasi bila H2S melebihi 10 ppm. [2]" {
+		t.Fatalf("trailing marker should attach to sentence 1: %+v", sents[1])
+	}
+	if got := []rune(answer)[sents[1].Start:sents[1].End]; string(got) != sents[1].Text {
+		t.Fatalf("sentence offsets do not match text: %q", string(got))
+	}
+}
+
+// Tanpa penanda, kalimat ditautkan lewat kecocokan kata; kalimat tanpa dukungan tidak ditautkan.
+func TestBuildLexicalFallback(t *testing.T) {
+	answer := "Gas H2S di atas 10 ppm mewajibkan evakuasi.\nHubungi supervisor shift."
+	cits, sents := citations.Build("", answer, sources(), citations.Options{})
+	if !reflect.DeepEqual(sents[0].ChunkIDs, []int64{42}) || sents[0].MatchedBy != "lexical" {
+		t.Fatalf("expected lexical link to chunk 42: %+v", sents[0])
+	}
+	if len(sents[1].ChunkIDs) != 0 {
+		t.Fatalf("unsupported sentence should have no chunks: %+v", sents[1])
+	}
+	if cits[0].Used || !cits[1].Used {
+		t.Fatalf("unexpected used flags: %+v", cits)
+	}
+}
+
+// Highlight menandai span yang cocok beserta offset dokumen bila snippet utuh.
+func TestBuildHighlights(t *testing.T) {
+	cits, _ := citations.Build("shut-in pressure setelah kick", "", sources(), citations.Options{Highlight: true})
+	hl := cits[0].Highlights
+	if len(hl) != 2 || hl[0].Text != "kick" || hl[1].Text != "shut-in pressure" {
+		t.Fatalf("unexpected highlights: %+v", hl)
+	}
+	if hl[1].DocStart == nil || *hl[1].DocStart != 100+hl[1].Start || *hl[1].DocEnd != 100+hl[1].End {
+		t.Fatalf("expected document offsets, got %+v", hl[1])
+	}
+	if cits[0].CharStart == nil || *cits[0].CharStart != 100 || cits[1].CharStart != nil {
+		t.Fatalf("unexpected chunk offsets: %+v %+v", cits[0], cits[1])
+	}
+}
+
+// Kata berimbuhan dicocokkan lewat stem analyzer pencarian: "pemeriksaan" menyorot "diperiksa".
+func TestBuildHighlightsStems(t *testing.T) {
+	src := []citations.Source{{Key: "MAN-3#p1", Snippet: "Katup diperiksa setiap minggu oleh teknisi."}}
+	cits, _ := citations.Build("jadwal pemeriksaan katup", "", src, citations.Options{Highlight: true})
+	hl := cits[0].Highlights
+	if len(hl) != 1 || hl[0].Text != "Katup diperiksa" {
+		t.Fatalf("unexpected highlights: %+v", hl)
+	}
+}
@@ -0,0 +1,255 @@
+// internal/handlers/http/admin_documents_handler.go
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"mcp-oilgas/internal/repositories/documents"
+	"mcp-oilgas/internal/vectorstore"
+)
+
+var (
+	documentsRepo *documents.Repo
+	vectorStore   vectorstore.Store
+)
+
+// SetDocuments dipanggil dari app.go bila DB tersedia.
+func SetDocuments(r *documents.Repo) { documentsRepo = r }
+
+// SetVectorStore dipanggil dari app.go bila VECTOR_STORE=memory|pgvector aktif.
+func SetVectorStore(s vectorstore.Store) { vectorStore = s }
+
+// AdminListDocuments: GET /admin/documents?type=sop&area=North&effective_after=2024&limit=&include_deleted=true
+func AdminListDocuments(w http.ResponseWriter, r *http.Request) {
+	if documentsRepo == nil {
+		http.Error(w, "documents unavailable (no database)", http.StatusServiceUnavailable)
+		return
+	}
+	f, err := documents.FilterFromQuery(r.URL.Query())
+	if err != nil {
+		http.Error(w, "bad request: invalid filters: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	withDeleted, _ := strconv.ParseBool(r.URL.Query().Get("include_deleted"))
+	docs, err := documentsRepo.List(r.Context(), f, limit, withDeleted)
+	if err != nil {
+		http.Error(w, "list error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{"documents": docs, "filters": f})
+}
+
+func docIDFromPath(r *http.Request) string {
+	return pathSegment(r, 0)
+}
+
+// pathSegment mengembalikan segmen path ke-n dari belakang (0 = terakhir), sudah di-unescape.
+func pathSegment(r *http.Request, n int) string {
+	parts := strings.Split(strings.TrimSuffix(r.URL.EscapedPath(), "/"), "/")
+	if n >= len(parts) {
+		return ""
+	}
+	id, err := url.PathUnescape(parts[len(parts)-1-n])
+	if err != nil {
+		return ""
+	}
+	return id
+}
+
+// AdminGetDocument: GET /admin/documents/{doc_id}
+func AdminGetDocument(w http.ResponseWriter, r *http.Request) {
+	if documentsRepo == nil {
+		http.Error(w, "documents unavailable (no database)", http.StatusServiceUnavailable)
+		return
+	}
+	id := docIDFromPath(r)
+	d, err := documentsRepo.Get(r.Context(), id)
+	if errors.Is(err, documents.ErrNotFound) {
+		http.Error(w, "document "+id+" not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "get error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(d)
+}
+
+// AdminUpdateDocument: PUT /admin/documents/{doc_id}
+// Field yang dikosongkan tidak mengubah nilai lama (lihat documents.Upsert).
+func AdminUpdateDocument(w http.ResponseWriter, r *http.Request) {
+	if documentsRepo == nil {
+		http.Error(w, "documents unavailable (no database)", http.StatusServiceUnavailable)
+		return
+	}
+	id := docIDFromPath(r)
+	var d documents.Document
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&d); err != nil {
+		http.Error(w, "bad request: invalid json: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if d.DocID != "" && d.DocID != id {
+		http.Error(w, "bad request: doc_id mismatch", http.StatusBadRequest)
+		return
+	}
+	d.DocID = id
+	if err := d.Normalize(); err != nil {
+		http.Error(w, "bad request: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := documentsRepo.Update(r.Context(), d); err != nil {
+		http.Error(w, "update error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	out, err := documentsRepo.Get(r.Context(), id)
+	if err != nil {
+		http.Error(w, "get error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(out)
+}
+
+// dropFromIndex mengeluarkan chunk dari indeks ANN dan vector store aktif segera (tanpa menunggu
+// sinkronisasi). doc != "" menghapus seluruh chunk dokumen dari store (soft/hard delete); doc kosong
+// hanya chunk ids (versi yang digantikan). Chunk yang di-restore masuk lagi lewat sinkronisasi store.
+func dropFromIndex(ctx context.Context, doc string, ids []int64) {
+	if vectorIndex != nil && len(ids) > 0 {
+		vectorIndex.Remove(ids...)
+	}
+	if err := vectorstore.Purge(ctx, vectorStore, doc, ids); err != nil {
+		log.Printf("[WARN] vector store purge (doc=%q, %d chunks): %v", doc, len(ids), err)
+	}
+}
+
+// AdminDeleteDocument: DELETE /admin/documents/{doc_id}?mode=soft|hard
+// soft (default): dokumen & chunk disembunyikan dari retrieval, bisa di-restore.
+// hard: dokumen, semua versi, chunk & file upload dihapus permanen.
+func AdminDeleteDocument(w http.ResponseWriter, r *http.Request) {
+	if documentsRepo == nil {
+		http.Error(w, "documents unavailable (no database)", http.StatusServiceUnavailable)
+		return
+	}
+	id := docIDFromPath(r)
+	mode := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("mode")))
+	var (
+		ids []int64
+		err error
+	)
+	resp := map[string]any{"doc_id": id}
+	switch mode {
+	case "", "soft":
+		mode = "soft"
+		ids, err = documentsRepo.SoftDelete(r.Context(), id)
+	case "hard":
+		var d *documents.Document
+		ids, d, err = documentsRepo.HardDelete(r.Context(), id)
+		if err == nil {
+			if p := documents.UploadPath(d, uploadsDir()); p != "" {
+				resp["file_removed"] = os.Remove(p) == nil
+				if dir := filepath.Dir(p); dir != filepath.Clean(uploadsDir()) {
+					_ = os.Remove(dir) // folder <hash> hanya terhapus bila sudah kosong
+				}
+			}
+		}
+	default:
+		http.Error(w, "bad request: mode must be soft or hard", http.StatusBadRequest)
+		return
+	}
+	if errors.Is(err, documents.ErrNotFound) {
+		http.Error(w, "document "+id+" not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "delete error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	dropFromIndex(r.Context(), id, ids)
+	resp["mode"], resp["chunks"] = mode, len(ids)
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+// AdminRestoreDocument: POST /admin/documents/{doc_id}/restore — batalkan soft delete.
+func AdminRestoreDocument(w http.ResponseWriter, r *http.Request) {
+	if documentsRepo == nil {
+		http.Error(w, "documents unavailable (no database)", http.StatusServiceUnavailable)
+		return
+	}
+	id := pathSegment(r, 1)
+	err := documentsRepo.Restore(r.Context(), id)
+	if errors.Is(err, documents.ErrNotFound) {
+		http.Error(w, "document "+id+" not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "restore error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if vectorIndex != nil {
+		_, _ = vectorIndex.Sync(r.Context())
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{"doc_id": id, "restored": true})
+}
+
+// AdminListDocumentVersions: GET /admin/documents/{doc_id}/versions
+func AdminListDocumentVersions(w http.ResponseWriter, r *http.Request) {
+	if documentsRepo == nil {
+		http.Error(w, "documents unavailable (no database)", http.StatusServiceUnavailable)
+		return
+	}
+	id := pathSegment(r, 1)
+	vs, err := documentsRepo.Versions(r.Context(), id)
+	if err != nil {
+		http.Error(w, "versions error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{"doc_id": id, "versions": vs})
+}
+
+// AdminActivateDocumentVersion: POST /admin/documents/{doc_id}/versions/{version}/activate
+// Jadikan versi tersimpan sebagai versi aktif (rollback); versi lain tidak lagi dipakai retrieval.
+func AdminActivateDocumentVersion(w http.ResponseWriter, r *http.Request) {
+	if documentsRepo == nil {
+		http.Error(w, "documents unavailable (no database)", http.StatusServiceUnavailable)
+		return
+	}
+	id := pathSegment(r, 3)
+	version, err := strconv.Atoi(pathSegment(r, 1))
+	if err != nil || version <= 0 {
+		http.Error(w, "bad request: invalid version", http.StatusBadRequest)
+		return
+	}
+	ids, err := documentsRepo.Activate(r.Context(), id, version)
+	switch {
+	case errors.Is(err, documents.ErrNotFound):
+		http.Error(w, "document "+id+" not found", http.StatusNotFound)
+		return
+	case errors.Is(err, documents.ErrVersionNotFound):
+		http.Error(w, "version not found (chunks pruned? see DOC_KEEP_VERSIONS)", http.StatusNotFound)
+		return
+	case err != nil:
+		http.Error(w, "activate error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	dropFromIndex(r.Context(), "", ids)
+	if vectorIndex != nil {
+		_, _ = vectorIndex.Sync(r.Context())
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{"doc_id": id, "version": version, "deactivated_chunks": len(ids)})
+}
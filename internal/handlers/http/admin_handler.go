@@ -2,12 +2,22 @@
 package http
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
+	"path"
 	"path/filepath"
+	"strconv"
+	"strings"
+
+	"mcp-oilgas/internal/chunking"
+	"mcp-oilgas/internal/ingest"
+	"mcp-oilgas/internal/repositories/documents"
 )
 
 type DocMeta struct {
@@ -15,44 +25,212 @@ type DocMeta struct {
 	Size     int64  `json:"size"`
 }
 
+// ----------------- Wiring antrian ingest -----------------
+var ingestJobs *ingest.JobStore
+
+// SetIngestJobs dipanggil dari app.go bila DB tersedia; nil = upload hanya disimpan ke disk.
+func SetIngestJobs(s *ingest.JobStore) { ingestJobs = s }
+
+// uploadsDir: UPLOADS_DIR (default "uploads"); harus sama dengan yang dibaca cmd/worker.
+func uploadsDir() string {
+	if v := strings.TrimSpace(os.Getenv("UPLOADS_DIR")); v != "" {
+		return v
+	}
+	return "uploads"
+}
+
+// maxUploadBytes: INGEST_MAX_UPLOAD_MB (default 50).
+func maxUploadBytes() int64 {
+	mb := int64(50)
+	if n, err := strconv.ParseInt(os.Getenv("INGEST_MAX_UPLOAD_MB"), 10, 64); err == nil && n > 0 {
+		mb = n
+	}
+	return mb << 20
+}
+
 func AdminListDocs(w http.ResponseWriter, r *http.Request) {
-	root := "uploads"
+	root := uploadsDir()
 	_ = os.MkdirAll(root, 0755)
 
-	files, _ := os.ReadDir(root)
+	// upload disimpan di <root>/<hash>/<nama>; file langsung di root berasal dari upload lama
 	var list []DocMeta
+	files, _ := os.ReadDir(root)
 	for _, f := range files {
-		if f.IsDir() { continue }
-		info, _ := f.Info()
-		list = append(list, DocMeta{Filename: f.Name(), Size: info.Size()})
+		if !f.IsDir() {
+			if info, err := f.Info(); err == nil && !strings.HasPrefix(f.Name(), ".upload-") {
+				list = append(list, DocMeta{Filename: f.Name(), Size: info.Size()})
+			}
+			continue
+		}
+		sub, _ := os.ReadDir(filepath.Join(root, f.Name()))
+		for _, g := range sub {
+			if info, err := g.Info(); err == nil && !g.IsDir() {
+				list = append(list, DocMeta{Filename: f.Name() + "/" + g.Name(), Size: info.Size()})
+			}
+		}
 	}
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]any{"docs": list})
 }
 
+// AdminUploadDoc menyimpan file ke UPLOADS_DIR lalu mengantrikan job ingest
+// (ekstrak → chunk → embed → doc_chunks) yang diproses cmd/worker. Upload ulang menjadi versi baru
+// dokumen; isi identik dilewati worker kecuali form force=true.
 func AdminUploadDoc(w http.ResponseWriter, r *http.Request) {
-	root := "uploads"
+	root := uploadsDir()
 	_ = os.MkdirAll(root, 0755)
 
+	r.Body = http.MaxBytesReader(w, r.Body, maxUploadBytes())
 	f, hdr, err := r.FormFile("file")
 	if err != nil {
+		var mbe *http.MaxBytesError
+		if errors.As(err, &mbe) {
+			http.Error(w, "file too large", http.StatusRequestEntityTooLarge)
+			return
+		}
 		http.Error(w, "file missing", http.StatusBadRequest)
 		return
 	}
 	defer f.Close()
 
-	dst := filepath.Join(root, filepath.Base(hdr.Filename))
-	out, err := os.Create(dst)
+	name := filepath.Base(hdr.Filename)
+	if name == "." || name == string(filepath.Separator) || !ingest.Supported(name) {
+		http.Error(w, "unsupported file type (pdf, docx, html, md, txt)", http.StatusUnsupportedMediaType)
+		return
+	}
+
+	collection := strings.ToLower(strings.TrimSpace(r.FormValue("collection")))
+	if collection == "" {
+		collection = chunking.DefaultCollection
+	}
+	if !chunking.ValidCollection(collection) {
+		http.Error(w, "invalid collection", http.StatusBadRequest)
+		return
+	}
+
+	meta, err := uploadMeta(r)
+	if err != nil {
+		http.Error(w, "invalid metadata: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	key, n, err := saveUpload(root, name, f)
 	if err != nil {
 		http.Error(w, "write error", http.StatusInternalServerError)
 		return
 	}
-	defer out.Close()
-	n, _ := io.Copy(out, f)
+	dst := filepath.Join(root, filepath.FromSlash(key))
+
+	resp := map[string]any{"ok": true, "saved": key, "bytes": n}
+	if ingestJobs != nil {
+		force, _ := strconv.ParseBool(r.FormValue("force"))
+		job := ingest.Job{Filename: name, Path: dst, DocID: ingest.DocIDFor(name), Title: ingest.TitleFor(name),
+			Collection: collection, Meta: meta, Force: force}
+		if meta != nil && meta.Title != "" {
+			job.Title = meta.Title
+		}
+		id, err := ingestJobs.Enqueue(r.Context(), job)
+		if err != nil {
+			http.Error(w, "enqueue error: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		resp["job_id"], resp["doc_id"], resp["status"] = id, job.DocID, ingest.StatusQueued
+		resp["collection"] = collection
+	} else {
+		resp["status"] = "stored" // tanpa DB: tidak ada job ingest
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+// saveUpload menulis isi upload ke file sementara di root sambil menghitung SHA-256, lalu
+// memindahkannya ke <root>/<hash>/<name> (ingest.UploadKey). Path per isi membuat upload ulang
+// nama yang sama tidak menimpa file milik job yang masih antri; isi identik menghasilkan file yang sama.
+func saveUpload(root, name string, src io.Reader) (key string, n int64, err error) {
+	tmp, err := os.CreateTemp(root, ".upload-*")
+	if err != nil {
+		return "", 0, err
+	}
+	defer os.Remove(tmp.Name()) // no-op setelah rename berhasil
+	h := sha256.New()
+	n, err = io.Copy(io.MultiWriter(tmp, h), src)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return "", 0, err
+	}
+	key = ingest.UploadKey(hex.EncodeToString(h.Sum(nil)), name)
+	dst := filepath.Join(root, filepath.FromSlash(key))
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return "", 0, err
+	}
+	if err := os.Rename(tmp.Name(), dst); err != nil {
+		return "", 0, err
+	}
+	return key, n, nil
+}
+
+// uploadMeta membaca metadata dokumen opsional dari form upload
+// (title, type, asset, well, area, revision, effective_date, lang, tags dipisah koma).
+func uploadMeta(r *http.Request) (*documents.Document, error) {
+	d := documents.Document{
+		Title:         strings.TrimSpace(r.FormValue("title")),
+		Type:          r.FormValue("type"),
+		Asset:         r.FormValue("asset"),
+		Well:          r.FormValue("well"),
+		Area:          r.FormValue("area"),
+		Revision:      r.FormValue("revision"),
+		EffectiveDate: r.FormValue("effective_date"),
+		Lang:          r.FormValue("lang"),
+		Tags:          strings.Split(r.FormValue("tags"), ","),
+	}
+	if err := d.Normalize(); err != nil {
+		return nil, err
+	}
+	if d.Title == "" && d.Type == "" && d.Asset == "" && d.Well == "" && d.Area == "" && d.Revision == "" &&
+		d.EffectiveDate == "" && d.Lang == "" && len(d.Tags) == 0 {
+		return nil, nil
+	}
+	return &d, nil
+}
 
-	// TODO: panggil pipeline vectorize embeddings di background
-	// (gunakan pkg/vector + cfg.LLM.EmbeddingModel)
+// AdminListIngestJobs: GET /admin/ingest/jobs?status=&limit=
+func AdminListIngestJobs(w http.ResponseWriter, r *http.Request) {
+	if ingestJobs == nil {
+		http.Error(w, "ingest queue unavailable (no database)", http.StatusServiceUnavailable)
+		return
+	}
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	jobs, err := ingestJobs.List(r.Context(), strings.TrimSpace(r.URL.Query().Get("status")), limit)
+	if err != nil {
+		http.Error(w, "list error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{"jobs": jobs})
+}
 
+// AdminGetIngestJob: GET /admin/ingest/jobs/{id}
+func AdminGetIngestJob(w http.ResponseWriter, r *http.Request) {
+	if ingestJobs == nil {
+		http.Error(w, "ingest queue unavailable (no database)", http.StatusServiceUnavailable)
+		return
+	}
+	id, err := strconv.ParseInt(path.Base(r.URL.Path), 10, 64)
+	if err != nil || id <= 0 {
+		http.Error(w, "bad request: invalid job id", http.StatusBadRequest)
+		return
+	}
+	job, err := ingestJobs.Get(r.Context(), id)
+	if errors.Is(err, ingest.ErrJobNotFound) {
+		http.Error(w, fmt.Sprintf("job %d not found", id), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "get error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
 	w.Header().Set("Content-Type", "application/json")
-	fmt.Fprintf(w, `{"ok":true,"saved":"%s","bytes":%d}`, filepath.Base(dst), n)
+	_ = json.NewEncoder(w).Encode(job)
 }
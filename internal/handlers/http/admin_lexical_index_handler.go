@@ -0,0 +1,39 @@
+// internal/handlers/http/admin_lexical_index_handler.go
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"mcp-oilgas/internal/lexindex"
+)
+
+var lexicalIndex *lexindex.Index
+
+// SetLexicalIndex dipanggil dari app.go bila LEXICAL_SEARCH=go|both.
+func SetLexicalIndex(x *lexindex.Index) { lexicalIndex = x }
+
+// AdminLexicalIndexStats: GET /admin/lexical-index
+func AdminLexicalIndexStats(w http.ResponseWriter, r *http.Request) {
+	st := lexindex.Stats{}
+	if lexicalIndex != nil {
+		st = lexicalIndex.Stats()
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(st)
+}
+
+// AdminLexicalIndexSync: POST /admin/lexical-index/sync — sinkronkan sekarang tanpa menunggu interval.
+func AdminLexicalIndexSync(w http.ResponseWriter, r *http.Request) {
+	if lexicalIndex == nil {
+		http.Error(w, "lexical index disabled", http.StatusServiceUnavailable)
+		return
+	}
+	res, err := lexicalIndex.Sync(r.Context())
+	if err != nil {
+		http.Error(w, "sync error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{"result": res, "stats": lexicalIndex.Stats()})
+}
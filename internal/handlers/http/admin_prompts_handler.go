@@ -0,0 +1,47 @@
+// internal/handlers/http/admin_prompts_handler.go
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"mcp-oilgas/internal/prompts"
+)
+
+type promptsStatus struct {
+	Version  string         `json:"version"`
+	Dir      string         `json:"dir"`
+	LoadedAt string         `json:"loaded_at"`
+	Prompts  []prompts.Info `json:"prompts"`
+	Error    string         `json:"error,omitempty"`
+}
+
+func currentPromptsStatus() promptsStatus {
+	s := prompts.Default()
+	return promptsStatus{
+		Version:  s.Version(),
+		Dir:      s.Dir(),
+		LoadedAt: s.LoadedAt().UTC().Format(time.RFC3339),
+		Prompts:  s.List(),
+	}
+}
+
+// AdminListPrompts menampilkan template prompt aktif beserta versinya.
+func AdminListPrompts(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(currentPromptsStatus())
+}
+
+// AdminReloadPrompts memuat ulang template dari PROMPTS_DIR tanpa rebuild/restart.
+// Bila ada template yang gagal di-parse, set lama tetap dipakai dan error dikembalikan.
+func AdminReloadPrompts(w http.ResponseWriter, r *http.Request) {
+	err := prompts.Default().Reload()
+	st := currentPromptsStatus()
+	w.Header().Set("Content-Type", "application/json")
+	if err != nil {
+		st.Error = err.Error()
+		w.WriteHeader(http.StatusUnprocessableEntity)
+	}
+	_ = json.NewEncoder(w).Encode(st)
+}
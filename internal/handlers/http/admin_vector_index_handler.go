@@ -0,0 +1,39 @@
+// internal/handlers/http/admin_vector_index_handler.go
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"mcp-oilgas/internal/vectorindex"
+)
+
+var vectorIndex *vectorindex.Index
+
+// SetVectorIndex dipanggil dari app.go bila indeks ANN aktif.
+func SetVectorIndex(x *vectorindex.Index) { vectorIndex = x }
+
+// AdminVectorIndexStats: GET /admin/vector-index
+func AdminVectorIndexStats(w http.ResponseWriter, r *http.Request) {
+	st := vectorindex.Stats{}
+	if vectorIndex != nil {
+		st = vectorIndex.Stats()
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(st)
+}
+
+// AdminVectorIndexSync: POST /admin/vector-index/sync — sinkronkan sekarang tanpa menunggu interval.
+func AdminVectorIndexSync(w http.ResponseWriter, r *http.Request) {
+	if vectorIndex == nil {
+		http.Error(w, "vector index disabled", http.StatusServiceUnavailable)
+		return
+	}
+	res, err := vectorIndex.Sync(r.Context())
+	if err != nil {
+		http.Error(w, "sync error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{"result": res, "stats": vectorIndex.Stats()})
+}
@@ -5,11 +5,19 @@ import (
 	"context"
 	"encoding/json"
 	"net/http"
+	"os"
+	"strings"
 	"time"
 
+	"mcp-oilgas/internal/audit"
+	"mcp-oilgas/internal/llm"
 	mcps "mcp-oilgas/internal/mcp"
-	"mcp-oilgas/internal/mcp/llm"
+	"mcp-oilgas/internal/prompts"
+	"mcp-oilgas/internal/repositories/documents"
+	mysqlrepo "mcp-oilgas/internal/repositories/mysql"
 	search "mcp-oilgas/internal/repositories/search"
+	"mcp-oilgas/internal/safety"
+	"mcp-oilgas/internal/verify"
 )
 
 type AskRequest struct {
@@ -18,11 +26,39 @@ type AskRequest struct {
 }
 
 type AskResponse struct {
-	Status  string            `json:"status"`
-	Plan    mcps.Plan         `json:"plan"`
-	Sources []mcps.ExecResult `json:"sources"`
-	Answer  string            `json:"answer"`
-	Error   string            `json:"error,omitempty"`
+	Status        string            `json:"status"`
+	Plan          mcps.Plan         `json:"plan"`
+	Sources       []mcps.ExecResult `json:"sources"`
+	Answer        string            `json:"answer"`
+	PromptVersion string            `json:"prompt_version"`
+	Verification  *verify.Report    `json:"verification,omitempty"`
+	Safety        *safety.Report    `json:"safety,omitempty"`
+	Error         string            `json:"error,omitempty"`
+}
+
+// Mode verifikasi jawaban /api/ask: params.verify atau env ASK_VERIFY_MODE (default "flag").
+//
+//	off        → tanpa verifikasi
+//	flag       → laporan verifikasi disertakan, status "unverified" bila gagal
+//	refuse     → jawaban diganti penolakan bila gagal
+//	regenerate → satu kali regenerasi dengan daftar klaim tak berdasar; bila masih gagal → flag
+const (
+	verifyOff        = "off"
+	verifyFlag       = "flag"
+	verifyRefuse     = "refuse"
+	verifyRegenerate = "regenerate"
+)
+
+func verifyMode(params map[string]interface{}) string {
+	mode := strings.ToLower(strings.TrimSpace(os.Getenv("ASK_VERIFY_MODE")))
+	if v, ok := params["verify"].(string); ok && strings.TrimSpace(v) != "" {
+		mode = strings.ToLower(strings.TrimSpace(v))
+	}
+	switch mode {
+	case verifyOff, verifyRefuse, verifyRegenerate:
+		return mode
+	}
+	return verifyFlag
 }
 
 type AskDeps struct {
@@ -54,12 +90,13 @@ func NewAskHandler(deps AskDeps) http.HandlerFunc {
 		for _, d := range defs {
 			tools = append(tools, llm.ToolLite{Name: d.Name, Description: d.Description})
 		}
-		planner, err := llm.NewRoutePlannerFromEnv()
+		// Satu client (dan satu sesi redaksi bila REDACT_LLM aktif) untuk planner & synth
+		oclient, err := llmClient()
 		var plan mcps.Plan
 		if err != nil {
 			plan = mcps.Plan{Mode: "rag", Fallback: true, Routes: []mcps.Route{{Kind: mcps.RouteRAG, Query: req.Question, TopK: 10}}, Reason: "planner init failed"}
 		} else {
-			raw, err := planner.PlanRaw(ctx, tools, req.Question)
+			raw, err := llm.NewRoutePlanner(oclient).PlanRaw(ctx, tools, req.Question)
 			if err != nil || raw == "" {
 				plan = mcps.Plan{Mode: "rag", Fallback: true, Routes: []mcps.Route{{Kind: mcps.RouteRAG, Query: req.Question, TopK: 10}}, Reason: "planner error/fallback"}
 			} else {
@@ -70,8 +107,9 @@ func NewAskHandler(deps AskDeps) http.HandlerFunc {
 		}
 
 		// Eksekusi routes (MCP/RAG)
-		ragFn := func(ctx context.Context, query string, topK int) ([]map[string]any, error) {
-			hits, err := deps.RAGRepo.Retrieve(ctx, query, topK)
+		// RAGRepo hanya mengurutkan dengan cosine, jadi opsi fusi planner tidak berlaku di sini
+		ragFn := func(ctx context.Context, query string, topK int, f documents.Filter, _ mysqlrepo.HybridOptions) ([]map[string]any, error) {
+			hits, err := deps.RAGRepo.RetrieveFiltered(ctx, query, topK, f)
 			if err != nil {
 				return nil, err
 			}
@@ -89,27 +127,58 @@ func NewAskHandler(deps AskDeps) http.HandlerFunc {
 			return out, nil
 		}
 		sources, _ := mcps.ExecuteRoutes(ctx, plan.Routes, ragFn)
+		// Saring prompt injection pada snippet/output tool sebelum masuk prompt
+		sources, screenRep := safety.Default().ScreenResults(audit.WithRequestID(ctx, r.Header.Get("X-Request-ID")), sources)
 
 		// Fase 2: Synth jawaban via LLM
-		oclient, err := llm.NewFromEnv()
 		answer := ""
+		var sys prompts.Rendered
+		var userPayload string
 		if err == nil {
-			payload := struct {
-				Question string            `json:"question"`
-				Sources  []mcps.ExecResult `json:"sources"`
-			}{Question: req.Question, Sources: sources}
-			b, _ := json.Marshal(payload)
-			sys := `Anda adalah asisten teknis.
-- Jawab singkat, akurat, gunakan data pada "sources".
-- Jika beberapa sumber, gabungkan dan sebutkan angka utama.
-- Jika data kurang, sebutkan batasannya. Balas hanya jawaban final.`
-			answer, _ = oclient.AnswerWithRAG(ctx, sys, string(b))
+			userPayload = synthPayload(req.Question, sources)
+			if rp, perr := prompts.Default().Render(prompts.AskSynth, prompts.Vars{Lang: "id"}); perr == nil {
+				sys = rp
+				answer, _ = oclient.AnswerWithRAG(ctx, sys.Text, userPayload)
+			}
 		}
 		if answer == "" {
 			answer = "Maaf, terjadi kendala saat menyusun jawaban."
 		}
 
-		resp := AskResponse{Status: "ok", Plan: plan, Sources: sources, Answer: answer}
+		// Fase 3: Verifikasi grounding (angka/tanggal/PO/sumur harus ada di sources)
+		status := "ok"
+		var report *verify.Report
+		if mode := verifyMode(req.Params); mode != verifyOff && sys.Text != "" {
+			ev := verify.NewEvidence(req.Question, sources)
+			rep := verify.Verify(answer, ev, verify.Options{})
+			if !rep.Passed && mode == verifyRegenerate {
+				retry := sys.Text + "\n- PENTING: klaim berikut TIDAK ada di sources, jangan sebutkan kecuali ada datanya: " +
+					strings.Join(rep.UnsupportedTexts(), ", ")
+				if again, rerr := oclient.AnswerWithRAG(ctx, retry, userPayload); rerr == nil && strings.TrimSpace(again) != "" {
+					answer = again
+					rep = verify.Verify(answer, ev, verify.Options{})
+				}
+			}
+			if !rep.Passed {
+				status = "unverified"
+				if mode == verifyRefuse {
+					answer = "Maaf, jawaban tidak dapat diverifikasi terhadap data sumber sehingga tidak ditampilkan."
+				}
+			}
+			report = &rep
+		}
+
+		resp := AskResponse{
+			Status:        status,
+			Plan:          plan,
+			Sources:       sources,
+			Answer:        answer,
+			PromptVersion: prompts.Default().Version(),
+			Verification:  report,
+		}
+		if screenRep.Any() {
+			resp.Safety = &screenRep
+		}
 		w.Header().Set("Content-Type", "application/json")
 		_ = json.NewEncoder(w).Encode(resp)
 	}
@@ -12,10 +12,19 @@ import (
 	"strings"
 	"time"
 
+	"mcp-oilgas/internal/answercache"
+	"mcp-oilgas/internal/audit"
 	"mcp-oilgas/internal/config"
+	"mcp-oilgas/internal/llm"
 	mcps "mcp-oilgas/internal/mcp"
-	"mcp-oilgas/internal/mcp/llm"
+	"mcp-oilgas/internal/prompts"
+	"mcp-oilgas/internal/redact"
+	"mcp-oilgas/internal/repositories/documents"
+	mysqlrepo "mcp-oilgas/internal/repositories/mysql"
 	search "mcp-oilgas/internal/repositories/search"
+	"mcp-oilgas/internal/safety"
+	"mcp-oilgas/internal/verify"
+	"mcp-oilgas/internal/viz"
 )
 
 // ----------------- Wiring RAGRepo -----------------
@@ -24,6 +33,12 @@ var ragRepo search.RAGRepo
 // SetRAGRepo dipanggil dari app.go setelah RAGRepo siap (DB & embeddings client OK).
 func SetRAGRepo(r search.RAGRepo) { ragRepo = r }
 
+// ----------------- Wiring Answer Cache -----------------
+var answerCache *answercache.Cache
+
+// SetAnswerCache mengaktifkan cache jawaban semantik untuk /chat/stream (nil = nonaktif).
+func SetAnswerCache(c *answercache.Cache) { answerCache = c }
+
 // ----------------- Request Models -----------------
 type sseAskRequest struct {
 	Question string                 `json:"question"`
@@ -80,22 +95,133 @@ func looksEnglish(s string) bool {
 	return letters >= int(float64(len(s))*0.35)
 }
 
-func systemPromptByLang(lang string) string {
-	if lang == "en" {
-		return `You are a technical assistant.
-- Use the "sources" data to answer the question.
-- Be concise and accurate; call out key numbers and conclusions.
-- If data is insufficient, state the limitation.
-- Write in natural English for the user.
-- If time series or tabular data is present, the client UI will render charts/tables in a separate section; you don't need to reformat them.`
-	}
-	// default: Indonesian
-	return `Anda adalah asisten teknis.
-- Gunakan data pada "sources" untuk menjawab pertanyaan.
-- Tulis ringkas, akurat, sebutkan angka/kesimpulan penting.
-- Jika data kurang, sebutkan keterbatasannya.
-- Balas dengan bahasa Indonesia yang alami.
-- Jika ada time series atau tabel, UI klien akan menampilkan chart/tabel di panel terpisah; Anda tidak perlu memformat ulang.`
+// systemPromptByLang merender template "chat_synth" (lihat internal/prompts; override rusak
+// otomatis jatuh ke template bawaan).
+func systemPromptByLang(lang string) (prompts.Rendered, error) {
+	return prompts.Default().Render(prompts.ChatSynth, prompts.Vars{Lang: lang})
+}
+
+// isAgentMode: aktif bila ?mode=agent atau params.mode="agent" / params.agent=true.
+func isAgentMode(r *http.Request, body sseAskRequest) bool {
+	if strings.EqualFold(strings.TrimSpace(r.URL.Query().Get("mode")), "agent") {
+		return true
+	}
+	if body.Params == nil {
+		return false
+	}
+	if v, ok := body.Params["mode"].(string); ok && strings.EqualFold(strings.TrimSpace(v), "agent") {
+		return true
+	}
+	v, _ := body.Params["agent"].(bool)
+	return v
+}
+
+// cacheBypassed: ?cache=off atau params.cache=false memaksa pipeline penuh.
+func cacheBypassed(r *http.Request, body sseAskRequest) bool {
+	if v := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("cache"))); v == "off" || v == "false" || v == "0" {
+		return true
+	}
+	if body.Params == nil {
+		return false
+	}
+	v, ok := body.Params["cache"].(bool)
+	return ok && !v
+}
+
+// llmClient mengembalikan client bersama; bila REDACT_LLM aktif dibungkus sesi redaksi per request
+// (PII/secret diganti placeholder sebelum dikirim, lalu dikembalikan di jawaban & params plan).
+func llmClient() (llm.Client, error) {
+	c, err := llm.Shared()
+	if err != nil {
+		return nil, err
+	}
+	if redact.LLMEnabled() {
+		c = redact.WrapClient(c, redact.Default().NewSession())
+	}
+	return c, nil
+}
+
+// synthPayload: pertanyaan (JSON) + sources yang dibungkus <untrusted_data> (lihat internal/safety),
+// sehingga isi dokumen/tool tidak bisa menyamar sebagai instruksi.
+func synthPayload(q string, sources []mcps.ExecResult) string {
+	qb, _ := json.Marshal(map[string]string{"question": q})
+	return string(qb) + "\n\nsources:\n" + safety.QuoteJSON("sources", sources)
+}
+
+// chatRetrieve: retriever RAG untuk route kind=rag (hybrid /rag/search_v2, fallback RAGRepo).
+func chatRetrieve(ctx context.Context, query string, topK int, f documents.Filter, opt mysqlrepo.HybridOptions) ([]map[string]any, error) {
+	// 1) Coba pakai hybrid endpoint /rag/search_v2 (BM25+cosine) – tidak butuh OpenAI di query-time
+	payload := map[string]any{
+		"query": query,
+		"top_k": topK,
+		"alpha": mysqlrepo.AlphaFromEnv(),
+	}
+	if !f.Empty() {
+		payload["filters"] = f
+	}
+	mcps.AddFusionParams(payload, opt)
+	b, _ := json.Marshal(payload)
+
+	req, _ := http.NewRequestWithContext(ctx, http.MethodPost, "http://localhost:8080/rag/search_v2", bytes.NewReader(b))
+	req.Header.Set("Content-Type", "application/json")
+
+	if resp, err := http.DefaultClient.Do(req); err == nil && resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		defer resp.Body.Close()
+		var r struct {
+			RetrievedChunks []struct {
+				ChunkID   int64  `json:"chunk_id"`
+				DocID     string `json:"doc_id"`
+				Title     string `json:"title"`
+				URL       string `json:"url"`
+				Snippet   string `json:"snippet"`
+				PageNo    int    `json:"page_no"`
+				CharStart *int   `json:"char_start"`
+				CharEnd   *int   `json:"char_end"`
+				Score     any    `json:"score"`
+			} `json:"retrieved_chunks"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&r); err != nil {
+			return nil, fmt.Errorf("decode rag response: %w", err)
+		}
+		out := make([]map[string]any, 0, len(r.RetrievedChunks))
+		for _, h := range r.RetrievedChunks {
+			m := map[string]any{
+				"chunk_id": h.ChunkID,
+				"doc_id":   h.DocID,
+				"title":    h.Title,
+				"url":      h.URL,
+				"snippet":  h.Snippet,
+				"page_no":  h.PageNo, // ← penting: page_no (bukan "page")
+				"score":    h.Score,
+			}
+			if h.CharStart != nil && h.CharEnd != nil {
+				m["char_start"], m["char_end"] = *h.CharStart, *h.CharEnd
+			}
+			out = append(out, m)
+		}
+		return out, nil
+	}
+
+	// 2) Fallback: kalau hybrid gagal, coba repo embeddings (kalau ada)
+	if ragRepo == nil {
+		return nil, fmt.Errorf("RAG hybrid & embeddings repo unavailable")
+	}
+	hits, err := ragRepo.RetrieveFiltered(ctx, query, topK, f)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]map[string]any, 0, len(hits))
+	for _, h := range hits {
+		out = append(out, map[string]any{
+			"doc_id":  h.DocID,
+			"title":   h.Title,
+			"url":     h.URL,
+			"snippet": h.Snippet,
+			"page_no": h.Page, // konsistenkan ke page_no
+			"score":   h.Score,
+		})
+	}
+	return out, nil
 }
 
 // ----------------- Handler -----------------
@@ -141,19 +267,6 @@ func ChatSSEHandler(w http.ResponseWriter, r *http.Request) {
 			lang = "id"
 		}
 	}
-	sseEvent(w, flusher, "meta", map[string]string{"lang": lang})
-
-	// 2) Init LLM + Planner
-	client, err := llm.NewFromEnv()
-	if err != nil {
-		sseEvent(w, flusher, "error", map[string]string{"message": "LLM init error: " + err.Error()})
-		return
-	}
-	planner, err := llm.NewRoutePlannerFromEnv()
-	if err != nil {
-		sseEvent(w, flusher, "warn", map[string]string{"message": "Planner init failed, fallback RAG"})
-	}
-
 	// Deadlines
 	ctx := r.Context()
 	if _, has := ctx.Deadline(); !has {
@@ -161,6 +274,70 @@ func ChatSSEHandler(w http.ResponseWriter, r *http.Request) {
 		ctx, cancel = context.WithTimeout(r.Context(), 75*time.Second)
 		defer cancel()
 	}
+	ctx = audit.WithRequestID(ctx, r.Header.Get("X-Request-ID"))
+	screener := safety.Default()
+
+	ragFn := chatRetrieve
+	agentMode := isAgentMode(r, body)
+
+	// Cache jawaban semantik: kandidat mirip + hasil tool (diputar ulang) tidak berubah → hit
+	var (
+		cq         answercache.Query
+		cacheScope string
+		useCache   = answerCache != nil && !cacheBypassed(r, body)
+	)
+	if useCache {
+		mode := "default"
+		if agentMode {
+			mode = "agent"
+		}
+		cacheScope = lang + "|" + mode + "|" + prompts.Default().Version()
+		cq = answerCache.Embed(ctx, q)
+		if e, sim := answerCache.Lookup(cq, cacheScope); e != nil {
+			replay, _ := mcps.ExecuteRoutes(ctx, e.Routes, ragFn)
+			replay, screenRep := screener.ScreenResults(ctx, replay)
+			if answercache.Fingerprint(replay) == e.Fingerprint {
+				sseEvent(w, flusher, "meta", map[string]any{
+					"lang":             lang,
+					"prompt_version":   prompts.Default().Version(),
+					"cache_hit":        true,
+					"cache_similarity": sim,
+					"cache_question":   e.Question,
+				})
+				if screenRep.Any() {
+					sseEvent(w, flusher, "safety", screenRep)
+				}
+				sseEvent(w, flusher, "sources", replay)
+				for _, v := range viz.FromResults(replay, viz.Options{}) {
+					sseEvent(w, flusher, "visualization", v)
+				}
+				sseEvent(w, flusher, "verification", verify.Verify(e.Answer, verify.NewEvidence(q, replay), verify.Options{}))
+				sseEvent(w, flusher, "done", map[string]any{
+					"final":          e.Answer,
+					"prompt_version": prompts.Default().Version(),
+					"cache_hit":      true,
+				})
+				time.Sleep(50 * time.Millisecond)
+				return
+			}
+			// data berubah sejak jawaban disimpan
+			answerCache.Invalidate(e.ID)
+		}
+	}
+
+	sseEvent(w, flusher, "meta", map[string]any{
+		"lang":           lang,
+		"prompt_version": prompts.Default().Version(),
+		"cache_hit":      false,
+	})
+
+	// 2) Init LLM + Planner
+	client, err := llmClient()
+	if err != nil {
+		sseEvent(w, flusher, "error", map[string]string{"message": "LLM init error: " + err.Error()})
+		return
+	}
+	planner := llm.NewRoutePlanner(client)
 
 	// 3) Planning
 	sseEvent(w, flusher, "phase", `"plan_start"`)
@@ -183,8 +360,10 @@ func ChatSSEHandler(w http.ResponseWriter, r *http.Request) {
 	})
 
 	// Muat tools dari folder schema dan expose ke FE
+	var tools []llm.ToolLite
 	if planner != nil {
-		tools, loadErr := llm.LoadToolsFromSchemaDir(schemaDir)
+		var loadErr error
+		tools, loadErr = llm.LoadToolsFromSchemaDir(schemaDir)
 		if loadErr != nil {
 			log.Println("[planner] LoadToolsFromSchemaDir error:", loadErr)
 			sseEvent(w, flusher, "warn", map[string]any{
@@ -219,7 +398,7 @@ func ChatSSEHandler(w http.ResponseWriter, r *http.Request) {
 			} else {
 				var p mcps.Plan
 				if uerr := json.Unmarshal([]byte(raw), &p); uerr != nil || len(p.Routes) == 0 {
-					log.Println("[planner] unmarshal fail or no routes; raw:", raw, "err:", uerr)
+					log.Println("[planner] unmarshal fail or no routes; raw:", redact.Log(raw), "err:", uerr)
 					sseEvent(w, flusher, "warn", map[string]any{
 						"message": "planner unmarshal fail or no routes",
 						"raw":     raw,
@@ -239,88 +418,67 @@ func ChatSSEHandler(w http.ResponseWriter, r *http.Request) {
 	// 4) Eksekusi Routes
 	sseEvent(w, flusher, "phase", `"exec_start"`)
 
-	ragFn := func(ctx context.Context, query string, topK int) ([]map[string]any, error) {
-		// 1) Coba pakai hybrid endpoint /rag/search_v2 (BM25+cosine) – tidak butuh OpenAI di query-time
-		payload := map[string]any{
-			"query": query,
-			"top_k": topK,
-			"alpha": 0.6,
+	var (
+		sources   []mcps.ExecResult
+		screenRep = safety.Report{Mode: screener.Mode()}
+	)
+	if agentMode {
+		// Mode agen: LLM melihat hasil antara lalu boleh memanggil tool lanjutan
+		agent := &mcps.Agent{LLM: client, Tools: tools, Config: mcps.AgentConfigFromEnv()}
+		agent.Screen = func(ctx context.Context, res []mcps.ExecResult) []mcps.ExecResult {
+			out, rep := screener.ScreenResults(ctx, res)
+			screenRep.Merge(rep)
+			return out
 		}
-		b, _ := json.Marshal(payload)
-
-		req, _ := http.NewRequestWithContext(ctx, http.MethodPost, "http://localhost:8080/rag/search_v2", bytes.NewReader(b))
-		req.Header.Set("Content-Type", "application/json")
-
-		if resp, err := http.DefaultClient.Do(req); err == nil && resp.StatusCode >= 200 && resp.StatusCode < 300 {
-			defer resp.Body.Close()
-			var r struct {
-				RetrievedChunks []struct {
-					DocID   string `json:"doc_id"`
-					Title   string `json:"title"`
-					URL     string `json:"url"`
-					Snippet string `json:"snippet"`
-					PageNo  int    `json:"page_no"`
-					Score   any    `json:"score"`
-				} `json:"retrieved_chunks"`
-			}
-			if err := json.NewDecoder(resp.Body).Decode(&r); err != nil {
-				return nil, fmt.Errorf("decode rag response: %w", err)
-			}
-			out := make([]map[string]any, 0, len(r.RetrievedChunks))
-			for _, h := range r.RetrievedChunks {
-				out = append(out, map[string]any{
-					"doc_id":  h.DocID,
-					"title":   h.Title,
-					"url":     h.URL,
-					"snippet": h.Snippet,
-					"page_no": h.PageNo, // ← penting: page_no (bukan "page")
-					"score":   h.Score,
+		sseEvent(w, flusher, "agent_start", map[string]any{
+			"max_steps":   agent.Config.MaxSteps,
+			"time_budget": agent.Config.TimeBudget.Seconds(),
+		})
+		run := agent.Run(ctx, q, plan, ragFn, mcps.AgentHooks{
+			OnStepStart: func(st mcps.AgentStep) {
+				sseEvent(w, flusher, "agent_step", map[string]any{
+					"index":   st.Index,
+					"thought": st.Thought,
+					"routes":  st.Routes,
 				})
-			}
-			return out, nil
-		}
-
-		// 2) Fallback: kalau hybrid gagal, coba repo embeddings (kalau ada)
-		if ragRepo == nil {
-			return nil, fmt.Errorf("RAG hybrid & embeddings repo unavailable")
-		}
-		hits, err := ragRepo.Retrieve(ctx, query, topK)
-		if err != nil {
-			return nil, err
-		}
-		out := make([]map[string]any, 0, len(hits))
-		for _, h := range hits {
-			out = append(out, map[string]any{
-				"doc_id":  h.DocID,
-				"title":   h.Title,
-				"url":     h.URL,
-				"snippet": h.Snippet,
-				"page_no": h.Page, // konsistenkan ke page_no
-				"score":   h.Score,
-			})
-		}
-		return out, nil
+			},
+			OnStepDone: func(st mcps.AgentStep) {
+				sseEvent(w, flusher, "agent_observation", st)
+			},
+		})
+		sseEvent(w, flusher, "agent_done", map[string]any{
+			"steps":       len(run.Steps),
+			"stop_reason": run.StopReason,
+		})
+		sources = run.Sources
+	} else {
+		sources, _ = mcps.ExecuteRoutes(ctx, plan.Routes, ragFn)
+		sources, screenRep = screener.ScreenResults(ctx, sources)
+	}
+	// Konten yang terindikasi prompt injection sudah dinetralkan/dibuang sesuai SAFETY_MODE
+	if screenRep.Any() {
+		sseEvent(w, flusher, "safety", screenRep)
 	}
-
-	sources, _ := mcps.ExecuteRoutes(ctx, plan.Routes, ragFn)
 	sseEvent(w, flusher, "sources", sources)
+	for _, v := range viz.FromResults(sources, viz.Options{}) {
+		sseEvent(w, flusher, "visualization", v)
+	}
 	sseEvent(w, flusher, "phase", `"exec_done"`)
 
 	// 5) Synthesizer (Streaming)
-	sys := systemPromptByLang(lang)
-
-	payload := struct {
-		Question string            `json:"question"`
-		Sources  []mcps.ExecResult `json:"sources"`
-	}{
-		Question: q,
-		Sources:  sources,
+	sys, err := systemPromptByLang(lang)
+	if err != nil {
+		sseEvent(w, flusher, "error", map[string]string{"message": "prompt error: " + err.Error()})
+		return
 	}
-	pb, _ := json.Marshal(payload)
+	sseEvent(w, flusher, "prompt", map[string]string{
+		"name":    sys.Name,
+		"version": sys.Version,
+	})
 
 	sseEvent(w, flusher, "phase", `"llm_start"`)
 
-	final, err := client.AnswerStream(ctx, sys, string(pb), func(delta string) error {
+	final, err := client.AnswerStream(ctx, sys.Text, synthPayload(q, sources), func(delta string) error {
 		sseEvent(w, flusher, "delta", map[string]string{"delta": delta})
 		return nil
 	})
@@ -329,7 +487,19 @@ func ChatSSEHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// 6) Selesai
-	sseEvent(w, flusher, "done", map[string]string{"final": final})
+	// 6) Verifikasi angka/tanggal/ID pada jawaban terhadap sources
+	report := verify.Verify(final, verify.NewEvidence(q, sources), verify.Options{})
+	sseEvent(w, flusher, "verification", report)
+
+	// hanya jawaban yang lolos verifikasi yang di-cache
+	if useCache && report.Passed {
+		answerCache.Store(cq, cacheScope, sources, final)
+	}
+
+	// 7) Selesai
+	sseEvent(w, flusher, "done", map[string]string{
+		"final":          final,
+		"prompt_version": prompts.Default().Version(),
+	})
 	time.Sleep(50 * time.Millisecond)
 }
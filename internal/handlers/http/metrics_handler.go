@@ -6,9 +6,47 @@ package http
 import (
 	"fmt"
 	"net/http"
+
+	"mcp-oilgas/internal/llm"
 )
 
 func MetricsHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
 	fmt.Fprintf(w, "# HELP app_up 1 if the app is up\n# TYPE app_up gauge\napp_up 1\n")
+
+	// Lapisan ketahanan LLM (retry/fallback/semaphore/circuit breaker)
+	s := llm.SharedResilience().Stats()
+	fmt.Fprintf(w, "# TYPE llm_inflight gauge\nllm_inflight %d\n", s.InFlight)
+	fmt.Fprintf(w, "# TYPE llm_queue_waiting gauge\nllm_queue_waiting %d\n", s.Waiting)
+	fmt.Fprintf(w, "# TYPE llm_retries_total counter\nllm_retries_total %d\n", s.Retries)
+	fmt.Fprintf(w, "# TYPE llm_fallbacks_total counter\nllm_fallbacks_total %d\n", s.Fallbacks)
+	fmt.Fprintf(w, "# TYPE llm_failures_total counter\nllm_failures_total %d\n", s.Failures)
+	fmt.Fprintf(w, "# TYPE llm_circuit_open gauge\n")
+	for model, state := range s.Breakers {
+		open := 0
+		if state == "open" {
+			open = 1
+		}
+		fmt.Fprintf(w, "llm_circuit_open{model=%q} %d\n", model, open)
+	}
+
+	// Telemetri per operasi (chat/json/stream/embed)
+	ops := llm.Telemetry()
+	fmt.Fprintf(w, "# TYPE llm_calls_total counter\n")
+	for _, op := range ops {
+		fmt.Fprintf(w, "llm_calls_total{op=%q} %d\n", op.Op, op.Calls)
+	}
+	fmt.Fprintf(w, "# TYPE llm_errors_total counter\n")
+	for _, op := range ops {
+		fmt.Fprintf(w, "llm_errors_total{op=%q} %d\n", op.Op, op.Errors)
+	}
+	fmt.Fprintf(w, "# TYPE llm_latency_seconds_sum counter\n")
+	for _, op := range ops {
+		fmt.Fprintf(w, "llm_latency_seconds_sum{op=%q} %.3f\n", op.Op, op.TotalDuration.Seconds())
+	}
+	fmt.Fprintf(w, "# TYPE llm_tokens_total counter\n")
+	for _, op := range ops {
+		fmt.Fprintf(w, "llm_tokens_total{op=%q,kind=\"prompt\"} %d\n", op.Op, op.PromptTokens)
+		fmt.Fprintf(w, "llm_tokens_total{op=%q,kind=\"completion\"} %d\n", op.Op, op.CompletionTokens)
+	}
 }
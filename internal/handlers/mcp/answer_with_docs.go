@@ -15,7 +15,14 @@ import (
 	"strings"
 	"time"
 
-	"mcp-oilgas/internal/mcp/llm"
+	"mcp-oilgas/internal/audit"
+	"mcp-oilgas/internal/citations"
+	"mcp-oilgas/internal/llm"
+	"mcp-oilgas/internal/prompts"
+	"mcp-oilgas/internal/redact"
+	"mcp-oilgas/internal/repositories/documents"
+	mysqlrepo "mcp-oilgas/internal/repositories/mysql"
+	"mcp-oilgas/internal/safety"
 )
 
 // ======= I/O types =======
@@ -24,46 +31,50 @@ type AnswerWithDocsInput struct {
 	Question        string        `json:"question"`
 	RetrievedChunks []DocChunkRef `json:"retrieved_chunks"`
 	TopK            int           `json:"top_k,omitempty"` // opsional, dipakai kalau mau auto-retrieve
+	// Filters (opsional) membatasi auto-retrieve ke dokumen dengan metadata tertentu;
+	// tidak berlaku untuk retrieved_chunks yang dikirim pemanggil.
+	Filters *documents.Filter `json:"filters,omitempty"`
+	// Highlight=false menonaktifkan span highlight di sources (default aktif).
+	Highlight *bool `json:"highlight,omitempty"`
+	// Fusion/RRFK (opsional) meneruskan strategi fusi /rag/search_v2 ke auto-retrieve.
+	Fusion string `json:"fusion,omitempty"`
+	RRFK   int    `json:"rrf_k,omitempty"`
 }
 
 type DocChunkRef struct {
+	ChunkID int64  `json:"chunk_id,omitempty"`
 	DocID   string `json:"doc_id"`
 	Title   string `json:"title,omitempty"`
 	URL     string `json:"url,omitempty"`
 	Snippet string `json:"snippet"`
 	PageNo  int    `json:"page_no,omitempty"`
+	// CharStart/CharEnd: offset rune snippet di teks dokumen (opsional, dari /rag/search_v2)
+	CharStart int `json:"char_start,omitempty"`
+	CharEnd   int `json:"char_end,omitempty"`
 }
 
 type AnswerWithDocsOutput struct {
 	Answer    string   `json:"answer"`
-	Citations []string `json:"citations"`
+	Citations []string `json:"citations"` // citation key unik (format lama), mis. "doc-1#p2"
+	// Sources: sitasi terstruktur per chunk (urutan = nomor [n] di prompt) dengan offset & highlight;
+	// Sentences: kalimat jawaban beserta chunk pendukungnya.
+	Sources       []citations.Citation `json:"sources"`
+	Sentences     []citations.Sentence `json:"sentences,omitempty"`
+	PromptVersion string               `json:"prompt_version,omitempty"`
+	FlaggedChunks []string             `json:"flagged_chunks,omitempty"` // citation key chunk yang terindikasi prompt injection
 }
 
 // ======= (Opsional) Hook ke RAG repo =======
 // Daftarkan fungsi ini dari layer wiring (app.go) bila ingin auto-retrieve saat input.RetrievedChunks kosong.
-var RetrieveFn func(ctx context.Context, query string, topK int) ([]DocChunkRef, error)
+var RetrieveFn func(ctx context.Context, query string, topK int, f documents.Filter, opt mysqlrepo.HybridOptions) ([]DocChunkRef, error)
 
-// ======= LLM client (lazy init) =======
-var llmClient llm.Client
-var llmInitErr error
-var llmOnce = struct {
-	done bool
-}{}
-
-func initLLM() {
-	if llmOnce.done {
-		return
-	}
-	llmClient, llmInitErr = llm.NewFromEnv()
-	llmOnce.done = true
-}
 
 // ======= Handler =======
 
 func AnswerWithDocsHandler(w http.ResponseWriter, r *http.Request) {
 	var input AnswerWithDocsInput
 	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
-		http.Error(w, "bad request: invalid json", http.StatusBadRequest)
+		http.Error(w, "bad request: invalid json: "+err.Error(), http.StatusBadRequest)
 		return
 	}
 	input.Question = strings.TrimSpace(input.Question)
@@ -81,7 +92,11 @@ func AnswerWithDocsHandler(w http.ResponseWriter, r *http.Request) {
 		}
 		ctx, cancel := context.WithTimeout(r.Context(), 4*time.Second)
 		defer cancel()
-		rc, err := RetrieveFn(ctx, input.Question, topK)
+		var filter documents.Filter
+		if input.Filters != nil {
+			filter = *input.Filters
+		}
+		rc, err := RetrieveFn(ctx, input.Question, topK, filter, mysqlrepo.HybridOptions{Fusion: input.Fusion, RRFK: input.RRFK})
 		if err != nil {
 			http.Error(w, "retrieve error: "+err.Error(), http.StatusInternalServerError)
 			return
@@ -94,17 +109,25 @@ func AnswerWithDocsHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Saring prompt injection pada snippet (dinetralkan/dibuang sesuai SAFETY_MODE)
+	ctx := audit.WithRequestID(r.Context(), r.Header.Get("X-Request-ID"))
+	chunks, flagged := screenChunks(ctx, chunks)
+
 	// Susun citations unik & prompt
 	cits := makeCitations(chunks) // e.g. ["doc-1#p2", "doc-7#p1"]
-	system := defaultSystemPrompt()
+	system, perr := defaultSystemPrompt()
 	user := buildUserPrompt(input.Question, chunks)
 
 	// Coba LLM kalau ada API key, jika tidak ada → fallback extractive
-	initLLM()
+	llmClient, llmInitErr := llm.Shared()
+	if llmInitErr == nil && redact.LLMEnabled() {
+		// PII/secret di snippet diganti placeholder; dikembalikan di jawaban
+		llmClient = redact.WrapClient(llmClient, redact.Default().NewSession())
+	}
 	var answer string
-	if llmInitErr == nil {
+	if llmInitErr == nil && perr == nil {
 		var err error
-		answer, err = llmClient.AnswerWithRAG(r.Context(), system, user)
+		answer, err = llmClient.AnswerWithRAG(ctx, system, user)
 		if err != nil {
 			// fallback ke extractive
 			answer = extractiveFallback(input.Question, chunks)
@@ -113,9 +136,16 @@ func AnswerWithDocsHandler(w http.ResponseWriter, r *http.Request) {
 		answer = extractiveFallback(input.Question, chunks)
 	}
 
+	answer = strings.TrimSpace(answer)
+	sources, sentences := citations.Build(input.Question, answer, citationSources(chunks),
+		citations.Options{Highlight: input.Highlight == nil || *input.Highlight})
 	resp := AnswerWithDocsOutput{
-		Answer:    strings.TrimSpace(answer),
-		Citations: cits,
+		Answer:        answer,
+		Citations:     cits,
+		Sources:       sources,
+		Sentences:     sentences,
+		PromptVersion: prompts.Default().Version(),
+		FlaggedChunks: flagged,
 	}
 	w.Header().Set("Content-Type", "application/json")
 	_ = json.NewEncoder(w).Encode(resp)
@@ -123,14 +153,33 @@ func AnswerWithDocsHandler(w http.ResponseWriter, r *http.Request) {
 
 // ======= Helpers =======
 
-func defaultSystemPrompt() string {
-	// jaga jawaban ringkas, sitasi pasti dari daftar yang diberikan
-	return `You are a helpful assistant for Retrieval-Augmented Generation.
-You must ONLY use the provided document snippets to answer.
-Cite using the provided citation keys if you use information from them.
-If the answer is not in the snippets, say you don't have enough information.`
+// defaultSystemPrompt merender template "docs_answer" (lihat internal/prompts).
+func defaultSystemPrompt() (string, error) {
+	rp, err := prompts.Default().Render(prompts.DocsAnswer, prompts.Vars{})
+	return rp.Text, err
+}
+
+// screenChunks menyaring snippet lewat safety.Default(); chunk yang dibuang (mode drop) dihilangkan.
+func screenChunks(ctx context.Context, chunks []DocChunkRef) ([]DocChunkRef, []string) {
+	s := safety.Default()
+	out := make([]DocChunkRef, 0, len(chunks))
+	var flagged []string
+	for _, c := range chunks {
+		key := citationKey(c)
+		sn, v, keep := s.Clean(ctx, "doc:"+key, c.Snippet)
+		if v.Flagged {
+			flagged = append(flagged, key)
+		}
+		if !keep {
+			continue
+		}
+		c.Snippet = sn
+		out = append(out, c)
+	}
+	return out, flagged
 }
 
+// buildUserPrompt: setiap snippet dibungkus <untrusted_data> agar isinya diperlakukan sebagai data.
 func buildUserPrompt(q string, chunks []DocChunkRef) string {
 	var b strings.Builder
 	b.WriteString("Question:\n")
@@ -149,10 +198,11 @@ func buildUserPrompt(q string, chunks []DocChunkRef) string {
 		if len(sn) > 800 {
 			sn = sn[:800] + "…"
 		}
-		b.WriteString(sn)
+		b.WriteString(safety.Quote(key, sn))
 		b.WriteString("\n---\n")
 	}
-	b.WriteString("\nInstructions:\n- Answer concisely in the user's language.\n- Include citation keys where appropriate.\n")
+	b.WriteString("\nInstructions:\n- Answer concisely in the user's language.\n" +
+		"- End each sentence that uses a snippet with its number in square brackets, e.g. [1] or [1][3].\n")
 	return b.String()
 }
 
@@ -164,6 +214,15 @@ func citationKey(c DocChunkRef) string {
 	return fmt.Sprintf("%s#p%d", c.DocID, page)
 }
 
+func citationSources(chunks []DocChunkRef) []citations.Source {
+	out := make([]citations.Source, len(chunks))
+	for i, c := range chunks {
+		out[i] = citations.Source{Key: citationKey(c), ChunkID: c.ChunkID, DocID: c.DocID, Title: c.Title, URL: c.URL,
+			Snippet: c.Snippet, Page: c.PageNo, CharStart: c.CharStart, CharEnd: c.CharEnd}
+	}
+	return out
+}
+
 func makeCitations(chunks []DocChunkRef) []string {
 	uniq := map[string]struct{}{}
 	for _, c := range chunks {
@@ -218,8 +277,8 @@ func extractiveFallback(question string, chunks []DocChunkRef) string {
 // Panggil fungsi ini dari layer app (mis. internal/app/app.go) setelah inisialisasi repo.
 // Contoh:
 /*
-   mcp.RegisterRetriever(func(ctx context.Context, q string, topK int) ([]mcp.DocChunkRef, error) {
-       hits, err := ragRepo.Retrieve(ctx, q, topK)
+   mcp.RegisterRetriever(func(ctx context.Context, q string, topK int, f documents.Filter, opt mysqlrepo.HybridOptions) ([]mcp.DocChunkRef, error) {
+       hits, err := ragRepo.RetrieveFiltered(ctx, q, topK, f)
        if err != nil { return nil, err }
        refs := make([]mcp.DocChunkRef, 0, len(hits))
        for _, h := range hits {
@@ -231,6 +290,6 @@ func extractiveFallback(question string, chunks []DocChunkRef) string {
       return refs, nil
   })
 */
-func RegisterRetriever(fn func(ctx context.Context, query string, topK int) ([]DocChunkRef, error)) {
+func RegisterRetriever(fn func(ctx context.Context, query string, topK int, f documents.Filter, opt mysqlrepo.HybridOptions) ([]DocChunkRef, error)) {
 	RetrieveFn = fn
 }
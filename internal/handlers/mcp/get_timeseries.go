@@ -178,10 +178,20 @@ func GetTimeseriesHandler(w http.ResponseWriter, r *http.Request) {
 		out = append(out, row)
 	}
 
+	// satuan & nama tag dari ts_signal (dipakai label sumbu visualisasi)
+	var unit, tagName string
+	if sig, err := timeseriesRepo.GetSignal(ctx, tagID); err == nil {
+		unit, tagName = sig.Unit.String, sig.TagName.String
+	} else if len(points) > 0 && points[0].Unit.Valid {
+		unit = points[0].Unit.String
+	}
+
 	resp := map[string]any{
-		"tag_id": tagID,
-		"count":  len(out),
-		"points": out,
+		"tag_id":   tagID,
+		"tag_name": tagName,
+		"unit":     unit,
+		"count":    len(out),
+		"points":   out,
 	}
 	w.Header().Set("Content-Type", "application/json")
 	_ = json.NewEncoder(w).Encode(resp)
@@ -4,49 +4,125 @@ package rag
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"os"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
+	"mcp-oilgas/internal/queryembed"
+	"mcp-oilgas/internal/queryrewrite"
+	"mcp-oilgas/internal/repositories/documents"
+	"mcp-oilgas/internal/repositories/embeddings"
 	mysqlrepo "mcp-oilgas/internal/repositories/mysql"
+	"mcp-oilgas/internal/rerank"
 )
 
+// QueryEmbedder menghasilkan embedding query di server (lihat queryembed.Embedder).
+type QueryEmbedder interface {
+	Embed(ctx context.Context, query string) ([]float32, queryembed.Source, error)
+}
+
+// HybridSearcher adalah backend retrieval hybrid: *mysql.RAGRepo (default) atau
+// vectorstore.RAG (memory/pgvector, lihat VECTOR_STORE).
+type HybridSearcher interface {
+	SearchHybridWith(ctx context.Context, query string, queryEmbedding []float32, alpha float64, topK int, f documents.Filter, opt mysqlrepo.HybridOptions) ([]mysqlrepo.Chunk, mysqlrepo.HybridStats, error)
+}
+
 type HandlerV2 struct {
-	RAG *mysqlrepo.RAGRepo
+	RAG HybridSearcher
+	// Embedder opsional: bila request tidak membawa query_embedding, query di-embed di server
+	// agar sinyal cosine ikut dipakai; gagal/timeout → BM25 saja (dilaporkan di "signals").
+	Embedder QueryEmbedder
+	// Reranker opsional: tahap pertama mengambil RerankConfig.Candidates kandidat,
+	// reranker menilai ulang, lalu dipotong ke top_k. Gagal/timeout → urutan hybrid.
+	Reranker     rerank.Reranker
+	RerankConfig rerank.Config
+	// Rewriter opsional: query diperluas (glosarium, terjemahan, multi-query, HyDE); hasil tiap
+	// sub-query digabung dengan RRF + dedup per chunk ID sebelum reranking.
+	Rewriter QueryRewriter
+}
+
+// QueryRewriter menghasilkan sub-query (lihat queryrewrite.Rewriter); query asli selalu pertama.
+type QueryRewriter interface {
+	Rewrite(ctx context.Context, query string) ([]queryrewrite.SubQuery, error)
 }
 
 type searchV2Req struct {
 	Query          string    `json:"query,omitempty"`
 	QueryEmbedding []float32 `json:"query_embedding,omitempty"` // biasanya 1536 dim (text-embedding-3-small)
 	TopK           int       `json:"top_k,omitempty"`
-	Alpha          float64   `json:"alpha,omitempty"` // 0..1
+	Alpha          *float64  `json:"alpha,omitempty"` // 0..1; kosong = RAG_ALPHA
+	// Filters membatasi hasil ke dokumen dengan metadata tertentu (lihat documents.Filter),
+	// mis. {"type":"sop","area":"North","effective_after":"2024"}.
+	Filters *documents.Filter `json:"filters,omitempty"`
+	// Fusion: linear (default, lihat RAG_FUSION) | rrf | weighted_rrf; RRFK = konstanta k RRF (default 60).
+	Fusion string `json:"fusion,omitempty"`
+	RRFK   int    `json:"rrf_k,omitempty"`
+	Debug  bool   `json:"debug,omitempty"` // sertakan skor per sinyal di tiap chunk
+	// Rerank=false melewati reranker yang dikonfigurasi (mis. untuk membandingkan hasil).
+	Rerank *bool `json:"rerank,omitempty"`
+	// Rewrite=false menonaktifkan query rewriting / multi-query untuk request ini.
+	Rewrite *bool `json:"rewrite,omitempty"`
 }
 
 type chunkDTO struct {
+	ChunkID int64    `json:"chunk_id,omitempty"` // doc_chunks.id (untuk sitasi)
 	DocID   string   `json:"doc_id,omitempty"`
 	Title   string   `json:"title,omitempty"`
 	URL     string   `json:"url,omitempty"`
 	PageNo  *int64   `json:"page_no,omitempty"`
 	Snippet string   `json:"snippet,omitempty"`
 	Score   *float64 `json:"score,omitempty"` // skor final hybrid
+	// CharStart/CharEnd: offset rune [start, end) snippet di teks dokumen (kosong untuk chunk lama)
+	CharStart *int64 `json:"char_start,omitempty"`
+	CharEnd   *int64 `json:"char_end,omitempty"`
+	// RerankScore: skor reranker (urutan hasil mengikuti skor ini bila ada)
+	RerankScore *float64 `json:"rerank_score,omitempty"`
+	// MatchedQueries: indeks sub-query (lihat "rewrites") yang menemukan chunk ini
+	MatchedQueries []int `json:"matched_queries,omitempty"`
+
+	Document *documents.Document   `json:"document,omitempty"` // metadata dokumen (jenis, area, revisi, ...)
+	Scores   *mysqlrepo.ScoreDebug `json:"scores,omitempty"`   // hanya bila debug
+}
+
+// signalsDTO melaporkan sinyal yang ikut menilai hasil.
+type signalsDTO struct {
+	BM25           bool                  `json:"bm25"`
+	Dense          bool                  `json:"dense"`
+	ANN            bool                  `json:"ann"`
+	QueryEmbedding queryembed.Source     `json:"query_embedding"` // client|provider|cache|cache_db|none
+	Fallback       string                `json:"fallback,omitempty"`
+	Rerank         string                `json:"rerank,omitempty"` // nama reranker yang dipakai
+	RerankError    string                `json:"rerank_error,omitempty"`
+	RewriteError   string                `json:"rewrite_error,omitempty"`
+	Stats          mysqlrepo.HybridStats `json:"stats"`
 }
 
 type searchV2Resp struct {
-	Query           string     `json:"query,omitempty"`
-	Alpha           float64    `json:"alpha"`
-	Count           int        `json:"count"`
-	RetrievedChunks []chunkDTO `json:"retrieved_chunks"`
+	Query           string                  `json:"query,omitempty"`
+	Alpha           float64                 `json:"alpha"`
+	Fusion          string                  `json:"fusion"`
+	RRFK            int                     `json:"rrf_k,omitempty"`
+	Filters         *documents.Filter       `json:"filters,omitempty"`
+	Rewrites        []queryrewrite.SubQuery `json:"rewrites,omitempty"` // sub-query yang dijalankan (bila lebih dari satu)
+	Signals         signalsDTO              `json:"signals"`
+	Count           int                     `json:"count"`
+	RetrievedChunks []chunkDTO              `json:"retrieved_chunks"`
 }
 
-func expectedDim() int {
-	if v := strings.TrimSpace(os.Getenv("EMBED_DIM")); v != "" {
-		if n, err := strconv.Atoi(v); err == nil && n > 0 {
-			return n
-		}
+// expectedDim: EMBED_DIM (lihat embeddings.ExpectedDim); embedding query berdimensi lain ditolak
+// agar tidak pernah dibandingkan dengan vektor model lain.
+func expectedDim() int { return embeddings.ExpectedDim() }
+
+// defaultFusion: env RAG_FUSION (linear|rrf|weighted_rrf), default linear.
+func defaultFusion() string {
+	if f, err := mysqlrepo.ParseFusion(os.Getenv("RAG_FUSION")); err == nil {
+		return f
 	}
-	return 1536 // default text-embedding-3-small
+	return mysqlrepo.FusionLinear
 }
 
 func (h *HandlerV2) SearchV2(w http.ResponseWriter, r *http.Request) {
@@ -58,7 +134,7 @@ func (h *HandlerV2) SearchV2(w http.ResponseWriter, r *http.Request) {
 	var req searchV2Req
 	if strings.Contains(r.Header.Get("Content-Type"), "application/json") && r.Method == http.MethodPost {
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			http.Error(w, "invalid json body", http.StatusBadRequest)
+			http.Error(w, "invalid json body: "+err.Error(), http.StatusBadRequest)
 			return
 		}
 	} else {
@@ -71,16 +147,65 @@ func (h *HandlerV2) SearchV2(w http.ResponseWriter, r *http.Request) {
 		}
 		if v := r.URL.Query().Get("alpha"); v != "" {
 			if a, err := strconv.ParseFloat(v, 64); err == nil {
-				req.Alpha = a
+				req.Alpha = &a
 			}
 		}
+		req.Fusion = r.URL.Query().Get("fusion")
+		if v := r.URL.Query().Get("rrf_k"); v != "" {
+			if n, err := strconv.Atoi(v); err == nil {
+				req.RRFK = n
+			}
+		}
+		req.Debug, _ = strconv.ParseBool(r.URL.Query().Get("debug"))
+		if v := r.URL.Query().Get("rerank"); v != "" {
+			if b, err := strconv.ParseBool(v); err == nil {
+				req.Rerank = &b
+			}
+		}
+		if v := r.URL.Query().Get("rewrite"); v != "" {
+			if b, err := strconv.ParseBool(v); err == nil {
+				req.Rewrite = &b
+			}
+		}
+		// filter via query string: ?type=sop&area=North&effective_after=2024
+		f, err := documents.FilterFromQuery(r.URL.Query())
+		if err != nil {
+			http.Error(w, "invalid filter: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		if !f.Empty() {
+			req.Filters = &f
+		}
+	}
+	var filter documents.Filter
+	if req.Filters != nil {
+		filter = *req.Filters
 	}
 
 	if req.TopK <= 0 || req.TopK > 100 {
 		req.TopK = 10
 	}
-	if req.Alpha < 0 || req.Alpha > 1 {
-		req.Alpha = 0.5
+	// alpha tidak dikirim (GET maupun POST) → RAG_ALPHA, bukan 0 (cosine murni)
+	alpha := mysqlrepo.AlphaFromEnv()
+	if req.Alpha != nil {
+		alpha = *req.Alpha
+	}
+	if alpha < 0 || alpha > 1 {
+		alpha = 0.5
+	}
+	if req.Fusion == "" {
+		req.Fusion = defaultFusion()
+	}
+	fusion, err := mysqlrepo.ParseFusion(req.Fusion)
+	if err != nil {
+		http.Error(w, "invalid fusion: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	req.Fusion = fusion
+	if req.Fusion == mysqlrepo.FusionLinear {
+		req.RRFK = 0
+	} else if req.RRFK <= 0 {
+		req.RRFK = mysqlrepo.DefaultRRFK
 	}
 	if req.Query == "" && len(req.QueryEmbedding) == 0 {
 		http.Error(w, "missing query or query_embedding", http.StatusBadRequest)
@@ -94,14 +219,95 @@ func (h *HandlerV2) SearchV2(w http.ResponseWriter, r *http.Request) {
 	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
 	defer cancel()
 
-	results, err := h.RAG.SearchHybrid(ctx, req.Query, req.QueryEmbedding, req.Alpha, req.TopK)
+	sig := signalsDTO{QueryEmbedding: queryembed.SourceNone}
+	switch {
+	case len(req.QueryEmbedding) > 0:
+		sig.QueryEmbedding = queryembed.SourceClient
+	case h.Embedder == nil:
+		sig.Fallback = "no query embedder configured"
+	case alpha < 1 || req.Fusion == mysqlrepo.FusionRRF: // alpha=1 berarti BM25 murni (kecuali rrf yang tak berbobot)
+		vec, src, err := h.Embedder.Embed(ctx, req.Query)
+		switch {
+		case err != nil:
+			sig.Fallback = "query embedding failed: " + err.Error()
+		case len(vec) != expectedDim():
+			sig.Fallback = fmt.Sprintf("query embedding dimension %d != EMBED_DIM %d", len(vec), expectedDim())
+		default:
+			req.QueryEmbedding, sig.QueryEmbedding = vec, src
+		}
+	}
+	// alpha permintaan dipakai sub-query yang berhasil di-embed
+	subAlpha := alpha
+	// tanpa embedding skor cosine selalu 0: nilai hanya dari BM25
+	if len(req.QueryEmbedding) == 0 {
+		alpha = 1
+	}
+
+	// reranking butuh teks query; tahap pertama mengambil kandidat lebih banyak
+	doRerank := h.Reranker != nil && req.Query != "" && (req.Rerank == nil || *req.Rerank)
+	firstK := req.TopK
+	if doRerank {
+		firstK = max(req.TopK, min(h.RerankConfig.Candidates, 100))
+	}
+	opt := mysqlrepo.HybridOptions{Fusion: req.Fusion, RRFK: req.RRFK, Debug: req.Debug}
+
+	// query asli langsung dicari dengan seluruh anggaran request, paralel dengan rewrite
+	base := make(chan subResult, 1)
+	go func() {
+		var res subResult
+		res.chunks, res.stats, res.err = h.RAG.SearchHybridWith(ctx, req.Query, req.QueryEmbedding, alpha, firstK, filter, opt)
+		base <- res
+	}()
+
+	// sub-query: query asli + glosarium/terjemahan/multi-query/HyDE. Rewrite & varian memakai
+	// sebagian anggaran saja; yang lewat batas dibuang tanpa mengorbankan hasil query asli.
+	subs := []queryrewrite.SubQuery{{Text: req.Query, Kind: queryrewrite.KindOriginal}}
+	varCtx, cancelVar := variantContext(ctx)
+	defer cancelVar()
+	if h.Rewriter != nil && req.Query != "" && (req.Rewrite == nil || *req.Rewrite) {
+		rs, err := h.Rewriter.Rewrite(varCtx, req.Query)
+		if err != nil {
+			sig.RewriteError = err.Error()
+		}
+		if len(rs) > 0 {
+			subs = rs
+		}
+	}
+
+	var (
+		results []mysqlrepo.Chunk
+		stats   mysqlrepo.HybridStats
+		matched map[int64][]int
+	)
+	if len(subs) > 1 {
+		results, stats, matched, err = h.searchMulti(varCtx, base, subs, subAlpha, firstK, filter, opt)
+	} else {
+		res := <-base
+		results, stats, err = res.chunks, res.stats, res.err
+	}
 	if err != nil {
 		http.Error(w, "search error: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
+	var rerankScores []float64
+	if doRerank {
+		// terjemahan ikut dinilai agar reranker leksikal tidak menghukum dokumen berbahasa korpus
+		rq := req.Query
+		for _, sq := range subs {
+			if sq.Kind == queryrewrite.KindTranslation {
+				rq += "\n" + sq.Text
+				break
+			}
+		}
+		results, rerankScores, err = h.rerank(ctx, rq, results, req.TopK)
+		sig.Rerank = h.Reranker.Name()
+		if err != nil {
+			sig.RerankError = err.Error()
+		}
+	}
 
 	out := make([]chunkDTO, 0, len(results))
-	for _, c := range results {
+	for i, c := range results {
 		var pagePtr *int64
 		if c.PageNo.Valid {
 			p := c.PageNo.Int64
@@ -112,22 +318,167 @@ func (h *HandlerV2) SearchV2(w http.ResponseWriter, r *http.Request) {
 			s := c.Score.Float64
 			scorePtr = &s
 		}
-		out = append(out, chunkDTO{
-			DocID:   c.DocID.String,
-			Title:   c.Title.String,
-			URL:     c.URL.String,
-			PageNo:  pagePtr,
-			Snippet: c.Snippet.String,
-			Score:   scorePtr,
-		})
+		dto := chunkDTO{
+			ChunkID:  c.ID,
+			DocID:    c.DocID.String,
+			Title:    c.Title.String,
+			URL:      c.URL.String,
+			PageNo:   pagePtr,
+			Snippet:  c.Snippet.String,
+			Score:    scorePtr,
+			Document: c.Doc,
+			Scores:   c.Debug,
+		}
+		if c.CharEnd.Valid && c.CharEnd.Int64 > c.CharStart.Int64 {
+			start, end := c.CharStart.Int64, c.CharEnd.Int64
+			dto.CharStart, dto.CharEnd = &start, &end
+		}
+		out = append(out, dto)
+		if i < len(rerankScores) {
+			s := rerankScores[i]
+			out[i].RerankScore = &s
+		}
+		out[i].MatchedQueries = matched[c.ID]
 	}
 
+	sig.Stats = stats
+	sig.BM25 = stats.BM25Candidates > 0
+	sig.Dense = stats.DenseScored > 0 && (subAlpha < 1 || req.Fusion == mysqlrepo.FusionRRF)
+	sig.ANN = stats.ANNCandidates > 0
+
 	resp := searchV2Resp{
 		Query:           req.Query,
-		Alpha:           req.Alpha,
+		Alpha:           alpha,
+		Fusion:          req.Fusion,
+		RRFK:            req.RRFK,
+		Filters:         req.Filters,
+		Signals:         sig,
 		Count:           len(out),
 		RetrievedChunks: out,
 	}
+	if len(subs) > 1 {
+		resp.Rewrites = subs
+	}
 	w.Header().Set("Content-Type", "application/json")
 	_ = json.NewEncoder(w).Encode(resp)
 }
+
+// rerank mengurutkan ulang results dengan h.Reranker dan memotong ke topK.
+// Error → urutan hybrid (dipotong ke topK) beserta error untuk dilaporkan di signals.
+func (h *HandlerV2) rerank(ctx context.Context, query string, results []mysqlrepo.Chunk, topK int) ([]mysqlrepo.Chunk, []float64, error) {
+	cut := func() []mysqlrepo.Chunk {
+		if len(results) > topK {
+			return results[:topK]
+		}
+		return results
+	}
+	if len(results) == 0 {
+		return results, nil, nil
+	}
+	timeout := h.RerankConfig.Timeout
+	if timeout <= 0 {
+		timeout = 3 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	passages := make([]string, len(results))
+	for i, c := range results {
+		passages[i] = c.Title.String + "\n" + c.Snippet.String
+	}
+	res, err := rerank.Apply(ctx, h.Reranker, query, passages, topK)
+	if err != nil {
+		return cut(), nil, err
+	}
+	out := make([]mysqlrepo.Chunk, 0, len(res))
+	scores := make([]float64, 0, len(res))
+	for _, x := range res {
+		out = append(out, results[x.Index])
+		scores = append(scores, x.Score)
+	}
+	return out, scores, nil
+}
+
+// variantBudget adalah porsi sisa anggaran request untuk rewrite + pencarian sub-query varian;
+// sisanya cadangan bagi query asli & reranking (mis. answer_with_docs membatasi 4 detik).
+const variantBudget = 0.6
+
+// variantContext menurunkan ctx dengan deadline lebih awal untuk rewrite & varian.
+func variantContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	dl, ok := ctx.Deadline()
+	if !ok {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, time.Duration(float64(time.Until(dl))*variantBudget))
+}
+
+type subResult struct {
+	chunks []mysqlrepo.Chunk
+	stats  mysqlrepo.HybridStats
+	err    error
+}
+
+// searchMulti menjalankan sub-query varian (subs[1:]) secara paralel dengan ctx (anggaran varian),
+// lalu menggabungkannya dengan hasil query asli dari base memakai RRF (dedup per chunk ID; skor
+// chunk = skor hybrid terbaik). Varian di-embed di server bila memungkinkan; gagal → BM25 saja.
+// Varian yang error/timeout dibuang; hanya error query asli yang menggagalkan pencarian.
+func (h *HandlerV2) searchMulti(ctx context.Context, base <-chan subResult, subs []queryrewrite.SubQuery, alpha float64, topK int, f documents.Filter, opt mysqlrepo.HybridOptions) ([]mysqlrepo.Chunk, mysqlrepo.HybridStats, map[int64][]int, error) {
+	wantDense := alpha < 1 || opt.Fusion == mysqlrepo.FusionRRF
+	results := make([]subResult, len(subs))
+	var wg sync.WaitGroup
+	for i := 1; i < len(subs); i++ {
+		wg.Add(1)
+		go func(i int, sq queryrewrite.SubQuery) {
+			defer wg.Done()
+			var emb []float32
+			if h.Embedder != nil && wantDense {
+				if vec, _, err := h.Embedder.Embed(ctx, sq.Text); err == nil && len(vec) == expectedDim() {
+					emb = vec
+				}
+			}
+			a := alpha
+			if len(emb) == 0 {
+				a = 1
+			}
+			res := &results[i]
+			res.chunks, res.stats, res.err = h.RAG.SearchHybridWith(ctx, sq.Text, emb, a, topK, f, opt)
+		}(i, subs[i])
+	}
+	results[0] = <-base
+	wg.Wait()
+
+	var stats mysqlrepo.HybridStats
+	if results[0].err != nil {
+		return nil, stats, nil, results[0].err
+	}
+	byID := map[int64]mysqlrepo.Chunk{}
+	lists := make([][]int64, len(subs))
+	for i, res := range results {
+		if res.err != nil {
+			continue
+		}
+		stats.BM25Candidates += res.stats.BM25Candidates
+		stats.ANNCandidates += res.stats.ANNCandidates
+		stats.RecentCandidates += res.stats.RecentCandidates
+		stats.DenseScored += res.stats.DenseScored
+		stats.DimSkipped += res.stats.DimSkipped
+		for _, c := range res.chunks {
+			lists[i] = append(lists[i], c.ID)
+			if prev, ok := byID[c.ID]; !ok || c.Score.Float64 > prev.Score.Float64 {
+				byID[c.ID] = c
+			}
+		}
+	}
+
+	merged := queryrewrite.MergeRRF(lists, opt.RRFK)
+	if len(merged) > topK {
+		merged = merged[:topK]
+	}
+	out := make([]mysqlrepo.Chunk, 0, len(merged))
+	matched := make(map[int64][]int, len(merged))
+	for _, m := range merged {
+		out = append(out, byID[m.ID])
+		matched[m.ID] = m.Queries
+	}
+	return out, stats, matched, nil
+}
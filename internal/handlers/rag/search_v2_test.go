@@ -0,0 +1,121 @@
+// internal/handlers/rag/search_v2_test.go
+
+package rag_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	ragh "mcp-oilgas/internal/handlers/rag"
+	"mcp-oilgas/internal/queryembed"
+	"mcp-oilgas/internal/queryrewrite"
+	"mcp-oilgas/internal/repositories/documents"
+	mysqlrepo "mcp-oilgas/internal/repositories/mysql"
+)
+
+type fakeRAG struct{ alpha []float64 }
+
+func (f *fakeRAG) SearchHybridWith(ctx context.Context, query string, emb []float32, alpha float64, topK int, flt documents.Filter, opt mysqlrepo.HybridOptions) ([]mysqlrepo.Chunk, mysqlrepo.HybridStats, error) {
+	f.alpha = append(f.alpha, alpha)
+	return nil, mysqlrepo.HybridStats{}, nil
+}
+
+type fakeEmbedder struct{}
+
+func (fakeEmbedder) Embed(ctx context.Context, q string) ([]float32, queryembed.Source, error) {
+	return []float32{1, 0, 0, 0}, queryembed.SourceProvider, nil
+}
+
+func post(t *testing.T, h *ragh.HandlerV2, body string) float64 {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/rag/search_v2", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	rr := httptest.NewRecorder()
+	h.SearchV2(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rr.Code, rr.Body.String())
+	}
+	var resp struct {
+		Alpha float64 `json:"alpha"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	return resp.Alpha
+}
+
+// POST tanpa alpha memakai RAG_ALPHA; alpha=0 eksplisit tetap cosine murni.
+func TestSearchV2PostAlphaDefault(t *testing.T) {
+	t.Setenv("RAG_ALPHA", "0.7")
+	t.Setenv("EMBED_PROVIDER", "openai")
+	t.Setenv("EMBED_DIM", "4")
+	rag := &fakeRAG{}
+	h := &ragh.HandlerV2{RAG: rag, Embedder: fakeEmbedder{}}
+
+	if a := post(t, h, `{"query":"prosedur H2S"}`); a != 0.7 || rag.alpha[0] != 0.7 {
+		t.Fatalf("missing alpha: want RAG_ALPHA 0.7, got resp=%v repo=%v", a, rag.alpha)
+	}
+	if a := post(t, h, `{"query":"prosedur H2S","alpha":0}`); a != 0 || rag.alpha[1] != 0 {
+		t.Fatalf("explicit alpha 0: got resp=%v repo=%v", a, rag.alpha)
+	}
+}
+
+// slowRAG mengembalikan satu chunk per query; query "lambat" menunggu sampai ctx habis.
+type slowRAG struct{}
+
+func (slowRAG) SearchHybridWith(ctx context.Context, query string, emb []float32, alpha float64, topK int, flt documents.Filter, opt mysqlrepo.HybridOptions) ([]mysqlrepo.Chunk, mysqlrepo.HybridStats, error) {
+	if strings.Contains(query, "lambat") {
+		<-ctx.Done()
+		return nil, mysqlrepo.HybridStats{}, ctx.Err()
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, mysqlrepo.HybridStats{}, err
+	}
+	return []mysqlrepo.Chunk{{ID: int64(len(query))}}, mysqlrepo.HybridStats{BM25Candidates: 1}, nil
+}
+
+type fakeRewriter struct {
+	block bool
+	subs  []queryrewrite.SubQuery
+}
+
+func (f fakeRewriter) Rewrite(ctx context.Context, q string) ([]queryrewrite.SubQuery, error) {
+	if f.block {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	return f.subs, nil
+}
+
+// Rewrite atau varian yang lambat tidak boleh menghabiskan anggaran query asli (mis. batas 4 detik
+// answer_with_docs): varian dibuang, hasil query asli tetap dikembalikan.
+func TestSearchV2SlowRewriteKeepsOriginal(t *testing.T) {
+	cases := map[string]fakeRewriter{
+		"slow rewrite": {block: true},
+		"slow variant": {subs: []queryrewrite.SubQuery{
+			{Text: "prosedur H2S", Kind: queryrewrite.KindOriginal},
+			{Text: "prosedur H2S lambat", Kind: queryrewrite.KindTranslation},
+		}},
+	}
+	for name, rw := range cases {
+		h := &ragh.HandlerV2{RAG: slowRAG{}, Rewriter: rw}
+		ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+		req := httptest.NewRequest(http.MethodPost, "/rag/search_v2", strings.NewReader(`{"query":"prosedur H2S"}`)).WithContext(ctx)
+		req.Header.Set("Content-Type", "application/json")
+		rr := httptest.NewRecorder()
+		h.SearchV2(rr, req)
+		cancel()
+		var resp struct {
+			Count int `json:"count"`
+		}
+		_ = json.Unmarshal(rr.Body.Bytes(), &resp)
+		if rr.Code != http.StatusOK || resp.Count != 1 {
+			t.Fatalf("%s: want original results, got %d %s", name, rr.Code, rr.Body.String())
+		}
+	}
+}
@@ -0,0 +1,256 @@
+// internal/ingest/extract.go
+// Ekstraksi teks per halaman dari file upload: PDF, DOCX, HTML, Markdown, TXT.
+package ingest
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"mcp-oilgas/internal/chunking"
+)
+
+// Page adalah teks satu halaman (No mulai dari 1). Format tanpa konsep halaman
+// (HTML/Markdown/TXT) menghasilkan satu halaman, kecuali TXT dengan form feed (\f).
+type Page = chunking.Page
+
+// ErrUnsupported dikembalikan untuk ekstensi yang tidak didukung.
+var ErrUnsupported = errors.New("unsupported file type")
+
+// Supported melaporkan apakah ekstensi file bisa diekstrak.
+func Supported(name string) bool {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".pdf", ".docx", ".html", ".htm", ".md", ".markdown", ".txt":
+		return true
+	}
+	return false
+}
+
+// ExtractFile membaca file di path lalu mengekstrak teks per halaman berdasarkan ekstensi.
+func ExtractFile(path string) ([]Page, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Extract(filepath.Base(path), b)
+}
+
+// Extract mengekstrak teks per halaman dari isi file; name dipakai untuk menentukan format.
+// Halaman kosong dibuang.
+func Extract(name string, data []byte) ([]Page, error) {
+	var (
+		pages []Page
+		err   error
+	)
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".pdf":
+		pages, err = extractPDF(data)
+	case ".docx":
+		pages, err = extractDOCX(data)
+	case ".html", ".htm":
+		pages = []Page{{No: 1, Text: htmlToText(string(data))}}
+	case ".md", ".markdown":
+		pages = []Page{{No: 1, Text: markdownToText(toUTF8(data))}}
+	case ".txt":
+		pages = splitFormFeed(toUTF8(data))
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupported, filepath.Ext(name))
+	}
+	if err != nil {
+		return nil, err
+	}
+	out := pages[:0]
+	for _, p := range pages {
+		p.Text = normalizeSpace(p.Text)
+		if p.Text != "" {
+			out = append(out, p)
+		}
+	}
+	return out, nil
+}
+
+// ----------------- TXT / Markdown -----------------
+
+// toUTF8: buang BOM; byte non-UTF-8 dianggap Latin-1.
+func toUTF8(b []byte) string {
+	b = bytes.TrimPrefix(b, []byte{0xEF, 0xBB, 0xBF})
+	if utf8.Valid(b) {
+		return string(b)
+	}
+	rs := make([]rune, len(b))
+	for i, c := range b {
+		rs[i] = rune(c)
+	}
+	return string(rs)
+}
+
+func splitFormFeed(s string) []Page {
+	parts := strings.Split(s, "\f")
+	pages := make([]Page, 0, len(parts))
+	for i, p := range parts {
+		pages = append(pages, Page{No: i + 1, Text: p})
+	}
+	return pages
+}
+
+var (
+	mdImage    = regexp.MustCompile(`!\[([^\]]*)\]\([^)]*\)`)
+	mdLink     = regexp.MustCompile(`\[([^\]]+)\]\([^)]*\)`)
+	mdHeading  = regexp.MustCompile(`(?m)^\s{0,3}#{1,6}\s*`)
+	mdEmphasis = regexp.MustCompile(`(\*\*|__|\*|_|~~|` + "`" + `)([^\s*_~` + "`" + `][^*_~` + "`" + `]*?)(\*\*|__|\*|_|~~|` + "`" + `)`)
+	mdFence    = regexp.MustCompile("(?m)^\\s*(```|~~~).*$")
+	mdQuote    = regexp.MustCompile(`(?m)^\s{0,3}>\s?`)
+	mdRule     = regexp.MustCompile(`(?m)^\s*([-*_]\s*){3,}$`)
+	mdTableSep = regexp.MustCompile(`(?m)^\s*\|?(\s*:?-+:?\s*\|)+\s*:?-*:?\s*$`)
+)
+
+// markdownToText membuang markup Markdown dan mempertahankan teksnya.
+func markdownToText(s string) string {
+	s = mdFence.ReplaceAllString(s, "")
+	s = mdImage.ReplaceAllString(s, "$1")
+	s = mdLink.ReplaceAllString(s, "$1")
+	s = mdHeading.ReplaceAllString(s, "")
+	s = mdQuote.ReplaceAllString(s, "")
+	s = mdRule.ReplaceAllString(s, "")
+	s = mdTableSep.ReplaceAllString(s, "")
+	s = mdEmphasis.ReplaceAllString(s, "$2")
+	return s
+}
+
+// ----------------- HTML -----------------
+
+var (
+	htmlDrop    = regexp.MustCompile(`(?is)<(script|style|noscript|template|head)\b[^>]*>.*?</(script|style|noscript|template|head)>`)
+	htmlComment = regexp.MustCompile(`(?s)<!--.*?-->`)
+	htmlBlock   = regexp.MustCompile(`(?i)</?(p|div|br|li|ul|ol|h[1-6]|tr|table|section|article|header|footer|blockquote|pre|hr|dt|dd)\b[^>]*>`)
+	htmlCell    = regexp.MustCompile(`(?i)</t[dh]\s*>`)
+	htmlTag     = regexp.MustCompile(`(?s)<[^>]*>`)
+)
+
+// htmlToText membuang tag & script/style, elemen blok menjadi baris baru, entity di-decode.
+func htmlToText(s string) string {
+	s = htmlComment.ReplaceAllString(s, "")
+	s = htmlDrop.ReplaceAllString(s, "")
+	s = htmlCell.ReplaceAllString(s, "\t")
+	s = htmlBlock.ReplaceAllString(s, "\n")
+	s = htmlTag.ReplaceAllString(s, "")
+	return html.UnescapeString(s)
+}
+
+// ----------------- DOCX -----------------
+
+// extractDOCX membaca word/document.xml. Page break eksplisit (w:br type=page) dan
+// penanda halaman hasil render Word (w:lastRenderedPageBreak) memisahkan halaman.
+func extractDOCX(data []byte) ([]Page, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("docx: %w", err)
+	}
+	var doc *zip.File
+	for _, f := range zr.File {
+		if f.Name == "word/document.xml" {
+			doc = f
+			break
+		}
+	}
+	if doc == nil {
+		return nil, errors.New("docx: word/document.xml not found")
+	}
+	rc, err := doc.Open()
+	if err != nil {
+		return nil, fmt.Errorf("docx: %w", err)
+	}
+	defer rc.Close()
+
+	var (
+		pages []Page
+		cur   strings.Builder
+		inT   bool
+	)
+	flush := func() {
+		pages = append(pages, Page{No: len(pages) + 1, Text: cur.String()})
+		cur.Reset()
+	}
+	dec := xml.NewDecoder(rc)
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("docx xml: %w", err)
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "t":
+				inT = true
+			case "tab":
+				cur.WriteByte('\t')
+			case "br", "cr":
+				if attr(t, "type") == "page" {
+					flush()
+				} else {
+					cur.WriteByte('\n')
+				}
+			case "lastRenderedPageBreak":
+				if strings.TrimSpace(cur.String()) != "" {
+					flush()
+				}
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "t":
+				inT = false
+			case "p":
+				cur.WriteByte('\n')
+			case "tc":
+				cur.WriteByte('\t')
+			}
+		case xml.CharData:
+			if inT {
+				cur.Write(t)
+			}
+		}
+	}
+	flush()
+	return pages, nil
+}
+
+func attr(e xml.StartElement, local string) string {
+	for _, a := range e.Attr {
+		if a.Name.Local == local {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+// ----------------- Util -----------------
+
+var (
+	reSpaces     = regexp.MustCompile(`[ \t\x{00A0}]+`)
+	reBlankLines = regexp.MustCompile(`\n{3,}`)
+)
+
+// normalizeSpace merapikan spasi berlebih sambil mempertahankan batas paragraf.
+func normalizeSpace(s string) string {
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	s = strings.ReplaceAll(s, "\r", "\n")
+	lines := strings.Split(s, "\n")
+	for i, l := range lines {
+		lines[i] = strings.TrimSpace(reSpaces.ReplaceAllString(l, " "))
+	}
+	s = strings.Join(lines, "\n")
+	s = reBlankLines.ReplaceAllString(s, "\n\n")
+	return strings.TrimSpace(s)
+}
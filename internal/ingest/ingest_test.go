@@ -0,0 +1,161 @@
+// internal/ingest/ingest_test.go
+
+package ingest_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"mcp-oilgas/internal/chunking"
+	"mcp-oilgas/internal/ingest"
+)
+
+// buildPDF menyusun PDF minimal: halaman 1 memakai font sederhana (stream Flate),
+// halaman 2 memakai font Type0 dengan ToUnicode CMap (kode 2 byte).
+func buildPDF(t *testing.T) []byte {
+	t.Helper()
+	flate := func(s string) string {
+		var b bytes.Buffer
+		zw := zlib.NewWriter(&b)
+		zw.Write([]byte(s))
+		zw.Close()
+		return b.String()
+	}
+	c1 := flate("BT /F1 12 Tf 72 720 Td (Laporan Harian Pengeboran) Tj 0 -14 Td [(NPT 14) -300 (jam)] TJ ET")
+	cmap := "/CIDInit /ProcSet findresource begin begincmap\n1 begincodespacerange <0000> <FFFF> endcodespacerange\n" +
+		"2 beginbfchar <0001> <0053> <0002> <0075> endbfchar\n1 beginbfrange <0003> <0005> <006D> endbfrange\nendcmap"
+	c2 := "BT /F2 12 Tf 72 720 Td <000100020003000400050002> Tj ET"
+	objs := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R 4 0 R] /Count 2 /Resources << /Font << /F1 5 0 R /F2 6 0 R >> >> >>",
+		"<< /Type /Page /Parent 2 0 R /Contents 7 0 R >>",
+		"<< /Type /Page /Parent 2 0 R /Contents 8 0 R >>",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>",
+		"<< /Type /Font /Subtype /Type0 /BaseFont /Foo /Encoding /Identity-H /ToUnicode 9 0 R >>",
+		fmt.Sprintf("<< /Length %d /Filter /FlateDecode >>\nstream\n%s\nendstream", len(c1), c1),
+		fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(c2), c2),
+		fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(cmap), cmap),
+	}
+	var b bytes.Buffer
+	b.WriteString("%PDF-1.4\n")
+	for i, o := range objs {
+		fmt.Fprintf(&b, "%d 0 obj\n%s\nendobj\n", i+1, o)
+	}
+	b.WriteString("trailer << /Root 1 0 R >>\n%%EOF\n")
+	return b.Bytes()
+}
+
+// PDF terpotong (mis. upload gagal di tengah) harus menghasilkan error/teks parsial, bukan panic.
+func TestExtractTruncatedPDF(t *testing.T) {
+	full := buildPDF(t)
+	cases := [][]byte{[]byte("%PDF-1.4\n1 0 obj\n<< /Type /Catalog /Pages 2 0 R >>\nendobj\n2 0 obj\n<4142")}
+	for n := len("%PDF-1.4\n"); n < len(full); n++ {
+		cases = append(cases, full[:n])
+	}
+	for _, data := range cases {
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					t.Fatalf("Extract panicked on %d bytes: %v", len(data), r)
+				}
+			}()
+			ingest.Extract("x.pdf", data)
+		}()
+	}
+}
+
+func TestExtractPDF(t *testing.T) {
+	pages, err := ingest.Extract("laporan.pdf", buildPDF(t))
+	if err != nil {
+		t.Fatalf("Extract: %v", err)
+	}
+	if len(pages) != 2 {
+		t.Fatalf("expected 2 pages, got %d: %+v", len(pages), pages)
+	}
+	if pages[0].No != 1 || pages[0].Text != "Laporan Harian Pengeboran\nNPT 14 jam" {
+		t.Fatalf("page 1: %q", pages[0].Text)
+	}
+	if pages[1].No != 2 || pages[1].Text != "Sumnou" {
+		t.Fatalf("page 2 (ToUnicode): %q", pages[1].Text)
+	}
+}
+
+func TestExtractDOCXPageBreak(t *testing.T) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	f, _ := zw.Create("word/document.xml")
+	f.Write([]byte(`<?xml version="1.0"?><w:document xmlns:w="w"><w:body>` +
+		`<w:p><w:r><w:t>Prosedur BOP</w:t></w:r></w:p>` +
+		`<w:p><w:r><w:t xml:space="preserve">Tekanan </w:t></w:r><w:r><w:t>5000 psi</w:t></w:r></w:p>` +
+		`<w:p><w:r><w:br w:type="page"/><w:t>Lampiran</w:t></w:r></w:p>` +
+		`</w:body></w:document>`))
+	zw.Close()
+
+	pages, err := ingest.Extract("sop.docx", buf.Bytes())
+	if err != nil {
+		t.Fatalf("Extract: %v", err)
+	}
+	if len(pages) != 2 || pages[0].Text != "Prosedur BOP\nTekanan 5000 psi" || pages[1].Text != "Lampiran" || pages[1].No != 2 {
+		t.Fatalf("unexpected pages: %+v", pages)
+	}
+}
+
+func TestExtractHTMLMarkdownTXT(t *testing.T) {
+	h, _ := ingest.Extract("a.html", []byte(`<html><head><title>x</title><style>p{}</style></head>`+
+		`<body><h1>Well A-12</h1><p>Gas &amp; oil <b>rate</b></p><script>alert(1)</script></body></html>`))
+	if len(h) != 1 || h[0].Text != "Well A-12\n\nGas & oil rate" {
+		t.Fatalf("html: %+v", h)
+	}
+	m, _ := ingest.Extract("a.md", []byte("# Judul\n\nLihat [SOP](http://x/sop) dan **penting**.\n\n```\ncode\n```"))
+	if len(m) != 1 || m[0].Text != "Judul\n\nLihat SOP dan penting.\n\ncode" {
+		t.Fatalf("markdown: %q", m[0].Text)
+	}
+	tx, _ := ingest.Extract("a.txt", []byte("hal satu\fhal dua"))
+	if len(tx) != 2 || tx[1].No != 2 || tx[1].Text != "hal dua" {
+		t.Fatalf("txt form feed: %+v", tx)
+	}
+	if _, err := ingest.Extract("a.xlsx", nil); err == nil {
+		t.Fatalf("expected unsupported error")
+	}
+}
+
+// Chunk memakai konfigurasi koleksi: tidak melebihi ukuran, halaman dipertahankan, ada overlap.
+func TestPipelineChunk(t *testing.T) {
+	var para []string
+	for i := 0; i < 40; i++ {
+		para = append(para, fmt.Sprintf("Kalimat nomor %d tentang tekanan casing dan laju gas.", i))
+	}
+	p := &ingest.Pipeline{Config: ingest.Config{Chunking: chunking.Collections{
+		"default": {Strategy: chunking.StrategyRecursive, Size: 2000},
+		"reports": {Strategy: chunking.StrategyPage, Size: 300, Overlap: 60},
+	}}}
+	pages := []ingest.Page{{No: 3, Text: strings.Join(para, " ")}, {No: 4, Text: "pendek"}}
+	if rows := p.Chunk("", pages); len(rows) != 2 || rows[1].Page != 3 || rows[1].PageEnd != 4 {
+		t.Fatalf("default collection: %d chunks", len(rows))
+	}
+	rows := p.Chunk("reports", pages)
+	if len(rows) < 3 {
+		t.Fatalf("expected several chunks, got %d", len(rows))
+	}
+	for i, r := range rows[:len(rows)-1] {
+		if n := utf8.RuneCountInString(r.Text); n > 300 || r.Page != 3 {
+			t.Fatalf("chunk %d: page %d len %d", i, r.Page, n)
+		}
+	}
+	if last := rows[len(rows)-1]; last.Page != 4 || last.Text != "pendek" {
+		t.Fatalf("last chunk: %+v", last)
+	}
+	// overlap: awal chunk ke-2 mengulang teks dari akhir chunk ke-1
+	head := strings.SplitN(rows[1].Text, " ", 4)[:3]
+	if !strings.Contains(rows[0].Text, strings.Join(head, " ")) {
+		t.Fatalf("expected overlap between chunk 0 and 1:\n%q\n%q", rows[0].Text, rows[1].Text)
+	}
+	if ingest.DocIDFor("SOP Well_Control (rev 2).PDF") != "sop-well-control-rev-2" {
+		t.Fatalf("DocIDFor: %q", ingest.DocIDFor("SOP Well_Control (rev 2).PDF"))
+	}
+}
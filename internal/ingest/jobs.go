@@ -0,0 +1,261 @@
+// internal/ingest/jobs.go
+// Antrian job ingest di tabel ingest_jobs (MySQL 8: klaim via FOR UPDATE SKIP LOCKED
+// sehingga beberapa worker aman berjalan paralel).
+package ingest
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"mcp-oilgas/internal/chunking"
+	"mcp-oilgas/internal/repositories/documents"
+)
+
+// Status job.
+const (
+	StatusQueued  = "queued"
+	StatusRunning = "running"
+	StatusDone    = "done"
+	StatusFailed  = "failed"
+)
+
+// Tahap pemrosesan (kolom stage) untuk dipantau dari endpoint admin.
+const (
+	StageExtract = "extract"
+	StageChunk   = "chunk"
+	StageEmbed   = "embed"
+	StageStore   = "store"
+)
+
+// ErrJobNotFound dikembalikan Get untuk id yang tidak ada.
+var ErrJobNotFound = errors.New("ingest job not found")
+
+// Job adalah satu baris ingest_jobs.
+type Job struct {
+	ID         int64  `json:"id"`
+	Filename   string `json:"filename"`
+	Path       string `json:"path"`
+	DocID      string `json:"doc_id"`
+	Title      string `json:"title"`
+	Collection string `json:"collection"`
+	// Meta berisi metadata dari form upload (jenis, area, revisi, ...) yang ditulis ke tabel documents.
+	Meta *documents.Document `json:"meta,omitempty"`
+	// Force memproses ulang walau isi file identik dengan versi yang sudah ada (mis. chunking berubah).
+	Force       bool       `json:"force,omitempty"`
+	ContentHash string     `json:"content_hash,omitempty"`
+	Version     int        `json:"doc_version,omitempty"`  // versi dokumen hasil job
+	DuplicateOf string     `json:"duplicate_of,omitempty"` // isi identik dengan dokumen ini → dilewati
+	Status      string     `json:"status"`
+	Stage       string     `json:"stage,omitempty"`
+	Attempts    int        `json:"attempts"`
+	Error       string     `json:"error,omitempty"`
+	Pages       int        `json:"pages"`
+	Chunks      int        `json:"chunks"`
+	WorkerID    string     `json:"worker_id,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	StartedAt   *time.Time `json:"started_at,omitempty"`
+	FinishedAt  *time.Time `json:"finished_at,omitempty"`
+}
+
+// JobStore mengakses tabel ingest_jobs.
+type JobStore struct {
+	DB          *sql.DB
+	MaxAttempts int // job gagal di-antrikan ulang sampai batas ini (default 3)
+}
+
+// NewJobStore membuat JobStore dengan MaxAttempts default.
+func NewJobStore(db *sql.DB) *JobStore { return &JobStore{DB: db, MaxAttempts: 3} }
+
+const jobColumns = `id, filename, path, doc_id, title, collection, meta, force_reingest, COALESCE(content_hash,''),
+	COALESCE(doc_version,0), COALESCE(duplicate_of,''), status, COALESCE(stage,''), attempts,
+	COALESCE(error,''), pages, chunks, COALESCE(worker_id,''), created_at, started_at, finished_at`
+
+func scanJob(row interface{ Scan(...any) error }) (*Job, error) {
+	var (
+		j        Job
+		meta     []byte
+		started  sql.NullTime
+		finished sql.NullTime
+	)
+	if err := row.Scan(&j.ID, &j.Filename, &j.Path, &j.DocID, &j.Title, &j.Collection, &meta, &j.Force, &j.ContentHash,
+		&j.Version, &j.DuplicateOf, &j.Status, &j.Stage, &j.Attempts,
+		&j.Error, &j.Pages, &j.Chunks, &j.WorkerID, &j.CreatedAt, &started, &finished); err != nil {
+		return nil, err
+	}
+	if len(meta) > 0 {
+		var d documents.Document
+		if json.Unmarshal(meta, &d) == nil {
+			j.Meta = &d
+		}
+	}
+	if started.Valid {
+		j.StartedAt = &started.Time
+	}
+	if finished.Valid {
+		j.FinishedAt = &finished.Time
+	}
+	return &j, nil
+}
+
+// Enqueue menambahkan job berstatus queued dan mengembalikan id-nya.
+func (s *JobStore) Enqueue(ctx context.Context, j Job) (int64, error) {
+	collection := j.Collection
+	if collection == "" {
+		collection = chunking.DefaultCollection
+	}
+	var meta any // NULL bila tanpa metadata
+	if j.Meta != nil {
+		b, _ := json.Marshal(j.Meta)
+		meta = string(b)
+	}
+	res, err := s.DB.ExecContext(ctx, `
+		INSERT INTO ingest_jobs (filename, path, doc_id, title, collection, meta, force_reingest, status)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`, j.Filename, j.Path, j.DocID, j.Title, collection, meta, j.Force, StatusQueued)
+	if err != nil {
+		return 0, fmt.Errorf("enqueue ingest job: %w", err)
+	}
+	return res.LastInsertId()
+}
+
+// Claim mengambil job queued tertua dan menandainya running. (nil, nil) bila antrian kosong.
+func (s *JobStore) Claim(ctx context.Context, workerID string) (*Job, error) {
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var id int64
+	err = tx.QueryRowContext(ctx, `
+		SELECT id FROM ingest_jobs
+		 WHERE status = ?
+		 ORDER BY id
+		 LIMIT 1
+		 FOR UPDATE SKIP LOCKED`, StatusQueued).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("claim ingest job: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE ingest_jobs
+		   SET status = ?, stage = ?, attempts = attempts + 1, worker_id = ?,
+		       started_at = NOW(), finished_at = NULL
+		 WHERE id = ?`, StatusRunning, StageExtract, workerID, id); err != nil {
+		return nil, fmt.Errorf("claim ingest job: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return s.Get(ctx, id)
+}
+
+// SetStage mencatat tahap yang sedang berjalan.
+func (s *JobStore) SetStage(ctx context.Context, id int64, stage string) error {
+	_, err := s.DB.ExecContext(ctx, `UPDATE ingest_jobs SET stage = ? WHERE id = ?`, stage, id)
+	return err
+}
+
+// Complete menandai job selesai beserta versi/hash dokumen (duplicate_of bila isi identik dilewati).
+func (s *JobStore) Complete(ctx context.Context, id int64, res Result) error {
+	var dup any
+	if res.DuplicateOf != "" {
+		dup = res.DuplicateOf
+	}
+	_, err := s.DB.ExecContext(ctx, `
+		UPDATE ingest_jobs
+		   SET status = ?, stage = NULL, error = NULL, pages = ?, chunks = ?, content_hash = ?, doc_version = ?,
+		       duplicate_of = ?, finished_at = NOW()
+		 WHERE id = ?`, StatusDone, res.Pages, res.Chunks, res.ContentHash, res.Version, dup, id)
+	return err
+}
+
+// Fail mencatat error; job di-antrikan ulang bila attempts < MaxAttempts, selain itu failed.
+func (s *JobStore) Fail(ctx context.Context, id int64, cause error) error {
+	return s.fail(ctx, id, cause, s.maxAttempts())
+}
+
+// Abort menandai job failed tanpa percobaan ulang (mis. panic saat memproses file yang rusak).
+func (s *JobStore) Abort(ctx context.Context, id int64, cause error) error {
+	return s.fail(ctx, id, cause, 0)
+}
+
+func (s *JobStore) fail(ctx context.Context, id int64, cause error, max int) error {
+	msg := cause.Error()
+	if len(msg) > 2000 {
+		msg = msg[:2000]
+	}
+	_, err := s.DB.ExecContext(ctx, `
+		UPDATE ingest_jobs
+		   SET status = IF(attempts < ?, ?, ?), error = ?, finished_at = NOW()
+		 WHERE id = ?`, max, StatusQueued, StatusFailed, msg, id)
+	return err
+}
+
+func (s *JobStore) maxAttempts() int {
+	if s.MaxAttempts <= 0 {
+		return 3
+	}
+	return s.MaxAttempts
+}
+
+// RequeueStale mengembalikan job running yang macet (worker mati) lebih lama dari olderThan ke antrian;
+// job yang sudah memakai MaxAttempts ditandai failed agar file yang membuat worker crash tidak diulang terus.
+func (s *JobStore) RequeueStale(ctx context.Context, olderThan time.Duration) (int64, error) {
+	max := s.maxAttempts()
+	res, err := s.DB.ExecContext(ctx, `
+		UPDATE ingest_jobs
+		   SET status = IF(attempts < ?, ?, ?),
+		       error = IF(attempts < ?, 'requeued: worker timeout', 'failed: worker timeout (max attempts reached)'),
+		       finished_at = IF(attempts < ?, NULL, NOW())
+		 WHERE status = ? AND started_at < NOW() - INTERVAL ? SECOND`,
+		max, StatusQueued, StatusFailed, max, max, StatusRunning, int64(olderThan.Seconds()))
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// Get mengambil satu job.
+func (s *JobStore) Get(ctx context.Context, id int64) (*Job, error) {
+	j, err := scanJob(s.DB.QueryRowContext(ctx, `SELECT `+jobColumns+` FROM ingest_jobs WHERE id = ?`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrJobNotFound
+	}
+	return j, err
+}
+
+// List mengembalikan job terbaru lebih dulu; status kosong = semua.
+func (s *JobStore) List(ctx context.Context, status string, limit int) ([]Job, error) {
+	if limit <= 0 || limit > 500 {
+		limit = 50
+	}
+	q := `SELECT ` + jobColumns + ` FROM ingest_jobs`
+	args := []any{}
+	if status != "" {
+		q += ` WHERE status = ?`
+		args = append(args, status)
+	}
+	q += ` ORDER BY id DESC LIMIT ?`
+	args = append(args, limit)
+
+	rows, err := s.DB.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []Job{}
+	for rows.Next() {
+		j, err := scanJob(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *j)
+	}
+	return out, rows.Err()
+}
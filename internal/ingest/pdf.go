@@ -0,0 +1,932 @@
+// internal/ingest/pdf.go
+// Ekstraksi teks PDF minimal tanpa dependensi eksternal:
+//   - objek biasa & object stream (PDF 1.5+), filter FlateDecode/ASCII85/ASCIIHex
+//   - urutan halaman dari page tree (/Root → /Pages → /Kids), resource diwariskan
+//   - operator teks (Tj, TJ, ', ", Td, TD, Tm, T*) termasuk di Form XObject
+//   - font dengan /ToUnicode CMap (mis. Type0 Identity-H); font sederhana dianggap Latin-1
+//
+// PDF terenkripsi dan PDF hasil scan (tanpa layer teks) tidak didukung (butuh OCR).
+package ingest
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/ascii85"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"unicode/utf16"
+)
+
+type (
+	pdfName    string
+	pdfKeyword string
+	pdfRef     int
+	pdfDict    map[string]any
+)
+
+type pdfObj struct {
+	raw    []byte // isi objek setelah "N G obj"
+	parsed bool
+	val    any
+	stream []byte // data stream mentah (belum di-decode)
+}
+
+type pdfDoc struct {
+	objs  map[int]*pdfObj
+	cmaps map[int]*cmap // cache ToUnicode per nomor objek font
+}
+
+var (
+	reObjHeader = regexp.MustCompile(`(\d+)\s+(\d+)\s+obj\b`)
+	reEncrypt   = regexp.MustCompile(`/Encrypt\s+\d+\s+\d+\s+R`)
+)
+
+// maxFormDepth membatasi rekursi Form XObject.
+const maxFormDepth = 5
+
+func extractPDF(data []byte) ([]Page, error) {
+	if !bytes.HasPrefix(bytes.TrimLeft(data, " \t\r\n"), []byte("%PDF")) {
+		return nil, errors.New("pdf: missing %PDF header")
+	}
+	if reEncrypt.Match(data) {
+		return nil, errors.New("pdf: encrypted documents are not supported")
+	}
+	doc := &pdfDoc{objs: map[int]*pdfObj{}, cmaps: map[int]*cmap{}}
+	locs := reObjHeader.FindAllSubmatchIndex(data, -1)
+	for i, loc := range locs {
+		n, _ := strconv.Atoi(string(data[loc[2]:loc[3]]))
+		end := len(data)
+		if i+1 < len(locs) {
+			end = locs[i+1][0]
+		}
+		body := data[loc[1]:end]
+		if j := bytes.LastIndex(body, []byte("endobj")); j >= 0 {
+			body = body[:j]
+		}
+		doc.objs[n] = &pdfObj{raw: body} // objek yang muncul belakangan (incremental update) menang
+	}
+	doc.loadObjectStreams()
+
+	pages := doc.pageList()
+	if len(pages) == 0 {
+		return nil, errors.New("pdf: no pages found")
+	}
+	out := make([]Page, 0, len(pages))
+	for i, p := range pages {
+		var content []byte
+		for _, c := range doc.contents(p.dict) {
+			content = append(content, c...)
+			content = append(content, '\n')
+		}
+		tx := &textExtractor{doc: doc}
+		tx.run(content, p.res, 0)
+		out = append(out, Page{No: i + 1, Text: tx.String()})
+	}
+	return out, nil
+}
+
+// ----------------- Objek -----------------
+
+func (d *pdfDoc) object(n int) *pdfObj {
+	o := d.objs[n]
+	if o == nil {
+		return nil
+	}
+	if !o.parsed {
+		o.parsed = true
+		lx := &lexer{b: o.raw}
+		o.val, _ = lx.value()
+		if dict, ok := o.val.(pdfDict); ok {
+			lx.skipWS()
+			if bytes.HasPrefix(lx.rest(), []byte("stream")) {
+				start := lx.i + len("stream")
+				if start < len(lx.b) && lx.b[start] == '\r' {
+					start++
+				}
+				if start < len(lx.b) && lx.b[start] == '\n' {
+					start++
+				}
+				end := -1
+				if l, ok := d.resolve(dict["Length"]).(float64); ok && start+int(l) <= len(lx.b) {
+					end = start + int(l)
+				}
+				if end < 0 {
+					if j := bytes.LastIndex(lx.b, []byte("endstream")); j >= start {
+						end = j
+					} else {
+						end = len(lx.b)
+					}
+				}
+				o.stream = lx.b[start:end]
+			}
+		}
+	}
+	return o
+}
+
+// resolve mengikuti referensi tidak langsung (n 0 R).
+func (d *pdfDoc) resolve(v any) any {
+	for i := 0; i < 8; i++ {
+		r, ok := v.(pdfRef)
+		if !ok {
+			return v
+		}
+		o := d.object(int(r))
+		if o == nil {
+			return nil
+		}
+		v = o.val
+	}
+	return v
+}
+
+func (d *pdfDoc) dict(v any) pdfDict {
+	dd, _ := d.resolve(v).(pdfDict)
+	return dd
+}
+
+// streamOf mengembalikan data stream yang sudah di-decode untuk referensi v.
+func (d *pdfDoc) streamOf(v any) (pdfDict, []byte) {
+	r, ok := v.(pdfRef)
+	if !ok {
+		return nil, nil
+	}
+	o := d.object(int(r))
+	if o == nil || o.stream == nil {
+		return nil, nil
+	}
+	dict, _ := o.val.(pdfDict)
+	data, err := d.decode(dict, o.stream)
+	if err != nil {
+		return dict, nil
+	}
+	return dict, data
+}
+
+func (d *pdfDoc) decode(dict pdfDict, data []byte) ([]byte, error) {
+	var filters []any
+	switch f := d.resolve(dict["Filter"]).(type) {
+	case pdfName:
+		filters = []any{f}
+	case []any:
+		filters = f
+	}
+	for _, f := range filters {
+		switch d.resolve(f) {
+		case pdfName("FlateDecode"), pdfName("Fl"):
+			zr, err := zlib.NewReader(bytes.NewReader(data))
+			if err != nil {
+				return nil, err
+			}
+			out, err := io.ReadAll(zr)
+			if err != nil && len(out) == 0 {
+				return nil, err
+			}
+			data = out // stream terpotong tetap dipakai sebagian
+		case pdfName("ASCII85Decode"), pdfName("A85"):
+			src := bytes.TrimSpace(data)
+			src = bytes.TrimPrefix(src, []byte("<~"))
+			if i := bytes.Index(src, []byte("~>")); i >= 0 {
+				src = src[:i]
+			}
+			out := make([]byte, len(src)*4/5+4)
+			n, _, err := ascii85.Decode(out, src, true)
+			if err != nil {
+				return nil, err
+			}
+			data = out[:n]
+		case pdfName("ASCIIHexDecode"), pdfName("AHx"):
+			data = decodeHex(data)
+		default:
+			return nil, fmt.Errorf("pdf: unsupported filter %v", f)
+		}
+	}
+	return data, nil
+}
+
+// loadObjectStreams membongkar /Type /ObjStm menjadi objek biasa.
+func (d *pdfDoc) loadObjectStreams() {
+	nums := make([]int, 0, len(d.objs))
+	for n := range d.objs {
+		nums = append(nums, n)
+	}
+	sort.Ints(nums)
+	for _, n := range nums {
+		o := d.object(n)
+		dict, _ := o.val.(pdfDict)
+		if dict == nil || dict["Type"] != pdfName("ObjStm") || o.stream == nil {
+			continue
+		}
+		data, err := d.decode(dict, o.stream)
+		if err != nil {
+			continue
+		}
+		count, _ := d.resolve(dict["N"]).(float64)
+		first, _ := d.resolve(dict["First"]).(float64)
+		if int(first) > len(data) {
+			continue
+		}
+		lx := &lexer{b: data[:int(first)]}
+		type entry struct{ num, off int }
+		entries := make([]entry, 0, int(count))
+		for i := 0; i < int(count); i++ {
+			a, ok1 := lx.value()
+			b, ok2 := lx.value()
+			an, okA := a.(float64)
+			bn, okB := b.(float64)
+			if !ok1 || !ok2 || !okA || !okB {
+				break
+			}
+			entries = append(entries, entry{int(an), int(bn)})
+		}
+		for i, e := range entries {
+			start := int(first) + e.off
+			end := len(data)
+			if i+1 < len(entries) {
+				end = int(first) + entries[i+1].off
+			}
+			if start < 0 || start > end || end > len(data) {
+				continue
+			}
+			if _, exists := d.objs[e.num]; !exists {
+				d.objs[e.num] = &pdfObj{raw: data[start:end]}
+			}
+		}
+	}
+}
+
+// ----------------- Halaman -----------------
+
+type pdfPage struct {
+	dict pdfDict
+	res  pdfDict
+}
+
+func (d *pdfDoc) pageList() []pdfPage {
+	var pages []pdfPage
+	seen := map[*pdfObj]bool{}
+	var walk func(node any, res pdfDict, depth int)
+	walk = func(node any, res pdfDict, depth int) {
+		if depth > 32 {
+			return
+		}
+		if r, ok := node.(pdfRef); ok {
+			o := d.object(int(r))
+			if o == nil || seen[o] {
+				return
+			}
+			seen[o] = true
+		}
+		n := d.dict(node)
+		if n == nil {
+			return
+		}
+		if rd := d.dict(n["Resources"]); rd != nil {
+			res = rd
+		}
+		switch n["Type"] {
+		case pdfName("Page"):
+			pages = append(pages, pdfPage{dict: n, res: res})
+		default: // Pages (atau Type hilang tapi punya Kids)
+			kids, _ := d.resolve(n["Kids"]).([]any)
+			for _, k := range kids {
+				walk(k, res, depth+1)
+			}
+		}
+	}
+	for _, n := range d.sortedNums() {
+		if c := d.dict(pdfRef(n)); c != nil && c["Type"] == pdfName("Catalog") {
+			walk(c["Pages"], nil, 0)
+			if len(pages) > 0 {
+				return pages
+			}
+		}
+	}
+	// fallback: semua objek /Type /Page berurutan nomor objek
+	for _, n := range d.sortedNums() {
+		if p := d.dict(pdfRef(n)); p != nil && p["Type"] == pdfName("Page") {
+			pages = append(pages, pdfPage{dict: p, res: d.dict(p["Resources"])})
+		}
+	}
+	return pages
+}
+
+func (d *pdfDoc) sortedNums() []int {
+	nums := make([]int, 0, len(d.objs))
+	for n := range d.objs {
+		nums = append(nums, n)
+	}
+	sort.Ints(nums)
+	return nums
+}
+
+func (d *pdfDoc) contents(page pdfDict) [][]byte {
+	var refs []any
+	switch c := page["Contents"].(type) {
+	case pdfRef:
+		if arr, ok := d.resolve(c).([]any); ok {
+			refs = arr
+		} else {
+			refs = []any{c}
+		}
+	case []any:
+		refs = c
+	}
+	var out [][]byte
+	for _, r := range refs {
+		if _, data := d.streamOf(r); data != nil {
+			out = append(out, data)
+		}
+	}
+	return out
+}
+
+// ----------------- Font & ToUnicode -----------------
+
+type cmap struct {
+	codeLen int
+	m       map[uint32]string
+}
+
+func (d *pdfDoc) fontCMap(res pdfDict, name pdfName) *cmap {
+	fonts := d.dict(res["Font"])
+	if fonts == nil {
+		return nil
+	}
+	ref, _ := fonts[string(name)].(pdfRef)
+	if c, ok := d.cmaps[int(ref)]; ok && ref != 0 {
+		return c
+	}
+	font := d.dict(fonts[string(name)])
+	var c *cmap
+	if font != nil {
+		if _, data := d.streamOf(font["ToUnicode"]); data != nil {
+			c = parseCMap(data)
+		}
+		if c == nil && font["Subtype"] == pdfName("Type0") {
+			c = &cmap{codeLen: 2, m: map[uint32]string{}} // tanpa ToUnicode: kode 2 byte tak terpetakan
+		}
+	}
+	if ref != 0 {
+		d.cmaps[int(ref)] = c
+	}
+	return c
+}
+
+// parseCMap membaca bfchar/bfrange dari CMap ToUnicode.
+func parseCMap(data []byte) *cmap {
+	c := &cmap{m: map[uint32]string{}}
+	lx := &lexer{b: data}
+	var ops []any
+	mode := ""
+	for {
+		v, ok := lx.value()
+		if !ok {
+			break
+		}
+		kw, isKw := v.(pdfKeyword)
+		if !isKw {
+			if mode != "" {
+				ops = append(ops, v)
+			}
+			continue
+		}
+		switch kw {
+		case "beginbfchar", "beginbfrange", "begincodespacerange":
+			mode, ops = string(kw), nil
+		case "endbfchar":
+			for i := 0; i+1 < len(ops); i += 2 {
+				src, _ := ops[i].(string)
+				dst, _ := ops[i+1].(string)
+				c.setLen(len(src))
+				c.m[codeOf([]byte(src))] = utf16BE([]byte(dst))
+			}
+			mode = ""
+		case "endbfrange":
+			for i := 0; i+2 < len(ops); i += 3 {
+				lo, _ := ops[i].(string)
+				hi, _ := ops[i+1].(string)
+				c.setLen(len(lo))
+				a, b := codeOf([]byte(lo)), codeOf([]byte(hi))
+				if b < a || b-a > 0xFFFF {
+					continue
+				}
+				switch dst := ops[i+2].(type) {
+				case string:
+					base := []byte(dst)
+					for code := a; code <= b; code++ {
+						cur := append([]byte(nil), base...)
+						if len(cur) > 0 {
+							cur[len(cur)-1] += byte(code - a)
+						}
+						c.m[code] = utf16BE(cur)
+					}
+				case []any:
+					for j, x := range dst {
+						if s, ok := x.(string); ok && a+uint32(j) <= b {
+							c.m[a+uint32(j)] = utf16BE([]byte(s))
+						}
+					}
+				}
+			}
+			mode = ""
+		case "endcodespacerange":
+			if len(ops) > 0 {
+				if s, ok := ops[0].(string); ok {
+					c.setLen(len(s))
+				}
+			}
+			mode = ""
+		}
+	}
+	if len(c.m) == 0 {
+		return nil
+	}
+	if c.codeLen == 0 {
+		c.codeLen = 1
+	}
+	return c
+}
+
+func (c *cmap) setLen(n int) {
+	if c.codeLen == 0 && n > 0 {
+		c.codeLen = n
+	}
+}
+
+func codeOf(b []byte) uint32 {
+	var v uint32
+	for _, x := range b {
+		v = v<<8 | uint32(x)
+	}
+	return v
+}
+
+func utf16BE(b []byte) string {
+	if len(b)%2 == 1 {
+		return string(rune(b[0]))
+	}
+	u := make([]uint16, len(b)/2)
+	for i := range u {
+		u[i] = uint16(b[2*i])<<8 | uint16(b[2*i+1])
+	}
+	return string(utf16.Decode(u))
+}
+
+func (c *cmap) decode(s []byte) string {
+	if c == nil {
+		return latin1(s)
+	}
+	var out []rune
+	step := c.codeLen
+	for i := 0; i+step <= len(s); i += step {
+		code := codeOf(s[i : i+step])
+		if t, ok := c.m[code]; ok {
+			out = append(out, []rune(t)...)
+		} else if step == 1 {
+			out = append(out, rune(s[i]))
+		}
+	}
+	return string(out)
+}
+
+func latin1(s []byte) string {
+	rs := make([]rune, 0, len(s))
+	for _, b := range s {
+		if b >= 0x20 || b == '\t' {
+			rs = append(rs, rune(b))
+		}
+	}
+	return string(rs)
+}
+
+// ----------------- Interpretasi content stream -----------------
+
+type textExtractor struct {
+	doc   *pdfDoc
+	buf   bytes.Buffer
+	lastY float64
+	hasY  bool
+}
+
+func (t *textExtractor) String() string { return t.buf.String() }
+
+func (t *textExtractor) newline() {
+	if t.buf.Len() > 0 && !bytes.HasSuffix(t.buf.Bytes(), []byte("\n")) {
+		t.buf.WriteByte('\n')
+	}
+}
+
+func (t *textExtractor) space() {
+	if b := t.buf.Bytes(); len(b) > 0 && b[len(b)-1] != ' ' && b[len(b)-1] != '\n' {
+		t.buf.WriteByte(' ')
+	}
+}
+
+func (t *textExtractor) show(c *cmap, v any) {
+	if s, ok := v.(string); ok {
+		t.buf.WriteString(c.decode([]byte(s)))
+	}
+}
+
+func (t *textExtractor) run(content []byte, res pdfDict, depth int) {
+	lx := &lexer{b: content}
+	var ops []any
+	var font *cmap
+	num := func(i int) float64 {
+		if i < len(ops) {
+			f, _ := ops[i].(float64)
+			return f
+		}
+		return 0
+	}
+	for {
+		v, ok := lx.value()
+		if !ok {
+			return
+		}
+		kw, isKw := v.(pdfKeyword)
+		if !isKw {
+			ops = append(ops, v)
+			continue
+		}
+		switch kw {
+		case "BT":
+			t.hasY = false
+		case "ET":
+			t.newline()
+		case "Tf":
+			if len(ops) >= 1 {
+				if n, ok := ops[0].(pdfName); ok {
+					font = t.doc.fontCMap(res, n)
+				}
+			}
+		case "Tj":
+			if len(ops) > 0 {
+				t.show(font, ops[len(ops)-1])
+			}
+		case "'", "\"":
+			t.newline()
+			if len(ops) > 0 {
+				t.show(font, ops[len(ops)-1])
+			}
+		case "TJ":
+			if len(ops) > 0 {
+				arr, _ := ops[len(ops)-1].([]any)
+				for _, x := range arr {
+					switch e := x.(type) {
+					case string:
+						t.show(font, e)
+					case float64:
+						if e < -200 { // geseran besar = spasi antar kata
+							t.space()
+						}
+					}
+				}
+			}
+		case "Td", "TD":
+			if num(1) != 0 {
+				t.newline()
+			} else if num(0) > 0 {
+				t.space()
+			}
+		case "Tm":
+			y := num(5)
+			if t.hasY && y != t.lastY {
+				t.newline()
+			} else if t.hasY {
+				t.space()
+			}
+			t.lastY, t.hasY = y, true
+		case "T*":
+			t.newline()
+		case "Do":
+			if depth < maxFormDepth && len(ops) > 0 {
+				if n, ok := ops[0].(pdfName); ok {
+					xobjs := t.doc.dict(res["XObject"])
+					if xobjs != nil {
+						dict, data := t.doc.streamOf(xobjs[string(n)])
+						if dict != nil && dict["Subtype"] == pdfName("Form") && data != nil {
+							fres := t.doc.dict(dict["Resources"])
+							if fres == nil {
+								fres = res
+							}
+							t.run(data, fres, depth+1)
+						}
+					}
+				}
+			}
+		case "BI":
+			lx.skipInlineImage()
+		}
+		ops = ops[:0]
+	}
+}
+
+// ----------------- Lexer -----------------
+
+type lexer struct {
+	b []byte
+	i int
+}
+
+// rest mengembalikan sisa input; aman bila posisi sudah melewati akhir data.
+func (l *lexer) rest() []byte {
+	if l.i >= len(l.b) {
+		return nil
+	}
+	return l.b[l.i:]
+}
+
+func isWS(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\r' || c == '\n' || c == '\f' || c == 0
+}
+
+func isDelim(c byte) bool {
+	switch c {
+	case '(', ')', '<', '>', '[', ']', '{', '}', '/', '%':
+		return true
+	}
+	return false
+}
+
+func (l *lexer) skipWS() {
+	for l.i < len(l.b) {
+		c := l.b[l.i]
+		if isWS(c) {
+			l.i++
+			continue
+		}
+		if c == '%' {
+			for l.i < len(l.b) && l.b[l.i] != '\n' && l.b[l.i] != '\r' {
+				l.i++
+			}
+			continue
+		}
+		return
+	}
+}
+
+// value membaca satu objek PDF. String (literal/hex) dikembalikan sebagai Go string berisi byte mentah.
+func (l *lexer) value() (any, bool) {
+	l.skipWS()
+	if l.i >= len(l.b) {
+		return nil, false
+	}
+	c := l.b[l.i]
+	switch {
+	case c == '/':
+		l.i++
+		start := l.i
+		for l.i < len(l.b) && !isWS(l.b[l.i]) && !isDelim(l.b[l.i]) {
+			l.i++
+		}
+		return pdfName(decodeNameEscapes(l.b[start:l.i])), true
+	case c == '(':
+		return l.literal(), true
+	case c == '<':
+		if l.i+1 < len(l.b) && l.b[l.i+1] == '<' {
+			l.i += 2
+			return l.dictBody(), true
+		}
+		l.i++
+		start := l.i
+		for l.i < len(l.b) && l.b[l.i] != '>' {
+			l.i++
+		}
+		s := decodeHex(l.b[start:l.i])
+		if l.i < len(l.b) { // '>' hanya dilewati bila ada (PDF terpotong)
+			l.i++
+		}
+		return string(s), true
+	case c == '[':
+		l.i++
+		var arr []any
+		for {
+			l.skipWS()
+			if l.i >= len(l.b) {
+				return arr, true
+			}
+			if l.b[l.i] == ']' {
+				l.i++
+				return arr, true
+			}
+			v, ok := l.value()
+			if !ok {
+				return arr, true
+			}
+			arr = append(arr, v)
+		}
+	case c == ']' || c == ')' || c == '{' || c == '}':
+		l.i++
+		return pdfKeyword(string(c)), true
+	case c == '>':
+		l.i++
+		if l.i < len(l.b) && l.b[l.i] == '>' {
+			l.i++
+		}
+		return pdfKeyword(">>"), true
+	case c == '+' || c == '-' || c == '.' || (c >= '0' && c <= '9'):
+		return l.number(), true
+	default:
+		start := l.i
+		for l.i < len(l.b) && !isWS(l.b[l.i]) && !isDelim(l.b[l.i]) {
+			l.i++
+		}
+		if l.i == start { // byte tak dikenal
+			l.i++
+		}
+		switch kw := string(l.b[start:l.i]); kw {
+		case "true":
+			return true, true
+		case "false":
+			return false, true
+		case "null":
+			return nil, true
+		default:
+			return pdfKeyword(kw), true
+		}
+	}
+}
+
+func (l *lexer) dictBody() pdfDict {
+	d := pdfDict{}
+	for {
+		l.skipWS()
+		if l.i >= len(l.b) {
+			return d
+		}
+		if l.b[l.i] == '>' {
+			l.i++
+			if l.i < len(l.b) && l.b[l.i] == '>' {
+				l.i++
+			}
+			return d
+		}
+		k, ok := l.value()
+		if !ok {
+			return d
+		}
+		name, isName := k.(pdfName)
+		if !isName {
+			continue
+		}
+		v, ok := l.value()
+		if !ok {
+			return d
+		}
+		d[string(name)] = v
+	}
+}
+
+func (l *lexer) number() any {
+	start := l.i
+	l.i++
+	isInt := l.b[start] != '.'
+	for l.i < len(l.b) {
+		c := l.b[l.i]
+		if c == '.' {
+			isInt = false
+		} else if c < '0' || c > '9' {
+			break
+		}
+		l.i++
+	}
+	f, _ := strconv.ParseFloat(string(l.b[start:l.i]), 64)
+	if !isInt || f < 0 {
+		return f
+	}
+	// cek referensi tidak langsung "n g R"
+	save := l.i
+	l.skipWS()
+	gs := l.i
+	for l.i < len(l.b) && l.b[l.i] >= '0' && l.b[l.i] <= '9' {
+		l.i++
+	}
+	if l.i > gs {
+		l.skipWS()
+		if l.i < len(l.b) && l.b[l.i] == 'R' && (l.i+1 == len(l.b) || isWS(l.b[l.i+1]) || isDelim(l.b[l.i+1])) {
+			l.i++
+			return pdfRef(int(f))
+		}
+	}
+	l.i = save
+	return f
+}
+
+func (l *lexer) literal() string {
+	l.i++ // '('
+	var out []byte
+	depth := 1
+	for l.i < len(l.b) {
+		c := l.b[l.i]
+		l.i++
+		switch c {
+		case '(':
+			depth++
+			out = append(out, c)
+		case ')':
+			depth--
+			if depth == 0 {
+				return string(out)
+			}
+			out = append(out, c)
+		case '\\':
+			if l.i >= len(l.b) {
+				return string(out)
+			}
+			e := l.b[l.i]
+			l.i++
+			switch e {
+			case 'n':
+				out = append(out, '\n')
+			case 'r':
+				out = append(out, '\r')
+			case 't':
+				out = append(out, '\t')
+			case 'b':
+				out = append(out, '\b')
+			case 'f':
+				out = append(out, '\f')
+			case '\r':
+				if l.i < len(l.b) && l.b[l.i] == '\n' {
+					l.i++
+				}
+			case '\n':
+			default:
+				if e >= '0' && e <= '7' {
+					v := int(e - '0')
+					for k := 0; k < 2 && l.i < len(l.b) && l.b[l.i] >= '0' && l.b[l.i] <= '7'; k++ {
+						v = v*8 + int(l.b[l.i]-'0')
+						l.i++
+					}
+					out = append(out, byte(v))
+				} else {
+					out = append(out, e)
+				}
+			}
+		default:
+			out = append(out, c)
+		}
+	}
+	return string(out)
+}
+
+// skipInlineImage melewati data biner "ID ... EI" setelah operator BI.
+func (l *lexer) skipInlineImage() {
+	idx := bytes.Index(l.rest(), []byte("ID"))
+	if idx < 0 {
+		l.i = len(l.b)
+		return
+	}
+	l.i += idx + 2
+	for l.i+2 < len(l.b) {
+		if isWS(l.b[l.i]) && l.b[l.i+1] == 'E' && l.b[l.i+2] == 'I' && (l.i+3 == len(l.b) || isWS(l.b[l.i+3])) {
+			l.i += 3
+			return
+		}
+		l.i++
+	}
+	l.i = len(l.b)
+}
+
+func decodeHex(b []byte) []byte {
+	clean := make([]byte, 0, len(b))
+	for _, c := range b {
+		if c == '>' {
+			break
+		}
+		if (c >= '0' && c <= '9') || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F') {
+			clean = append(clean, c)
+		}
+	}
+	if len(clean)%2 == 1 {
+		clean = append(clean, '0')
+	}
+	out := make([]byte, len(clean)/2)
+	_, _ = hex.Decode(out, clean)
+	return out
+}
+
+func decodeNameEscapes(b []byte) string {
+	if !bytes.Contains(b, []byte("#")) {
+		return string(b)
+	}
+	var out []byte
+	for i := 0; i < len(b); i++ {
+		if b[i] == '#' && i+2 < len(b) {
+			if v, err := strconv.ParseUint(string(b[i+1:i+3]), 16, 8); err == nil {
+				out = append(out, byte(v))
+				i += 2
+				continue
+			}
+		}
+		out = append(out, b[i])
+	}
+	return string(out)
+}
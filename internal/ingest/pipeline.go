@@ -0,0 +1,258 @@
+// internal/ingest/pipeline.go
+// Pipeline satu dokumen: ekstrak → chunk (internal/chunking, per koleksi) → embed → simpan ke doc_chunks.
+package ingest
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"mcp-oilgas/internal/chunking"
+	"mcp-oilgas/internal/llm"
+	"mcp-oilgas/internal/repositories/documents"
+	"mcp-oilgas/internal/repositories/embeddings"
+	"mcp-oilgas/pkg/vector"
+)
+
+// Config mengatur chunking per koleksi & batch embedding.
+type Config struct {
+	Chunking   chunking.Collections // nil → chunking.DefaultConfig() untuk semua koleksi
+	EmbedBatch int                  // teks per panggilan Embed (default 64)
+	// EmbedStorage: format kolom embedding (f32|i8|json, lihat repositories/embeddings); kosong = f32.
+	EmbedStorage string
+	// EmbedVersion: label versi embedder yang dicatat per chunk (EMBED_VERSION), opsional.
+	EmbedVersion string
+	// KeepVersions: jumlah versi dokumen yang chunk-nya disimpan (termasuk yang aktif); 0 = semua.
+	KeepVersions int
+}
+
+// ConfigFromEnv memuat chunking.Default() (CHUNKING_CONFIG_FILE, INGEST_CHUNK_*), INGEST_EMBED_BATCH
+// EMBED_STORAGE, EMBED_VERSION dan DOC_KEEP_VERSIONS (default 3).
+func ConfigFromEnv() Config {
+	cfg := Config{Chunking: chunking.Default(), EmbedBatch: 64, EmbedStorage: embeddings.StorageFromEnv(),
+		EmbedVersion: embeddings.ModelFromEnv().Version, KeepVersions: 3}
+	envInt("INGEST_EMBED_BATCH", &cfg.EmbedBatch)
+	if n, err := strconv.Atoi(os.Getenv("DOC_KEEP_VERSIONS")); err == nil && n >= 0 {
+		cfg.KeepVersions = n
+	}
+	return cfg
+}
+
+func envInt(key string, dst *int) {
+	if n, err := strconv.Atoi(os.Getenv(key)); err == nil && n > 0 {
+		*dst = n
+	}
+}
+
+// Pipeline memproses satu job. Embedder nil = chunk disimpan tanpa embedding
+// (tetap bisa dicari via BM25; isi embedding belakangan dengan cmd/ingest-docs).
+type Pipeline struct {
+	DB       *sql.DB
+	Embedder llm.Embedder
+	Config   Config
+}
+
+// Result adalah ringkasan hasil satu job.
+type Result struct {
+	Pages       int
+	Chunks      int
+	Version     int    // versi dokumen yang aktif setelah job
+	ContentHash string // sha256 file
+	DuplicateOf string // diisi bila isi identik dengan dokumen aktif (tidak ada versi baru)
+}
+
+// Chunk memotong halaman-halaman memakai konfigurasi koleksi.
+func (p *Pipeline) Chunk(collection string, pages []Page) []chunking.Chunk {
+	cfg := chunking.DefaultConfig()
+	if p.Config.Chunking != nil {
+		cfg = p.Config.Chunking.For(collection)
+	}
+	return chunking.Split(pages, cfg)
+}
+
+// Run menjalankan seluruh tahap; stage (boleh nil) dipanggil saat berpindah tahap.
+// Upload ulang doc_id yang sama menjadi versi baru (chunk versi lama di-supersede, lihat
+// documents.NextVersion); file yang isinya identik dengan dokumen aktif mana pun dilewati
+// kecuali job.Force.
+func (p *Pipeline) Run(ctx context.Context, job Job, stage func(string)) (Result, error) {
+	if stage == nil {
+		stage = func(string) {}
+	}
+	stage(StageExtract)
+	hash, err := documents.HashFile(job.Path)
+	if err != nil {
+		return Result{}, fmt.Errorf("hash %s: %w", job.Filename, err)
+	}
+	if !job.Force {
+		dup, ver, err := documents.FindByHash(ctx, p.DB, hash)
+		if err == nil {
+			return Result{Version: ver, ContentHash: hash, DuplicateOf: dup}, nil
+		}
+		if !errors.Is(err, documents.ErrNotFound) {
+			return Result{}, fmt.Errorf("dedup lookup: %w", err)
+		}
+	}
+	pages, err := ExtractFile(job.Path)
+	if err != nil {
+		return Result{}, fmt.Errorf("extract %s: %w", job.Filename, err)
+	}
+	if len(pages) == 0 {
+		return Result{}, errors.New("no text extracted (scanned PDF/image-only documents need OCR)")
+	}
+
+	stage(StageChunk)
+	rows := p.Chunk(job.Collection, pages)
+
+	stage(StageEmbed)
+	vecs, err := p.embed(ctx, job.Title, rows)
+	if err != nil {
+		return Result{}, err
+	}
+
+	stage(StageStore)
+	version, err := p.store(ctx, job, hash, rows, vecs)
+	if err != nil {
+		return Result{}, err
+	}
+	return Result{Pages: len(pages), Chunks: len(rows), Version: version, ContentHash: hash}, nil
+}
+
+// embed memakai judul dokumen + heading/header tabel + isi chunk (lihat Chunk.EmbedText).
+func (p *Pipeline) embed(ctx context.Context, title string, rows []chunking.Chunk) ([][]float32, error) {
+	if p.Embedder == nil {
+		return nil, nil
+	}
+	batch := p.Config.EmbedBatch
+	if batch <= 0 {
+		batch = 64
+	}
+	out := make([][]float32, 0, len(rows))
+	for i := 0; i < len(rows); i += batch {
+		end := i + batch
+		if end > len(rows) {
+			end = len(rows)
+		}
+		texts := make([]string, 0, end-i)
+		for _, r := range rows[i:end] {
+			texts = append(texts, r.EmbedText(title))
+		}
+		vecs, err := p.Embedder.Embed(ctx, texts)
+		if err != nil {
+			return nil, fmt.Errorf("embed chunks %d-%d: %w", i, end, err)
+		}
+		out = append(out, vecs...)
+	}
+	return out, nil
+}
+
+func (p *Pipeline) store(ctx context.Context, job Job, hash string, rows []chunking.Chunk, vecs [][]float32) (int, error) {
+	tx, err := p.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	version, err := documents.NextVersion(ctx, tx, job.DocID, p.Config.KeepVersions)
+	if err != nil {
+		return 0, err
+	}
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO doc_chunks (doc_id, doc_version, title, url, snippet, page_no, chunk_index, char_start, char_end,
+		                        section, `+embeddings.InsertColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return 0, err
+	}
+	defer stmt.Close()
+
+	url := "uploads/" + job.Filename
+	if k := UploadKey(hash, job.Filename); strings.HasSuffix(filepath.ToSlash(job.Path), "/"+k) {
+		url = "uploads/" + k
+	}
+	doc := documents.Document{DocID: job.DocID, Title: job.Title, URL: url, Collection: job.Collection}
+	if job.Meta != nil {
+		doc = *job.Meta
+		doc.DocID, doc.Title, doc.URL, doc.Collection = job.DocID, job.Title, url, job.Collection
+	}
+	if err := documents.Upsert(ctx, tx, doc); err != nil {
+		return 0, err
+	}
+	storage := p.Config.EmbedStorage
+	if storage == "" {
+		storage = vector.FormatF32
+	}
+	model := embeddings.Model{Version: p.Config.EmbedVersion}
+	if p.Embedder != nil {
+		model.Name = p.Embedder.EmbedModel()
+	}
+	for i, r := range rows {
+		var emb embeddings.Values // NULL bila tanpa embedder
+		if i < len(vecs) {
+			if emb, err = embeddings.Encode(vecs[i], model, storage); err != nil {
+				return 0, fmt.Errorf("encode embedding %d: %w", i, err)
+			}
+		}
+		var section any
+		if r.Heading != "" {
+			section = truncate(r.Heading, 255)
+		}
+		if _, err := stmt.ExecContext(ctx, job.DocID, version, job.Title, url, r.Text, r.Page,
+			r.Index, r.Start, r.End, section, emb.JSON, emb.Bin, emb.Model, emb.Dim, emb.Version); err != nil {
+			return 0, fmt.Errorf("insert chunk %d: %w", i, err)
+		}
+	}
+	if err := documents.RecordVersion(ctx, tx, documents.Version{DocID: job.DocID, Version: version, ContentHash: hash,
+		Filename: job.Filename, Title: job.Title, Revision: doc.Revision, Chunks: len(rows), JobID: job.ID}); err != nil {
+		return 0, err
+	}
+	return version, tx.Commit()
+}
+
+var reDocID = regexp.MustCompile(`[^a-z0-9]+`)
+
+// DocIDFor menurunkan doc_id stabil dari nama file (upload ulang file yang sama = dokumen yang sama).
+func DocIDFor(filename string) string {
+	base := strings.TrimSuffix(filepath.Base(filename), filepath.Ext(filename))
+	id := strings.Trim(reDocID.ReplaceAllString(strings.ToLower(base), "-"), "-")
+	if id == "" {
+		id = "doc"
+	}
+	if len(id) > 60 {
+		id = strings.TrimRight(id[:60], "-")
+	}
+	return id
+}
+
+// UploadKey mengembalikan path relatif file upload di UPLOADS_DIR: "<sha256[:16]>/<nama file>".
+// Path dialamatkan isi, jadi upload ulang nama yang sama dengan isi berbeda tidak menimpa file
+// yang mungkin belum dibaca job sebelumnya.
+func UploadKey(hash, filename string) string {
+	if len(hash) > 16 {
+		hash = hash[:16]
+	}
+	return hash + "/" + filepath.Base(filename)
+}
+
+// truncate memotong s ke maks. n rune (kolom VARCHAR).
+func truncate(s string, n int) string {
+	if r := []rune(s); len(r) > n {
+		return string(r[:n])
+	}
+	return s
+}
+
+// TitleFor menurunkan judul dari nama file (underscore/dash → spasi).
+func TitleFor(filename string) string {
+	base := strings.TrimSuffix(filepath.Base(filename), filepath.Ext(filename))
+	t := strings.Join(strings.Fields(strings.NewReplacer("_", " ", "-", " ").Replace(base)), " ")
+	if len(t) > 255 {
+		t = t[:255]
+	}
+	return t
+}
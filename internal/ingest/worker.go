@@ -0,0 +1,128 @@
+// internal/ingest/worker.go
+// Worker: polling antrian ingest_jobs lalu menjalankan Pipeline per job.
+package ingest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"runtime/debug"
+	"strconv"
+	"time"
+)
+
+// Worker memproses job satu per satu; jalankan beberapa proses untuk paralelisme.
+type Worker struct {
+	Store      *JobStore
+	Pipeline   *Pipeline
+	ID         string
+	Poll       time.Duration // jeda saat antrian kosong (default 3s)
+	JobTimeout time.Duration // batas waktu satu job (default 10m)
+}
+
+// WorkerFromEnv mengisi Poll/JobTimeout dari INGEST_POLL_INTERVAL & INGEST_JOB_TIMEOUT (detik).
+func WorkerFromEnv(store *JobStore, p *Pipeline) *Worker {
+	host, _ := os.Hostname()
+	w := &Worker{
+		Store:      store,
+		Pipeline:   p,
+		ID:         host + "-" + strconv.Itoa(os.Getpid()),
+		Poll:       3 * time.Second,
+		JobTimeout: 10 * time.Minute,
+	}
+	if n, err := strconv.Atoi(os.Getenv("INGEST_POLL_INTERVAL")); err == nil && n > 0 {
+		w.Poll = time.Duration(n) * time.Second
+	}
+	if n, err := strconv.Atoi(os.Getenv("INGEST_JOB_TIMEOUT")); err == nil && n > 0 {
+		w.JobTimeout = time.Duration(n) * time.Second
+	}
+	return w
+}
+
+// Run berjalan sampai ctx selesai. Job running yang macet > 2×JobTimeout diantrikan ulang.
+func (w *Worker) Run(ctx context.Context) {
+	log.Printf("[ingest] worker %s started (poll %s)", w.ID, w.Poll)
+	lastSweep := time.Time{}
+	for ctx.Err() == nil {
+		if time.Since(lastSweep) > time.Minute {
+			if n, err := w.Store.RequeueStale(ctx, 2*w.JobTimeout); err != nil {
+				log.Printf("[ingest] requeue stale: %v", err)
+			} else if n > 0 {
+				log.Printf("[ingest] requeued %d stale jobs", n)
+			}
+			lastSweep = time.Now()
+		}
+
+		job, err := w.Store.Claim(ctx, w.ID)
+		if err != nil {
+			log.Printf("[ingest] claim: %v", err)
+		}
+		if job == nil {
+			select {
+			case <-ctx.Done():
+			case <-time.After(w.Poll):
+			}
+			continue
+		}
+		w.process(ctx, job)
+	}
+	log.Printf("[ingest] worker %s stopped", w.ID)
+}
+
+func (w *Worker) process(ctx context.Context, job *Job) {
+	start := time.Now()
+	jctx, cancel := context.WithTimeout(ctx, w.JobTimeout)
+	defer cancel()
+
+	res, err := w.run(jctx, ctx, job)
+	// status ditulis dengan ctx induk agar tetap tercatat walau job timeout
+	var pe *panicError
+	if errors.As(err, &pe) {
+		log.Printf("[ingest] job %d (%s) panicked: %v\n%s", job.ID, job.Filename, pe.val, pe.stack)
+		if ferr := w.Store.Abort(ctx, job.ID, err); ferr != nil {
+			log.Printf("[ingest] job %d mark failed: %v", job.ID, ferr)
+		}
+		return
+	}
+	if err != nil {
+		log.Printf("[ingest] job %d (%s) attempt %d failed: %v", job.ID, job.Filename, job.Attempts, err)
+		if ferr := w.Store.Fail(ctx, job.ID, err); ferr != nil {
+			log.Printf("[ingest] job %d mark failed: %v", job.ID, ferr)
+		}
+		return
+	}
+	if err := w.Store.Complete(ctx, job.ID, res); err != nil {
+		log.Printf("[ingest] job %d mark done: %v", job.ID, err)
+		return
+	}
+	if res.DuplicateOf != "" {
+		log.Printf("[ingest] job %d (%s) skipped: identical to %s v%d", job.ID, job.Filename, res.DuplicateOf, res.Version)
+		return
+	}
+	log.Printf("[ingest] job %d (%s) done: %s v%d, %d pages, %d chunks in %s",
+		job.ID, job.Filename, job.DocID, res.Version, res.Pages, res.Chunks, time.Since(start).Round(time.Millisecond))
+}
+
+// panicError membungkus panic dari pipeline (mis. parser file rusak) supaya worker tetap hidup.
+type panicError struct {
+	val   any
+	stack []byte
+}
+
+func (e *panicError) Error() string { return fmt.Sprintf("panic: %v", e.val) }
+
+// run menjalankan pipeline dan mengubah panic menjadi *panicError.
+func (w *Worker) run(jctx, ctx context.Context, job *Job) (res Result, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = &panicError{val: r, stack: debug.Stack()}
+		}
+	}()
+	return w.Pipeline.Run(jctx, *job, func(stage string) {
+		if err := w.Store.SetStage(ctx, job.ID, stage); err != nil {
+			log.Printf("[ingest] job %d set stage: %v", job.ID, err)
+		}
+	})
+}
@@ -0,0 +1,457 @@
+// internal/lexindex/index.go
+// Indeks BM25 in-process atas doc_chunks (title + snippet) yang dianalisis dengan
+// internal/analysis (stemming Indonesia/Inggris, stopword, sinonim di sisi query). Dipakai
+// mysql.RAGRepo sebagai pengganti atau pendamping FULLTEXT MySQL (LEXICAL_SEARCH=go|both).
+//
+// Siklus hidup sama dengan vectorindex: Start membangun indeks penuh dari MySQL lalu Sync
+// berkala menyamakan chunk baru/hilang/diubah. Tidak ada snapshot di disk; tokenisasi ulang
+// korpus saat start cukup murah dibanding embedding.
+package lexindex
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"mcp-oilgas/internal/analysis"
+	"mcp-oilgas/pkg/vector"
+)
+
+// Config dibaca dari env LEXICAL_*.
+type Config struct {
+	SyncInterval time.Duration // 0 = tanpa sync berkala
+	K1           float64
+	B            float64
+}
+
+// ConfigFromEnv: LEXICAL_SYNC_INTERVAL (detik, default 30), LEXICAL_BM25_K1 (default 1.2),
+// LEXICAL_BM25_B (default 0.75).
+func ConfigFromEnv() Config {
+	cfg := Config{SyncInterval: 30 * time.Second, K1: 1.2, B: 0.75}
+	if n, err := strconv.Atoi(os.Getenv("LEXICAL_SYNC_INTERVAL")); err == nil && n >= 0 {
+		cfg.SyncInterval = time.Duration(n) * time.Second
+	}
+	if f, err := strconv.ParseFloat(os.Getenv("LEXICAL_BM25_K1"), 64); err == nil && f > 0 {
+		cfg.K1 = f
+	}
+	if f, err := strconv.ParseFloat(os.Getenv("LEXICAL_BM25_B"), 64); err == nil && f >= 0 && f <= 1 {
+		cfg.B = f
+	}
+	return cfg
+}
+
+// watermark meringkas isi doc_chunks aktif; sama → tidak ada yang perlu disinkronkan.
+type watermark struct {
+	Count     int64
+	MaxID     int64
+	UpdatedAt int64
+}
+
+// Stats untuk endpoint admin.
+type Stats struct {
+	Enabled   bool      `json:"enabled"`
+	Ready     bool      `json:"ready"`
+	Docs      int       `json:"docs"`
+	Terms     int       `json:"terms"`
+	Deleted   int       `json:"deleted"`
+	AvgLen    float64   `json:"avg_len"`
+	LastSync  time.Time `json:"last_sync,omitempty"`
+	LastError string    `json:"last_error,omitempty"`
+}
+
+type posting struct {
+	slot int32
+	tf   uint32
+}
+
+type entry struct {
+	id    int64
+	len   int
+	alive bool
+}
+
+// Index adalah inverted index BM25 dengan kunci dan sinkronisasi ke MySQL.
+type Index struct {
+	db  *sql.DB
+	cfg Config
+	an  *analysis.Analyzer
+
+	mu       sync.RWMutex // melindungi semua field di bawahnya
+	slots    []entry
+	byID     map[int64]int32
+	postings map[string][]posting
+	live     int
+	deleted  int
+	totalLen int64
+	ready    bool
+	lastSync time.Time
+	lastErr  string
+
+	syncMu     sync.Mutex // serialisasi Sync
+	mark       watermark
+	hasUpdated bool
+}
+
+// New membuat indeks kosong (belum siap sampai Sync pertama selesai). an nil = analysis.Default().
+func New(db *sql.DB, cfg Config, an *analysis.Analyzer) *Index {
+	if an == nil {
+		an = analysis.Default()
+	}
+	if cfg.K1 <= 0 {
+		cfg.K1 = 1.2
+	}
+	return &Index{db: db, cfg: cfg, an: an, byID: map[int64]int32{}, postings: map[string][]posting{}}
+}
+
+// Ready melaporkan apakah indeks sudah memuat korpus.
+func (x *Index) Ready() bool {
+	if x == nil {
+		return false
+	}
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	return x.ready
+}
+
+// Add menganalisis dan menyisipkan (atau mengganti) satu chunk.
+func (x *Index) Add(id int64, text string) {
+	tf := map[string]uint32{}
+	n := 0
+	for _, t := range x.an.Terms(text) {
+		tf[t]++
+		n++
+	}
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	x.removeLocked(id)
+	slot := int32(len(x.slots))
+	x.slots = append(x.slots, entry{id: id, len: n, alive: true})
+	x.byID[id] = slot
+	for t, c := range tf {
+		x.postings[t] = append(x.postings[t], posting{slot: slot, tf: c})
+	}
+	x.live++
+	x.totalLen += int64(n)
+}
+
+// Remove menghapus chunk dari indeks (tombstone; dipadatkan saat Sync).
+func (x *Index) Remove(ids ...int64) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	for _, id := range ids {
+		x.removeLocked(id)
+	}
+}
+
+func (x *Index) removeLocked(id int64) {
+	slot, ok := x.byID[id]
+	if !ok {
+		return
+	}
+	e := &x.slots[slot]
+	e.alive = false
+	delete(x.byID, id)
+	x.live--
+	x.deleted++
+	x.totalLen -= int64(e.len)
+}
+
+// Search mengembalikan k chunk dengan skor BM25 tertinggi untuk query (term query dianalisis
+// dengan Analyzer yang sama, termasuk ekspansi sinonim berbobot); nil bila indeks belum siap.
+func (x *Index) Search(query string, k int) []vector.Hit {
+	if x == nil || k <= 0 {
+		return nil
+	}
+	terms := x.an.QueryTerms(query)
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	if !x.ready || x.live == 0 || len(terms) == 0 {
+		return nil
+	}
+	n := float64(x.live)
+	avg := float64(x.totalLen) / n
+	if avg <= 0 {
+		avg = 1
+	}
+	k1, b := x.cfg.K1, x.cfg.B
+
+	scores := map[int32]float64{}
+	for _, t := range terms {
+		ps := x.postings[t.Text]
+		df := 0
+		for _, p := range ps {
+			if x.slots[p.slot].alive {
+				df++
+			}
+		}
+		if df == 0 {
+			continue
+		}
+		idf := math.Log(1 + (n-float64(df)+0.5)/(float64(df)+0.5))
+		for _, p := range ps {
+			e := x.slots[p.slot]
+			if !e.alive {
+				continue
+			}
+			tf := float64(p.tf)
+			scores[p.slot] += t.Weight * idf * tf * (k1 + 1) / (tf + k1*(1-b+b*float64(e.len)/avg))
+		}
+	}
+
+	hits := make([]vector.Hit, 0, len(scores))
+	for slot, s := range scores {
+		hits = append(hits, vector.Hit{ID: x.slots[slot].id, Score: s})
+	}
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].ID < hits[j].ID
+	})
+	if len(hits) > k {
+		hits = hits[:k]
+	}
+	return hits
+}
+
+// compactLocked membuang tombstone dari postings dan slot.
+func (x *Index) compactLocked() {
+	remap := make([]int32, len(x.slots))
+	slots := make([]entry, 0, x.live)
+	for i, e := range x.slots {
+		remap[i] = -1
+		if e.alive {
+			remap[i] = int32(len(slots))
+			x.byID[e.id] = int32(len(slots))
+			slots = append(slots, e)
+		}
+	}
+	for t, ps := range x.postings {
+		out := ps[:0]
+		for _, p := range ps {
+			if s := remap[p.slot]; s >= 0 {
+				out = append(out, posting{slot: s, tf: p.tf})
+			}
+		}
+		if len(out) == 0 {
+			delete(x.postings, t)
+		} else {
+			x.postings[t] = out
+		}
+	}
+	x.slots = slots
+	x.deleted = 0
+}
+
+// Stats mengembalikan ringkasan kondisi indeks.
+func (x *Index) Stats() Stats {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	st := Stats{
+		Enabled: true, Ready: x.ready, Docs: x.live, Terms: len(x.postings), Deleted: x.deleted,
+		LastSync: x.lastSync, LastError: x.lastErr,
+	}
+	if x.live > 0 {
+		st.AvgLen = float64(x.totalLen) / float64(x.live)
+	}
+	return st
+}
+
+// ---- sinkronisasi dengan MySQL ----
+
+// liveWhere: chunk yang boleh ada di indeks — versi dokumen aktif, tidak dihapus.
+const liveWhere = `deleted_at IS NULL AND superseded = 0`
+
+// Start melakukan sinkron awal lalu (bila SyncInterval > 0) sync berkala sampai ctx selesai.
+// Dijalankan di goroutine; pencarian sebelum siap mengembalikan nil (RAGRepo memakai FULLTEXT).
+func (x *Index) Start(ctx context.Context) {
+	t0 := time.Now()
+	if _, err := x.Sync(ctx); err != nil {
+		log.Printf("[lexindex] initial sync: %v", err)
+	} else {
+		st := x.Stats()
+		log.Printf("[lexindex] ready: %d chunks, %d terms in %s", st.Docs, st.Terms, time.Since(t0).Round(time.Millisecond))
+	}
+	if x.cfg.SyncInterval <= 0 {
+		return
+	}
+	tk := time.NewTicker(x.cfg.SyncInterval)
+	defer tk.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-tk.C:
+			if _, err := x.Sync(ctx); err != nil {
+				log.Printf("[lexindex] sync: %v", err)
+			}
+		}
+	}
+}
+
+// SyncResult merangkum satu Sync.
+type SyncResult struct {
+	Added   int `json:"added"`
+	Removed int `json:"removed"`
+}
+
+// Sync menyamakan indeks dengan doc_chunks aktif: chunk baru atau berubah sejak sync terakhir
+// dianalisis ulang, chunk yang hilang/dihapus/tergantikan versi baru dikeluarkan.
+func (x *Index) Sync(ctx context.Context) (SyncResult, error) {
+	x.syncMu.Lock()
+	defer x.syncMu.Unlock()
+	res, err := x.sync(ctx)
+	x.mu.Lock()
+	x.lastSync = time.Now()
+	x.lastErr = ""
+	if err != nil {
+		x.lastErr = err.Error()
+	}
+	x.mu.Unlock()
+	return res, err
+}
+
+func (x *Index) sync(ctx context.Context) (SyncResult, error) {
+	var res SyncResult
+	if x.db == nil {
+		return res, fmt.Errorf("lexindex: DB is nil")
+	}
+	if !x.hasUpdated {
+		var n int
+		_ = x.db.QueryRowContext(ctx, `
+			SELECT COUNT(*) FROM information_schema.columns
+			 WHERE table_schema = DATABASE() AND table_name = 'doc_chunks' AND column_name = 'updated_at'`).Scan(&n)
+		x.hasUpdated = n > 0
+	}
+
+	var (
+		cur     watermark
+		updated sql.NullInt64
+	)
+	q := `SELECT COUNT(*), COALESCE(MAX(id),0), NULL FROM doc_chunks WHERE ` + liveWhere
+	if x.hasUpdated {
+		q = `SELECT COUNT(*), COALESCE(MAX(id),0), UNIX_TIMESTAMP(MAX(updated_at)) FROM doc_chunks WHERE ` + liveWhere
+	}
+	if err := x.db.QueryRowContext(ctx, q).Scan(&cur.Count, &cur.MaxID, &updated); err != nil {
+		return res, fmt.Errorf("watermark: %w", err)
+	}
+	cur.UpdatedAt = updated.Int64
+	if x.Ready() && cur == x.mark {
+		return res, nil
+	}
+
+	live := make(map[int64]bool, cur.Count)
+	rows, err := x.db.QueryContext(ctx, `SELECT id FROM doc_chunks WHERE `+liveWhere)
+	if err != nil {
+		return res, fmt.Errorf("list ids: %w", err)
+	}
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return res, err
+		}
+		live[id] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return res, err
+	}
+
+	x.mu.RLock()
+	var stale, missing []int64
+	for id := range x.byID {
+		if !live[id] {
+			stale = append(stale, id)
+		}
+	}
+	for id := range live {
+		if _, ok := x.byID[id]; !ok {
+			missing = append(missing, id)
+		}
+	}
+	x.mu.RUnlock()
+
+	// chunk yang teksnya diperbarui di tempat sejak sync terakhir
+	if x.hasUpdated && x.Ready() && x.mark.UpdatedAt > 0 {
+		rows, err := x.db.QueryContext(ctx, `SELECT id FROM doc_chunks WHERE `+liveWhere+` AND updated_at >= FROM_UNIXTIME(?)`, x.mark.UpdatedAt)
+		if err != nil {
+			return res, fmt.Errorf("list updated: %w", err)
+		}
+		for rows.Next() {
+			var id int64
+			if err := rows.Scan(&id); err == nil {
+				missing = append(missing, id)
+			}
+		}
+		rows.Close()
+	}
+
+	x.Remove(stale...)
+	res.Removed = len(stale)
+
+	added, err := x.load(ctx, missing)
+	res.Added = added
+	if err != nil {
+		return res, err
+	}
+
+	x.mu.Lock()
+	if x.deleted > 1000 && x.deleted > x.live/4 {
+		x.compactLocked()
+	}
+	x.ready = true
+	x.mu.Unlock()
+	x.mark = cur
+	return res, nil
+}
+
+// load membaca title + snippet chunk ids (batch) dan menyisipkannya.
+func (x *Index) load(ctx context.Context, ids []int64) (int, error) {
+	const batch = 500
+	added := 0
+	for len(ids) > 0 {
+		n := min(batch, len(ids))
+		part := ids[:n]
+		ids = ids[n:]
+
+		args := make([]any, len(part))
+		for i, id := range part {
+			args[i] = id
+		}
+		rows, err := x.db.QueryContext(ctx, `SELECT id, title, snippet FROM doc_chunks WHERE `+liveWhere+` AND id IN (`+
+			strings.TrimSuffix(strings.Repeat("?,", len(part)), ",")+`)`, args...)
+		if err != nil {
+			return added, fmt.Errorf("load chunks: %w", err)
+		}
+		for rows.Next() {
+			var (
+				id             int64
+				title, snippet sql.NullString
+			)
+			if err := rows.Scan(&id, &title, &snippet); err != nil {
+				rows.Close()
+				return added, err
+			}
+			x.Add(id, title.String+"\n"+snippet.String)
+			added++
+		}
+		rows.Close()
+	}
+	return added, nil
+}
+
+// MarkReady menandai indeks siap tanpa Sync (indeks yang diisi manual lewat Add, mis. di test).
+func (x *Index) MarkReady() {
+	x.mu.Lock()
+	x.ready = true
+	x.mu.Unlock()
+}
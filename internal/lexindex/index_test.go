@@ -0,0 +1,45 @@
+// internal/lexindex/index_test.go
+
+package lexindex_test
+
+import (
+	"testing"
+
+	"mcp-oilgas/internal/lexindex"
+)
+
+func TestSearchMatchesInflectedIndonesian(t *testing.T) {
+	x := lexindex.New(nil, lexindex.Config{K1: 1.2, B: 0.75}, nil)
+	x.Add(1, "Pemeriksaan katup secara berkala oleh teknisi")
+	x.Add(2, "Prosedur penanganan kebocoran gas H2S di sumur")
+	x.Add(3, "Jadwal pelatihan pemadam kebakaran")
+	x.Add(4, "Valve leak response procedure for the wellhead")
+	x.MarkReady()
+
+	hits := x.Search("periksa katup", 10)
+	if len(hits) == 0 || hits[0].ID != 1 {
+		t.Fatalf("periksa katup: %+v", hits)
+	}
+	hits = x.Search("menangani bocor", 10)
+	if len(hits) == 0 || hits[0].ID != 2 {
+		t.Fatalf("menangani bocor: %+v", hits)
+	}
+	// sinonim: kebocoran katup → leak / valve (dokumen berbahasa Inggris)
+	found := false
+	for _, h := range x.Search("kebocoran katup", 10) {
+		found = found || h.ID == 4
+	}
+	if !found {
+		t.Fatalf("synonym expansion did not reach English chunk")
+	}
+
+	x.Remove(2)
+	for _, h := range x.Search("menangani bocor", 10) {
+		if h.ID == 2 {
+			t.Fatalf("removed chunk still returned")
+		}
+	}
+	if st := x.Stats(); st.Docs != 3 || st.Deleted != 1 {
+		t.Fatalf("stats: %+v", st)
+	}
+}
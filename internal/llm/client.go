@@ -0,0 +1,105 @@
+// internal/llm/client.go
+// Satu-satunya paket client LLM: kontrak chat/JSON/streaming/embeddings + instance bersama.
+package llm
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// Client adalah kontrak minimal yang dipakai layer lain (planner/synthesizer/SSE).
+type Client interface {
+	// Jawaban naratif biasa (bebas format) — dipakai untuk tahap sintesis jawaban akhir.
+	AnswerWithRAG(ctx context.Context, system, prompt string) (string, error)
+	// Jawaban dalam format JSON object valid — dipakai oleh Planner agar output bisa di-unmarshal.
+	AnswerJSON(ctx context.Context, user, system string) (string, error)
+	// Streaming delta token untuk SSE — dipakai saat menyusun jawaban akhir secara bertahap.
+	AnswerStream(ctx context.Context, system, prompt string, onDelta func(delta string) error) (string, error)
+	// Nama model chat utama.
+	Model() string
+}
+
+// Embedder menghasilkan embedding (satu vektor per teks, urutan sama dengan input).
+type Embedder interface {
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+	// Nama model embedding (disimpan bersama vektor agar re-embed bisa dideteksi).
+	EmbedModel() string
+}
+
+var (
+	sharedMu      sync.Mutex
+	sharedCli     *OpenAIClient
+	sharedRes     *Resilience
+	sharedResOnce sync.Once
+)
+
+// Shared mengembalikan satu Client bersama untuk seluruh proses (dibuat sekali dari env).
+// Gagal init (mis. OPENAI_API_KEY belum di-set) tidak di-cache, sehingga panggilan berikutnya mencoba lagi.
+func Shared() (Client, error) {
+	c, err := shared()
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// NewEmbedder membuat Embedder sesuai cfg.EmbedProvider.
+func NewEmbedder(cfg Config) (Embedder, error) {
+	switch cfg.EmbedProvider {
+	case ProviderLocal:
+		l := NewLocal(cfg.EmbedDim)
+		l.Model = cfg.EmbedModel
+		return l, nil
+	case ProviderOpenAI, "":
+		c, err := NewOpenAI(cfg)
+		if err != nil {
+			return nil, err
+		}
+		return c, nil
+	}
+	return nil, fmt.Errorf("unknown EMBED_PROVIDER %q (openai|local)", cfg.EmbedProvider)
+}
+
+// SharedEmbedder mengembalikan Embedder bersama: instance yang sama dengan Shared untuk
+// provider openai, atau Local (tanpa OPENAI_API_KEY) untuk EMBED_PROVIDER=local.
+func SharedEmbedder() (Embedder, error) {
+	if cfg := ConfigFromEnv(); cfg.EmbedProvider != ProviderOpenAI {
+		return NewEmbedder(cfg)
+	}
+	c, err := shared()
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func shared() (*OpenAIClient, error) {
+	sharedMu.Lock()
+	defer sharedMu.Unlock()
+	if sharedCli != nil {
+		return sharedCli, nil
+	}
+	c, err := NewOpenAI(ConfigFromEnv())
+	if err != nil {
+		return nil, err
+	}
+	sharedCli = c
+	return c, nil
+}
+
+// SharedResilience mengembalikan lapisan ketahanan global (dibuat dari env saat pertama dipakai).
+func SharedResilience() *Resilience {
+	sharedResOnce.Do(func() { sharedRes = NewResilience(ResilienceConfigFromEnv()) })
+	return sharedRes
+}
+
+// NewFromEnv membuat Client baru dari env (lihat ConfigFromEnv). Handler sebaiknya memakai
+// Shared() agar koneksi HTTP ikut di-pool.
+func NewFromEnv() (Client, error) {
+	c, err := NewOpenAI(ConfigFromEnv())
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
@@ -0,0 +1,97 @@
+// internal/llm/config.go
+// Konfigurasi bersama semua panggilan LLM (chat, JSON, streaming, embeddings).
+package llm
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Config dipakai bersama oleh OpenAIClient (base URL, model, timeout).
+type Config struct {
+	APIKey     string
+	BaseURL    string // kosong = endpoint resmi OpenAI
+	ChatModel  string
+	EmbedModel string
+	// Endpoint embeddings terpisah (server OpenAI-compatible lain, mis. TEI/vLLM/Ollama);
+	// kosong = sama dengan BaseURL/APIKey.
+	EmbedBaseURL string
+	EmbedAPIKey  string
+	// EmbedProvider: openai (default, termasuk server kompatibel) | local (lihat Local, tanpa jaringan).
+	EmbedProvider string
+	EmbedDim      int // dimensi vektor yang diharapkan (EMBED_DIM; default per provider)
+
+	ChatTimeout   time.Duration // AnswerWithRAG
+	JSONTimeout   time.Duration // AnswerJSON (planner/router)
+	StreamTimeout time.Duration // AnswerStream
+	EmbedTimeout  time.Duration // Embed
+	// Timeout di atas hanya dipakai bila ctx pemanggil tidak punya deadline.
+}
+
+// ConfigFromEnv membaca:
+//   - OPENAI_API_KEY
+//   - OPENAI_BASE_URL (alias lama: OPENAI_API_BASE)
+//   - OPENAI_MODEL (default gpt-4o-mini), OPENAI_EMBED_MODEL (default text-embedding-3-small)
+//   - EMBED_BASE_URL, EMBED_API_KEY (opsional, endpoint embeddings terpisah)
+//   - EMBED_PROVIDER (openai|local), EMBED_DIM (default 1536; local 512)
+//   - LLM_CHAT_TIMEOUT, LLM_JSON_TIMEOUT, LLM_STREAM_TIMEOUT, LLM_EMBED_TIMEOUT (detik)
+func ConfigFromEnv() Config {
+	cfg := Config{
+		APIKey:        strings.TrimSpace(os.Getenv("OPENAI_API_KEY")),
+		BaseURL:       strings.TrimSpace(os.Getenv("OPENAI_BASE_URL")),
+		ChatModel:     strings.TrimSpace(os.Getenv("OPENAI_MODEL")),
+		EmbedModel:    strings.TrimSpace(os.Getenv("OPENAI_EMBED_MODEL")),
+		EmbedBaseURL:  strings.TrimSpace(os.Getenv("EMBED_BASE_URL")),
+		EmbedAPIKey:   strings.TrimSpace(os.Getenv("EMBED_API_KEY")),
+		EmbedProvider: strings.ToLower(strings.TrimSpace(os.Getenv("EMBED_PROVIDER"))),
+		ChatTimeout:   18 * time.Second,
+		JSONTimeout:   8 * time.Second,
+		StreamTimeout: 60 * time.Second,
+		EmbedTimeout:  15 * time.Second,
+	}
+	if cfg.BaseURL == "" {
+		cfg.BaseURL = strings.TrimSpace(os.Getenv("OPENAI_API_BASE"))
+	}
+	if cfg.ChatModel == "" {
+		cfg.ChatModel = "gpt-4o-mini" // default ringan, mendukung JSON mode & streaming
+	}
+	if cfg.EmbedProvider == "" {
+		cfg.EmbedProvider = ProviderOpenAI
+	}
+	if n, err := strconv.Atoi(strings.TrimSpace(os.Getenv("EMBED_DIM"))); err == nil && n > 0 {
+		cfg.EmbedDim = n
+	}
+	switch {
+	case cfg.EmbedProvider == ProviderLocal:
+		if cfg.EmbedModel == "" {
+			cfg.EmbedModel = LocalModel
+		}
+		if cfg.EmbedDim == 0 {
+			cfg.EmbedDim = DefaultLocalDim
+		}
+	case cfg.EmbedModel == "":
+		cfg.EmbedModel = "text-embedding-3-small"
+	}
+	if cfg.EmbedDim == 0 {
+		cfg.EmbedDim = 1536 // text-embedding-3-small
+	}
+	envSeconds("LLM_CHAT_TIMEOUT", &cfg.ChatTimeout)
+	envSeconds("LLM_JSON_TIMEOUT", &cfg.JSONTimeout)
+	envSeconds("LLM_STREAM_TIMEOUT", &cfg.StreamTimeout)
+	envSeconds("LLM_EMBED_TIMEOUT", &cfg.EmbedTimeout)
+	return cfg
+}
+
+// Provider embedding (EMBED_PROVIDER).
+const (
+	ProviderOpenAI = "openai"
+	ProviderLocal  = "local"
+)
+
+func envSeconds(key string, dst *time.Duration) {
+	if n, err := strconv.Atoi(os.Getenv(key)); err == nil && n > 0 {
+		*dst = time.Duration(n) * time.Second
+	}
+}
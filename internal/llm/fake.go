@@ -0,0 +1,142 @@
+// internal/llm/fake.go
+// Test double untuk Client & Embedder: tanpa jaringan, deterministik, mencatat panggilan.
+package llm
+
+import (
+	"context"
+	"hash/fnv"
+	"math"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// FakeCall adalah satu panggilan yang diterima Fake.
+type FakeCall struct {
+	Op     string
+	System string
+	User   string
+}
+
+// Fake mengimplementasikan Client & Embedder untuk test dan mode offline.
+//   - AnswerWithRAG/AnswerStream mengembalikan Answer (stream dipotong per kata).
+//   - AnswerJSON mengembalikan JSON berikutnya dari JSONReplies (yang terakhir diulang), default "{}".
+//   - Embed menghasilkan vektor bag-of-words ber-hash berdimensi Dim (default 64), ternormalisasi.
+//   - Err (bila diisi) dikembalikan oleh semua method.
+type Fake struct {
+	Answer      string
+	JSONReplies []string
+	Dim         int
+	Err         error
+
+	mu    sync.Mutex
+	calls []FakeCall
+	jsonN int
+}
+
+// Calls mengembalikan salinan panggilan yang tercatat.
+func (f *Fake) Calls() []FakeCall {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]FakeCall(nil), f.calls...)
+}
+
+func (f *Fake) log(op, system, user string) {
+	f.mu.Lock()
+	f.calls = append(f.calls, FakeCall{Op: op, System: system, User: user})
+	f.mu.Unlock()
+}
+
+// Model mengembalikan "fake".
+func (f *Fake) Model() string { return "fake" }
+
+// EmbedModel mengembalikan "fake-embed".
+func (f *Fake) EmbedModel() string { return "fake-embed" }
+
+// AnswerWithRAG mengembalikan Answer.
+func (f *Fake) AnswerWithRAG(ctx context.Context, system, prompt string) (string, error) {
+	f.log(OpChat, system, prompt)
+	if f.Err != nil {
+		return "", f.Err
+	}
+	return f.Answer, nil
+}
+
+// AnswerJSON mengembalikan balasan JSON berikutnya.
+func (f *Fake) AnswerJSON(ctx context.Context, user, system string) (string, error) {
+	f.log(OpJSON, system, user)
+	if f.Err != nil {
+		return "", f.Err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(f.JSONReplies) == 0 {
+		return "{}", nil
+	}
+	i := f.jsonN
+	if i >= len(f.JSONReplies) {
+		i = len(f.JSONReplies) - 1
+	}
+	f.jsonN++
+	return f.JSONReplies[i], nil
+}
+
+// AnswerStream mengirim Answer per kata ke onDelta.
+func (f *Fake) AnswerStream(ctx context.Context, system, prompt string, onDelta func(delta string) error) (string, error) {
+	f.log(OpStream, system, prompt)
+	if f.Err != nil {
+		return "", f.Err
+	}
+	var out strings.Builder
+	for _, w := range strings.SplitAfter(f.Answer, " ") {
+		if w == "" {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return out.String(), err
+		}
+		out.WriteString(w)
+		if onDelta != nil {
+			if err := onDelta(w); err != nil {
+				return out.String(), err
+			}
+		}
+	}
+	return out.String(), nil
+}
+
+// Embed menghasilkan vektor hash bag-of-words (teks serupa → cosine tinggi).
+func (f *Fake) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	f.log(OpEmbed, "", strings.Join(texts, "\n"))
+	if f.Err != nil {
+		return nil, f.Err
+	}
+	dim := f.Dim
+	if dim <= 0 {
+		dim = 64
+	}
+	out := make([][]float32, len(texts))
+	for i, t := range texts {
+		v := make([]float32, dim)
+		words := strings.FieldsFunc(strings.ToLower(t), func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		})
+		for _, w := range words {
+			h := fnv.New32a()
+			h.Write([]byte(w))
+			v[h.Sum32()%uint32(dim)]++
+		}
+		var n float64
+		for _, x := range v {
+			n += float64(x) * float64(x)
+		}
+		if n > 0 {
+			inv := float32(1 / math.Sqrt(n))
+			for j := range v {
+				v[j] *= inv
+			}
+		}
+		out[i] = v
+	}
+	return out, nil
+}
@@ -0,0 +1,141 @@
+// internal/llm/local.go
+// Embedder lokal tanpa jaringan & tanpa dependensi (EMBED_PROVIDER=local): proyeksi fitur n-gram
+// ber-hash (feature hashing) ke Dim dimensi. Fitur per teks:
+//
+//	kata      ("kick")            bobot 1
+//	bigram    ("well control")    bobot 0.5
+//	char 3..5 ("<ki", "kic", ...) bobot total 1 per kata (tahan typo & imbuhan id: "pengeboran"/"pemboran")
+//
+// Bobot diakumulasi lalu diredam sublinear (sqrt), setiap fitur diberi tanda ± dari hash agar
+// tabrakan saling meniadakan, lalu vektor dinormalisasi L2. Deterministik: cocok untuk rig
+// air-gapped & CI. Kualitasnya di bawah model neural, jadi tetap pakai hybrid BM25 + rerank.
+package llm
+
+import (
+	"context"
+	"hash/fnv"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// LocalModel adalah nama model yang dicatat di embedding_model untuk vektor Local.
+const LocalModel = "local-ngram-v1"
+
+// DefaultLocalDim adalah dimensi default Local (EMBED_DIM mengganti).
+const DefaultLocalDim = 512
+
+// Local mengimplementasikan Embedder secara lokal.
+type Local struct {
+	Dim   int
+	Model string // default LocalModel
+}
+
+// NewLocal membuat embedder lokal; dim <= 0 → DefaultLocalDim.
+func NewLocal(dim int) *Local {
+	if dim <= 0 {
+		dim = DefaultLocalDim
+	}
+	return &Local{Dim: dim, Model: LocalModel}
+}
+
+// EmbedModel mengembalikan nama model lokal.
+func (l *Local) EmbedModel() string {
+	if l.Model == "" {
+		return LocalModel
+	}
+	return l.Model
+}
+
+// Embed menghasilkan satu vektor per teks (teks kosong → vektor nol).
+func (l *Local) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	dim := l.Dim
+	if dim <= 0 {
+		dim = DefaultLocalDim
+	}
+	out := make([][]float32, len(texts))
+	for i, t := range texts {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		out[i] = localVector(t, dim)
+	}
+	return out, nil
+}
+
+// localStopwords: kata fungsi id/en yang tidak membawa makna topik.
+var localStopwords = map[string]bool{
+	"yang": true, "dan": true, "di": true, "ke": true, "dari": true, "untuk": true, "dengan": true,
+	"pada": true, "ini": true, "itu": true, "atau": true, "adalah": true, "dalam": true, "apa": true,
+	"the": true, "of": true, "and": true, "to": true, "a": true, "an": true, "in": true, "on": true,
+	"for": true, "is": true, "are": true, "with": true, "by": true, "what": true, "how": true,
+}
+
+func localVector(text string, dim int) []float32 {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	feats := map[string]float64{}
+	prev := ""
+	for _, w := range words {
+		if localStopwords[w] {
+			prev = ""
+			continue
+		}
+		feats["w:"+w]++
+		if prev != "" {
+			feats["b:"+prev+" "+w] += 0.5
+		}
+		prev = w
+		grams := charGrams(w)
+		for _, g := range grams {
+			feats["c:"+g] += 1 / float64(len(grams))
+		}
+	}
+
+	// urutan penjumlahan float harus tetap (urutan map acak) agar vektor identik bit per bit
+	keys := make([]string, 0, len(feats))
+	for f := range feats {
+		keys = append(keys, f)
+	}
+	sort.Strings(keys)
+	v := make([]float32, dim)
+	for _, f := range keys {
+		wt := feats[f]
+		h := fnv.New64a()
+		h.Write([]byte(f))
+		s := h.Sum64()
+		x := float32(math.Sqrt(wt))
+		if s>>63 == 1 {
+			x = -x
+		}
+		v[s%uint64(dim)] += x
+	}
+	var n float64
+	for _, x := range v {
+		n += float64(x) * float64(x)
+	}
+	if n > 0 {
+		inv := float32(1 / math.Sqrt(n))
+		for j := range v {
+			v[j] *= inv
+		}
+	}
+	return v
+}
+
+// charGrams mengembalikan n-gram karakter 3..5 dari "<w>"; kata pendek (<= 2 rune) tanpa n-gram.
+func charGrams(w string) []string {
+	r := []rune("<" + w + ">")
+	if len(r) <= 4 {
+		return nil
+	}
+	var out []string
+	for n := 3; n <= 5; n++ {
+		for i := 0; i+n <= len(r); i++ {
+			out = append(out, string(r[i:i+n]))
+		}
+	}
+	return out
+}
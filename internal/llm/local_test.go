@@ -0,0 +1,65 @@
+package llm_test
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"mcp-oilgas/internal/llm"
+)
+
+func dot(a, b []float32) float64 {
+	var s float64
+	for i := range a {
+		s += float64(a[i]) * float64(b[i])
+	}
+	return s
+}
+
+func TestLocalEmbedder(t *testing.T) {
+	e := llm.NewLocal(256)
+	texts := []string{
+		"Prosedur penanganan kick pada sumur pengeboran",
+		"Langkah penanganan kick saat pemboran sumur",
+		"Laporan keuangan purchase order vendor",
+		"",
+	}
+	v, err := e.Embed(context.Background(), texts)
+	if err != nil {
+		t.Fatalf("embed: %v", err)
+	}
+	if len(v) != 4 || len(v[0]) != 256 {
+		t.Fatalf("unexpected shape: %d x %d", len(v), len(v[0]))
+	}
+	if n := dot(v[0], v[0]); math.Abs(n-1) > 1e-5 {
+		t.Fatalf("vector not normalized: %f", n)
+	}
+	if dot(v[3], v[3]) != 0 {
+		t.Fatalf("empty text should give zero vector")
+	}
+	if near, far := dot(v[0], v[1]), dot(v[0], v[2]); near <= far+0.2 {
+		t.Fatalf("similar texts should score higher: near=%.3f far=%.3f", near, far)
+	}
+
+	// deterministik antar instance (vektor korpus & query harus cocok)
+	w, _ := llm.NewLocal(256).Embed(context.Background(), texts[:1])
+	for i := range w[0] {
+		if w[0][i] != v[0][i] {
+			t.Fatalf("local embedding not deterministic at %d", i)
+		}
+	}
+}
+
+func TestNewEmbedderLocal(t *testing.T) {
+	e, err := llm.NewEmbedder(llm.Config{EmbedProvider: llm.ProviderLocal, EmbedModel: llm.LocalModel, EmbedDim: 128})
+	if err != nil {
+		t.Fatalf("new embedder: %v", err)
+	}
+	v, err := e.Embed(context.Background(), []string{"well control"})
+	if err != nil || len(v[0]) != 128 || e.EmbedModel() != llm.LocalModel {
+		t.Fatalf("unexpected local embedder: %v dim=%d model=%s", err, len(v[0]), e.EmbedModel())
+	}
+	if _, err := llm.NewEmbedder(llm.Config{EmbedProvider: "bogus"}); err == nil {
+		t.Fatalf("unknown provider should fail")
+	}
+}
@@ -0,0 +1,243 @@
+// internal/llm/openai.go
+// Implementasi Client + Embedder berbasis go-openai. Semua panggilan melewati Resilience
+// (retry, fallback model, semaphore, circuit breaker) dan dicatat ke telemetri.
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// OpenAIClient adalah implementasi Client & Embedder berbasis go-openai.
+type OpenAIClient struct {
+	api    *openai.Client
+	embAPI *openai.Client // = api kecuali EMBED_BASE_URL/EMBED_API_KEY diisi
+	cfg    Config
+	res    *Resilience
+}
+
+// NewOpenAI membuat client dari cfg; lapisan ketahanan dipakai bersama (SharedResilience).
+func NewOpenAI(cfg Config) (*OpenAIClient, error) {
+	if cfg.APIKey == "" {
+		return nil, errors.New("OPENAI_API_KEY not set")
+	}
+	oc := openai.DefaultConfig(cfg.APIKey)
+	if cfg.BaseURL != "" {
+		oc.BaseURL = cfg.BaseURL
+	}
+	api := openai.NewClientWithConfig(oc)
+	embAPI := api
+	if cfg.EmbedBaseURL != "" || cfg.EmbedAPIKey != "" {
+		key := cfg.EmbedAPIKey
+		if key == "" {
+			key = cfg.APIKey
+		}
+		ec := openai.DefaultConfig(key)
+		ec.BaseURL = oc.BaseURL
+		if cfg.EmbedBaseURL != "" {
+			ec.BaseURL = cfg.EmbedBaseURL
+		}
+		embAPI = openai.NewClientWithConfig(ec)
+	}
+	return &OpenAIClient{
+		api:    api,
+		embAPI: embAPI,
+		cfg:    cfg,
+		res:    SharedResilience(),
+	}, nil
+}
+
+// Model mengembalikan model chat utama.
+func (c *OpenAIClient) Model() string { return c.cfg.ChatModel }
+
+// EmbedModel mengembalikan model embedding.
+func (c *OpenAIClient) EmbedModel() string { return c.cfg.EmbedModel }
+
+// withDefaultTimeout: deadline defensif bila pemanggil tidak memberi deadline.
+func withDefaultTimeout(ctx context.Context, d time.Duration) (context.Context, context.CancelFunc) {
+	if _, ok := ctx.Deadline(); ok || d <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, d)
+}
+
+func chatMessages(system, user string) []openai.ChatCompletionMessage {
+	return []openai.ChatCompletionMessage{
+		{Role: openai.ChatMessageRoleSystem, Content: system},
+		{Role: openai.ChatMessageRoleUser, Content: user},
+	}
+}
+
+// AnswerWithRAG meminta model menghasilkan jawaban naratif/final untuk user.
+func (c *OpenAIClient) AnswerWithRAG(ctx context.Context, system, prompt string) (string, error) {
+	ctx, cancel := withDefaultTimeout(ctx, c.cfg.ChatTimeout)
+	defer cancel()
+	return c.complete(ctx, OpChat, openai.ChatCompletionRequest{
+		Messages:    chatMessages(system, prompt),
+		Temperature: 0.2,
+	})
+}
+
+// AnswerJSON meminta model merespons JSON object valid (JSON mode).
+func (c *OpenAIClient) AnswerJSON(ctx context.Context, user, system string) (string, error) {
+	ctx, cancel := withDefaultTimeout(ctx, c.cfg.JSONTimeout)
+	defer cancel()
+	out, err := c.complete(ctx, OpJSON, openai.ChatCompletionRequest{
+		Messages:    chatMessages(system, user),
+		Temperature: 0.0,
+		ResponseFormat: &openai.ChatCompletionResponseFormat{
+			Type: openai.ChatCompletionResponseFormatTypeJSONObject,
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+	return stripCodeFence(out), nil
+}
+
+func (c *OpenAIClient) complete(ctx context.Context, op string, req openai.ChatCompletionRequest) (string, error) {
+	start := time.Now()
+	call := CallInfo{Op: op, InputChars: messagesLen(req.Messages)}
+	var out string
+	err := c.res.Do(ctx, c.cfg.ChatModel, func(ctx context.Context, model string) error {
+		req.Model = model
+		call.Model = model
+		resp, err := c.api.CreateChatCompletion(ctx, req)
+		if err != nil {
+			return fmt.Errorf("openai completion (%s): %w", op, err)
+		}
+		if len(resp.Choices) == 0 {
+			return errors.New("no completion choices")
+		}
+		call.PromptTokens = resp.Usage.PromptTokens
+		call.CompletionTokens = resp.Usage.CompletionTokens
+		out = strings.TrimSpace(resp.Choices[0].Message.Content)
+		return nil
+	})
+	call.OutputChars, call.Duration, call.Err = len(out), time.Since(start), err
+	record(call)
+	return out, err
+}
+
+// AnswerStream melakukan chat completion secara streaming (token-by-token).
+// Retry/fallback hanya selama belum ada delta terkirim ke pemanggil.
+func (c *OpenAIClient) AnswerStream(ctx context.Context, system, prompt string, onDelta func(delta string) error) (string, error) {
+	ctx, cancel := withDefaultTimeout(ctx, c.cfg.StreamTimeout)
+	defer cancel()
+
+	req := openai.ChatCompletionRequest{
+		Messages:    chatMessages(system, prompt),
+		Temperature: 0.2,
+		Stream:      true,
+	}
+	start := time.Now()
+	call := CallInfo{Op: OpStream, InputChars: messagesLen(req.Messages)}
+
+	var final strings.Builder
+	err := c.res.Do(ctx, c.cfg.ChatModel, func(ctx context.Context, model string) error {
+		req.Model = model
+		call.Model = model
+		stream, err := c.api.CreateChatCompletionStream(ctx, req)
+		if err != nil {
+			return fmt.Errorf("openai stream init: %w", err)
+		}
+		defer stream.Close()
+
+		for {
+			resp, err := stream.Recv()
+			if err != nil {
+				// io.EOF = selesai normal
+				if errors.Is(err, io.EOF) {
+					return nil
+				}
+				err = fmt.Errorf("openai stream recv: %w", err)
+				if final.Len() > 0 {
+					return NoRetry(err)
+				}
+				return err
+			}
+			for _, ch := range resp.Choices {
+				delta := ch.Delta.Content
+				if delta == "" {
+					continue
+				}
+				final.WriteString(delta)
+				if onDelta != nil {
+					if derr := onDelta(delta); derr != nil {
+						return NoRetry(derr)
+					}
+				}
+			}
+		}
+	})
+	call.OutputChars, call.Duration, call.Err = final.Len(), time.Since(start), err
+	record(call)
+	return final.String(), err
+}
+
+// Embed menghasilkan embedding untuk texts dengan model embedding dari Config.
+// Fallback model chat tidak berlaku untuk embedding (dimensi harus konsisten).
+func (c *OpenAIClient) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	if len(texts) == 0 {
+		return nil, nil
+	}
+	ctx, cancel := withDefaultTimeout(ctx, c.cfg.EmbedTimeout)
+	defer cancel()
+
+	start := time.Now()
+	call := CallInfo{Op: OpEmbed, Model: c.cfg.EmbedModel}
+	for _, t := range texts {
+		call.InputChars += len(t)
+	}
+	var out [][]float32
+	err := c.res.DoModel(ctx, c.cfg.EmbedModel, func(ctx context.Context, model string) error {
+		resp, err := c.embAPI.CreateEmbeddings(ctx, openai.EmbeddingRequestStrings{
+			Input: texts,
+			Model: openai.EmbeddingModel(model),
+		})
+		if err != nil {
+			return fmt.Errorf("openai embeddings: %w", err)
+		}
+		if len(resp.Data) != len(texts) {
+			return NoRetry(fmt.Errorf("openai embeddings: got %d vectors for %d inputs", len(resp.Data), len(texts)))
+		}
+		out = make([][]float32, len(texts))
+		for _, d := range resp.Data {
+			if d.Index < 0 || d.Index >= len(out) {
+				return NoRetry(fmt.Errorf("openai embeddings: index %d out of range", d.Index))
+			}
+			out[d.Index] = d.Embedding
+		}
+		call.PromptTokens = resp.Usage.PromptTokens
+		return nil
+	})
+	call.Duration, call.Err = time.Since(start), err
+	record(call)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// stripCodeFence membersihkan bila model menyelipkan ```json ... ```.
+func stripCodeFence(out string) string {
+	out = strings.TrimPrefix(out, "```json")
+	out = strings.TrimPrefix(out, "```JSON")
+	out = strings.TrimPrefix(out, "```")
+	out = strings.TrimSuffix(out, "```")
+	return strings.TrimSpace(out)
+}
+
+func messagesLen(msgs []openai.ChatCompletionMessage) int {
+	n := 0
+	for _, m := range msgs {
+		n += len(m.Content)
+	}
+	return n
+}
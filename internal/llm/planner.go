@@ -1,4 +1,4 @@
-// internal/mcp/llm/planner.go
+// internal/llm/planner.go
 package llm
 
 import (
@@ -12,6 +12,8 @@ import (
 	"sort"
 	"strings"
 	"time"
+
+	"mcp-oilgas/internal/prompts"
 )
 
 // ToolLite: representasi tool dari schema (tanpa import mcp untuk hindari cycle)
@@ -29,12 +31,16 @@ type ToolLite struct {
 // RoutePlanner bertumpu pada Client
 type RoutePlanner struct{ client Client }
 
+// NewRoutePlanner membuat planner di atas client yang sudah ada.
+func NewRoutePlanner(c Client) *RoutePlanner { return &RoutePlanner{client: c} }
+
+// NewRoutePlannerFromEnv memakai Client bersama (Shared).
 func NewRoutePlannerFromEnv() (*RoutePlanner, error) {
-	c, err := NewFromEnv()
+	c, err := Shared()
 	if err != nil {
 		return nil, err
 	}
-	return &RoutePlanner{client: c}, nil
+	return NewRoutePlanner(c), nil
 }
 
 // ====== Struktur schema JSON dasar ======
@@ -237,37 +243,16 @@ func (p *RoutePlanner) planRawInternal(ctx context.Context, tools []ToolLite, qu
 		})
 	}
 
-	// ---- 2. Buat system prompt untuk ROUTER LLM ----
-	sys := `Anda adalah ROUTER. Jawab HANYA dengan JSON VALID sesuai schema berikut.
-Aturan:
-- Pilih HANYA dari tools yang disediakan pada "tools".
-- Jika pertanyaan jelas tentang Purchase Order (PO/vendor/ETA/amount/status), jangan pilih timeseries/production/drilling.
-- Gunakan "rag" hanya bila tidak ada tool MCP yang cocok.
-- Jangan mengarang nama tool atau field params yang tidak ada.
-- Output HARUS valid object, TANPA teks lain, TANPA markdown.
-- Jika pertanyaan menyebut tag/timeseries/signal (mis: OIL_*, GAS_*, *_D01) dan ada tanggal/range,
-  PILIH tool "get_timeseries" (kind="mcp"), JANGAN pilih RAG.
-  - Jika hanya satu tanggal (YYYY-MM-DD), gunakan:
-    start_date = "YYYY-MM-DDT00:00:00Z"
-    end_date   = "YYYY-MM-DDT23:59:59Z" (atau "YYYY-MM-DD+1 T00:00:00Z")
-  - Sertakan: tag, start_date, end_date, opsional agg="raw".
-- Jika pertanyaan tentang Purchase Order (PO/vendor/ETA/amount/status), pilih tool PO terkait. Jangan pilih timeseries.
-- Gunakan "rag" hanya jika tidak ada tool MCP yang cocok.
-- Output HARUS object JSON valid tanpa teks lain.
-Skema keluaran:
-{
-  "mode": "mcp" | "rag" | "hybrid",
-  "routes": [
-    {
-      "kind": "mcp" | "rag",
-      "tool": "<nama tool jika kind=mcp>",
-      "params": { },
-      "query": "<string untuk rag>",
-      "top_k": 10
-    }
-  ],
-  "reason": "string singkat"
-}`
+	// ---- 2. Buat system prompt untuk ROUTER LLM (template "planner") ----
+	ptools := make([]prompts.Tool, 0, len(tools))
+	for _, t := range tools {
+		ptools = append(ptools, prompts.Tool{Name: t.Name, Description: t.Description})
+	}
+	rp, err := prompts.Default().Render(prompts.Planner, prompts.Vars{Lang: "id", Tools: ptools})
+	if err != nil {
+		return "", fmt.Errorf("planner prompt: %w", err)
+	}
+	sys := rp.Text
 
 	// ---- 3. Payload yang diberikan ke LLM ----
 	payload := struct {
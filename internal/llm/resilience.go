@@ -0,0 +1,412 @@
+// internal/llm/resilience.go
+// Lapisan ketahanan panggilan LLM: retry + jittered backoff untuk error sementara (429/5xx/jaringan),
+// rantai model fallback, semaphore global (antrian) dan circuit breaker per model.
+package llm
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// Error yang dikembalikan lapisan ketahanan.
+var (
+	ErrCircuitOpen  = errors.New("llm: circuit open for all models")
+	ErrQueueTimeout = errors.New("llm: timed out waiting for a concurrency slot")
+)
+
+// ResilienceConfig mengatur retry, fallback, konkurensi & circuit breaker.
+type ResilienceConfig struct {
+	MaxRetries       int           // retry per model (di luar percobaan pertama)
+	BackoffBase      time.Duration // backoff awal; naik 2x per percobaan (full jitter)
+	BackoffMax       time.Duration
+	FallbackModels   []string      // dicoba berurutan setelah model utama
+	MaxConcurrency   int           // slot panggilan LLM simultan (seluruh proses)
+	QueueTimeout     time.Duration // batas tunggu slot
+	BreakerThreshold int           // gagal beruntun sebelum circuit terbuka
+	BreakerCooldown  time.Duration // lama circuit terbuka sebelum half-open
+}
+
+// ResilienceConfigFromEnv membaca LLM_MAX_RETRIES, LLM_BACKOFF_BASE_MS, LLM_BACKOFF_MAX_MS,
+// LLM_FALLBACK_MODELS (dipisah koma), LLM_MAX_CONCURRENCY, LLM_QUEUE_TIMEOUT (detik),
+// LLM_BREAKER_THRESHOLD, LLM_BREAKER_COOLDOWN (detik).
+func ResilienceConfigFromEnv() ResilienceConfig {
+	cfg := ResilienceConfig{
+		MaxRetries:       3,
+		BackoffBase:      500 * time.Millisecond,
+		BackoffMax:       8 * time.Second,
+		MaxConcurrency:   8,
+		QueueTimeout:     10 * time.Second,
+		BreakerThreshold: 5,
+		BreakerCooldown:  30 * time.Second,
+	}
+	if n, err := strconv.Atoi(os.Getenv("LLM_MAX_RETRIES")); err == nil && n >= 0 {
+		cfg.MaxRetries = n
+	}
+	if n, err := strconv.Atoi(os.Getenv("LLM_BACKOFF_BASE_MS")); err == nil && n > 0 {
+		cfg.BackoffBase = time.Duration(n) * time.Millisecond
+	}
+	if n, err := strconv.Atoi(os.Getenv("LLM_BACKOFF_MAX_MS")); err == nil && n > 0 {
+		cfg.BackoffMax = time.Duration(n) * time.Millisecond
+	}
+	for _, m := range strings.Split(os.Getenv("LLM_FALLBACK_MODELS"), ",") {
+		if m = strings.TrimSpace(m); m != "" {
+			cfg.FallbackModels = append(cfg.FallbackModels, m)
+		}
+	}
+	if n, err := strconv.Atoi(os.Getenv("LLM_MAX_CONCURRENCY")); err == nil && n > 0 {
+		cfg.MaxConcurrency = n
+	}
+	if n, err := strconv.Atoi(os.Getenv("LLM_QUEUE_TIMEOUT")); err == nil && n > 0 {
+		cfg.QueueTimeout = time.Duration(n) * time.Second
+	}
+	if n, err := strconv.Atoi(os.Getenv("LLM_BREAKER_THRESHOLD")); err == nil && n > 0 {
+		cfg.BreakerThreshold = n
+	}
+	if n, err := strconv.Atoi(os.Getenv("LLM_BREAKER_COOLDOWN")); err == nil && n > 0 {
+		cfg.BreakerCooldown = time.Duration(n) * time.Second
+	}
+	return cfg
+}
+
+// Resilience dipakai bersama oleh semua panggilan LLM dalam proses.
+type Resilience struct {
+	cfg ResilienceConfig
+	sem chan struct{}
+
+	mu       sync.Mutex
+	breakers map[string]*breaker
+
+	waiting   atomic.Int64
+	retries   atomic.Int64
+	fallbacks atomic.Int64
+	failures  atomic.Int64
+}
+
+// NewResilience membuat lapisan ketahanan dari cfg.
+func NewResilience(cfg ResilienceConfig) *Resilience {
+	if cfg.MaxConcurrency <= 0 {
+		cfg.MaxConcurrency = 8
+	}
+	if cfg.BreakerThreshold <= 0 {
+		cfg.BreakerThreshold = 5
+	}
+	if cfg.BreakerCooldown <= 0 {
+		cfg.BreakerCooldown = 30 * time.Second
+	}
+	return &Resilience{
+		cfg:      cfg,
+		sem:      make(chan struct{}, cfg.MaxConcurrency),
+		breakers: map[string]*breaker{},
+	}
+}
+
+// noRetryError menandai error yang tidak boleh diulang (mis. stream yang sudah mengirim delta).
+type noRetryError struct{ err error }
+
+func (e noRetryError) Error() string { return e.err.Error() }
+func (e noRetryError) Unwrap() error { return e.err }
+
+// NoRetry membungkus err agar Do tidak mengulang maupun pindah model.
+func NoRetry(err error) error {
+	if err == nil {
+		return nil
+	}
+	return noRetryError{err}
+}
+
+// Do menjalankan fn untuk model utama lalu fallback, dengan retry, slot konkurensi
+// dan circuit breaker. fn dipanggil ulang dengan model yang sedang dicoba.
+func (r *Resilience) Do(ctx context.Context, primary string, fn func(ctx context.Context, model string) error) error {
+	return r.run(ctx, append([]string{primary}, r.cfg.FallbackModels...), fn)
+}
+
+// DoModel sama seperti Do tetapi tanpa rantai fallback (mis. embedding: dimensi harus tetap).
+func (r *Resilience) DoModel(ctx context.Context, model string, fn func(ctx context.Context, model string) error) error {
+	return r.run(ctx, []string{model}, fn)
+}
+
+func (r *Resilience) run(ctx context.Context, models []string, fn func(ctx context.Context, model string) error) error {
+	primary := models[0]
+	var lastErr error
+	for i, model := range models {
+		if model == "" || (i > 0 && model == primary) {
+			continue
+		}
+		br := r.breaker(model)
+		ok, probe := br.allow()
+		if !ok {
+			if lastErr == nil {
+				lastErr = ErrCircuitOpen
+			}
+			continue
+		}
+		if i > 0 {
+			r.fallbacks.Add(1)
+		}
+		err, done := r.tryModel(ctx, model, br, probe, lastErr, fn)
+		if done {
+			return err
+		}
+		lastErr = err
+	}
+	if lastErr == nil {
+		lastErr = ErrCircuitOpen
+	}
+	return lastErr
+}
+
+// tryModel menjalankan percobaan (dengan retry) untuk satu model. done=true berarti hasil final
+// (sukses, error permanen, ctx selesai, antrian penuh); false = lanjut ke model berikutnya.
+func (r *Resilience) tryModel(ctx context.Context, model string, br *breaker, probe bool, lastErr error,
+	fn func(ctx context.Context, model string) error) (err error, done bool) {
+	settled := false
+	defer func() {
+		// slot half-open wajib dilepas di semua jalur keluar; bila tidak, allow() menolak model ini selamanya
+		if probe && !settled {
+			br.release()
+		}
+	}()
+	for attempt := 0; attempt <= r.cfg.MaxRetries; attempt++ {
+		if attempt > 0 {
+			r.retries.Add(1)
+			if err := sleepCtx(ctx, r.backoff(attempt)); err != nil {
+				return lastErr, true
+			}
+		}
+
+		release, err := r.acquire(ctx)
+		if err != nil {
+			return err, true
+		}
+		err = fn(ctx, model)
+		release()
+		if err == nil {
+			br.success()
+			settled = true
+			return nil, true
+		}
+		lastErr = err
+
+		class := classify(ctx, err)
+		if class == classPermanent {
+			return err, true
+		}
+		r.failures.Add(1)
+		if br.failure(r.cfg.BreakerThreshold, r.cfg.BreakerCooldown) {
+			settled = true
+			return lastErr, false // circuit terbuka → model berikutnya
+		}
+		if class == classNextModel {
+			return lastErr, false // model tidak tersedia → model berikutnya
+		}
+	}
+	return lastErr, false
+}
+
+func (r *Resilience) acquire(ctx context.Context) (func(), error) {
+	r.waiting.Add(1)
+	defer r.waiting.Add(-1)
+
+	var timeout <-chan time.Time
+	if r.cfg.QueueTimeout > 0 {
+		t := time.NewTimer(r.cfg.QueueTimeout)
+		defer t.Stop()
+		timeout = t.C
+	}
+	select {
+	case r.sem <- struct{}{}:
+		return func() { <-r.sem }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-timeout:
+		return nil, ErrQueueTimeout
+	}
+}
+
+// backoff: full jitter dalam [d/2, d], d = base·2^(attempt-1) dibatasi BackoffMax.
+func (r *Resilience) backoff(attempt int) time.Duration {
+	d := r.cfg.BackoffBase << (attempt - 1)
+	if d <= 0 || (r.cfg.BackoffMax > 0 && d > r.cfg.BackoffMax) {
+		d = r.cfg.BackoffMax
+	}
+	if d <= 0 {
+		return 0
+	}
+	half := d / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
+func (r *Resilience) breaker(model string) *breaker {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	b := r.breakers[model]
+	if b == nil {
+		b = &breaker{}
+		r.breakers[model] = b
+	}
+	return b
+}
+
+// ResilienceStats adalah snapshot untuk /metrics.
+type ResilienceStats struct {
+	InFlight  int
+	Waiting   int64
+	Retries   int64
+	Fallbacks int64
+	Failures  int64
+	Breakers  map[string]string // model → closed|open|half_open
+}
+
+// Stats mengembalikan snapshot counter & status breaker.
+func (r *Resilience) Stats() ResilienceStats {
+	s := ResilienceStats{
+		InFlight:  len(r.sem),
+		Waiting:   r.waiting.Load(),
+		Retries:   r.retries.Load(),
+		Fallbacks: r.fallbacks.Load(),
+		Failures:  r.failures.Load(),
+		Breakers:  map[string]string{},
+	}
+	r.mu.Lock()
+	models := make([]string, 0, len(r.breakers))
+	for m := range r.breakers {
+		models = append(models, m)
+	}
+	r.mu.Unlock()
+	sort.Strings(models)
+	for _, m := range models {
+		s.Breakers[m] = r.breaker(m).state()
+	}
+	return s
+}
+
+// ---- circuit breaker ----
+
+type breaker struct {
+	mu        sync.Mutex
+	fails     int
+	openUntil time.Time
+	probing   bool // half-open: satu panggilan percobaan sedang berjalan
+}
+
+// allow: ok = panggilan boleh jalan; probe = pemanggil memegang slot percobaan half-open
+// (harus diakhiri success, failure atau release).
+func (b *breaker) allow() (ok, probe bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.openUntil.IsZero() {
+		return true, false
+	}
+	if time.Now().Before(b.openUntil) || b.probing {
+		return false, false
+	}
+	b.probing = true // half-open
+	return true, true
+}
+
+func (b *breaker) success() {
+	b.mu.Lock()
+	b.fails, b.openUntil, b.probing = 0, time.Time{}, false
+	b.mu.Unlock()
+}
+
+// release mengakhiri percobaan half-open tanpa mengubah status (error non-layanan).
+func (b *breaker) release() {
+	b.mu.Lock()
+	b.probing = false
+	b.mu.Unlock()
+}
+
+// failure mencatat kegagalan; true bila circuit (kembali) terbuka.
+func (b *breaker) failure(threshold int, cooldown time.Duration) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.fails++
+	if b.probing || b.fails >= threshold {
+		b.openUntil = time.Now().Add(cooldown)
+		b.probing = false
+		return true
+	}
+	return false
+}
+
+func (b *breaker) state() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch {
+	case b.openUntil.IsZero():
+		return "closed"
+	case time.Now().Before(b.openUntil):
+		return "open"
+	default:
+		return "half_open"
+	}
+}
+
+// ---- klasifikasi error ----
+
+type errClass int
+
+const (
+	classRetryable errClass = iota
+	classNextModel          // model tidak tersedia → langsung fallback
+	classPermanent          // request salah / dibatalkan → kembalikan apa adanya
+)
+
+func classify(ctx context.Context, err error) errClass {
+	var nr noRetryError
+	if errors.As(err, &nr) {
+		return classPermanent
+	}
+	if ctx.Err() != nil || errors.Is(err, context.Canceled) {
+		return classPermanent
+	}
+	if errors.Is(err, ErrQueueTimeout) {
+		return classPermanent
+	}
+
+	status := 0
+	var apiErr *openai.APIError
+	var reqErr *openai.RequestError
+	switch {
+	case errors.As(err, &apiErr):
+		status = apiErr.HTTPStatusCode
+	case errors.As(err, &reqErr):
+		status = reqErr.HTTPStatusCode
+	}
+	switch {
+	case status == http.StatusTooManyRequests, status == http.StatusRequestTimeout, status >= 500:
+		return classRetryable
+	case status == http.StatusNotFound:
+		return classNextModel
+	case status >= 400:
+		return classPermanent
+	}
+	// tanpa status HTTP: timeout/koneksi terputus/EOF prematur → dianggap sementara
+	return classRetryable
+}
+
+func sleepCtx(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
@@ -0,0 +1,130 @@
+package llm_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	openai "github.com/sashabaranov/go-openai"
+
+	"mcp-oilgas/internal/llm"
+)
+
+func apiErr(code int) error {
+	return &openai.APIError{HTTPStatusCode: code, Message: http.StatusText(code)}
+}
+
+func testConfig() llm.ResilienceConfig {
+	return llm.ResilienceConfig{
+		MaxRetries:       2,
+		BackoffBase:      time.Millisecond,
+		BackoffMax:       2 * time.Millisecond,
+		FallbackModels:   []string{"fallback"},
+		MaxConcurrency:   2,
+		QueueTimeout:     time.Second,
+		BreakerThreshold: 3,
+		BreakerCooldown:  time.Hour,
+	}
+}
+
+func TestRetryThenSucceed(t *testing.T) {
+	r := llm.NewResilience(testConfig())
+	calls := 0
+	err := r.Do(context.Background(), "primary", func(ctx context.Context, model string) error {
+		calls++
+		if calls < 3 {
+			return apiErr(http.StatusTooManyRequests)
+		}
+		return nil
+	})
+	if err != nil || calls != 3 {
+		t.Fatalf("want success after 3 calls, got err=%v calls=%d", err, calls)
+	}
+}
+
+func TestFallbackAndBreaker(t *testing.T) {
+	r := llm.NewResilience(testConfig())
+	var used []string
+	fn := func(ctx context.Context, model string) error {
+		used = append(used, model)
+		if model == "primary" {
+			return apiErr(http.StatusServiceUnavailable)
+		}
+		return nil
+	}
+	if err := r.Do(context.Background(), "primary", fn); err != nil {
+		t.Fatalf("fallback should succeed: %v", err)
+	}
+	if used[len(used)-1] != "fallback" || len(used) != 4 {
+		t.Fatalf("want 3 primary attempts then fallback, got %v", used)
+	}
+	if st := r.Stats().Breakers["primary"]; st != "open" {
+		t.Fatalf("primary breaker should be open, got %q", st)
+	}
+
+	// circuit terbuka → langsung ke fallback tanpa menyentuh primary
+	used = nil
+	if err := r.Do(context.Background(), "primary", fn); err != nil || len(used) != 1 || used[0] != "fallback" {
+		t.Fatalf("want direct fallback, got used=%v err=%v", used, err)
+	}
+}
+
+func TestPermanentErrorNotRetried(t *testing.T) {
+	r := llm.NewResilience(testConfig())
+	calls := 0
+	err := r.Do(context.Background(), "primary", func(ctx context.Context, model string) error {
+		calls++
+		return apiErr(http.StatusBadRequest)
+	})
+	var ae *openai.APIError
+	if !errors.As(err, &ae) || calls != 1 {
+		t.Fatalf("want single call with 400, got err=%v calls=%d", err, calls)
+	}
+}
+
+// Probe half-open yang batal (ctx selesai saat menunggu slot) harus melepas slot percobaan,
+// supaya model tidak terkunci sampai proses restart.
+func TestHalfOpenProbeReleasedOnCancel(t *testing.T) {
+	cfg := testConfig()
+	cfg.FallbackModels = nil
+	cfg.MaxRetries = 0
+	cfg.MaxConcurrency = 1
+	cfg.BreakerThreshold = 1
+	cfg.BreakerCooldown = time.Millisecond
+	r := llm.NewResilience(cfg)
+
+	r.Do(context.Background(), "primary", func(ctx context.Context, model string) error {
+		return apiErr(http.StatusServiceUnavailable)
+	})
+	time.Sleep(5 * time.Millisecond) // → half-open
+
+	// tahan satu-satunya slot konkurensi lewat model lain
+	hold, started := make(chan struct{}), make(chan struct{})
+	go r.DoModel(context.Background(), "other", func(ctx context.Context, model string) error {
+		close(started)
+		<-hold
+		return nil
+	})
+	<-started
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+	err := r.Do(ctx, "primary", func(ctx context.Context, model string) error { return nil })
+	cancel()
+	close(hold)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("want deadline exceeded while queued, got %v", err)
+	}
+
+	calls := 0
+	err = r.Do(context.Background(), "primary", func(ctx context.Context, model string) error {
+		calls++
+		return nil
+	})
+	if err != nil || calls != 1 {
+		t.Fatalf("probe slot leaked: err=%v calls=%d", err, calls)
+	}
+	if st := r.Stats().Breakers["primary"]; st != "closed" {
+		t.Fatalf("breaker should close after successful probe, got %q", st)
+	}
+}
@@ -0,0 +1,88 @@
+// internal/llm/telemetry.go
+// Telemetri panggilan LLM: counter per operasi (untuk /metrics) + observer opsional.
+package llm
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Operasi yang dicatat.
+const (
+	OpChat   = "chat"
+	OpJSON   = "json"
+	OpStream = "stream"
+	OpEmbed  = "embed"
+)
+
+// CallInfo adalah ringkasan satu panggilan LLM (setelah retry/fallback selesai).
+type CallInfo struct {
+	Op               string
+	Model            string // model yang terakhir dicoba
+	Duration         time.Duration
+	Err              error
+	InputChars       int
+	OutputChars      int
+	PromptTokens     int
+	CompletionTokens int
+}
+
+// OpStats adalah akumulasi per operasi.
+type OpStats struct {
+	Op               string
+	Calls            int64
+	Errors           int64
+	TotalDuration    time.Duration
+	PromptTokens     int64
+	CompletionTokens int64
+}
+
+var (
+	telMu     sync.Mutex
+	telStats  = map[string]*OpStats{}
+	observers []func(CallInfo)
+)
+
+// Observe mendaftarkan fungsi yang dipanggil setiap kali satu panggilan LLM selesai
+// (mis. untuk audit log / tracing). Dipanggil sinkron; jaga agar cepat.
+func Observe(fn func(CallInfo)) {
+	telMu.Lock()
+	observers = append(observers, fn)
+	telMu.Unlock()
+}
+
+func record(c CallInfo) {
+	telMu.Lock()
+	st := telStats[c.Op]
+	if st == nil {
+		st = &OpStats{Op: c.Op}
+		telStats[c.Op] = st
+	}
+	st.Calls++
+	if c.Err != nil {
+		st.Errors++
+	}
+	st.TotalDuration += c.Duration
+	st.PromptTokens += int64(c.PromptTokens)
+	st.CompletionTokens += int64(c.CompletionTokens)
+	obs := make([]func(CallInfo), len(observers))
+	copy(obs, observers)
+	telMu.Unlock()
+
+	for _, fn := range obs {
+		fn(c)
+	}
+}
+
+// Telemetry mengembalikan snapshot statistik per operasi, terurut nama operasi.
+func Telemetry() []OpStats {
+	telMu.Lock()
+	defer telMu.Unlock()
+	out := make([]OpStats, 0, len(telStats))
+	for _, st := range telStats {
+		out = append(out, *st)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Op < out[j].Op })
+	return out
+}
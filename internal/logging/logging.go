@@ -4,8 +4,11 @@
 package logging
 
 import (
+	"io"
 	"log"
 	"os"
+
+	"mcp-oilgas/internal/redact"
 )
 
 var (
@@ -15,7 +18,11 @@ var (
 )
 
 func Init() {
-	Info = log.New(os.Stdout, "INFO: ", log.Ldate|log.Ltime|log.Lshortfile)
-	Warn = log.New(os.Stdout, "WARN: ", log.Ldate|log.Ltime|log.Lshortfile)
-	Error = log.New(os.Stderr, "ERROR: ", log.Ldate|log.Ltime|log.Lshortfile)
+	var stdout, stderr io.Writer = os.Stdout, os.Stderr
+	if redact.LogsEnabled() {
+		stdout, stderr = redact.NewWriter(stdout, nil), redact.NewWriter(stderr, nil)
+	}
+	Info = log.New(stdout, "INFO: ", log.Ldate|log.Ltime|log.Lshortfile)
+	Warn = log.New(stdout, "WARN: ", log.Ldate|log.Ltime|log.Lshortfile)
+	Error = log.New(stderr, "ERROR: ", log.Ldate|log.Ltime|log.Lshortfile)
 }
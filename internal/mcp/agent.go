@@ -0,0 +1,278 @@
+// internal/mcp/agent.go
+// Mode agen: LLM melihat ExecResult antara lalu menerbitkan panggilan tool lanjutan,
+// dibatasi jumlah langkah & waktu. Dipakai /chat/stream?mode=agent.
+package mcp
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"mcp-oilgas/internal/llm"
+	"mcp-oilgas/internal/prompts"
+)
+
+// JSONAnswerer adalah bagian dari llm.Client yang dibutuhkan agen.
+type JSONAnswerer interface {
+	AnswerJSON(ctx context.Context, user, system string) (string, error)
+}
+
+// AgentConfig membatasi loop agen.
+type AgentConfig struct {
+	MaxSteps         int           // termasuk langkah awal (plan dari planner)
+	TimeBudget       time.Duration // total waktu loop (eksekusi + keputusan LLM)
+	MaxRoutesPerStep int
+	MaxObsChars      int // batas ukuran JSON hasil per route yang dikirim balik ke LLM
+}
+
+// AgentConfigFromEnv membaca AGENT_MAX_STEPS, AGENT_TIME_BUDGET (detik), AGENT_MAX_ROUTES_PER_STEP.
+func AgentConfigFromEnv() AgentConfig {
+	cfg := AgentConfig{
+		MaxSteps:         4,
+		TimeBudget:       40 * time.Second,
+		MaxRoutesPerStep: 3,
+		MaxObsChars:      4000,
+	}
+	if n, err := strconv.Atoi(os.Getenv("AGENT_MAX_STEPS")); err == nil && n > 0 {
+		cfg.MaxSteps = n
+	}
+	if n, err := strconv.Atoi(os.Getenv("AGENT_TIME_BUDGET")); err == nil && n > 0 {
+		cfg.TimeBudget = time.Duration(n) * time.Second
+	}
+	if n, err := strconv.Atoi(os.Getenv("AGENT_MAX_ROUTES_PER_STEP")); err == nil && n > 0 {
+		cfg.MaxRoutesPerStep = n
+	}
+	return cfg
+}
+
+// AgentStep adalah satu iterasi agen (di-stream sebagai SSE).
+type AgentStep struct {
+	Index      int          `json:"index"`
+	Thought    string       `json:"thought,omitempty"`
+	Routes     []Route      `json:"routes,omitempty"`
+	Results    []ExecResult `json:"results,omitempty"`
+	DurationMS int64        `json:"duration_ms"`
+	Error      string       `json:"error,omitempty"`
+}
+
+// AgentHooks dipanggil di setiap titik penting loop; semua opsional.
+type AgentHooks struct {
+	OnStepStart func(step AgentStep) // sebelum eksekusi routes
+	OnStepDone  func(step AgentStep) // setelah eksekusi routes
+}
+
+// AgentRun adalah hasil akhir loop agen.
+type AgentRun struct {
+	Steps      []AgentStep  `json:"steps"`
+	Sources    []ExecResult `json:"-"`
+	StopReason string       `json:"stop_reason"` // final|max_steps|time_budget|canceled|no_new_routes|llm_error
+}
+
+// Agent menjalankan loop plan → execute → observe → (follow-up)*.
+type Agent struct {
+	LLM    JSONAnswerer
+	Tools  []llm.ToolLite
+	Config AgentConfig
+	// Screen (opsional) membersihkan hasil tool/dokumen sebelum dilihat LLM & dikembalikan
+	// sebagai Sources (mis. penyaringan prompt injection dari internal/safety).
+	Screen func(ctx context.Context, results []ExecResult) []ExecResult
+}
+
+type agentDecision struct {
+	Action  string  `json:"action"`
+	Thought string  `json:"thought"`
+	Routes  []Route `json:"routes"`
+}
+
+// Run mengeksekusi `initial` sebagai langkah pertama lalu meminta LLM memutuskan langkah berikutnya
+// sampai action=final atau anggaran langkah/waktu habis.
+func (a *Agent) Run(
+	ctx context.Context,
+	question string,
+	initial Plan,
+	ragFn RAGFunc,
+	hooks AgentHooks,
+) AgentRun {
+	cfg := a.Config
+	if cfg.MaxSteps <= 0 {
+		cfg = AgentConfigFromEnv()
+	}
+
+	loopCtx, cancel := context.WithTimeout(ctx, cfg.TimeBudget)
+	defer cancel()
+
+	run := AgentRun{StopReason: "max_steps"}
+	seen := map[string]struct{}{}
+	routes := initial.Routes
+	thought := initial.Reason
+
+	for i := 0; i < cfg.MaxSteps; i++ {
+		routes = dedupeRoutes(routes, seen, cfg.MaxRoutesPerStep)
+		if len(routes) == 0 {
+			run.StopReason = "no_new_routes"
+			break
+		}
+
+		started := time.Now()
+		step := AgentStep{Index: i + 1, Thought: thought, Routes: routes}
+		if hooks.OnStepStart != nil {
+			hooks.OnStepStart(step)
+		}
+
+		results, _ := ExecuteRoutes(loopCtx, routes, ragFn)
+		if a.Screen != nil {
+			results = a.Screen(loopCtx, results)
+		}
+		step.Results = results
+		step.DurationMS = time.Since(started).Milliseconds()
+		run.Steps = append(run.Steps, step)
+		run.Sources = append(run.Sources, results...)
+		if hooks.OnStepDone != nil {
+			hooks.OnStepDone(step)
+		}
+
+		if loopCtx.Err() != nil {
+			run.StopReason = ctxStopReason(ctx)
+			break
+		}
+		if i+1 >= cfg.MaxSteps {
+			break
+		}
+
+		dec, err := a.decide(loopCtx, question, run.Steps, cfg, cfg.MaxSteps-(i+1))
+		if err != nil {
+			if loopCtx.Err() != nil {
+				run.StopReason = ctxStopReason(ctx)
+			} else {
+				run.StopReason = "llm_error"
+				run.Steps[len(run.Steps)-1].Error = err.Error()
+			}
+			break
+		}
+		if !strings.EqualFold(dec.Action, "call") || len(dec.Routes) == 0 {
+			run.StopReason = "final"
+			break
+		}
+
+		// normalisasi ringan (rewrite RAG → rag_search_v2); pertanyaan kosong agar
+		// heuristik top-amount tidak menambah route di langkah lanjutan
+		next := NormalizePlan(loopCtx, "", Plan{Mode: "mcp", Routes: dec.Routes})
+		routes = next.Routes
+		thought = dec.Thought
+	}
+	return run
+}
+
+// ctxStopReason membedakan request yang dibatalkan klien (ctx induk) dari anggaran waktu agen yang habis.
+func ctxStopReason(parent context.Context) string {
+	if errors.Is(parent.Err(), context.Canceled) {
+		return "canceled"
+	}
+	return "time_budget"
+}
+
+func (a *Agent) decide(ctx context.Context, question string, steps []AgentStep, cfg AgentConfig, remaining int) (agentDecision, error) {
+	if a.LLM == nil {
+		return agentDecision{}, errors.New("agent: llm not configured")
+	}
+
+	ptools := make([]prompts.Tool, 0, len(a.Tools))
+	for _, t := range a.Tools {
+		ptools = append(ptools, prompts.Tool{Name: t.Name, Description: t.Description})
+	}
+	rp, err := prompts.Default().Render(prompts.AgentStep, prompts.Vars{
+		Lang:  "id",
+		Tools: ptools,
+		Extra: map[string]any{"max_routes": cfg.MaxRoutesPerStep, "remaining_steps": remaining},
+	})
+	if err != nil {
+		return agentDecision{}, err
+	}
+
+	type obs struct {
+		Route Route  `json:"route"`
+		Data  string `json:"data,omitempty"`
+		Error string `json:"error,omitempty"`
+	}
+	type stepObs struct {
+		Index   int    `json:"index"`
+		Thought string `json:"thought,omitempty"`
+		Results []obs  `json:"results"`
+	}
+	history := make([]stepObs, 0, len(steps))
+	for _, s := range steps {
+		so := stepObs{Index: s.Index, Thought: s.Thought}
+		for _, r := range s.Results {
+			so.Results = append(so.Results, obs{Route: r.Route, Data: truncateJSON(r.Data, cfg.MaxObsChars), Error: r.Error})
+		}
+		history = append(history, so)
+	}
+
+	payload := struct {
+		Question string         `json:"question"`
+		Tools    []llm.ToolLite `json:"tools"`
+		Steps    []stepObs      `json:"steps"`
+	}{Question: question, Tools: a.Tools, Steps: history}
+	ub, _ := json.Marshal(payload)
+
+	raw, err := a.LLM.AnswerJSON(ctx, string(ub), rp.Text)
+	if err != nil {
+		return agentDecision{}, fmt.Errorf("agent AnswerJSON: %w", err)
+	}
+	var dec agentDecision
+	if err := json.Unmarshal([]byte(strings.TrimSpace(raw)), &dec); err != nil {
+		return agentDecision{}, fmt.Errorf("agent decision unmarshal: %w", err)
+	}
+	return dec, nil
+}
+
+// dedupeRoutes membuang route yang sudah pernah dieksekusi (kind+tool+params+query sama)
+// dan memotong ke max route per langkah.
+func dedupeRoutes(routes []Route, seen map[string]struct{}, max int) []Route {
+	out := make([]Route, 0, len(routes))
+	for _, r := range routes {
+		if r.Kind == "" {
+			r.Kind = RouteMCP
+		}
+		h := sha256.New()
+		fmt.Fprintf(h, "%s|%s|%s|%s", r.Kind, r.Tool, strings.TrimSpace(string(r.Params)), r.Query)
+		if r.Filters != nil {
+			fb, _ := json.Marshal(r.Filters)
+			h.Write(fb)
+		}
+		key := hex.EncodeToString(h.Sum(nil))
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+		out = append(out, r)
+		if max > 0 && len(out) >= max {
+			break
+		}
+	}
+	return out
+}
+
+func truncateJSON(v any, max int) string {
+	if v == nil {
+		return ""
+	}
+	b, _ := json.Marshal(v)
+	if max > 0 && len(b) > max {
+		// mundur ke awal rune agar karakter multi-byte tidak terpotong (UTF-8 tetap valid)
+		n := max
+		for n > 0 && !utf8.RuneStart(b[n]) {
+			n--
+		}
+		return string(b[:n]) + "…(truncated)"
+	}
+	return string(b)
+}
@@ -0,0 +1,194 @@
+// internal/mcp/agent_test.go
+
+package mcp_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"mcp-oilgas/internal/mcp"
+	"mcp-oilgas/internal/repositories/documents"
+	mysqlrepo "mcp-oilgas/internal/repositories/mysql"
+)
+
+// fakeLLM mengembalikan keputusan agen dari fungsi next (dipanggil dengan nomor panggilan, mulai 1).
+type fakeLLM struct {
+	calls int
+	user  string // payload panggilan terakhir
+	next  func(ctx context.Context, n int) (string, error)
+}
+
+func (f *fakeLLM) AnswerJSON(ctx context.Context, user, system string) (string, error) {
+	f.calls++
+	f.user = user
+	return f.next(ctx, f.calls)
+}
+
+func callRAG(queries ...string) string {
+	routes := make([]map[string]any, 0, len(queries))
+	for _, q := range queries {
+		routes = append(routes, map[string]any{"kind": "rag", "query": q})
+	}
+	b, _ := json.Marshal(map[string]any{"action": "call", "thought": "lanjut", "routes": routes})
+	return string(b)
+}
+
+// initialPlan menormalkan plan awal seperti keluaran planner agar bisa dibandingkan dengan follow-up.
+func initialPlan(queries ...string) mcp.Plan {
+	p := mcp.Plan{Mode: "mcp", Reason: "awal"}
+	for _, q := range queries {
+		p.Routes = append(p.Routes, mcp.Route{Kind: mcp.RouteRAG, Query: q})
+	}
+	return mcp.NormalizePlan(context.Background(), "", p)
+}
+
+func okRAG(ctx context.Context, query string, topK int, f documents.Filter, opt mysqlrepo.HybridOptions) ([]map[string]any, error) {
+	return []map[string]any{{"snippet": "hasil " + query}}, nil
+}
+
+func agentCfg() mcp.AgentConfig {
+	return mcp.AgentConfig{MaxSteps: 3, TimeBudget: 5 * time.Second, MaxRoutesPerStep: 2, MaxObsChars: 200}
+}
+
+func TestAgentStopsOnFinal(t *testing.T) {
+	llm := &fakeLLM{next: func(context.Context, int) (string, error) {
+		return `{"action":"final","thought":"cukup"}`, nil
+	}}
+	a := &mcp.Agent{LLM: llm, Config: agentCfg()}
+	var started, done int
+	run := a.Run(context.Background(), "prosedur H2S", initialPlan("prosedur H2S"), okRAG, mcp.AgentHooks{
+		OnStepStart: func(mcp.AgentStep) { started++ },
+		OnStepDone:  func(mcp.AgentStep) { done++ },
+	})
+	if run.StopReason != "final" || len(run.Steps) != 1 || llm.calls != 1 {
+		t.Fatalf("want final after 1 step, got %q steps=%d calls=%d", run.StopReason, len(run.Steps), llm.calls)
+	}
+	if started != 1 || done != 1 || len(run.Sources) != 1 || run.Steps[0].Thought != "awal" {
+		t.Fatalf("unexpected hooks/sources: start=%d done=%d sources=%d step=%+v", started, done, len(run.Sources), run.Steps[0])
+	}
+}
+
+func TestAgentStepBudget(t *testing.T) {
+	llm := &fakeLLM{next: func(_ context.Context, n int) (string, error) {
+		return callRAG(fmt.Sprintf("lanjutan %d", n)), nil
+	}}
+	a := &mcp.Agent{LLM: llm, Config: agentCfg()}
+	run := a.Run(context.Background(), "q", initialPlan("awal"), okRAG, mcp.AgentHooks{})
+	// LLM tidak dipanggil lagi setelah langkah terakhir
+	if run.StopReason != "max_steps" || len(run.Steps) != 3 || llm.calls != 2 {
+		t.Fatalf("want max_steps after 3 steps, got %q steps=%d calls=%d", run.StopReason, len(run.Steps), llm.calls)
+	}
+	last := run.Steps[2]
+	if last.Index != 3 || last.Thought != "lanjut" || last.Routes[0].Query != "lanjutan 2" || last.Results[0].Error != "" {
+		t.Fatalf("unexpected last step: %+v", last)
+	}
+}
+
+func TestAgentDedupesRoutes(t *testing.T) {
+	// route yang sama dengan langkah awal dibuang; tersisa satu route baru
+	llm := &fakeLLM{next: func(_ context.Context, n int) (string, error) {
+		if n == 1 {
+			return callRAG("a", "c"), nil
+		}
+		return callRAG("a", "b", "c"), nil
+	}}
+	a := &mcp.Agent{LLM: llm, Config: agentCfg()}
+	run := a.Run(context.Background(), "q", initialPlan("a", "a", "b", "x"), okRAG, mcp.AgentHooks{})
+
+	if len(run.Steps) != 2 || run.StopReason != "no_new_routes" {
+		t.Fatalf("want no_new_routes after 2 steps, got %q steps=%d", run.StopReason, len(run.Steps))
+	}
+	// duplikat dalam satu langkah dibuang & dipotong ke MaxRoutesPerStep
+	if r := run.Steps[0].Routes; len(r) != 2 || r[0].Query != "a" || r[1].Query != "b" {
+		t.Fatalf("step 1 routes: %+v", r)
+	}
+	if r := run.Steps[1].Routes; len(r) != 1 || r[0].Query != "c" {
+		t.Fatalf("step 2 routes: %+v", r)
+	}
+}
+
+func TestAgentNoInitialRoutes(t *testing.T) {
+	llm := &fakeLLM{next: func(context.Context, int) (string, error) { return "", errors.New("unexpected call") }}
+	run := (&mcp.Agent{LLM: llm, Config: agentCfg()}).Run(context.Background(), "q", mcp.Plan{}, okRAG, mcp.AgentHooks{})
+	if run.StopReason != "no_new_routes" || len(run.Steps) != 0 || llm.calls != 0 {
+		t.Fatalf("got %q steps=%d calls=%d", run.StopReason, len(run.Steps), llm.calls)
+	}
+}
+
+func TestAgentTimeBudget(t *testing.T) {
+	cfg := agentCfg()
+	cfg.TimeBudget = 30 * time.Millisecond
+
+	// anggaran habis saat eksekusi route
+	slowRAG := func(ctx context.Context, query string, topK int, f documents.Filter, opt mysqlrepo.HybridOptions) ([]map[string]any, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	llm := &fakeLLM{next: func(context.Context, int) (string, error) { return callRAG("b"), nil }}
+	run := (&mcp.Agent{LLM: llm, Config: cfg}).Run(context.Background(), "q", initialPlan("a"), slowRAG, mcp.AgentHooks{})
+	if run.StopReason != "time_budget" || len(run.Steps) != 1 || llm.calls != 0 {
+		t.Fatalf("exec: got %q steps=%d calls=%d", run.StopReason, len(run.Steps), llm.calls)
+	}
+	if run.Steps[0].Results[0].Error == "" {
+		t.Fatalf("want route error on timeout: %+v", run.Steps[0].Results)
+	}
+
+	// anggaran habis saat menunggu keputusan LLM
+	llm = &fakeLLM{next: func(ctx context.Context, _ int) (string, error) {
+		<-ctx.Done()
+		return "", ctx.Err()
+	}}
+	run = (&mcp.Agent{LLM: llm, Config: cfg}).Run(context.Background(), "q", initialPlan("a"), okRAG, mcp.AgentHooks{})
+	if run.StopReason != "time_budget" || len(run.Steps) != 1 || run.Steps[0].Error != "" {
+		t.Fatalf("decide: got %q steps=%+v", run.StopReason, run.Steps)
+	}
+}
+
+func TestAgentCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	llm := &fakeLLM{next: func(ctx context.Context, _ int) (string, error) {
+		cancel()
+		<-ctx.Done()
+		return "", ctx.Err()
+	}}
+	run := (&mcp.Agent{LLM: llm, Config: agentCfg()}).Run(ctx, "q", initialPlan("a"), okRAG, mcp.AgentHooks{})
+	if run.StopReason != "canceled" || len(run.Steps) != 1 || run.Steps[0].Error != "" {
+		t.Fatalf("got %q steps=%+v", run.StopReason, run.Steps)
+	}
+}
+
+// Observasi yang dipotong MaxObsChars tidak boleh memotong rune multi-byte.
+func TestAgentTruncatesObservationOnRuneBoundary(t *testing.T) {
+	wideRAG := func(ctx context.Context, query string, topK int, f documents.Filter, opt mysqlrepo.HybridOptions) ([]map[string]any, error) {
+		return []map[string]any{{"snippet": strings.Repeat("é", 200)}}, nil
+	}
+	for _, max := range []int{100, 101} {
+		llm := &fakeLLM{next: func(context.Context, int) (string, error) { return `{"action":"final"}`, nil }}
+		cfg := agentCfg()
+		cfg.MaxObsChars = max
+		(&mcp.Agent{LLM: llm, Config: cfg}).Run(context.Background(), "q", initialPlan("a"), wideRAG, mcp.AgentHooks{})
+		if !strings.Contains(llm.user, "(truncated)") || strings.ContainsRune(llm.user, '\uFFFD') {
+			t.Fatalf("max=%d: observation split a rune: %s", max, llm.user)
+		}
+	}
+}
+
+func TestAgentLLMError(t *testing.T) {
+	cases := map[string]*mcp.Agent{
+		"error":    {LLM: &fakeLLM{next: func(context.Context, int) (string, error) { return "", errors.New("boom") }}},
+		"bad json": {LLM: &fakeLLM{next: func(context.Context, int) (string, error) { return "bukan json", nil }}},
+		"no llm":   {},
+	}
+	for name, a := range cases {
+		a.Config = agentCfg()
+		run := a.Run(context.Background(), "q", initialPlan("a"), okRAG, mcp.AgentHooks{})
+		if run.StopReason != "llm_error" || len(run.Steps) != 1 || run.Steps[0].Error == "" {
+			t.Fatalf("%s: got %q steps=%+v", name, run.StopReason, run.Steps)
+		}
+	}
+}
@@ -10,6 +10,9 @@ import (
 	"io"
 	"net/http"
 	"strings"
+
+	"mcp-oilgas/internal/repositories/documents"
+	mysqlrepo "mcp-oilgas/internal/repositories/mysql"
 )
 
 type ExecResult struct {
@@ -18,11 +21,15 @@ type ExecResult struct {
 	Error string      `json:"error,omitempty"`
 }
 
+// RAGFunc mengambil chunk dokumen untuk route RAG (filter kosong = tanpa batasan metadata;
+// opt = strategi fusi pilihan planner, kosong = default RAG_FUSION).
+type RAGFunc func(ctx context.Context, query string, topK int, f documents.Filter, opt mysqlrepo.HybridOptions) ([]map[string]any, error)
+
 // ExecuteRoutes menjalankan semua rute: MCP in-process dan/atau RAG.
 func ExecuteRoutes(
 	ctx context.Context,
 	routes []Route,
-	ragFn func(ctx context.Context, query string, topK int) ([]map[string]any, error),
+	ragFn RAGFunc,
 ) ([]ExecResult, error) {
 	var out []ExecResult
 
@@ -78,7 +85,16 @@ func ExecuteRoutes(
 			if topk <= 0 || topk > 50 {
 				topk = 10
 			}
-			hits, err := ragFn(ctx, r.Query, topk)
+			fp, err := RouteFilter(r)
+			if err != nil {
+				out = append(out, ExecResult{Route: r, Error: "invalid filters: " + err.Error()})
+				continue
+			}
+			var filter documents.Filter
+			if fp != nil {
+				filter = *fp
+			}
+			hits, err := ragFn(ctx, r.Query, topk, filter, RouteFusion(r))
 			if err != nil {
 				out = append(out, ExecResult{Route: r, Error: err.Error()})
 				continue
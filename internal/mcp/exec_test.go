@@ -0,0 +1,34 @@
+// internal/mcp/exec_test.go
+
+package mcp_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"mcp-oilgas/internal/mcp"
+	"mcp-oilgas/internal/repositories/documents"
+	mysqlrepo "mcp-oilgas/internal/repositories/mysql"
+)
+
+// Strategi fusi yang dipilih planner harus sampai ke retriever RAG.
+func TestExecuteRoutesPassesFusion(t *testing.T) {
+	p := mcp.NormalizePlan(context.Background(), "prosedur H2S", mcp.Plan{Routes: []mcp.Route{{
+		Kind:   mcp.RouteRAG,
+		Query:  "prosedur H2S",
+		Params: json.RawMessage(`{"fusion":"rrf","rrf_k":30,"debug":true}`),
+	}}})
+
+	var got mysqlrepo.HybridOptions
+	ragFn := func(ctx context.Context, query string, topK int, f documents.Filter, opt mysqlrepo.HybridOptions) ([]map[string]any, error) {
+		got = opt
+		return nil, nil
+	}
+	if _, err := mcp.ExecuteRoutes(context.Background(), p.Routes, ragFn); err != nil {
+		t.Fatalf("ExecuteRoutes: %v", err)
+	}
+	if got.Fusion != "rrf" || got.RRFK != 30 || !got.Debug {
+		t.Fatalf("fusion options not forwarded: %+v (params %s)", got, p.Routes[0].Params)
+	}
+}
@@ -7,6 +7,9 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+
+	"mcp-oilgas/internal/repositories/documents"
+	mysqlrepo "mcp-oilgas/internal/repositories/mysql"
 )
 
 type RouteKind string
@@ -23,6 +26,8 @@ type Route struct {
 	Params   json.RawMessage `json:"params,omitempty"`   // payload JSON utk handler tool (RAW)
 	Query    string          `json:"query,omitempty"`    // utk RAG
 	TopK     int             `json:"top_k,omitempty"`    // utk RAG
+	// Filters (opsional, utk RAG) membatasi dokumen berdasarkan metadata (jenis, area, sumur, revisi, ...).
+	Filters *documents.Filter `json:"filters,omitempty"`
 }
 
 type Plan struct {
@@ -68,8 +73,13 @@ func NormalizePlan(ctx context.Context, question string, p Plan) Plan {
 			body := map[string]any{
 				"query": q,
 				"top_k": pickTopK(r.TopK, 10),
-				"alpha": 0.6,
+				"alpha": mysqlrepo.AlphaFromEnv(),
+			}
+			if f, err := RouteFilter(*r); err == nil && f != nil {
+				r.Filters = f
+				body["filters"] = f
 			}
+			AddFusionParams(body, RouteFusion(*r))
 			b, _ := json.Marshal(body)
 			r.Tool = "rag_search_v2"
 			r.Params = b
@@ -94,7 +104,7 @@ func NormalizePlan(ctx context.Context, question string, p Plan) Plan {
 				body := map[string]any{
 					"query": q,
 					"top_k": pickTopK(r.TopK, 10),
-					"alpha": 0.6,
+					"alpha": mysqlrepo.AlphaFromEnv(),
 				}
 				b, _ := json.Marshal(body)
 				r.Kind = RouteRAG
@@ -160,7 +170,7 @@ func NormalizePlan(ctx context.Context, question string, p Plan) Plan {
 			Endpoint: "/rag/search_v2",
 			Query:    "Sebutkan vendor, status, dan ETA dari PO dengan nilai (amount) tertinggi.",
 			TopK:     10,
-			Params:   mustJSON(map[string]any{"query": "Sebutkan vendor, status, dan ETA dari PO dengan nilai (amount) tertinggi.", "top_k": 10, "alpha": 0.6}),
+			Params:   mustJSON(map[string]any{"query": "Sebutkan vendor, status, dan ETA dari PO dengan nilai (amount) tertinggi.", "top_k": 10, "alpha": mysqlrepo.AlphaFromEnv()}),
 		})
 	}
 
@@ -175,6 +185,57 @@ func NormalizePlan(ctx context.Context, question string, p Plan) Plan {
 	return p
 }
 
+// RouteFilter mengambil filter metadata route RAG: field Filters, atau params.filters dari planner.
+// (nil, nil) bila tidak ada filter.
+func RouteFilter(r Route) (*documents.Filter, error) {
+	if r.Filters != nil {
+		return r.Filters, nil
+	}
+	if isJSONNullOrEmpty(r.Params) {
+		return nil, nil
+	}
+	var tmp struct {
+		Filters json.RawMessage `json:"filters"`
+	}
+	if err := json.Unmarshal(r.Params, &tmp); err != nil || isJSONNullOrEmpty(tmp.Filters) {
+		return nil, nil
+	}
+	var f documents.Filter
+	if err := json.Unmarshal(tmp.Filters, &f); err != nil {
+		return nil, err
+	}
+	if f.Empty() {
+		return nil, nil
+	}
+	return &f, nil
+}
+
+// RouteFusion membaca opsi fusi rag_search_v2 (fusion, rrf_k, debug) dari params route.
+func RouteFusion(r Route) mysqlrepo.HybridOptions {
+	var tmp struct {
+		Fusion string `json:"fusion"`
+		RRFK   int    `json:"rrf_k"`
+		Debug  bool   `json:"debug"`
+	}
+	if isJSONNullOrEmpty(r.Params) || json.Unmarshal(r.Params, &tmp) != nil {
+		return mysqlrepo.HybridOptions{}
+	}
+	return mysqlrepo.HybridOptions{Fusion: tmp.Fusion, RRFK: tmp.RRFK, Debug: tmp.Debug}
+}
+
+// AddFusionParams menulis opsi fusi yang terisi ke body/payload rag_search_v2.
+func AddFusionParams(body map[string]any, opt mysqlrepo.HybridOptions) {
+	if opt.Fusion != "" {
+		body["fusion"] = opt.Fusion
+	}
+	if opt.RRFK > 0 {
+		body["rrf_k"] = opt.RRFK
+	}
+	if opt.Debug {
+		body["debug"] = true
+	}
+}
+
 func hasRAG(routes []Route) bool {
 	for _, r := range routes {
 		if r.Kind == RouteRAG {
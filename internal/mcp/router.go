@@ -17,7 +17,9 @@ import (
 	"strings"
 	"time"
 
-	"mcp-oilgas/internal/mcp/llm"
+	"mcp-oilgas/internal/llm"
+	"mcp-oilgas/internal/prompts"
+	"mcp-oilgas/internal/redact"
 )
 
 // ====== Structured log payload ======
@@ -30,19 +32,26 @@ type mcpLog struct {
 	Question        string `json:"question,omitempty"`
 	RequestTool     string `json:"request_tool,omitempty"`
 	ChosenTool      string `json:"chosen_tool,omitempty"`
-	DecisionBy      string `json:"decision_by,omitempty"` // explicit|llm|keyword|default|explicit-plan
+	DecisionBy      string `json:"decision_by,omitempty"` // explicit|llm|keyword|semantic|default|explicit-plan
 	CatalogCount    int    `json:"catalog_count,omitempty"`
 	RegisteredCount int    `json:"registered_count,omitempty"`
 	HasAPIKey       bool   `json:"has_api_key"`
 	DurationMS      int64  `json:"duration_ms,omitempty"`
+	PromptVersion   string `json:"prompt_version,omitempty"`
 	Error           string `json:"error,omitempty"`
 }
 
 func logJSON(l mcpLog) {
 	l.At = time.Now().Format(time.RFC3339Nano)
+	if l.PromptVersion == "" {
+		l.PromptVersion = prompts.Default().Version()
+	}
 	if l.Level == "" {
 		l.Level = "info"
 	}
+	// pertanyaan & pesan error bisa berisi PII/secret
+	l.Question = redact.Log(l.Question)
+	l.Error = redact.Log(l.Error)
 	b, _ := json.Marshal(l)
 	log.Println(string(b))
 }
@@ -295,6 +304,7 @@ func RouterHandler(w http.ResponseWriter, r *http.Request) {
 						payload["lang"] = lang
 					}
 				}
+				AddFusionParams(payload, RouteFusion(rt))
 
 				buf, _ := json.Marshal(payload)
 
@@ -334,10 +344,12 @@ func RouterHandler(w http.ResponseWriter, r *http.Request) {
 		}
 
 		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-Prompt-Version", prompts.Default().Version())
 		_ = json.NewEncoder(w).Encode(map[string]any{
 			"mode":            "mcp",
 			"routes_executed": len(normRoutes),
 			"items":           results,
+			"prompt_version":  prompts.Default().Version(),
 		})
 
 		logJSON(mcpLog{
@@ -428,6 +440,14 @@ func RouterHandler(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	// 3.5) Routing semantik via embedding (jalan juga tanpa LLM: EMBED_PROVIDER=local)
+	if tool == "" {
+		if chosen := chooseToolSemantic(r.Context(), question); chosen != "" {
+			tool = chosen
+			decision = "semantic"
+		}
+	}
+
 	// 4) Default final
 	if tool == "" {
 		tool = "answer_with_docs"
@@ -477,6 +497,7 @@ func RouterHandler(w http.ResponseWriter, r *http.Request) {
 
 	// 5) Execute (single tool, kompatibel lama)
 	h, ok := Get(tool)
+	w.Header().Set("X-Prompt-Version", prompts.Default().Version())
 	if !ok {
 		resp := ToolResponse{Success: false, Error: "tool not found: " + tool}
 		w.Header().Set("Content-Type", "application/json")
@@ -599,12 +620,12 @@ func chooseToolWithLLM(ctx context.Context, question string) string {
 		return ""
 	}
 
-	client, err := llm.NewFromEnv()
+	client, err := llm.Shared()
 	if err != nil {
 		return ""
 	}
 
-	system := llmSystemPromptID()
+	system := llmSystemPromptID(filtered)
 	user := buildChooserUserPrompt(question, filtered)
 
 	// Timeout singkat agar responsif
@@ -628,11 +649,17 @@ func chooseToolWithLLM(ctx context.Context, question string) string {
 	return ""
 }
 
-func llmSystemPromptID() string {
-	return `Anda adalah agen router.
-- Pilih tepat SATU nama tool dari daftar.
-- Balas hanya dengan nama tool (misal: get_timeseries).
-- Jika ragu, pilih "answer_with_docs".`
+// llmSystemPromptID merender template "tool_chooser" (lihat internal/prompts).
+func llmSystemPromptID(defs []ToolDef) string {
+	tools := make([]prompts.Tool, 0, len(defs))
+	for _, d := range defs {
+		tools = append(tools, prompts.Tool{Name: d.Name, Description: d.Description})
+	}
+	rp, err := prompts.Default().Render(prompts.ToolChooser, prompts.Vars{Lang: "id", Tools: tools})
+	if err != nil {
+		log.Printf("[router] render prompt: %v", err)
+	}
+	return rp.Text
 }
 
 func buildChooserUserPrompt(question string, defs []ToolDef) string {
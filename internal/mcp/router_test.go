@@ -9,10 +9,7 @@ import (
 	"net/http/httptest"
 	"testing"
 
-	"github.com/gorilla/mux"
-
 	apppkg "mcp-oilgas/internal/app"
-	"mcp-oilgas/internal/mcp"
 )
 
 // Payload minimal agar AnswerWithDocsHandler bisa jalan tanpa LLM (fallback extractive)
@@ -44,11 +41,6 @@ func TestMCPRouteExecutesRegisteredTool(t *testing.T) {
 	}
 	rawPayload, _ := json.Marshal(p)
 
-	reqBody, _ := json.Marshal(toolRequest{
-		Tool: "answer_with_docs",
-		// Payload diteruskan sebagai body ke handler target oleh RouterHandler
-		Payload: map[string]interface{}{},
-	})
 	// Ganti body agar sesuai struktur yang di-forward (payload langsung sebagai body):
 	// RouterHandler akan memilih forward=req.Payload jika ada;
 	// supaya itu terjadi, kita isi field Payload kosong—lalu langsung gunakan rawPayload sebagai body request.
@@ -69,7 +61,7 @@ func TestMCPRouteExecutesRegisteredTool(t *testing.T) {
 	// sehingga RouterHandler akan memilih forward=req.Payload.
 	bodyWrapper := map[string]any{
 		"tool": "answer_with_docs",
-		"payload": map[string]any{
+		"params": map[string]any{
 			"question": "Apa isi dokumen?",
 			"retrieved_chunks": []map[string]any{
 				{"doc_id": "DOC_X", "snippet": "Ini konten sampel dokumen untuk diuji."},
@@ -0,0 +1,120 @@
+// internal/mcp/semantic.go
+// Routing semantik: pilih tool berdasarkan kemiripan embedding pertanyaan dengan nama + deskripsi
+// tool. Dipakai setelah LLM chooser & keyword gagal, sehingga tetap jalan tanpa LLM
+// (EMBED_PROVIDER=local di rig air-gapped / CI).
+//
+//	ROUTER_SEMANTIC=off               menonaktifkan
+//	ROUTER_SEMANTIC_MIN_SCORE=0.2     cosine minimum agar tool dipilih
+package mcp
+
+import (
+	"context"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"mcp-oilgas/internal/llm"
+)
+
+// vektor tool di-cache per model embedding (katalog tool statis)
+var (
+	semMu    sync.Mutex
+	semModel string
+	semTools map[string][]float32
+)
+
+func semanticMinScore() float64 {
+	if f, err := strconv.ParseFloat(strings.TrimSpace(os.Getenv("ROUTER_SEMANTIC_MIN_SCORE")), 64); err == nil {
+		return f
+	}
+	return 0.2
+}
+
+func chooseToolSemantic(ctx context.Context, question string) string {
+	if strings.EqualFold(strings.TrimSpace(os.Getenv("ROUTER_SEMANTIC")), "off") || strings.TrimSpace(question) == "" {
+		return ""
+	}
+	emb, err := llm.SharedEmbedder()
+	if err != nil {
+		return ""
+	}
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, 3*time.Second)
+		defer cancel()
+	}
+	tools, err := toolVectors(ctx, emb)
+	if err != nil || len(tools) == 0 {
+		return ""
+	}
+	qv, err := emb.Embed(ctx, []string{question})
+	if err != nil || len(qv) != 1 {
+		return ""
+	}
+	best, bestScore := "", semanticMinScore()
+	for name, tv := range tools {
+		if s := cosine(qv[0], tv); s >= bestScore {
+			best, bestScore = name, s
+		}
+	}
+	return best
+}
+
+// toolVectors meng-embed tool terdaftar (nama + deskripsi) sekali per model.
+func toolVectors(ctx context.Context, emb llm.Embedder) (map[string][]float32, error) {
+	semMu.Lock()
+	defer semMu.Unlock()
+	if semTools != nil && semModel == emb.EmbedModel() {
+		return semTools, nil
+	}
+	defs, err := LoadToolDefs()
+	if err != nil {
+		return nil, err
+	}
+	reg := map[string]bool{}
+	for _, name := range List() {
+		reg[strings.ToLower(name)] = true
+	}
+	var names, texts []string
+	for _, d := range defs {
+		if !reg[strings.ToLower(d.Name)] {
+			continue
+		}
+		names = append(names, d.Name)
+		texts = append(texts, strings.ReplaceAll(d.Name, "_", " ")+"\n"+d.Description)
+	}
+	if len(texts) == 0 {
+		return nil, nil
+	}
+	vecs, err := emb.Embed(ctx, texts)
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[string][]float32, len(names))
+	for i, n := range names {
+		if i < len(vecs) {
+			out[n] = vecs[i]
+		}
+	}
+	semModel, semTools = emb.EmbedModel(), out
+	return out, nil
+}
+
+func cosine(a, b []float32) float64 {
+	if len(a) != len(b) {
+		return 0
+	}
+	var dot, na, nb float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		na += float64(a[i]) * float64(a[i])
+		nb += float64(b[i]) * float64(b[i])
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+}
@@ -0,0 +1,281 @@
+// internal/prompts/prompts.go
+// Template prompt (system prompt LLM) yang bisa di-versioning & di-reload tanpa rebuild.
+//
+// Sumber template:
+//  1. Bawaan (embed) di internal/prompts/templates/*.tmpl
+//  2. Override dari folder PROMPTS_DIR (default "configs/prompts") bila file dengan nama sama ada.
+//
+// Setiap template boleh mendeklarasikan versinya di baris pertama:
+//
+//	{{- /* version: 3 */ -}}
+//
+// Versi set (Version()) = hash pendek seluruh template aktif, dipakai untuk korelasi
+// kualitas jawaban dengan versi prompt.
+package prompts
+
+import (
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+)
+
+// Nama template yang dikenal aplikasi.
+const (
+	ChatSynth      = "chat_synth"      // synthesizer SSE /chat/stream
+	AskSynth       = "ask_synth"       // synthesizer /api/ask
+	DocsAnswer     = "docs_answer"     // answer_with_docs
+	ToolChooser    = "tool_chooser"    // pemilih tool tunggal di /mcp/route
+	Planner        = "planner"         // planner JSON-mode (multi-route)
+	AgentStep      = "agent_step"      // keputusan langkah lanjutan mode agen
+	InjectionCheck = "injection_check" // klasifier prompt injection (internal/safety)
+	Rerank         = "rerank"          // reranker pointwise berbasis LLM (internal/rerank)
+	QueryRewrite   = "query_rewrite"   // terjemahan, multi-query & HyDE (internal/queryrewrite)
+)
+
+//go:embed templates/*.tmpl
+var builtinFS embed.FS
+
+// Tool adalah ringkasan tool yang bisa dirujuk template.
+type Tool struct {
+	Name        string
+	Description string
+}
+
+// Vars adalah variabel yang tersedia di setiap template.
+type Vars struct {
+	Lang  string         // "id" | "en"
+	Date  string         // YYYY-MM-DD (UTC); diisi otomatis bila kosong
+	Tools []Tool         // daftar tool yang tersedia
+	Extra map[string]any // variabel tambahan per template
+}
+
+// ToolNames mengembalikan nama tool dipisah koma (helper untuk template).
+func (v Vars) ToolNames() string {
+	names := make([]string, 0, len(v.Tools))
+	for _, t := range v.Tools {
+		names = append(names, t.Name)
+	}
+	return strings.Join(names, ", ")
+}
+
+// Rendered adalah hasil render satu template.
+type Rendered struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	Text    string `json:"-"`
+}
+
+// Ref mengembalikan "name@version" untuk logging/telemetri.
+func (r Rendered) Ref() string { return r.Name + "@" + r.Version }
+
+type entry struct {
+	name    string
+	version string
+	source  string // "builtin" | path file override
+	tpl     *template.Template
+}
+
+// Store menyimpan template aktif (thread-safe).
+type Store struct {
+	mu      sync.RWMutex
+	dir     string
+	entries map[string]*entry
+	setVer  string
+	loaded  time.Time
+}
+
+var reVersion = regexp.MustCompile(`^\s*\{\{-?\s*/\*\s*version:\s*([^\s*]+)\s*\*/\s*-?\}\}`)
+
+// NewStore membuat Store baru dan memuat template dari builtin + dir override.
+func NewStore(dir string) (*Store, error) {
+	s := &Store{dir: dir}
+	if err := s.Reload(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+var (
+	defaultStore *Store
+	defaultOnce  sync.Once
+)
+
+// Default mengembalikan Store global (PROMPTS_DIR, default "configs/prompts").
+// Bila override gagal di-parse saat start, Store tetap dibuat dari template bawaan.
+func Default() *Store {
+	defaultOnce.Do(func() {
+		dir := strings.TrimSpace(os.Getenv("PROMPTS_DIR"))
+		if dir == "" {
+			dir = "configs/prompts"
+		}
+		s, err := NewStore(dir)
+		if err != nil {
+			s = &Store{dir: dir}
+			_ = s.load(false)
+		}
+		defaultStore = s
+	})
+	return defaultStore
+}
+
+var (
+	builtinStore *Store
+	builtinOnce  sync.Once
+)
+
+// Builtin mengembalikan Store yang hanya berisi template bawaan (embed), tanpa override.
+// Render memakainya sebagai fallback bila template override gagal dirender.
+func Builtin() *Store {
+	builtinOnce.Do(func() {
+		builtinStore = &Store{}
+		_ = builtinStore.load(false)
+	})
+	return builtinStore
+}
+
+// Reload memuat ulang template. Jika ada template override yang gagal di-parse,
+// template lama dipertahankan dan error dikembalikan.
+func (s *Store) Reload() error { return s.load(true) }
+
+func (s *Store) load(withOverrides bool) error {
+	next := map[string]*entry{}
+
+	builtins, err := fs.Glob(builtinFS, "templates/*.tmpl")
+	if err != nil {
+		return err
+	}
+	for _, p := range builtins {
+		b, err := builtinFS.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		e, err := parseEntry(strings.TrimSuffix(filepath.Base(p), ".tmpl"), string(b), "builtin")
+		if err != nil {
+			return err
+		}
+		next[e.name] = e
+	}
+
+	if withOverrides && s.dir != "" {
+		files, _ := filepath.Glob(filepath.Join(s.dir, "*.tmpl"))
+		for _, p := range files {
+			b, err := os.ReadFile(p)
+			if err != nil {
+				return fmt.Errorf("read prompt %s: %w", p, err)
+			}
+			e, err := parseEntry(strings.TrimSuffix(filepath.Base(p), ".tmpl"), string(b), p)
+			if err != nil {
+				return err
+			}
+			next[e.name] = e
+		}
+	}
+
+	s.mu.Lock()
+	s.entries = next
+	s.setVer = setVersion(next)
+	s.loaded = time.Now()
+	s.mu.Unlock()
+	return nil
+}
+
+func parseEntry(name, text, source string) (*entry, error) {
+	tpl, err := template.New(name).Option("missingkey=zero").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("parse prompt %s (%s): %w", name, source, err)
+	}
+	ver := "0"
+	if m := reVersion.FindStringSubmatch(text); len(m) == 2 {
+		ver = m[1]
+	}
+	// sertakan hash isi agar edit tanpa bump versi tetap terlacak
+	sum := sha256.Sum256([]byte(text))
+	ver = ver + "-" + hex.EncodeToString(sum[:])[:8]
+	return &entry{name: name, version: ver, source: source, tpl: tpl}, nil
+}
+
+func setVersion(m map[string]*entry) string {
+	names := make([]string, 0, len(m))
+	for n := range m {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	h := sha256.New()
+	for _, n := range names {
+		fmt.Fprintf(h, "%s@%s\n", n, m[n].version)
+	}
+	return hex.EncodeToString(h.Sum(nil))[:12]
+}
+
+// Render mengeksekusi template `name` dengan variabel v. Bila template override gagal
+// dieksekusi, template bawaan dengan nama sama dipakai (versi ikut versi bawaan) agar satu
+// file rusak di PROMPTS_DIR tidak mematikan pemanggil.
+func (s *Store) Render(name string, v Vars) (Rendered, error) {
+	s.mu.RLock()
+	e, ok := s.entries[name]
+	s.mu.RUnlock()
+	if !ok {
+		return Rendered{}, fmt.Errorf("prompt template not found: %s", name)
+	}
+	if v.Date == "" {
+		v.Date = time.Now().UTC().Format("2006-01-02")
+	}
+	var b strings.Builder
+	if err := e.tpl.Execute(&b, v); err != nil {
+		if e.source != "builtin" {
+			if rp, berr := Builtin().Render(name, v); berr == nil {
+				log.Printf("[prompts] render %s (%s): %v; memakai template bawaan", name, e.source, err)
+				return rp, nil
+			}
+		}
+		return Rendered{}, fmt.Errorf("render prompt %s: %w", name, err)
+	}
+	return Rendered{Name: name, Version: e.version, Text: strings.TrimSpace(b.String())}, nil
+}
+
+// Version mengembalikan versi set prompt aktif.
+func (s *Store) Version() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.setVer
+}
+
+// Info adalah ringkasan template aktif (untuk endpoint admin).
+type Info struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	Source  string `json:"source"`
+}
+
+// List mengembalikan daftar template aktif, terurut nama.
+func (s *Store) List() []Info {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make([]Info, 0, len(s.entries))
+	for _, e := range s.entries {
+		out = append(out, Info{Name: e.name, Version: e.version, Source: e.source})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// Dir mengembalikan folder override yang dipakai Store.
+func (s *Store) Dir() string { return s.dir }
+
+// LoadedAt mengembalikan waktu terakhir template dimuat.
+func (s *Store) LoadedAt() time.Time {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.loaded
+}
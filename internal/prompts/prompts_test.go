@@ -0,0 +1,102 @@
+// internal/prompts/prompts_test.go
+
+package prompts_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"mcp-oilgas/internal/prompts"
+)
+
+// Template bawaan harus bisa dirender untuk kedua bahasa.
+func TestBuiltinChatSynthByLang(t *testing.T) {
+	s, err := prompts.NewStore("")
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	en, err := s.Render(prompts.ChatSynth, prompts.Vars{Lang: "en"})
+	if err != nil {
+		t.Fatalf("render en: %v", err)
+	}
+	if !strings.HasPrefix(en.Text, "You are a technical assistant.") {
+		t.Fatalf("unexpected en prompt: %q", en.Text)
+	}
+	id, _ := s.Render(prompts.ChatSynth, prompts.Vars{Lang: "id"})
+	if !strings.HasPrefix(id.Text, "Anda adalah asisten teknis.") {
+		t.Fatalf("unexpected id prompt: %q", id.Text)
+	}
+	if !strings.HasPrefix(en.Version, "2-") {
+		t.Fatalf("expected declared version 2, got %q", en.Version)
+	}
+}
+
+// Override di folder + Reload harus mengganti template & versi set tanpa rebuild.
+func TestOverrideAndReload(t *testing.T) {
+	dir := t.TempDir()
+	s, err := prompts.NewStore(dir)
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	before := s.Version()
+
+	body := "{{- /* version: 7 */ -}}\nRouter {{.Lang}} {{.Date}}: {{.ToolNames}}"
+	if err := os.WriteFile(filepath.Join(dir, "tool_chooser.tmpl"), []byte(body), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Reload(); err != nil {
+		t.Fatalf("Reload: %v", err)
+	}
+	if s.Version() == before {
+		t.Fatalf("expected set version to change after override")
+	}
+	rp, err := s.Render(prompts.ToolChooser, prompts.Vars{
+		Lang:  "en",
+		Date:  "2025-09-01",
+		Tools: []prompts.Tool{{Name: "get_timeseries"}, {Name: "answer_with_docs"}},
+	})
+	if err != nil {
+		t.Fatalf("render: %v", err)
+	}
+	if rp.Text != "Router en 2025-09-01: get_timeseries, answer_with_docs" {
+		t.Fatalf("unexpected render: %q", rp.Text)
+	}
+	if !strings.HasPrefix(rp.Version, "7-") {
+		t.Fatalf("expected version 7, got %q", rp.Version)
+	}
+
+	// template rusak → error, set lama dipertahankan
+	good := s.Version()
+	_ = os.WriteFile(filepath.Join(dir, "tool_chooser.tmpl"), []byte("{{ .Lang "), 0o644)
+	if err := s.Reload(); err == nil {
+		t.Fatalf("expected parse error on broken template")
+	}
+	if s.Version() != good {
+		t.Fatalf("expected previous prompt set to be kept on reload error")
+	}
+}
+
+// Override yang lolos parse tapi gagal dieksekusi → Render jatuh ke template bawaan.
+func TestRenderFallsBackToBuiltin(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "chat_synth.tmpl"), []byte("{{ .Missing.Field }}"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "custom.tmpl"), []byte("{{ .Missing.Field }}"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	s, err := prompts.NewStore(dir)
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	rp, err := s.Render(prompts.ChatSynth, prompts.Vars{Lang: "en"})
+	if err != nil || !strings.HasPrefix(rp.Text, "You are a technical assistant.") || !strings.HasPrefix(rp.Version, "2-") {
+		t.Fatalf("builtin fallback: %+v %v", rp, err)
+	}
+	// tanpa template bawaan dengan nama sama → error tetap dikembalikan
+	if _, err := s.Render("custom", prompts.Vars{}); err == nil {
+		t.Fatalf("expected render error for override without builtin")
+	}
+}
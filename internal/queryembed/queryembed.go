@@ -0,0 +1,215 @@
+// internal/queryembed/queryembed.go
+// Embedding query di sisi server untuk retrieval hybrid (/rag/search_v2): LRU in-memory →
+// cache persisten MySQL (query_embeddings) → provider (llm.Embedder), dibatasi timeout.
+// Pemanggil yang mendapat error (timeout/provider mati) melanjutkan dengan BM25 saja.
+//
+// Cache persisten hanya menyimpan hash query (sha256), bukan teksnya.
+package queryembed
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"errors"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"mcp-oilgas/internal/llm"
+	"mcp-oilgas/pkg/vector"
+)
+
+// Source menjelaskan asal embedding query.
+type Source string
+
+const (
+	SourceNone     Source = "none"
+	SourceClient   Source = "client"   // dikirim pemanggil (query_embedding)
+	SourceLRU      Source = "cache"    // LRU in-memory
+	SourceStore    Source = "cache_db" // tabel query_embeddings
+	SourceProvider Source = "provider" // baru dihitung oleh embedder
+)
+
+// ErrUnavailable dikembalikan bila tidak ada embedder.
+var ErrUnavailable = errors.New("query embedder unavailable")
+
+// Config dibaca dari env QUERY_EMBED_*.
+type Config struct {
+	Enabled bool
+	Timeout time.Duration // batas total (cache DB + provider)
+	LRUSize int
+	Persist bool          // simpan/baca tabel query_embeddings
+	TTL     time.Duration // umur entri cache DB; 0 = tanpa batas
+}
+
+// ConfigFromEnv: QUERY_EMBED (on|off), QUERY_EMBED_TIMEOUT_MS (1500), QUERY_EMBED_CACHE_SIZE (2000),
+// QUERY_EMBED_CACHE_PERSIST (true), QUERY_EMBED_CACHE_TTL_HOURS (720).
+func ConfigFromEnv() Config {
+	cfg := Config{Enabled: true, Timeout: 1500 * time.Millisecond, LRUSize: 2000, Persist: true, TTL: 720 * time.Hour}
+	switch strings.ToLower(strings.TrimSpace(os.Getenv("QUERY_EMBED"))) {
+	case "off", "false", "0", "no":
+		cfg.Enabled = false
+	}
+	if n, err := strconv.Atoi(os.Getenv("QUERY_EMBED_TIMEOUT_MS")); err == nil && n > 0 {
+		cfg.Timeout = time.Duration(n) * time.Millisecond
+	}
+	if n, err := strconv.Atoi(os.Getenv("QUERY_EMBED_CACHE_SIZE")); err == nil && n >= 0 {
+		cfg.LRUSize = n
+	}
+	if b, err := strconv.ParseBool(os.Getenv("QUERY_EMBED_CACHE_PERSIST")); err == nil {
+		cfg.Persist = b
+	}
+	if n, err := strconv.Atoi(os.Getenv("QUERY_EMBED_CACHE_TTL_HOURS")); err == nil && n >= 0 {
+		cfg.TTL = time.Duration(n) * time.Hour
+	}
+	return cfg
+}
+
+// Embedder menghasilkan embedding query dengan cache berlapis.
+type Embedder struct {
+	emb llm.Embedder
+	db  *sql.DB // nil = tanpa cache persisten
+	cfg Config
+
+	mu    sync.Mutex
+	ll    *list.List // depan = paling baru dipakai
+	items map[[32]byte]*list.Element
+}
+
+type entry struct {
+	key [32]byte
+	vec []float32
+}
+
+// New membuat Embedder; db boleh nil.
+func New(emb llm.Embedder, db *sql.DB, cfg Config) *Embedder {
+	if !cfg.Persist {
+		db = nil
+	}
+	return &Embedder{emb: emb, db: db, cfg: cfg, ll: list.New(), items: map[[32]byte]*list.Element{}}
+}
+
+// Normalize merapikan spasi query; teks inilah yang di-embed dan di-hash.
+func Normalize(q string) string { return strings.Join(strings.Fields(q), " ") }
+
+func (e *Embedder) key(q string) [32]byte {
+	return sha256.Sum256([]byte(e.emb.EmbedModel() + "\x00" + q))
+}
+
+// Embed mengembalikan embedding query beserta asalnya. Error (termasuk timeout) berarti
+// pemanggil sebaiknya melanjutkan tanpa sinyal dense.
+func (e *Embedder) Embed(ctx context.Context, query string) ([]float32, Source, error) {
+	if e == nil || e.emb == nil {
+		return nil, SourceNone, ErrUnavailable
+	}
+	q := Normalize(query)
+	if q == "" {
+		return nil, SourceNone, errors.New("empty query")
+	}
+	k := e.key(q)
+	if v := e.get(k); v != nil {
+		return v, SourceLRU, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, e.cfg.Timeout)
+	defer cancel()
+
+	if v := e.load(ctx, k); v != nil {
+		e.put(k, v)
+		return v, SourceStore, nil
+	}
+	vecs, err := e.emb.Embed(ctx, []string{q})
+	if err != nil {
+		return nil, SourceNone, err
+	}
+	if len(vecs) == 0 || len(vecs[0]) == 0 {
+		return nil, SourceNone, errors.New("empty query embedding")
+	}
+	v := vecs[0]
+	e.put(k, v)
+	if e.db != nil {
+		go e.store(k, v) // jangan tunda respons
+	}
+	return v, SourceProvider, nil
+}
+
+// ---- LRU ----
+
+func (e *Embedder) get(k [32]byte) []float32 {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if el, ok := e.items[k]; ok {
+		e.ll.MoveToFront(el)
+		return el.Value.(*entry).vec
+	}
+	return nil
+}
+
+func (e *Embedder) put(k [32]byte, v []float32) {
+	if e.cfg.LRUSize <= 0 {
+		return
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if el, ok := e.items[k]; ok {
+		el.Value.(*entry).vec = v
+		e.ll.MoveToFront(el)
+		return
+	}
+	e.items[k] = e.ll.PushFront(&entry{key: k, vec: v})
+	for e.ll.Len() > e.cfg.LRUSize {
+		old := e.ll.Back()
+		e.ll.Remove(old)
+		delete(e.items, old.Value.(*entry).key)
+	}
+}
+
+// Len mengembalikan jumlah entri LRU.
+func (e *Embedder) Len() int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.ll.Len()
+}
+
+// ---- cache persisten ----
+
+func (e *Embedder) load(ctx context.Context, k [32]byte) []float32 {
+	if e.db == nil {
+		return nil
+	}
+	q := `SELECT embedding FROM query_embeddings WHERE model = ? AND query_hash = ?`
+	args := []any{e.emb.EmbedModel(), k[:]}
+	if e.cfg.TTL > 0 {
+		q += ` AND created_at >= NOW() - INTERVAL ? SECOND`
+		args = append(args, int64(e.cfg.TTL/time.Second))
+	}
+	var b []byte
+	if err := e.db.QueryRowContext(ctx, q, args...).Scan(&b); err != nil {
+		if !errors.Is(err, sql.ErrNoRows) && ctx.Err() == nil {
+			log.Printf("[queryembed] load: %v", err)
+		}
+		return nil
+	}
+	v, err := vector.Decode(b)
+	if err != nil {
+		return nil
+	}
+	return v
+}
+
+func (e *Embedder) store(k [32]byte, v []float32) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	_, err := e.db.ExecContext(ctx, `
+		INSERT INTO query_embeddings (model, query_hash, dim, embedding)
+		VALUES (?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE dim = VALUES(dim), embedding = VALUES(embedding), created_at = CURRENT_TIMESTAMP`,
+		e.emb.EmbedModel(), k[:], len(v), vector.EncodeF32(v))
+	if err != nil {
+		log.Printf("[queryembed] store: %v", err)
+	}
+}
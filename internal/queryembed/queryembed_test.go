@@ -0,0 +1,74 @@
+package queryembed_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"mcp-oilgas/internal/queryembed"
+)
+
+type countingEmbedder struct {
+	calls int
+	delay time.Duration
+}
+
+func (c *countingEmbedder) EmbedModel() string { return "test-embed" }
+
+func (c *countingEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	c.calls++
+	select {
+	case <-time.After(c.delay):
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	out := make([][]float32, len(texts))
+	for i, t := range texts {
+		out[i] = []float32{float32(len(t)), 1}
+	}
+	return out, nil
+}
+
+func TestEmbedCachesAndNormalizes(t *testing.T) {
+	fe := &countingEmbedder{}
+	e := queryembed.New(fe, nil, queryembed.Config{Timeout: time.Second, LRUSize: 2})
+
+	v, src, err := e.Embed(context.Background(), "  permit   to work ")
+	if err != nil || src != queryembed.SourceProvider || v[0] != float32(len("permit to work")) {
+		t.Fatalf("first embed: v=%v src=%s err=%v", v, src, err)
+	}
+	if _, src, _ := e.Embed(context.Background(), "permit to work"); src != queryembed.SourceLRU {
+		t.Fatalf("expected LRU hit for whitespace variant, got %s", src)
+	}
+	if fe.calls != 1 {
+		t.Fatalf("provider calls = %d, want 1", fe.calls)
+	}
+
+	// kapasitas 2: entri paling lama dibuang
+	_, _, _ = e.Embed(context.Background(), "b")
+	_, _, _ = e.Embed(context.Background(), "c")
+	if e.Len() != 2 {
+		t.Fatalf("lru len = %d", e.Len())
+	}
+	if _, src, _ := e.Embed(context.Background(), "permit to work"); src != queryembed.SourceProvider {
+		t.Fatalf("expected eviction, got %s", src)
+	}
+}
+
+func TestEmbedTimeout(t *testing.T) {
+	e := queryembed.New(&countingEmbedder{delay: time.Second}, nil, queryembed.Config{Timeout: 20 * time.Millisecond, LRUSize: 10})
+	start := time.Now()
+	_, src, err := e.Embed(context.Background(), "slow query")
+	if !errors.Is(err, context.DeadlineExceeded) || src != queryembed.SourceNone {
+		t.Fatalf("expected deadline exceeded, got src=%s err=%v", src, err)
+	}
+	if time.Since(start) > 500*time.Millisecond {
+		t.Fatalf("timeout not enforced")
+	}
+
+	var nilEmb *queryembed.Embedder
+	if _, _, err := nilEmb.Embed(context.Background(), "x"); !errors.Is(err, queryembed.ErrUnavailable) {
+		t.Fatalf("nil embedder: %v", err)
+	}
+}
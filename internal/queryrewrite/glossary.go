@@ -0,0 +1,158 @@
+// internal/queryrewrite/glossary.go
+package queryrewrite
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+)
+
+// Glossary memetakan istilah (huruf kecil, boleh frasa sampai 3 kata) ke padanan/kepanjangannya.
+// Dipakai untuk ekspansi akronim (NPT → non-productive time) dan padanan istilah Indonesia → Inggris
+// sehingga query berbahasa Indonesia tetap cocok secara leksikal dengan SOP berbahasa Inggris.
+type Glossary map[string][]string
+
+// builtinGlossary: akronim domain migas & padanan istilah operasi yang umum.
+var builtinGlossary = Glossary{
+	// akronim
+	"npt":  {"non-productive time"},
+	"bop":  {"blowout preventer"},
+	"wo":   {"work order"},
+	"eta":  {"estimated time of arrival"},
+	"po":   {"purchase order"},
+	"ptw":  {"permit to work"},
+	"hse":  {"health safety environment"},
+	"k3":   {"health and safety"},
+	"sop":  {"standard operating procedure"},
+	"moc":  {"management of change"},
+	"jsa":  {"job safety analysis"},
+	"lel":  {"lower explosive limit"},
+	"h2s":  {"hydrogen sulfide"},
+	"ppe":  {"personal protective equipment"},
+	"apd":  {"personal protective equipment"},
+	"rop":  {"rate of penetration"},
+	"esd":  {"emergency shutdown"},
+	"loto": {"lockout tagout"},
+	"mud":  {"drilling fluid"},
+	"kick": {"well control influx"},
+
+	// Indonesia → Inggris
+	"prosedur":              {"procedure"},
+	"penanganan":            {"handling", "response"},
+	"penanggulangan":        {"response", "control"},
+	"sumur":                 {"well"},
+	"pengeboran":            {"drilling"},
+	"pemboran":              {"drilling"},
+	"kontrol sumur":         {"well control"},
+	"semburan liar":         {"blowout"},
+	"keselamatan":           {"safety"},
+	"izin kerja":            {"permit to work"},
+	"tekanan":               {"pressure"},
+	"kebocoran":             {"leak"},
+	"tumpahan":              {"spill"},
+	"perawatan":             {"maintenance"},
+	"pemeliharaan":          {"maintenance"},
+	"inspeksi":              {"inspection"},
+	"darurat":               {"emergency"},
+	"tanggap darurat":       {"emergency response"},
+	"pelatihan":             {"training"},
+	"produksi":              {"production"},
+	"pipa":                  {"pipeline"},
+	"katup":                 {"valve"},
+	"pompa":                 {"pump"},
+	"kebakaran":             {"fire"},
+	"ruang terbatas":        {"confined space"},
+	"pengujian gas":         {"gas testing"},
+	"bahan kimia":           {"chemical"},
+	"pengadaan":             {"procurement"},
+	"pemasok":               {"vendor", "supplier"},
+	"perintah kerja":        {"work order"},
+	"waktu tidak produktif": {"non-productive time"},
+}
+
+// BuiltinGlossary mengembalikan salinan glosarium bawaan.
+func BuiltinGlossary() Glossary {
+	g := make(Glossary, len(builtinGlossary))
+	for k, v := range builtinGlossary {
+		g[k] = append([]string(nil), v...)
+	}
+	return g
+}
+
+// LoadGlossary membaca glosarium tambahan dari file JSON {"istilah": ["padanan", ...]}.
+// File tidak ada = glosarium kosong.
+func LoadGlossary(path string) (Glossary, error) {
+	if path == "" {
+		return nil, nil
+	}
+	b, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var raw map[string][]string
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	g := make(Glossary, len(raw))
+	for k, v := range raw {
+		if k = strings.Join(words(k), " "); k != "" {
+			g[k] = v
+		}
+	}
+	return g, nil
+}
+
+// Merge menimpa/menambah entri g dengan other (other menang).
+func (g Glossary) Merge(other Glossary) Glossary {
+	out := make(Glossary, len(g)+len(other))
+	for k, v := range g {
+		out[k] = v
+	}
+	for k, v := range other {
+		out[k] = v
+	}
+	return out
+}
+
+// Expand mengembalikan padanan untuk istilah glosarium yang muncul di q (frasa terpanjang didahulukan),
+// tanpa duplikat dan tanpa kata yang sudah ada di q.
+func (g Glossary) Expand(q string) []string {
+	ws := words(q)
+	inQuery := map[string]bool{}
+	for _, w := range ws {
+		inQuery[w] = true
+	}
+	seen := map[string]bool{}
+	var out []string
+	for i := 0; i < len(ws); {
+		n := 0
+		for size := min(3, len(ws)-i); size >= 1; size-- {
+			exp, ok := g[strings.Join(ws[i:i+size], " ")]
+			if !ok {
+				continue
+			}
+			for _, e := range exp {
+				if e = strings.ToLower(strings.TrimSpace(e)); e != "" && !seen[e] && !inQuery[e] {
+					seen[e] = true
+					out = append(out, e)
+				}
+			}
+			n = size
+			break
+		}
+		i += max(n, 1)
+	}
+	return out
+}
+
+// words: huruf kecil, dipisah di non huruf/angka.
+func words(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
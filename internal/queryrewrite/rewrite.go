@@ -0,0 +1,253 @@
+// internal/queryrewrite/rewrite.go
+// Transformasi query sebelum retrieval: query asli selalu dipertahankan, lalu ditambah sub-query
+//
+//	glossary:    query + kepanjangan akronim/padanan istilah (offline, lihat glossary.go)
+//	translation: terjemahan ke bahasa korpus (LLM)
+//	multi:       variasi query (LLM multi-query)
+//	hyde:        paragraf hipotetis yang menjawab query (HyDE; terutama untuk sinyal dense)
+//
+// Hasil pencarian semua sub-query digabung dengan dedup per chunk ID (lihat MergeRRF).
+package queryrewrite
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"mcp-oilgas/internal/llm"
+	"mcp-oilgas/internal/prompts"
+	"mcp-oilgas/internal/redact"
+)
+
+// Kind menandai asal sub-query.
+type Kind string
+
+const (
+	KindOriginal    Kind = "original"
+	KindGlossary    Kind = "glossary"
+	KindTranslation Kind = "translation"
+	KindMulti       Kind = "multi"
+	KindHyDE        Kind = "hyde"
+)
+
+// SubQuery adalah satu query yang dijalankan ke retriever.
+type SubQuery struct {
+	Text string `json:"text"`
+	Kind Kind   `json:"kind"`
+}
+
+// Mode rewriting (env QUERY_REWRITE).
+const (
+	ModeOff      = "off"
+	ModeGlossary = "glossary" // hanya ekspansi glosarium (tanpa LLM)
+	ModeLLM      = "llm"      // glosarium + terjemahan/multi-query/HyDE via LLM
+)
+
+// Config dibaca dari env QUERY_REWRITE_*.
+type Config struct {
+	Mode         string
+	CorpusLang   string        // bahasa mayoritas dokumen, mis. "en"
+	Translate    bool          // llm: terjemahkan ke CorpusLang
+	Multi        int           // llm: jumlah variasi query (0 = tanpa multi-query)
+	HyDE         bool          // llm: tambahkan dokumen hipotetis
+	Timeout      time.Duration // batas panggilan LLM; lewat → glosarium saja
+	GlossaryFile string        // glosarium tambahan (JSON), opsional
+}
+
+// ConfigFromEnv: QUERY_REWRITE (off|glossary|llm, default glossary), CORPUS_LANG (en),
+// QUERY_REWRITE_TRANSLATE (true), QUERY_REWRITE_MULTI (2), QUERY_REWRITE_HYDE (false),
+// QUERY_REWRITE_TIMEOUT_MS (2500), QUERY_GLOSSARY_FILE (configs/query_glossary.json).
+func ConfigFromEnv() Config {
+	cfg := Config{
+		Mode:         strings.ToLower(strings.TrimSpace(os.Getenv("QUERY_REWRITE"))),
+		CorpusLang:   strings.TrimSpace(os.Getenv("CORPUS_LANG")),
+		Translate:    true,
+		Multi:        2,
+		Timeout:      2500 * time.Millisecond,
+		GlossaryFile: "configs/query_glossary.json",
+	}
+	switch cfg.Mode {
+	case "":
+		cfg.Mode = ModeGlossary
+	case "false", "0", "no", "none":
+		cfg.Mode = ModeOff
+	}
+	if cfg.CorpusLang == "" {
+		cfg.CorpusLang = "en"
+	}
+	if b, err := strconv.ParseBool(os.Getenv("QUERY_REWRITE_TRANSLATE")); err == nil {
+		cfg.Translate = b
+	}
+	if n, err := strconv.Atoi(os.Getenv("QUERY_REWRITE_MULTI")); err == nil && n >= 0 {
+		cfg.Multi = min(n, 5)
+	}
+	if b, err := strconv.ParseBool(os.Getenv("QUERY_REWRITE_HYDE")); err == nil {
+		cfg.HyDE = b
+	}
+	if n, err := strconv.Atoi(os.Getenv("QUERY_REWRITE_TIMEOUT_MS")); err == nil && n > 0 {
+		cfg.Timeout = time.Duration(n) * time.Millisecond
+	}
+	if v := strings.TrimSpace(os.Getenv("QUERY_GLOSSARY_FILE")); v != "" {
+		cfg.GlossaryFile = v
+	}
+	return cfg
+}
+
+// Rewriter menghasilkan sub-query. LLM nil = llm.Shared() (hanya dipakai pada ModeLLM).
+type Rewriter struct {
+	LLM      llm.Client
+	cfg      Config
+	glossary Glossary
+}
+
+// New membuat Rewriter; nil bila cfg.Mode off. Glosarium = bawaan + cfg.GlossaryFile.
+func New(cfg Config) (*Rewriter, error) {
+	switch cfg.Mode {
+	case ModeOff:
+		return nil, nil
+	case ModeGlossary, ModeLLM:
+	default:
+		return nil, fmt.Errorf("queryrewrite: unknown QUERY_REWRITE %q (off|glossary|llm)", cfg.Mode)
+	}
+	g := BuiltinGlossary()
+	custom, err := LoadGlossary(cfg.GlossaryFile)
+	if err != nil {
+		return nil, fmt.Errorf("queryrewrite: glossary: %w", err)
+	}
+	return &Rewriter{cfg: cfg, glossary: g.Merge(custom)}, nil
+}
+
+// NewWithGlossary membuat Rewriter dengan glosarium tertentu (tanpa membaca file).
+func NewWithGlossary(cfg Config, g Glossary) *Rewriter {
+	return &Rewriter{cfg: cfg, glossary: g}
+}
+
+// Rewrite mengembalikan sub-query tanpa duplikat, query asli selalu pertama. Error LLM tidak
+// menggagalkan rewriting: sub-query glosarium tetap dikembalikan bersama error untuk dilaporkan.
+func (r *Rewriter) Rewrite(ctx context.Context, query string) ([]SubQuery, error) {
+	q := strings.TrimSpace(query)
+	out := []SubQuery{{Text: q, Kind: KindOriginal}}
+	if r == nil || q == "" {
+		return out, nil
+	}
+	seen := map[string]bool{normKey(q): true}
+	add := func(text string, kind Kind) {
+		text = strings.TrimSpace(text)
+		if k := normKey(text); k != "" && !seen[k] {
+			seen[k] = true
+			out = append(out, SubQuery{Text: text, Kind: kind})
+		}
+	}
+
+	if exp := r.glossary.Expand(q); len(exp) > 0 {
+		add(q+" "+strings.Join(exp, " "), KindGlossary)
+	}
+	if r.cfg.Mode != ModeLLM || (!r.cfg.Translate && r.cfg.Multi == 0 && !r.cfg.HyDE) {
+		return out, nil
+	}
+
+	res, err := r.llmRewrite(ctx, q)
+	if err != nil {
+		return out, err
+	}
+	if r.cfg.Translate && res.Translation != "" {
+		add(res.Translation, KindTranslation)
+		// padanan glosarium juga untuk terjemahan (akronim yang dipertahankan LLM)
+		if exp := r.glossary.Expand(res.Translation); len(exp) > 0 {
+			add(res.Translation+" "+strings.Join(exp, " "), KindGlossary)
+		}
+	}
+	for i, v := range res.Queries {
+		if i >= r.cfg.Multi {
+			break
+		}
+		add(v, KindMulti)
+	}
+	if r.cfg.HyDE && res.Hypothetical != "" {
+		add(res.Hypothetical, KindHyDE)
+	}
+	return out, nil
+}
+
+type llmResult struct {
+	Translation  string   `json:"translation"`
+	Queries      []string `json:"queries"`
+	Hypothetical string   `json:"hypothetical"`
+}
+
+func (r *Rewriter) llmRewrite(ctx context.Context, q string) (llmResult, error) {
+	var res llmResult
+	cli := r.LLM
+	if cli == nil {
+		var err error
+		if cli, err = llm.Shared(); err != nil {
+			return res, err
+		}
+		if redact.LLMEnabled() {
+			cli = redact.WrapClient(cli, redact.Default().NewSession())
+		}
+	}
+	rp, err := prompts.Default().Render(prompts.QueryRewrite, prompts.Vars{Extra: map[string]any{
+		"corpus_lang": r.cfg.CorpusLang,
+		"translate":   r.cfg.Translate,
+		"multi":       r.cfg.Multi,
+		"hyde":        r.cfg.HyDE,
+	}})
+	if err != nil {
+		return res, err
+	}
+	if r.cfg.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.cfg.Timeout)
+		defer cancel()
+	}
+	ub, _ := json.Marshal(map[string]string{"query": q})
+	raw, err := cli.AnswerJSON(ctx, string(ub), rp.Text)
+	if err != nil {
+		return res, fmt.Errorf("query rewrite: %w", err)
+	}
+	if err := json.Unmarshal([]byte(raw), &res); err != nil {
+		return res, fmt.Errorf("query rewrite: invalid json: %w", err)
+	}
+	return res, nil
+}
+
+func normKey(s string) string { return strings.Join(words(s), " ") }
+
+// Merged adalah satu item hasil MergeRRF.
+type Merged struct {
+	ID      int64
+	Score   float64 // Σ 1/(k + rank) atas daftar yang memuat ID ini
+	Queries []int   // indeks sub-query yang menemukan ID ini
+}
+
+// MergeRRF menggabungkan daftar ID hasil tiap sub-query (urutan = peringkat) dengan
+// Reciprocal Rank Fusion dan dedup per ID. k <= 0 → 60. Seri diputus oleh kemunculan pertama.
+func MergeRRF(lists [][]int64, k int) []Merged {
+	if k <= 0 {
+		k = 60
+	}
+	pos := map[int64]int{}
+	var out []Merged
+	for qi, ids := range lists {
+		for rank, id := range ids {
+			i, ok := pos[id]
+			if !ok {
+				i = len(out)
+				pos[id] = i
+				out = append(out, Merged{ID: id})
+			} else if last := out[i].Queries; last[len(last)-1] == qi {
+				continue // ID ganda di daftar yang sama
+			}
+			out[i].Score += 1 / float64(k+rank+1)
+			out[i].Queries = append(out[i].Queries, qi)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Score > out[j].Score })
+	return out
+}
@@ -0,0 +1,68 @@
+package queryrewrite_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"mcp-oilgas/internal/llm"
+	"mcp-oilgas/internal/queryrewrite"
+)
+
+func TestGlossaryExpand(t *testing.T) {
+	g := queryrewrite.BuiltinGlossary()
+	got := strings.Join(g.Expand("Prosedur penanganan kick di sumur"), " | ")
+	for _, want := range []string{"procedure", "handling", "well control influx", "well"} {
+		if !strings.Contains(got, want) {
+			t.Fatalf("expand missing %q: %s", want, got)
+		}
+	}
+	// frasa terpanjang didahulukan; kata yang sudah ada di query tidak diulang
+	if got := g.Expand("izin kerja PTW"); len(got) != 1 || got[0] != "permit to work" {
+		t.Fatalf("expand phrase: %v", got)
+	}
+}
+
+func TestRewriteLLM(t *testing.T) {
+	fake := &llm.Fake{JSONReplies: []string{`{
+		"translation": "well control kick procedure",
+		"queries": ["kick handling steps", "influx response", "third one"],
+		"hypothetical": "When a kick is detected, shut in the well using the BOP."}`}}
+	cfg := queryrewrite.Config{Mode: queryrewrite.ModeLLM, CorpusLang: "en", Translate: true, Multi: 2, HyDE: true}
+	r := queryrewrite.NewWithGlossary(cfg, queryrewrite.BuiltinGlossary())
+	r.LLM = fake
+
+	subs, err := r.Rewrite(context.Background(), "prosedur penanganan kick")
+	if err != nil {
+		t.Fatalf("rewrite: %v", err)
+	}
+	kinds := map[queryrewrite.Kind]int{}
+	for _, s := range subs {
+		kinds[s.Kind]++
+	}
+	if subs[0].Kind != queryrewrite.KindOriginal || kinds[queryrewrite.KindTranslation] != 1 ||
+		kinds[queryrewrite.KindMulti] != 2 || kinds[queryrewrite.KindHyDE] != 1 || kinds[queryrewrite.KindGlossary] == 0 {
+		t.Fatalf("unexpected sub-queries: %+v", subs)
+	}
+
+	// LLM gagal → sub-query glosarium tetap dikembalikan bersama error
+	r.LLM = &llm.Fake{Err: errors.New("down")}
+	subs, err = r.Rewrite(context.Background(), "prosedur penanganan kick")
+	if err == nil || len(subs) != 2 || subs[1].Kind != queryrewrite.KindGlossary {
+		t.Fatalf("fallback: %v %+v", err, subs)
+	}
+}
+
+func TestMergeRRFDedup(t *testing.T) {
+	m := queryrewrite.MergeRRF([][]int64{{1, 2, 3}, {3, 4, 3}, {5, 3}}, 60)
+	if len(m) != 5 {
+		t.Fatalf("expected 5 unique ids, got %+v", m)
+	}
+	if m[0].ID != 3 || len(m[0].Queries) != 3 {
+		t.Fatalf("id 3 (found by all sub-queries) should rank first: %+v", m[0])
+	}
+	if m[1].ID != 1 {
+		t.Fatalf("tie-break should follow first appearance: %+v", m)
+	}
+}
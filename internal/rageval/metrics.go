@@ -0,0 +1,81 @@
+// internal/rageval/metrics.go
+// Metrik retrieval atas daftar chunk ID terurut vs himpunan chunk relevan (grade > 0).
+//
+//	recall@k = |relevan ∩ top-k| / |relevan|
+//	MRR      = 1 / rank chunk relevan pertama (0 bila tidak ada), dirata-rata per query
+//	nDCG@k   = DCG@k / IDCG@k, DCG = Σ (2^grade - 1) / log2(rank + 1)
+package rageval
+
+import (
+	"math"
+	"sort"
+)
+
+// RecallAt menghitung recall@k.
+func RecallAt(ranked []int64, rel map[int64]int, k int) float64 {
+	total := 0
+	for _, g := range rel {
+		if g > 0 {
+			total++
+		}
+	}
+	if total == 0 {
+		return 0
+	}
+	hit := 0
+	for i, id := range ranked {
+		if i >= k {
+			break
+		}
+		if rel[id] > 0 {
+			hit++
+		}
+	}
+	return float64(hit) / float64(total)
+}
+
+// ReciprocalRank mengembalikan 1/rank chunk relevan pertama.
+func ReciprocalRank(ranked []int64, rel map[int64]int) float64 {
+	for i, id := range ranked {
+		if rel[id] > 0 {
+			return 1 / float64(i+1)
+		}
+	}
+	return 0
+}
+
+// NDCGAt menghitung nDCG@k dengan relevansi bertingkat.
+func NDCGAt(ranked []int64, rel map[int64]int, k int) float64 {
+	dcg := 0.0
+	for i, id := range ranked {
+		if i >= k {
+			break
+		}
+		dcg += gain(rel[id], i)
+	}
+	var grades []int
+	for _, g := range rel {
+		if g > 0 {
+			grades = append(grades, g)
+		}
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(grades)))
+	idcg := 0.0
+	for i, g := range grades {
+		if i >= k {
+			break
+		}
+		idcg += gain(g, i)
+	}
+	if idcg == 0 {
+		return 0
+	}
+	return dcg / idcg
+}
+
+func gain(grade, pos int) float64 {
+	if grade <= 0 {
+		return 0
+	}
+	return (math.Pow(2, float64(grade)) - 1) / math.Log2(float64(pos+2))
+}
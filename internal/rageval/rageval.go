@@ -0,0 +1,272 @@
+// internal/rageval/rageval.go
+// Evaluasi kualitas retrieval: dataset berlabel (query → chunk relevan) dijalankan ke beberapa
+// konfigurasi retriever (BM25, cosine, hybrid dengan alpha/prefilter berbeda) lalu dilaporkan
+// recall@k, MRR dan nDCG@k per konfigurasi. Dipakai cmd/rag-eval.
+//
+// Dataset: JSONL (satu case per baris) atau array JSON.
+//
+//	{"id": "kick-1", "query": "prosedur penanganan kick", "relevant": [812, 815], "grades": {"812": 2}}
+//
+// relevant = chunk ID (doc_chunks.id) dengan grade 1; grades opsional menimpa grade per chunk.
+// filters (opsional) = documents.Filter, diteruskan ke retriever.
+package rageval
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"mcp-oilgas/internal/repositories/documents"
+)
+
+// Case adalah satu query berlabel.
+type Case struct {
+	ID       string            `json:"id,omitempty"`
+	Query    string            `json:"query"`
+	Relevant []int64           `json:"relevant"`
+	Grades   map[string]int    `json:"grades,omitempty"`
+	Filters  *documents.Filter `json:"filters,omitempty"`
+}
+
+// Relevance mengembalikan grade per chunk ID (relevant = 1, ditimpa grades).
+func (c Case) Relevance() map[int64]int {
+	rel := make(map[int64]int, len(c.Relevant)+len(c.Grades))
+	for _, id := range c.Relevant {
+		rel[id] = 1
+	}
+	for k, g := range c.Grades {
+		if id, err := strconv.ParseInt(k, 10, 64); err == nil {
+			rel[id] = g
+		}
+	}
+	return rel
+}
+
+// LoadDataset membaca dataset JSONL / array JSON dari path.
+func LoadDataset(path string) ([]Case, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseDataset(b)
+}
+
+// ParseDataset mem-parse dataset; case tanpa query atau tanpa chunk relevan ditolak.
+func ParseDataset(b []byte) ([]Case, error) {
+	var cases []Case
+	if t := bytes.TrimSpace(b); len(t) > 0 && t[0] == '[' {
+		if err := json.Unmarshal(t, &cases); err != nil {
+			return nil, fmt.Errorf("dataset: %w", err)
+		}
+	} else {
+		sc := bufio.NewScanner(bytes.NewReader(b))
+		sc.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+		for line := 1; sc.Scan(); line++ {
+			l := strings.TrimSpace(sc.Text())
+			if l == "" || strings.HasPrefix(l, "#") || strings.HasPrefix(l, "//") {
+				continue
+			}
+			var c Case
+			if err := json.Unmarshal([]byte(l), &c); err != nil {
+				return nil, fmt.Errorf("dataset line %d: %w", line, err)
+			}
+			cases = append(cases, c)
+		}
+		if err := sc.Err(); err != nil {
+			return nil, err
+		}
+	}
+	for i := range cases {
+		c := &cases[i]
+		c.Query = strings.TrimSpace(c.Query)
+		if c.ID == "" {
+			c.ID = strconv.Itoa(i + 1)
+		}
+		if c.Query == "" {
+			return nil, fmt.Errorf("dataset case %s: empty query", c.ID)
+		}
+		if len(c.Relevance()) == 0 {
+			return nil, fmt.Errorf("dataset case %s: no relevant chunks", c.ID)
+		}
+	}
+	if len(cases) == 0 {
+		return nil, fmt.Errorf("dataset: no cases")
+	}
+	return cases, nil
+}
+
+// Retriever mengembalikan chunk ID terurut (paling relevan dulu), paling banyak k.
+type Retriever func(ctx context.Context, c Case, k int) ([]int64, error)
+
+// Config adalah satu konfigurasi retrieval yang dibandingkan.
+type Config struct {
+	Name     string
+	Params   map[string]any // dicatat di laporan (alpha, prefilter, fusion, ...)
+	Retrieve Retriever
+}
+
+// Options mengatur evaluasi.
+type Options struct {
+	Ks       []int         // cut-off recall/nDCG; default 1,3,5,10
+	PerQuery bool          // sertakan hasil per query di laporan
+	Timeout  time.Duration // per query, default 30s
+}
+
+// QueryResult adalah hasil satu query pada satu konfigurasi.
+type QueryResult struct {
+	ID        string          `json:"id"`
+	Query     string          `json:"query"`
+	Retrieved []int64         `json:"retrieved"`
+	Recall    map[int]float64 `json:"recall"`
+	RR        float64         `json:"rr"`
+	NDCG      map[int]float64 `json:"ndcg"`
+	LatencyMS float64         `json:"latency_ms"`
+	Error     string          `json:"error,omitempty"`
+}
+
+// Result adalah metrik rata-rata satu konfigurasi. Query yang gagal dihitung 0.
+type Result struct {
+	Name      string          `json:"name"`
+	Params    map[string]any  `json:"params,omitempty"`
+	Queries   int             `json:"queries"`
+	Errors    int             `json:"errors"`
+	Recall    map[int]float64 `json:"recall"`
+	MRR       float64         `json:"mrr"`
+	NDCG      map[int]float64 `json:"ndcg"`
+	LatencyMS float64         `json:"latency_ms"` // rata-rata
+	PerQuery  []QueryResult   `json:"per_query,omitempty"`
+}
+
+// Report adalah hasil evaluasi semua konfigurasi.
+type Report struct {
+	GeneratedAt time.Time      `json:"generated_at"`
+	Dataset     string         `json:"dataset,omitempty"`
+	Cases       int            `json:"cases"`
+	Ks          []int          `json:"ks"`
+	Env         map[string]any `json:"env,omitempty"` // konteks run (model embedding, DSN host, ...)
+	Results     []Result       `json:"results"`
+}
+
+// Evaluate menjalankan setiap case ke setiap konfigurasi secara berurutan.
+func Evaluate(ctx context.Context, cases []Case, cfgs []Config, opt Options) Report {
+	ks := append([]int(nil), opt.Ks...)
+	if len(ks) == 0 {
+		ks = []int{1, 3, 5, 10}
+	}
+	sort.Ints(ks)
+	depth := ks[len(ks)-1]
+	if opt.Timeout <= 0 {
+		opt.Timeout = 30 * time.Second
+	}
+
+	rep := Report{GeneratedAt: time.Now().UTC(), Cases: len(cases), Ks: ks}
+	for _, cfg := range cfgs {
+		res := Result{Name: cfg.Name, Params: cfg.Params, Recall: map[int]float64{}, NDCG: map[int]float64{}}
+		var latency float64
+		for _, c := range cases {
+			if ctx.Err() != nil {
+				break
+			}
+			qr := runCase(ctx, cfg, c, ks, depth, opt.Timeout)
+			res.Queries++
+			if qr.Error != "" {
+				res.Errors++
+			}
+			for _, k := range ks {
+				res.Recall[k] += qr.Recall[k]
+				res.NDCG[k] += qr.NDCG[k]
+			}
+			res.MRR += qr.RR
+			latency += qr.LatencyMS
+			if opt.PerQuery {
+				res.PerQuery = append(res.PerQuery, qr)
+			}
+		}
+		if n := float64(res.Queries); n > 0 {
+			for _, k := range ks {
+				res.Recall[k] /= n
+				res.NDCG[k] /= n
+			}
+			res.MRR /= n
+			res.LatencyMS = latency / n
+		}
+		rep.Results = append(rep.Results, res)
+	}
+	return rep
+}
+
+func runCase(ctx context.Context, cfg Config, c Case, ks []int, depth int, timeout time.Duration) QueryResult {
+	qr := QueryResult{ID: c.ID, Query: c.Query, Recall: map[int]float64{}, NDCG: map[int]float64{}}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	start := time.Now()
+	ids, err := cfg.Retrieve(ctx, c, depth)
+	qr.LatencyMS = float64(time.Since(start).Microseconds()) / 1000
+	if err != nil {
+		qr.Error = err.Error()
+		return qr
+	}
+	if len(ids) > depth {
+		ids = ids[:depth]
+	}
+	qr.Retrieved = ids
+	rel := c.Relevance()
+	for _, k := range ks {
+		qr.Recall[k] = RecallAt(ids, rel, k)
+		qr.NDCG[k] = NDCGAt(ids, rel, k)
+	}
+	qr.RR = ReciprocalRank(ids, rel)
+	return qr
+}
+
+// WriteTable menulis tabel perbandingan (satu baris per konfigurasi).
+func (r Report) WriteTable(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	head := []string{"config"}
+	for _, k := range r.Ks {
+		head = append(head, fmt.Sprintf("R@%d", k))
+	}
+	head = append(head, "MRR")
+	for _, k := range r.Ks {
+		head = append(head, fmt.Sprintf("nDCG@%d", k))
+	}
+	head = append(head, "ms", "err")
+	fmt.Fprintln(tw, strings.Join(head, "\t")+"\t")
+	for _, res := range r.Results {
+		row := []string{res.Name}
+		for _, k := range r.Ks {
+			row = append(row, fmt.Sprintf("%.3f", res.Recall[k]))
+		}
+		row = append(row, fmt.Sprintf("%.3f", res.MRR))
+		for _, k := range r.Ks {
+			row = append(row, fmt.Sprintf("%.3f", res.NDCG[k]))
+		}
+		row = append(row, fmt.Sprintf("%.1f", res.LatencyMS), strconv.Itoa(res.Errors))
+		fmt.Fprintln(tw, strings.Join(row, "\t")+"\t")
+	}
+	return tw.Flush()
+}
+
+// Best mengembalikan konfigurasi dengan nDCG@k tertinggi (k terbesar di laporan), MRR sebagai tie-break.
+func (r Report) Best() (Result, bool) {
+	if len(r.Results) == 0 || len(r.Ks) == 0 {
+		return Result{}, false
+	}
+	k := r.Ks[len(r.Ks)-1]
+	best := r.Results[0]
+	for _, res := range r.Results[1:] {
+		if res.NDCG[k] > best.NDCG[k] || (res.NDCG[k] == best.NDCG[k] && res.MRR > best.MRR) {
+			best = res
+		}
+	}
+	return best, true
+}
@@ -0,0 +1,77 @@
+// internal/rageval/rageval_test.go
+
+package rageval_test
+
+import (
+	"context"
+	"math"
+	"strings"
+	"testing"
+
+	"mcp-oilgas/internal/rageval"
+)
+
+func near(a, b float64) bool { return math.Abs(a-b) < 1e-9 }
+
+func TestMetrics(t *testing.T) {
+	rel := map[int64]int{2: 1, 5: 1}
+	ranked := []int64{7, 2, 9, 5}
+	if got := rageval.RecallAt(ranked, rel, 2); !near(got, 0.5) {
+		t.Fatalf("recall@2 = %v, want 0.5", got)
+	}
+	if got := rageval.RecallAt(ranked, rel, 4); !near(got, 1) {
+		t.Fatalf("recall@4 = %v, want 1", got)
+	}
+	if got := rageval.ReciprocalRank(ranked, rel); !near(got, 0.5) {
+		t.Fatalf("rr = %v, want 0.5", got)
+	}
+	// DCG = 1/log2(3) + 1/log2(5); IDCG = 1 + 1/log2(3)
+	want := (1/math.Log2(3) + 1/math.Log2(5)) / (1 + 1/math.Log2(3))
+	if got := rageval.NDCGAt(ranked, rel, 4); !near(got, want) {
+		t.Fatalf("ndcg@4 = %v, want %v", got, want)
+	}
+	if got := rageval.NDCGAt([]int64{2, 5}, rel, 10); !near(got, 1) {
+		t.Fatalf("perfect ranking ndcg = %v, want 1", got)
+	}
+}
+
+func TestParseDatasetAndEvaluate(t *testing.T) {
+	data := "# komentar\n" +
+		`{"id": "a", "query": "kick", "relevant": [1], "grades": {"2": 2}}` + "\n" +
+		`{"query": "h2s", "relevant": [3]}` + "\n"
+	cases, err := rageval.ParseDataset([]byte(data))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if len(cases) != 2 || cases[1].ID != "2" || cases[0].Relevance()[2] != 2 {
+		t.Fatalf("unexpected cases: %+v", cases)
+	}
+	if _, err := rageval.ParseDataset([]byte(`[{"query": "x"}]`)); err == nil {
+		t.Fatalf("expected error for case without relevant chunks")
+	}
+
+	perfect := func(ctx context.Context, c rageval.Case, k int) ([]int64, error) {
+		if c.Query == "kick" {
+			return []int64{2, 1}, nil
+		}
+		return []int64{3}, nil
+	}
+	miss := func(ctx context.Context, c rageval.Case, k int) ([]int64, error) { return []int64{9}, nil }
+	rep := rageval.Evaluate(context.Background(), cases, []rageval.Config{
+		{Name: "perfect", Retrieve: perfect},
+		{Name: "miss", Retrieve: miss},
+	}, rageval.Options{Ks: []int{1, 5}})
+	if r := rep.Results[0]; !near(r.Recall[5], 1) || !near(r.MRR, 1) || !near(r.NDCG[5], 1) {
+		t.Fatalf("perfect retriever metrics: %+v", r)
+	}
+	if r := rep.Results[1]; r.MRR != 0 || r.Recall[5] != 0 {
+		t.Fatalf("miss retriever metrics: %+v", r)
+	}
+	if best, _ := rep.Best(); best.Name != "perfect" {
+		t.Fatalf("best = %s", best.Name)
+	}
+	var b strings.Builder
+	if err := rep.WriteTable(&b); err != nil || !strings.Contains(b.String(), "nDCG@5") {
+		t.Fatalf("table: %v\n%s", err, b.String())
+	}
+}
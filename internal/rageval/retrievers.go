@@ -0,0 +1,126 @@
+// internal/rageval/retrievers.go
+// Adapter Retriever untuk repo RAG yang ada: mysql.RAGRepo (BM25 / hybrid / cosine) dan
+// search.RAGRepo (prefilter BM25 → cosine, dipakai /ask & fallback SSE).
+package rageval
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"mcp-oilgas/internal/llm"
+	"mcp-oilgas/internal/repositories/documents"
+	mysqlrepo "mcp-oilgas/internal/repositories/mysql"
+	"mcp-oilgas/internal/repositories/search"
+)
+
+func filterOf(c Case) documents.Filter {
+	if c.Filters != nil {
+		return *c.Filters
+	}
+	return documents.Filter{}
+}
+
+// BM25 mengevaluasi leksikal saja (mysql.RAGRepo.SearchBM25Filtered; FULLTEXT atau indeks Go
+// sesuai repo.LexicalMode).
+func BM25(repo *mysqlrepo.RAGRepo) Retriever {
+	return func(ctx context.Context, c Case, k int) ([]int64, error) {
+		chunks, err := repo.SearchBM25Filtered(ctx, c.Query, k, filterOf(c))
+		if err != nil {
+			return nil, err
+		}
+		ids := make([]int64, 0, len(chunks))
+		for _, ch := range chunks {
+			ids = append(ids, ch.ID)
+		}
+		return ids, nil
+	}
+}
+
+// Hybrid mengevaluasi mysql.RAGRepo.SearchHybridWith; alpha 0 + fusi linear = cosine saja
+// (atas kandidat BM25 + ANN bila repo.ANN diisi).
+func Hybrid(repo *mysqlrepo.RAGRepo, emb llm.Embedder, alpha float64, opt mysqlrepo.HybridOptions) Retriever {
+	return func(ctx context.Context, c Case, k int) ([]int64, error) {
+		vecs, err := emb.Embed(ctx, []string{c.Query})
+		if err != nil {
+			return nil, err
+		}
+		if len(vecs) != 1 {
+			return nil, errors.New("no embedding for query")
+		}
+		chunks, _, err := repo.SearchHybridWith(ctx, c.Query, vecs[0], alpha, k, filterOf(c), opt)
+		if err != nil {
+			return nil, err
+		}
+		ids := make([]int64, 0, len(chunks))
+		for _, ch := range chunks {
+			ids = append(ids, ch.ID)
+		}
+		return ids, nil
+	}
+}
+
+// Search mengevaluasi search.RAGRepo (prefilter BM25 lalu urut cosine).
+func Search(repo search.RAGRepo) Retriever {
+	return func(ctx context.Context, c Case, k int) ([]int64, error) {
+		hits, err := repo.RetrieveFiltered(ctx, c.Query, k, filterOf(c))
+		if err != nil {
+			return nil, err
+		}
+		ids := make([]int64, 0, len(hits))
+		for _, h := range hits {
+			ids = append(ids, h.ChunkID)
+		}
+		return ids, nil
+	}
+}
+
+// CachedEmbedder meng-cache embedding per teks agar setiap query hanya di-embed sekali
+// walaupun dievaluasi di banyak konfigurasi.
+type CachedEmbedder struct {
+	llm.Embedder
+
+	mu    sync.Mutex
+	cache map[string][]float32
+}
+
+// NewCachedEmbedder membungkus emb.
+func NewCachedEmbedder(emb llm.Embedder) *CachedEmbedder {
+	return &CachedEmbedder{Embedder: emb, cache: map[string][]float32{}}
+}
+
+// Embed mengembalikan salinan vektor (pemanggil boleh menormalisasi di tempat).
+func (c *CachedEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	out := make([][]float32, len(texts))
+	var miss []string
+	c.mu.Lock()
+	for i, t := range texts {
+		if v, ok := c.cache[t]; ok {
+			out[i] = append([]float32(nil), v...)
+		} else {
+			miss = append(miss, t)
+		}
+	}
+	c.mu.Unlock()
+	if len(miss) == 0 {
+		return out, nil
+	}
+	vecs, err := c.Embedder.Embed(ctx, miss)
+	if err != nil {
+		return nil, err
+	}
+	if len(vecs) != len(miss) {
+		return nil, errors.New("embedder returned wrong number of vectors")
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i, t := range miss {
+		c.cache[t] = append([]float32(nil), vecs[i]...)
+	}
+	for i, t := range texts {
+		if out[i] == nil {
+			out[i] = append([]float32(nil), c.cache[t]...)
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,50 @@
+// internal/redact/client.go
+// Dekorator llm.Client: payload user diredaksi sebelum dikirim, placeholder dikembalikan di keluaran.
+package redact
+
+import (
+	"context"
+
+	"mcp-oilgas/internal/llm"
+)
+
+type client struct {
+	next llm.Client
+	s    *Session
+}
+
+// WrapClient membungkus c sehingga semua prompt user melewati s.Redact dan semua jawaban
+// (termasuk delta streaming & JSON planner) melewati s.Restore. System prompt (template
+// tepercaya) tidak diubah.
+func WrapClient(c llm.Client, s *Session) llm.Client {
+	if c == nil || s == nil {
+		return c
+	}
+	return &client{next: c, s: s}
+}
+
+func (c *client) Model() string { return c.next.Model() }
+
+func (c *client) AnswerWithRAG(ctx context.Context, system, prompt string) (string, error) {
+	out, err := c.next.AnswerWithRAG(ctx, system, c.s.Redact(prompt))
+	return c.s.Restore(out), err
+}
+
+func (c *client) AnswerJSON(ctx context.Context, user, system string) (string, error) {
+	out, err := c.next.AnswerJSON(ctx, c.s.Redact(user), system)
+	return c.s.RestoreJSON(out), err
+}
+
+func (c *client) AnswerStream(ctx context.Context, system, prompt string, onDelta func(delta string) error) (string, error) {
+	sr := c.s.NewStreamRestorer()
+	final, err := c.next.AnswerStream(ctx, system, c.s.Redact(prompt), func(delta string) error {
+		if d := sr.Write(delta); d != "" && onDelta != nil {
+			return onDelta(d)
+		}
+		return nil
+	})
+	if tail := sr.Flush(); tail != "" && onDelta != nil && err == nil {
+		err = onDelta(tail)
+	}
+	return c.s.Restore(final), err
+}
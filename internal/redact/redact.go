@@ -0,0 +1,247 @@
+// internal/redact/redact.go
+// Redaksi PII & secret (email, telepon, NIK/NPWP, nomor kartu, API key/token) untuk log dan,
+// opsional, untuk payload yang dikirim ke LLM.
+//
+//   - Log: nilai diganti label tetap, mis. "[EMAIL]" (tidak bisa dikembalikan).
+//   - LLM: nilai diganti placeholder bernomor per request, mis. "[EMAIL_1]", lalu dikembalikan
+//     ke nilai asli di jawaban final (lihat Session & WrapClient).
+//
+// Konfigurasi (env):
+//   - REDACT_LOGS           true (default) | false
+//   - REDACT_LLM            false (default) | true
+//   - REDACT_DISABLE        nama pola bawaan yang dimatikan, dipisah koma (mis. "phone,card")
+//   - REDACT_PATTERNS_FILE  JSON pola tambahan (default configs/redact_patterns.json, opsional):
+//     [{"name":"vendor","pattern":"(?i)\\bPT\\s+Maju\\s+Jaya\\b"}, {"name":"contract","pattern":"...","group":1}]
+package redact
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Pattern adalah satu aturan redaksi. Group > 0 = hanya submatch tersebut yang diganti
+// (mis. nilai pada "password=xxx"), 0 = seluruh cocokan.
+type Pattern struct {
+	Name    string `json:"name"`
+	Pattern string `json:"pattern"`
+	Group   int    `json:"group,omitempty"`
+
+	re *regexp.Regexp
+}
+
+// Builtin: urutan penting — pola yang lebih spesifik (secret) didahulukan.
+var builtin = []Pattern{
+	{Name: "api_key", Pattern: `\bsk-(?:proj-)?[A-Za-z0-9_\-]{20,}`},
+	{Name: "api_key", Pattern: `\bAKIA[0-9A-Z]{16}\b`},
+	{Name: "api_key", Pattern: `\bgh[pousr]_[A-Za-z0-9]{36,}\b`},
+	{Name: "api_key", Pattern: `\bxox[abpr]-[A-Za-z0-9-]{10,}`},
+	{Name: "token", Pattern: `\beyJ[A-Za-z0-9_-]{8,}\.[A-Za-z0-9_-]{8,}\.[A-Za-z0-9_-]{8,}`},
+	{Name: "token", Pattern: `(?i)\bbearer\s+([A-Za-z0-9._~+/-]{16,}=*)`, Group: 1},
+	{Name: "secret", Pattern: `(?i)\b(?:password|passwd|pwd|secret|api[_-]?key|access[_-]?token|token)\s*[:=]\s*"?([^\s",;]{4,})`, Group: 1},
+	{Name: "email", Pattern: `\b[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}\b`},
+	{Name: "npwp", Pattern: `\b\d{2}\.\d{3}\.\d{3}\.\d-\d{3}\.\d{3}\b`},
+	{Name: "card", Pattern: `\b\d{4}[ -]\d{4}[ -]\d{4}[ -]\d{1,7}\b`},
+	{Name: "nik", Pattern: `\b\d{16}\b`},
+	{Name: "phone", Pattern: `(?:\+62[\s-]?|\b0)8\d{1,3}[\s-]?\d{3,4}[\s-]?\d{3,5}\b`},
+	{Name: "phone", Pattern: `\+\d{1,3}[\s-]?\(?\d{1,4}\)?[\s-]?\d{3,4}[\s-]?\d{3,4}\b`},
+}
+
+// Redactor menerapkan sekumpulan pola. Aman dipakai konkuren.
+type Redactor struct {
+	patterns []Pattern
+}
+
+// New mengompilasi pola; pola tidak valid mengembalikan error.
+func New(patterns []Pattern) (*Redactor, error) {
+	out := make([]Pattern, 0, len(patterns))
+	for _, p := range patterns {
+		re, err := regexp.Compile(p.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("redact pattern %q: %w", p.Name, err)
+		}
+		if p.Group < 0 || p.Group > re.NumSubexp() {
+			return nil, fmt.Errorf("redact pattern %q: group %d out of range", p.Name, p.Group)
+		}
+		if p.Name == "" {
+			p.Name = "custom"
+		}
+		p.re = re
+		out = append(out, p)
+	}
+	return &Redactor{patterns: out}, nil
+}
+
+// Builtin mengembalikan salinan pola bawaan (tanpa yang disebut di disable).
+func Builtin(disable ...string) []Pattern {
+	off := map[string]bool{}
+	for _, d := range disable {
+		off[strings.ToLower(strings.TrimSpace(d))] = true
+	}
+	out := make([]Pattern, 0, len(builtin))
+	for _, p := range builtin {
+		if !off[p.Name] {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// match adalah satu rentang byte yang akan diganti.
+type match struct {
+	start, end int
+	name       string
+}
+
+// find mengumpulkan cocokan semua pola; rentang yang tumpang tindih dengan cocokan pola
+// sebelumnya diabaikan (pola awal menang).
+func (r *Redactor) find(s string) []match {
+	var ms []match
+	overlaps := func(a, b int) bool {
+		for _, m := range ms {
+			if a < m.end && b > m.start {
+				return true
+			}
+		}
+		return false
+	}
+	for _, p := range r.patterns {
+		for _, loc := range p.re.FindAllStringSubmatchIndex(s, -1) {
+			a, b := loc[2*p.Group], loc[2*p.Group+1]
+			if a < 0 || a == b || overlaps(a, b) {
+				continue
+			}
+			ms = append(ms, match{a, b, p.Name})
+		}
+	}
+	sort.Slice(ms, func(i, j int) bool { return ms[i].start < ms[j].start })
+	return ms
+}
+
+func (r *Redactor) replace(s string, repl func(m match, val string) string) string {
+	if r == nil || s == "" {
+		return s
+	}
+	ms := r.find(s)
+	if len(ms) == 0 {
+		return s
+	}
+	var b strings.Builder
+	pos := 0
+	for _, m := range ms {
+		b.WriteString(s[pos:m.start])
+		b.WriteString(repl(m, s[m.start:m.end]))
+		pos = m.end
+	}
+	b.WriteString(s[pos:])
+	return b.String()
+}
+
+// Redact mengganti nilai sensitif dengan label tetap, mis. "[EMAIL]" (untuk log).
+func (r *Redactor) Redact(s string) string {
+	return r.replace(s, func(m match, _ string) string { return "[" + strings.ToUpper(m.name) + "]" })
+}
+
+// ----------------- Konfigurasi & instance bersama -----------------
+
+// Config dibaca dari env (lihat komentar paket).
+type Config struct {
+	Logs         bool
+	LLM          bool
+	Disable      []string
+	PatternsFile string
+}
+
+// ConfigFromEnv membaca REDACT_*.
+func ConfigFromEnv() Config {
+	cfg := Config{Logs: true, PatternsFile: "configs/redact_patterns.json"}
+	if v := strings.ToLower(strings.TrimSpace(os.Getenv("REDACT_LOGS"))); v == "false" || v == "0" || v == "off" {
+		cfg.Logs = false
+	}
+	if v := strings.ToLower(strings.TrimSpace(os.Getenv("REDACT_LLM"))); v == "true" || v == "1" || v == "on" {
+		cfg.LLM = true
+	}
+	for _, d := range strings.Split(os.Getenv("REDACT_DISABLE"), ",") {
+		if d = strings.TrimSpace(d); d != "" {
+			cfg.Disable = append(cfg.Disable, d)
+		}
+	}
+	if v := strings.TrimSpace(os.Getenv("REDACT_PATTERNS_FILE")); v != "" {
+		cfg.PatternsFile = v
+	}
+	return cfg
+}
+
+// LoadPatterns membaca pola tambahan dari file JSON. File tidak ada = tidak ada pola.
+func LoadPatterns(path string) ([]Pattern, error) {
+	if path == "" {
+		return nil, nil
+	}
+	b, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var ps []Pattern
+	if err := json.Unmarshal(b, &ps); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	return ps, nil
+}
+
+var (
+	defaultOnce sync.Once
+	defaultR    *Redactor
+	defaultCfg  Config
+)
+
+func initDefault() {
+	defaultOnce.Do(func() {
+		defaultCfg = ConfigFromEnv()
+		patterns := Builtin(defaultCfg.Disable...)
+		custom, err := LoadPatterns(defaultCfg.PatternsFile)
+		if err != nil {
+			log.Printf("[redact] custom patterns: %v", err)
+		}
+		// pola kustom didahulukan agar istilah domain (vendor, no. kontrak) tidak terpotong pola bawaan
+		r, err := New(append(custom, patterns...))
+		if err != nil {
+			log.Printf("[redact] %v; hanya memakai pola bawaan", err)
+			r, _ = New(patterns)
+		}
+		defaultR = r
+	})
+}
+
+// Default mengembalikan Redactor bersama (pola bawaan + REDACT_PATTERNS_FILE).
+func Default() *Redactor {
+	initDefault()
+	return defaultR
+}
+
+// LogsEnabled: REDACT_LOGS (default true).
+func LogsEnabled() bool {
+	initDefault()
+	return defaultCfg.Logs
+}
+
+// LLMEnabled: REDACT_LLM (default false).
+func LLMEnabled() bool {
+	initDefault()
+	return defaultCfg.LLM
+}
+
+// Log meredaksi s untuk log bila REDACT_LOGS aktif.
+func Log(s string) string {
+	if !LogsEnabled() {
+		return s
+	}
+	return Default().Redact(s)
+}
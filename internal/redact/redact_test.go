@@ -0,0 +1,91 @@
+// internal/redact/redact_test.go
+
+package redact_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"mcp-oilgas/internal/llm"
+	"mcp-oilgas/internal/redact"
+)
+
+func newRedactor(t *testing.T, extra ...redact.Pattern) *redact.Redactor {
+	t.Helper()
+	r, err := redact.New(append(extra, redact.Builtin()...))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	return r
+}
+
+// Pola bawaan untuk log: email, telepon, NIK, API key, nilai password.
+func TestRedactBuiltin(t *testing.T) {
+	r := newRedactor(t)
+	in := "hubungi budi.s@vendor.co.id / +62 812-3456-7890, NIK 3174012345678901, key sk-abcdefghijklmnopqrstuvwx password=Rahasia123 PO-2024-0012 amount 125000000"
+	out := r.Redact(in)
+	for _, leak := range []string{"budi.s@vendor.co.id", "812-3456-7890", "3174012345678901", "sk-abcdefghijklmnopqrstuvwx", "Rahasia123"} {
+		if strings.Contains(out, leak) {
+			t.Fatalf("leaked %q in %q", leak, out)
+		}
+	}
+	for _, keep := range []string{"[EMAIL]", "[PHONE]", "[NIK]", "[API_KEY]", "password=[SECRET]", "PO-2024-0012", "125000000"} {
+		if !strings.Contains(out, keep) {
+			t.Fatalf("expected %q in %q", keep, out)
+		}
+	}
+}
+
+// Placeholder sesi konsisten per nilai dan bisa dikembalikan (termasuk sebagai isi string JSON).
+func TestSessionRoundTrip(t *testing.T) {
+	r := newRedactor(t, redact.Pattern{Name: "vendor", Pattern: `PT "Maju" Jaya`})
+	s := r.NewSession()
+	red := s.Redact(`Vendor PT "Maju" Jaya (ops@maju.co.id) dan cc ops@maju.co.id`)
+	if strings.Count(red, "[EMAIL_1]") != 2 || !strings.Contains(red, "[VENDOR_1]") || s.Len() != 2 {
+		t.Fatalf("unexpected redaction: %q (len %d)", red, s.Len())
+	}
+	if got := s.Restore("Kirim ke [EMAIL_1]; [EMAIL_9] tetap"); got != "Kirim ke ops@maju.co.id; [EMAIL_9] tetap" {
+		t.Fatalf("Restore: %q", got)
+	}
+	if got := s.RestoreJSON(`{"vendor":"[VENDOR_1]"}`); got != `{"vendor":"PT \"Maju\" Jaya"}` {
+		t.Fatalf("RestoreJSON: %q", got)
+	}
+}
+
+// Placeholder yang terpotong antar-delta tetap dikembalikan utuh.
+func TestStreamRestorer(t *testing.T) {
+	s := newRedactor(t).NewSession()
+	s.Redact("a@b.co")
+	sr := s.NewStreamRestorer()
+	var out strings.Builder
+	for _, d := range []string{"Email: [EM", "AIL", "_1] ok [", "catatan]"} {
+		out.WriteString(sr.Write(d))
+	}
+	out.WriteString(sr.Flush())
+	if out.String() != "Email: a@b.co ok [catatan]" {
+		t.Fatalf("stream restore: %q", out.String())
+	}
+}
+
+// WrapClient: model hanya melihat placeholder, pemanggil menerima nilai asli.
+func TestWrapClient(t *testing.T) {
+	fake := &llm.Fake{Answer: "Vendor bisa dihubungi di [EMAIL_1] hari ini."}
+	c := redact.WrapClient(fake, newRedactor(t).NewSession())
+
+	var deltas strings.Builder
+	final, err := c.AnswerStream(context.Background(), "sys", "email vendor: sales@acme.com", func(d string) error {
+		deltas.WriteString(d)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("AnswerStream: %v", err)
+	}
+	if sent := fake.Calls()[0].User; strings.Contains(sent, "sales@acme.com") || !strings.Contains(sent, "[EMAIL_1]") {
+		t.Fatalf("prompt not redacted: %q", sent)
+	}
+	want := "Vendor bisa dihubungi di sales@acme.com hari ini."
+	if final != want || deltas.String() != want {
+		t.Fatalf("final=%q deltas=%q", final, deltas.String())
+	}
+}
@@ -0,0 +1,130 @@
+// internal/redact/session.go
+// Placeholder reversibel per request untuk payload LLM.
+package redact
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// placeholderRe mencocokkan placeholder buatan Session, mis. "[EMAIL_3]".
+var placeholderRe = regexp.MustCompile(`\[([A-Z][A-Z0-9_]*)_(\d+)\]`)
+
+// Session memetakan nilai asli ↔ placeholder selama satu request. Nilai yang sama
+// selalu mendapat placeholder yang sama sehingga model tetap bisa menalar relasinya.
+type Session struct {
+	r *Redactor
+
+	mu      sync.Mutex
+	byValue map[string]string // nilai asli → placeholder
+	byPh    map[string]string // placeholder → nilai asli
+	counter map[string]int    // per label
+}
+
+// NewSession membuat sesi baru di atas Redactor r.
+func (r *Redactor) NewSession() *Session {
+	return &Session{
+		r:       r,
+		byValue: map[string]string{},
+		byPh:    map[string]string{},
+		counter: map[string]int{},
+	}
+}
+
+// Redact mengganti nilai sensitif dengan placeholder bernomor.
+func (s *Session) Redact(text string) string {
+	if s == nil {
+		return text
+	}
+	return s.r.replace(text, func(m match, val string) string {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if ph, ok := s.byValue[val]; ok {
+			return ph
+		}
+		label := strings.ToUpper(m.name)
+		s.counter[label]++
+		ph := fmt.Sprintf("[%s_%d]", label, s.counter[label])
+		s.byValue[val] = ph
+		s.byPh[ph] = val
+		return ph
+	})
+}
+
+// Len mengembalikan jumlah nilai unik yang sudah diredaksi.
+func (s *Session) Len() int {
+	if s == nil {
+		return 0
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.byValue)
+}
+
+// Restore mengembalikan placeholder yang dikenal ke nilai asli (placeholder asing dibiarkan).
+func (s *Session) Restore(text string) string {
+	return s.restore(text, func(v string) string { return v })
+}
+
+// RestoreJSON seperti Restore, tetapi nilai asli di-escape sebagai isi string JSON
+// (untuk keluaran JSON mode, mis. params plan).
+func (s *Session) RestoreJSON(text string) string {
+	return s.restore(text, func(v string) string {
+		b, _ := json.Marshal(v)
+		return string(b[1 : len(b)-1])
+	})
+}
+
+func (s *Session) restore(text string, enc func(string) string) string {
+	if s == nil || !strings.Contains(text, "[") {
+		return text
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.byPh) == 0 {
+		return text
+	}
+	return placeholderRe.ReplaceAllStringFunc(text, func(ph string) string {
+		if v, ok := s.byPh[ph]; ok {
+			return enc(v)
+		}
+		return ph
+	})
+}
+
+// StreamRestorer mengembalikan placeholder pada stream delta token. Placeholder bisa terpotong
+// di antara dua delta, jadi ekor yang mungkin awal placeholder ditahan sampai lengkap.
+type StreamRestorer struct {
+	s   *Session
+	buf strings.Builder
+}
+
+// NewStreamRestorer membuat restorer untuk satu stream.
+func (s *Session) NewStreamRestorer() *StreamRestorer { return &StreamRestorer{s: s} }
+
+// maxPlaceholderLen: batas panjang ekor yang ditahan.
+const maxPlaceholderLen = 48
+
+// Write menerima delta mentah dan mengembalikan teks yang sudah aman dikirim ("" bila ditahan).
+func (sr *StreamRestorer) Write(delta string) string {
+	sr.buf.WriteString(delta)
+	text := sr.buf.String()
+	cut := len(text)
+	if i := strings.LastIndexByte(text, '['); i >= 0 && !strings.Contains(text[i:], "]") && len(text)-i <= maxPlaceholderLen {
+		cut = i
+	}
+	out := sr.s.Restore(text[:cut])
+	sr.buf.Reset()
+	sr.buf.WriteString(text[cut:])
+	return out
+}
+
+// Flush mengembalikan sisa teks yang masih ditahan.
+func (sr *StreamRestorer) Flush() string {
+	out := sr.s.Restore(sr.buf.String())
+	sr.buf.Reset()
+	return out
+}
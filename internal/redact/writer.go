@@ -0,0 +1,36 @@
+// internal/redact/writer.go
+// Writer yang meredaksi setiap baris log sebelum diteruskan.
+package redact
+
+import (
+	"io"
+	"log"
+)
+
+type writer struct {
+	next io.Writer
+	r    *Redactor
+}
+
+// NewWriter membungkus w; setiap Write diredaksi dengan r (nil = Default()).
+// Satu panggilan log.Print* = satu Write, sehingga nilai tidak terpotong antar-Write.
+func NewWriter(w io.Writer, r *Redactor) io.Writer {
+	if r == nil {
+		r = Default()
+	}
+	return &writer{next: w, r: r}
+}
+
+func (w *writer) Write(p []byte) (int, error) {
+	if _, err := io.WriteString(w.next, w.r.Redact(string(p))); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// InstallLogFilter memasang redaksi pada logger standar bila REDACT_LOGS aktif.
+func InstallLogFilter() {
+	if LogsEnabled() {
+		log.SetOutput(NewWriter(log.Writer(), nil))
+	}
+}
@@ -0,0 +1,37 @@
+// internal/reembed/checkpoint.go
+package reembed
+
+// tracker memajukan checkpoint hanya sampai batch berurutan yang sudah selesai, sehingga
+// walaupun worker paralel selesai tidak berurutan, last_id yang disimpan selalu aman
+// (semua chunk dengan id <= last_id sudah diproses).
+type tracker struct {
+	next    int // seq batch berikutnya yang ditunggu
+	pending map[int]batchDone
+}
+
+type batchDone struct {
+	seq       int
+	lastID    int64
+	processed int
+	failed    int
+}
+
+func newTracker() *tracker { return &tracker{pending: map[int]batchDone{}} }
+
+// done mencatat batch selesai dan mengembalikan akumulasi batch berurutan yang kini bisa
+// di-checkpoint (ok=false bila masih menunggu batch sebelumnya).
+func (t *tracker) done(d batchDone) (adv batchDone, ok bool) {
+	t.pending[d.seq] = d
+	for {
+		b, found := t.pending[t.next]
+		if !found {
+			return adv, ok
+		}
+		delete(t.pending, t.next)
+		t.next++
+		adv.seq, adv.lastID = b.seq, b.lastID
+		adv.processed += b.processed
+		adv.failed += b.failed
+		ok = true
+	}
+}
@@ -0,0 +1,445 @@
+// internal/reembed/reembed.go
+// Job re-embedding doc_chunks ke model embedding baru tanpa menghapus data:
+//
+//  1. Run mengisi kolom bayangan embedding_next_* per batch (worker paralel, rate limit, retry).
+//     Checkpoint (reembed_jobs.last_id) disimpan setelah setiap batch berurutan selesai, sehingga
+//     job yang dihentikan bisa dilanjutkan dengan nama yang sama. Query tetap memakai kolom aktif
+//     (EMBED_READ=current), atau vektor baru bila sudah ada (EMBED_READ=next).
+//  2. Cutover memindahkan kolom bayangan ke kolom aktif setelah cakupan lengkap.
+package reembed
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"mcp-oilgas/internal/chunking"
+	"mcp-oilgas/internal/llm"
+	"mcp-oilgas/internal/repositories/embeddings"
+)
+
+// Config satu job re-embedding.
+type Config struct {
+	Name        string           // kunci checkpoint; default Model.String()
+	Model       embeddings.Model // model target (dicatat di embedding_next_model/version)
+	Dim         int              // dimensi yang diharapkan; 0 = ikuti batch pertama
+	Storage     string           // f32|i8
+	Batch       int              // chunk per panggilan Embed (default 64)
+	Concurrency int              // worker paralel (default 2)
+	RPS         float64          // maksimal panggilan Embed per detik; 0 = tanpa batas
+	Retries     int              // percobaan ulang per batch (default 5)
+	Where       string           // filter SQL tambahan, opsional
+	Reset       bool             // mulai ulang dari id 0
+}
+
+// Progress diringkas di akhir Run.
+type Progress struct {
+	LastID    int64 `json:"last_id"`
+	Processed int64 `json:"processed"`
+	Failed    int64 `json:"failed"`
+	Pending   int64 `json:"pending"` // sisa chunk yang belum punya vektor model target
+}
+
+// Runner menjalankan job; Logf opsional.
+type Runner struct {
+	DB       *sql.DB
+	Embedder llm.Embedder
+	Cfg      Config
+	Logf     func(format string, args ...any)
+}
+
+func (r *Runner) logf(format string, args ...any) {
+	if r.Logf != nil {
+		r.Logf(format, args...)
+	}
+}
+
+func (r *Runner) defaults() {
+	if r.Cfg.Name == "" {
+		r.Cfg.Name = r.Cfg.Model.String()
+	}
+	if r.Cfg.Batch <= 0 {
+		r.Cfg.Batch = 64
+	}
+	if r.Cfg.Concurrency <= 0 {
+		r.Cfg.Concurrency = 2
+	}
+	if r.Cfg.Retries <= 0 {
+		r.Cfg.Retries = 5
+	}
+}
+
+// pending: chunk yang belum punya vektor model target, baik di kolom bayangan maupun aktif.
+// Argumen: model, version, model, version.
+const pending = `NOT (COALESCE(embedding_next_model, '') = ? AND COALESCE(embedding_next_version, '') = ?)
+	AND NOT (COALESCE(embedding_model, '') = ? AND COALESCE(embedding_version, '') = ? AND embedding_bin IS NOT NULL)`
+
+func (r *Runner) pendingWhere() (string, []any) {
+	m := r.Cfg.Model
+	w := pending
+	if r.Cfg.Where != "" {
+		w += ` AND (` + r.Cfg.Where + `)`
+	}
+	return w, []any{m.Name, m.Version, m.Name, m.Version}
+}
+
+// Pending menghitung chunk yang belum punya vektor model target.
+func (r *Runner) Pending(ctx context.Context) (int64, error) {
+	r.defaults()
+	w, args := r.pendingWhere()
+	var n int64
+	err := r.DB.QueryRowContext(ctx, `SELECT COUNT(*) FROM doc_chunks WHERE `+w, args...).Scan(&n)
+	return n, err
+}
+
+type work struct {
+	seq    int
+	ids    []int64
+	texts  []string
+	lastID int64
+}
+
+// Run memproses chunk pending mulai dari checkpoint job.
+func (r *Runner) Run(ctx context.Context) (Progress, error) {
+	r.defaults()
+	var prog Progress
+	if r.Embedder == nil {
+		return prog, errors.New("reembed: embedder is nil")
+	}
+	lastID, err := r.startJob(ctx)
+	if err != nil {
+		return prog, err
+	}
+	prog.LastID = lastID
+	r.logf("job %q: model=%s dim=%d resume from id %d", r.Cfg.Name, r.Cfg.Model, r.Cfg.Dim, lastID)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	jobs := make(chan work, r.Cfg.Concurrency)
+	results := make(chan batchDone, r.Cfg.Concurrency)
+	var (
+		fatalOnce sync.Once
+		fatal     error
+		dimMu     sync.Mutex
+		dim       = r.Cfg.Dim // 0 → dikunci dari batch pertama
+	)
+	curDim := func() int { dimMu.Lock(); defer dimMu.Unlock(); return dim }
+	fail := func(err error) {
+		fatalOnce.Do(func() { fatal = err; cancel() })
+	}
+	limiter := newLimiter(r.Cfg.RPS)
+	defer limiter.stop()
+
+	// producer: batch berurutan menurut id
+	go func() {
+		defer close(jobs)
+		w, args := r.pendingWhere()
+		after, seq := lastID, 0
+		for {
+			b, err := r.fetch(ctx, w, args, after)
+			if err != nil {
+				if ctx.Err() == nil {
+					fail(err)
+				}
+				return
+			}
+			if len(b.ids) == 0 {
+				return
+			}
+			b.seq, after = seq, b.lastID
+			seq++
+			select {
+			case jobs <- b:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for i := 0; i < r.Cfg.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for b := range jobs {
+				vecs, err := r.embedWithRetry(ctx, limiter, b.texts)
+				d := batchDone{seq: b.seq, lastID: b.lastID}
+				if err != nil {
+					if ctx.Err() != nil {
+						return
+					}
+					// batch gagal dilewati; chunk-nya tetap pending untuk run berikutnya (-reset)
+					r.logf("batch ids %d..%d failed after %d attempts: %v", b.ids[0], b.lastID, r.Cfg.Retries, err)
+					d.failed = len(b.ids)
+				} else {
+					dimMu.Lock()
+					if dim == 0 {
+						dim = len(vecs[0])
+					}
+					want := dim
+					dimMu.Unlock()
+					for _, v := range vecs {
+						if len(v) != want {
+							fail(fmt.Errorf("reembed: model returned dimension %d, expected %d", len(v), want))
+							return
+						}
+					}
+					if err := r.store(ctx, b.ids, vecs); err != nil {
+						fail(err)
+						return
+					}
+					d.processed = len(b.ids)
+				}
+				select {
+				case results <- d:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+	go func() { wg.Wait(); close(results) }()
+
+	t := newTracker()
+	for d := range results {
+		adv, ok := t.done(d)
+		if !ok {
+			continue
+		}
+		prog.LastID = adv.lastID
+		prog.Processed += int64(adv.processed)
+		prog.Failed += int64(adv.failed)
+		if err := r.checkpoint(context.WithoutCancel(ctx), adv, curDim()); err != nil {
+			fail(err)
+			continue
+		}
+		r.logf("checkpoint id %d: processed %d, failed %d", prog.LastID, prog.Processed, prog.Failed)
+	}
+
+	status, msg := "done", ""
+	switch {
+	case fatal != nil:
+		status, msg = "failed", fatal.Error()
+	case ctx.Err() != nil:
+		status, msg = "running", "interrupted"
+	}
+	bg := context.WithoutCancel(ctx)
+	_, _ = r.DB.ExecContext(bg, `UPDATE reembed_jobs SET status = ?, last_error = ?, dim = COALESCE(dim, ?) WHERE name = ?`,
+		status, nullable(msg), nullableInt(curDim()), r.Cfg.Name)
+	if n, err := r.Pending(bg); err == nil {
+		prog.Pending = n
+	}
+	if fatal != nil {
+		return prog, fatal
+	}
+	return prog, ctx.Err()
+}
+
+func (r *Runner) startJob(ctx context.Context) (int64, error) {
+	m := r.Cfg.Model
+	var model, version string
+	var lastID int64
+	err := r.DB.QueryRowContext(ctx, `SELECT model, version, last_id FROM reembed_jobs WHERE name = ?`, r.Cfg.Name).
+		Scan(&model, &version, &lastID)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		_, err = r.DB.ExecContext(ctx, `INSERT INTO reembed_jobs (name, model, version, dim) VALUES (?, ?, ?, ?)`,
+			r.Cfg.Name, m.Name, m.Version, nullableInt(r.Cfg.Dim))
+		return 0, err
+	case err != nil:
+		return 0, err
+	}
+	if model != m.Name || version != m.Version {
+		return 0, fmt.Errorf("reembed: job %q targets %s@%s, not %s", r.Cfg.Name, model, version, m)
+	}
+	if r.Cfg.Reset {
+		lastID = 0
+		_, err = r.DB.ExecContext(ctx, `UPDATE reembed_jobs SET last_id = 0, processed = 0, failed = 0, status = 'running', last_error = NULL WHERE name = ?`, r.Cfg.Name)
+	} else {
+		_, err = r.DB.ExecContext(ctx, `UPDATE reembed_jobs SET status = 'running', last_error = NULL WHERE name = ?`, r.Cfg.Name)
+	}
+	return lastID, err
+}
+
+func (r *Runner) fetch(ctx context.Context, where string, args []any, after int64) (work, error) {
+	var b work
+	rows, err := r.DB.QueryContext(ctx, `
+		SELECT id, COALESCE(title, ''), COALESCE(section, ''), COALESCE(snippet, '')
+		  FROM doc_chunks
+		 WHERE id > ? AND `+where+`
+		 ORDER BY id LIMIT ?`, append(append([]any{after}, args...), r.Cfg.Batch)...)
+	if err != nil {
+		return b, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			id                      int64
+			title, section, snippet string
+		)
+		if err := rows.Scan(&id, &title, &section, &snippet); err != nil {
+			return b, err
+		}
+		// teks sama dengan pipeline ingest (judul + heading + isi chunk)
+		txt := chunking.Chunk{Heading: section, Text: snippet}.EmbedText(title)
+		if len(txt) > 8000 {
+			txt = txt[:8000]
+		}
+		if strings.TrimSpace(txt) == "" {
+			txt = title
+		}
+		b.ids = append(b.ids, id)
+		b.texts = append(b.texts, txt)
+		b.lastID = id
+	}
+	return b, rows.Err()
+}
+
+func (r *Runner) embedWithRetry(ctx context.Context, lim *limiter, texts []string) ([][]float32, error) {
+	var err error
+	backoff := time.Second
+	for attempt := 1; attempt <= r.Cfg.Retries; attempt++ {
+		if err := lim.wait(ctx); err != nil {
+			return nil, err
+		}
+		var vecs [][]float32
+		vecs, err = r.Embedder.Embed(ctx, texts)
+		if err == nil && len(vecs) != len(texts) {
+			err = fmt.Errorf("got %d vectors for %d texts", len(vecs), len(texts))
+		}
+		if err == nil {
+			return vecs, nil
+		}
+		if attempt == r.Cfg.Retries {
+			break
+		}
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		backoff = min(backoff*2, 30*time.Second)
+	}
+	return nil, err
+}
+
+func (r *Runner) store(ctx context.Context, ids []int64, vecs [][]float32) error {
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	stmt, err := tx.PrepareContext(ctx, `UPDATE doc_chunks SET `+embeddings.NextSetColumns+` WHERE id = ?`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	for i, id := range ids {
+		v, err := embeddings.Encode(vecs[i], r.Cfg.Model, r.Cfg.Storage)
+		if err != nil {
+			return err
+		}
+		if v.Bin == nil {
+			return fmt.Errorf("reembed: storage %q has no binary form", r.Cfg.Storage)
+		}
+		if _, err := stmt.ExecContext(ctx, v.Bin, v.Model, v.Version, v.Dim, id); err != nil {
+			return fmt.Errorf("update id=%d: %w", id, err)
+		}
+	}
+	return tx.Commit()
+}
+
+func (r *Runner) checkpoint(ctx context.Context, adv batchDone, dim int) error {
+	_, err := r.DB.ExecContext(ctx, `
+		UPDATE reembed_jobs SET last_id = ?, processed = processed + ?, failed = failed + ?, dim = COALESCE(dim, ?)
+		 WHERE name = ?`, adv.lastID, adv.processed, adv.failed, nullableInt(dim), r.Cfg.Name)
+	return err
+}
+
+// Cutover memindahkan vektor model target dari kolom bayangan ke kolom aktif (per batch).
+// Tanpa force, ditolak selama masih ada chunk pending.
+func (r *Runner) Cutover(ctx context.Context, force bool) (int64, error) {
+	r.defaults()
+	if !force {
+		n, err := r.Pending(ctx)
+		if err != nil {
+			return 0, err
+		}
+		if n > 0 {
+			return 0, fmt.Errorf("reembed: %d chunks still pending for %s; run the job first or use force", n, r.Cfg.Model)
+		}
+	}
+	var total int64
+	for {
+		res, err := r.DB.ExecContext(ctx, `
+			UPDATE doc_chunks
+			   SET embedding_bin = embedding_next_bin, embedding = NULL,
+			       embedding_model = embedding_next_model, embedding_version = embedding_next_version,
+			       embedding_dim = embedding_next_dim,
+			       embedding_next_bin = NULL, embedding_next_model = NULL,
+			       embedding_next_version = NULL, embedding_next_dim = NULL
+			 WHERE embedding_next_bin IS NOT NULL
+			   AND embedding_next_model = ? AND COALESCE(embedding_next_version, '') = ?
+			 LIMIT ?`, r.Cfg.Model.Name, r.Cfg.Model.Version, r.Cfg.Batch*10)
+		if err != nil {
+			return total, err
+		}
+		n, _ := res.RowsAffected()
+		total += n
+		if n == 0 {
+			break
+		}
+		r.logf("cutover: %d rows", total)
+	}
+	_, _ = r.DB.ExecContext(ctx, `UPDATE reembed_jobs SET status = 'cutover' WHERE name = ?`, r.Cfg.Name)
+	return total, nil
+}
+
+func nullable(s string) any {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+func nullableInt(n int) any {
+	if n <= 0 {
+		return nil
+	}
+	return n
+}
+
+// limiter membatasi panggilan Embed per detik (dibagi semua worker).
+type limiter struct {
+	t *time.Ticker
+}
+
+func newLimiter(rps float64) *limiter {
+	if rps <= 0 {
+		return &limiter{}
+	}
+	return &limiter{t: time.NewTicker(time.Duration(float64(time.Second) / rps))}
+}
+
+func (l *limiter) wait(ctx context.Context) error {
+	if l.t == nil {
+		return ctx.Err()
+	}
+	select {
+	case <-l.t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (l *limiter) stop() {
+	if l.t != nil {
+		l.t.Stop()
+	}
+}
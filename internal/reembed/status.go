@@ -0,0 +1,75 @@
+// internal/reembed/status.go
+package reembed
+
+import (
+	"context"
+	"database/sql"
+)
+
+// ModelCoverage adalah jumlah chunk per (kolom, model, versi, dimensi).
+type ModelCoverage struct {
+	Column  string `json:"column"` // current|next
+	Model   string `json:"model"`
+	Version string `json:"version"`
+	Dim     int    `json:"dim"`
+	Chunks  int64  `json:"chunks"`
+}
+
+// Coverage meringkas model embedding di kolom aktif dan bayangan.
+func Coverage(ctx context.Context, db *sql.DB) ([]ModelCoverage, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT 'current', COALESCE(embedding_model, ''), COALESCE(embedding_version, ''), COALESCE(embedding_dim, 0), COUNT(*)
+		  FROM doc_chunks WHERE embedding_bin IS NOT NULL OR embedding IS NOT NULL
+		 GROUP BY 2, 3, 4
+		UNION ALL
+		SELECT 'next', COALESCE(embedding_next_model, ''), COALESCE(embedding_next_version, ''), COALESCE(embedding_next_dim, 0), COUNT(*)
+		  FROM doc_chunks WHERE embedding_next_bin IS NOT NULL
+		 GROUP BY 2, 3, 4
+		 ORDER BY 1, 5 DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []ModelCoverage
+	for rows.Next() {
+		var c ModelCoverage
+		if err := rows.Scan(&c.Column, &c.Model, &c.Version, &c.Dim, &c.Chunks); err != nil {
+			return nil, err
+		}
+		out = append(out, c)
+	}
+	return out, rows.Err()
+}
+
+// Job adalah satu baris reembed_jobs.
+type Job struct {
+	Name      string `json:"name"`
+	Model     string `json:"model"`
+	Version   string `json:"version"`
+	Dim       int    `json:"dim"`
+	LastID    int64  `json:"last_id"`
+	Processed int64  `json:"processed"`
+	Failed    int64  `json:"failed"`
+	Status    string `json:"status"`
+	LastError string `json:"last_error,omitempty"`
+}
+
+// Jobs mengembalikan semua job re-embedding, terbaru dulu.
+func Jobs(ctx context.Context, db *sql.DB) ([]Job, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT name, model, version, COALESCE(dim, 0), last_id, processed, failed, status, COALESCE(last_error, '')
+		  FROM reembed_jobs ORDER BY updated_at DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []Job
+	for rows.Next() {
+		var j Job
+		if err := rows.Scan(&j.Name, &j.Model, &j.Version, &j.Dim, &j.LastID, &j.Processed, &j.Failed, &j.Status, &j.LastError); err != nil {
+			return nil, err
+		}
+		out = append(out, j)
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,229 @@
+// internal/repositories/documents/documents.go
+// Tabel documents: satu baris per doc_id dengan metadata (jenis, aset, sumur, area, revisi,
+// tanggal efektif, bahasa, tag) yang dipakai untuk memfilter retrieval doc_chunks.
+package documents
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrNotFound dikembalikan Get untuk doc_id yang tidak ada.
+var ErrNotFound = errors.New("document not found")
+
+// Document adalah metadata satu dokumen.
+type Document struct {
+	DocID         string     `json:"doc_id"`
+	Title         string     `json:"title,omitempty"`
+	URL           string     `json:"url,omitempty"`
+	Collection    string     `json:"collection,omitempty"`
+	Type          string     `json:"type,omitempty"` // sop|manual|report|permit|...
+	Asset         string     `json:"asset,omitempty"`
+	Well          string     `json:"well,omitempty"`
+	Area          string     `json:"area,omitempty"`
+	Revision      string     `json:"revision,omitempty"`
+	EffectiveDate string     `json:"effective_date,omitempty"` // YYYY-MM-DD
+	Lang          string     `json:"lang,omitempty"`
+	Tags          []string   `json:"tags,omitempty"`
+	Version       int        `json:"version,omitempty"`      // versi aktif (lihat versions.go)
+	ContentHash   string     `json:"content_hash,omitempty"` // sha256 file versi aktif
+	DeletedAt     *time.Time `json:"deleted_at,omitempty"`   // soft delete
+	UpdatedAt     *time.Time `json:"updated_at,omitempty"`
+}
+
+// Normalize merapikan nilai (jenis/bahasa/tag huruf kecil, tanggal parsial → YYYY-MM-DD).
+func (d *Document) Normalize() error {
+	d.DocID = strings.TrimSpace(d.DocID)
+	d.Collection = strings.ToLower(strings.TrimSpace(d.Collection))
+	d.Type = strings.ToLower(strings.TrimSpace(d.Type))
+	d.Lang = strings.ToLower(strings.TrimSpace(d.Lang))
+	d.Asset, d.Well, d.Area = strings.TrimSpace(d.Asset), strings.TrimSpace(d.Well), strings.TrimSpace(d.Area)
+	d.Revision = strings.TrimSpace(d.Revision)
+	if s := strings.TrimSpace(d.EffectiveDate); s != "" {
+		start, _, err := parseDateRange(s)
+		if err != nil {
+			return fmt.Errorf("effective_date: %w", err)
+		}
+		d.EffectiveDate = start.Format(dateLayout)
+	}
+	tags := d.Tags[:0]
+	for _, t := range d.Tags {
+		if t = strings.ToLower(strings.TrimSpace(t)); t != "" {
+			tags = append(tags, t)
+		}
+	}
+	d.Tags = tags
+	return nil
+}
+
+// Execer dipenuhi *sql.DB maupun *sql.Tx (Upsert bisa ikut transaksi ingest).
+type Execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+func nullable(s string) any {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+// Upsert menyisipkan/memperbarui dokumen. Field metadata kosong tidak menimpa nilai yang sudah ada,
+// sehingga upload ulang tanpa metadata tidak menghapus metadata yang diisi admin.
+func Upsert(ctx context.Context, db Execer, d Document) error {
+	if err := d.Normalize(); err != nil {
+		return err
+	}
+	if d.DocID == "" {
+		return errors.New("documents: empty doc_id")
+	}
+	if d.Collection == "" {
+		d.Collection = "default"
+	}
+	var tags any
+	if len(d.Tags) > 0 {
+		b, _ := json.Marshal(d.Tags)
+		tags = string(b)
+	}
+	_, err := db.ExecContext(ctx, `
+		INSERT INTO documents (doc_id, title, url, collection, doc_type, asset_id, well_id, area,
+		                       revision, effective_date, lang, tags)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE
+		  title          = IF(VALUES(title) = '', title, VALUES(title)),
+		  url            = COALESCE(VALUES(url), url),
+		  collection     = VALUES(collection),
+		  doc_type       = COALESCE(VALUES(doc_type), doc_type),
+		  asset_id       = COALESCE(VALUES(asset_id), asset_id),
+		  well_id        = COALESCE(VALUES(well_id), well_id),
+		  area           = COALESCE(VALUES(area), area),
+		  revision       = COALESCE(VALUES(revision), revision),
+		  effective_date = COALESCE(VALUES(effective_date), effective_date),
+		  lang           = COALESCE(VALUES(lang), lang),
+		  tags           = COALESCE(VALUES(tags), tags)`,
+		d.DocID, d.Title, nullable(d.URL), d.Collection, nullable(d.Type), nullable(d.Asset), nullable(d.Well),
+		nullable(d.Area), nullable(d.Revision), nullable(d.EffectiveDate), nullable(d.Lang), tags)
+	if err != nil {
+		return fmt.Errorf("upsert document %s: %w", d.DocID, err)
+	}
+	return nil
+}
+
+// Repo membaca & memperbarui tabel documents.
+type Repo struct {
+	DB *sql.DB
+}
+
+// Update menyimpan metadata dari admin (lihat Upsert); label revisi juga dicatat pada versi aktif
+// agar filter revision tetap menemukan versi tersebut setelah di-supersede.
+func (r *Repo) Update(ctx context.Context, d Document) error {
+	if err := Upsert(ctx, r.DB, d); err != nil {
+		return err
+	}
+	_, err := r.DB.ExecContext(ctx, `
+		UPDATE document_versions v JOIN documents d ON d.doc_id = v.doc_id AND v.version = d.current_version
+		   SET v.revision = d.revision
+		 WHERE d.doc_id = ? AND d.revision IS NOT NULL`, strings.TrimSpace(d.DocID))
+	return err
+}
+
+const docColumns = `doc_id, title, COALESCE(url,''), collection, COALESCE(doc_type,''), COALESCE(asset_id,''),
+	COALESCE(well_id,''), COALESCE(area,''), COALESCE(revision,''),
+	COALESCE(DATE_FORMAT(effective_date, '%Y-%m-%d'),''), COALESCE(lang,''), tags, current_version,
+	COALESCE(content_hash,''), deleted_at, updated_at`
+
+func scanDoc(row interface{ Scan(...any) error }) (*Document, error) {
+	var (
+		d       Document
+		tags    []byte
+		deleted sql.NullTime
+		updated time.Time
+	)
+	if err := row.Scan(&d.DocID, &d.Title, &d.URL, &d.Collection, &d.Type, &d.Asset, &d.Well, &d.Area,
+		&d.Revision, &d.EffectiveDate, &d.Lang, &tags, &d.Version, &d.ContentHash, &deleted, &updated); err != nil {
+		return nil, err
+	}
+	if len(tags) > 0 {
+		_ = json.Unmarshal(tags, &d.Tags)
+	}
+	if deleted.Valid {
+		d.DeletedAt = &deleted.Time
+	}
+	d.UpdatedAt = &updated
+	return &d, nil
+}
+
+// Get mengambil satu dokumen.
+func (r *Repo) Get(ctx context.Context, docID string) (*Document, error) {
+	d, err := scanDoc(r.DB.QueryRowContext(ctx, `SELECT `+docColumns+` FROM documents WHERE doc_id = ?`, docID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return d, err
+}
+
+// List mengembalikan dokumen yang cocok dengan filter, terbaru lebih dulu. Dokumen yang
+// di-soft-delete hanya disertakan bila includeDeleted.
+func (r *Repo) List(ctx context.Context, f Filter, limit int, includeDeleted bool) ([]Document, error) {
+	if limit <= 0 || limit > 1000 {
+		limit = 100
+	}
+	q := `SELECT ` + docColumns + ` FROM documents`
+	where, args := f.Where("doc_id")
+	if !includeDeleted {
+		where = strings.TrimPrefix(where+" AND deleted_at IS NULL", " AND ")
+	}
+	if where != "" {
+		q += ` WHERE ` + where
+	}
+	q += ` ORDER BY updated_at DESC, doc_id LIMIT ?`
+	args = append(args, limit)
+	return r.query(ctx, q, args...)
+}
+
+// GetMany mengambil metadata untuk beberapa doc_id sekaligus (doc_id tanpa baris dilewati).
+func (r *Repo) GetMany(ctx context.Context, ids []string) (map[string]Document, error) {
+	out := make(map[string]Document, len(ids))
+	if len(ids) == 0 {
+		return out, nil
+	}
+	uniq := make([]string, 0, len(ids))
+	seen := map[string]bool{}
+	for _, id := range ids {
+		if id != "" && !seen[id] {
+			seen[id] = true
+			uniq = append(uniq, id)
+		}
+	}
+	where, args := Filter{DocIDs: uniq}.Where("doc_id")
+	docs, err := r.query(ctx, `SELECT `+docColumns+` FROM documents WHERE `+where, args...)
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range docs {
+		out[d.DocID] = d
+	}
+	return out, nil
+}
+
+func (r *Repo) query(ctx context.Context, q string, args ...any) ([]Document, error) {
+	rows, err := r.DB.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []Document
+	for rows.Next() {
+		d, err := scanDoc(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *d)
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,144 @@
+package documents_test
+
+import (
+	"encoding/json"
+	"net/url"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"mcp-oilgas/internal/repositories/documents"
+)
+
+func TestFilterUnmarshalAliases(t *testing.T) {
+	var f documents.Filter
+	in := `{"doc_type": "SOP", "area": "North", "revised_after": 2024, "tags": "h2s, Confined Space"}`
+	if err := json.Unmarshal([]byte(in), &f); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if !reflect.DeepEqual([]string(f.Types), []string{"sop"}) || !reflect.DeepEqual([]string(f.Areas), []string{"North"}) {
+		t.Fatalf("unexpected type/area: %+v", f)
+	}
+	if f.EffectiveFrom != "2025-01-01" || f.EffectiveTo != "" {
+		t.Fatalf("revised_after 2024 should start at 2025-01-01, got %q..%q", f.EffectiveFrom, f.EffectiveTo)
+	}
+	if !reflect.DeepEqual([]string(f.Tags), []string{"h2s", "confined space"}) {
+		t.Fatalf("unexpected tags: %v", f.Tags)
+	}
+
+	if err := json.Unmarshal([]byte(`{"type": "sop", "colour": "red"}`), &f); err == nil || !strings.Contains(err.Error(), "colour") {
+		t.Fatalf("expected unknown key error, got %v", err)
+	}
+	if err := json.Unmarshal([]byte(`{"effective_to": "last year"}`), &f); err == nil {
+		t.Fatalf("expected invalid date error")
+	}
+}
+
+func TestFilterWhere(t *testing.T) {
+	if w, args := (documents.Filter{}).Where("c.doc_id"); w != "" || len(args) != 0 {
+		t.Fatalf("empty filter should produce no predicate, got %q %v", w, args)
+	}
+
+	f := documents.Filter{
+		DocIDs:        documents.StringList{"DOC-1"},
+		Types:         documents.StringList{"sop", "manual"},
+		Tags:          documents.StringList{"h2s"},
+		EffectiveFrom: "2025-01-01",
+	}
+	w, args := f.Where("c.doc_id")
+	want := "c.doc_id IN (?) AND c.doc_id IN (SELECT d.doc_id FROM documents d WHERE d.doc_type IN (?,?) AND " +
+		"JSON_CONTAINS(d.tags, JSON_QUOTE(?)) AND d.effective_date >= ?)"
+	if w != want {
+		t.Fatalf("where mismatch:\n got %s\nwant %s", w, want)
+	}
+	if !reflect.DeepEqual(args, []any{"DOC-1", "sop", "manual", "h2s", "2025-01-01"}) {
+		t.Fatalf("args mismatch: %v", args)
+	}
+}
+
+func TestFilterChunkWhere(t *testing.T) {
+	w, args := (documents.Filter{}).ChunkWhere("c.doc_id")
+	if w != "c.deleted_at IS NULL AND c.superseded = 0" || len(args) != 0 {
+		t.Fatalf("default chunk predicate mismatch: %q %v", w, args)
+	}
+
+	var f documents.Filter
+	if err := json.Unmarshal([]byte(`{"doc_id": "DOC-1", "version": "2"}`), &f); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	w, args = f.ChunkWhere("doc_id")
+	if w != "deleted_at IS NULL AND doc_version IN (?) AND doc_id IN (?)" || !reflect.DeepEqual(args, []any{"2", "DOC-1"}) {
+		t.Fatalf("versioned chunk predicate mismatch: %q %v", w, args)
+	}
+
+	w, _ = (documents.Filter{Revisions: documents.StringList{"B"}}).ChunkWhere("doc_id")
+	if strings.Contains(w, "superseded") || !strings.Contains(w, "document_versions v WHERE v.revision IN (?)") {
+		t.Fatalf("revision filter should search version history: %q", w)
+	}
+	if err := json.Unmarshal([]byte(`{"version": "latest"}`), &f); err == nil {
+		t.Fatalf("expected invalid version error")
+	}
+}
+
+func TestFilterMatches(t *testing.T) {
+	doc := documents.Document{DocID: "SOP-7", Type: "sop", Area: "North", EffectiveDate: "2025-03-10", Tags: []string{"h2s"}}
+	cases := []struct {
+		f    documents.Filter
+		want bool
+	}{
+		{documents.Filter{}, true},
+		{documents.Filter{Types: documents.StringList{"sop"}, Areas: documents.StringList{"north"}}, true},
+		{documents.Filter{Areas: documents.StringList{"South"}}, false},
+		{documents.Filter{Tags: documents.StringList{"h2s", "lockout"}}, false},
+		{documents.Filter{EffectiveFrom: "2025-01-01"}, true},
+		{documents.Filter{EffectiveTo: "2024-12-31"}, false},
+	}
+	for i, c := range cases {
+		if got := c.f.Matches(doc); got != c.want {
+			t.Fatalf("case %d: Matches=%v want %v", i, got, c.want)
+		}
+	}
+	if (documents.Filter{EffectiveFrom: "2020-01-01"}).Matches(documents.Document{DocID: "x"}) {
+		t.Fatalf("document without effective_date must not match a date filter")
+	}
+}
+
+func TestFilterFromQuery(t *testing.T) {
+	q := url.Values{"type": {"sop,manual"}, "well": {"A-12"}, "effective_before": {"2024-06"}, "top_k": {"5"}}
+	f, err := documents.FilterFromQuery(q)
+	if err != nil {
+		t.Fatalf("from query: %v", err)
+	}
+	if len(f.Types) != 2 || f.Wells[0] != "A-12" || f.EffectiveTo != "2024-05-31" {
+		t.Fatalf("unexpected filter: %+v", f)
+	}
+	if _, err := documents.FilterFromQuery(url.Values{"effective_from": {"soon"}}); err == nil {
+		t.Fatalf("expected invalid date error")
+	}
+}
+
+func TestDocumentNormalize(t *testing.T) {
+	d := documents.Document{DocID: " D1 ", Type: "SOP", EffectiveDate: "2024-07", Tags: []string{" H2S ", ""}}
+	if err := d.Normalize(); err != nil {
+		t.Fatalf("normalize: %v", err)
+	}
+	if d.DocID != "D1" || d.Type != "sop" || d.EffectiveDate != "2024-07-01" || !reflect.DeepEqual(d.Tags, []string{"h2s"}) {
+		t.Fatalf("unexpected normalized document: %+v", d)
+	}
+}
+
+func TestUploadPath(t *testing.T) {
+	cases := map[string]string{
+		"uploads/0123abcd/sop.pdf": filepath.Join("up", "0123abcd", "sop.pdf"),
+		"uploads/sop.pdf":          filepath.Join("up", "sop.pdf"),
+		"uploads/../etc/passwd":    "",
+		"uploads/a/b/c.pdf":        "",
+		"https://x/sop.pdf":        "",
+	}
+	for u, want := range cases {
+		if got := documents.UploadPath(&documents.Document{URL: u}, "up"); got != want {
+			t.Fatalf("UploadPath(%q) = %q, want %q", u, got, want)
+		}
+	}
+}
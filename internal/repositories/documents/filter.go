@@ -0,0 +1,344 @@
+// internal/repositories/documents/filter.go
+// Filter metadata dokumen untuk retrieval (SearchHybrid, /rag/search_v2, answer_with_docs).
+// Contoh: "hanya SOP area North yang direvisi setelah 2024" →
+//
+//	{"type": "sop", "area": "North", "effective_after": "2024"}
+package documents
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// StringList menerima string tunggal ("sop"), daftar dipisah koma ("sop,manual") atau array JSON.
+type StringList []string
+
+func (l *StringList) UnmarshalJSON(b []byte) error {
+	var one string
+	if err := json.Unmarshal(b, &one); err == nil {
+		*l = splitList(one)
+		return nil
+	}
+	var many []string
+	if err := json.Unmarshal(b, &many); err != nil {
+		return fmt.Errorf("expected string or array of strings")
+	}
+	var out []string
+	for _, s := range many {
+		out = append(out, splitList(s)...)
+	}
+	*l = out
+	return nil
+}
+
+func splitList(s string) []string {
+	var out []string
+	for _, p := range strings.Split(s, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// Filter: semua field bersifat AND; nilai dalam satu field bersifat OR (kecuali Tags: semua harus ada).
+// Tanggal disimpan ternormalisasi "YYYY-MM-DD" (inklusif).
+type Filter struct {
+	DocIDs        StringList `json:"doc_id,omitempty"`
+	Collections   StringList `json:"collection,omitempty"`
+	Types         StringList `json:"type,omitempty"`
+	Assets        StringList `json:"asset,omitempty"`
+	Wells         StringList `json:"well,omitempty"`
+	Areas         StringList `json:"area,omitempty"`
+	Revisions     StringList `json:"revision,omitempty"`
+	Langs         StringList `json:"lang,omitempty"`
+	Tags          StringList `json:"tags,omitempty"`
+	Versions      StringList `json:"version,omitempty"` // versi upload (doc_chunks.doc_version); kosong = terbaru
+	EffectiveFrom string     `json:"effective_from,omitempty"`
+	EffectiveTo   string     `json:"effective_to,omitempty"`
+}
+
+// Alias kunci yang lazim dipakai planner/LLM → kunci kanonik.
+var filterKeys = map[string]string{
+	"doc_id": "doc_id", "doc_ids": "doc_id",
+	"collection": "collection", "collections": "collection",
+	"type": "type", "types": "type", "doc_type": "type", "document_type": "type",
+	"asset": "asset", "assets": "asset", "asset_id": "asset",
+	"well": "well", "wells": "well", "well_id": "well",
+	"area": "area", "areas": "area",
+	"revision": "revision", "rev": "revision",
+	"version": "version", "versions": "version", "doc_version": "version",
+	"lang": "lang", "language": "lang",
+	"tags": "tags", "tag": "tags",
+	"effective_from": "effective_from", "effective_since": "effective_from",
+	"effective_to": "effective_to", "effective_until": "effective_to",
+	"effective_after": "effective_after", "revised_after": "effective_after",
+	"effective_before": "effective_before", "revised_before": "effective_before",
+}
+
+// UnmarshalJSON menerima alias kunci dan tanggal parsial ("2024", "2024-06").
+// Kunci tak dikenal ditolak agar filter tidak diam-diam diabaikan.
+func (f *Filter) UnmarshalJSON(b []byte) error {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return fmt.Errorf("filters: expected object")
+	}
+	var out Filter
+	var unknown []string
+	for k, v := range raw {
+		key, ok := filterKeys[strings.ToLower(strings.TrimSpace(k))]
+		if !ok {
+			unknown = append(unknown, k)
+			continue
+		}
+		if string(v) == "null" {
+			continue
+		}
+		var list StringList
+		if err := json.Unmarshal(v, &list); err != nil {
+			// angka (mis. "effective_after": 2024) diperlakukan sebagai string
+			var n json.Number
+			if json.Unmarshal(v, &n) != nil {
+				return fmt.Errorf("filters.%s: %v", k, err)
+			}
+			list = StringList{n.String()}
+		}
+		if err := out.set(key, list); err != nil {
+			return fmt.Errorf("filters.%s: %w", k, err)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return fmt.Errorf("filters: unknown key(s) %s", strings.Join(unknown, ", "))
+	}
+	*f = out
+	return nil
+}
+
+// FilterFromQuery membaca filter dari query string (GET /rag/search_v2?type=sop&area=North).
+// Parameter yang bukan kunci filter diabaikan.
+func FilterFromQuery(q url.Values) (Filter, error) {
+	var f Filter
+	for k, vs := range q {
+		key, ok := filterKeys[strings.ToLower(k)]
+		if !ok {
+			continue
+		}
+		var list StringList
+		for _, v := range vs {
+			list = append(list, splitList(v)...)
+		}
+		if err := f.set(key, list); err != nil {
+			return Filter{}, fmt.Errorf("%s: %w", k, err)
+		}
+	}
+	return f, nil
+}
+
+func (f *Filter) set(key string, vals StringList) error {
+	switch key {
+	case "doc_id":
+		f.DocIDs = append(f.DocIDs, vals...)
+	case "collection":
+		f.Collections = append(f.Collections, lower(vals)...)
+	case "type":
+		f.Types = append(f.Types, lower(vals)...)
+	case "asset":
+		f.Assets = append(f.Assets, vals...)
+	case "well":
+		f.Wells = append(f.Wells, vals...)
+	case "area":
+		f.Areas = append(f.Areas, vals...)
+	case "revision":
+		f.Revisions = append(f.Revisions, vals...)
+	case "lang":
+		f.Langs = append(f.Langs, lower(vals)...)
+	case "tags":
+		f.Tags = append(f.Tags, lower(vals)...)
+	case "version":
+		for _, v := range vals {
+			if n, err := strconv.Atoi(v); err != nil || n <= 0 {
+				return fmt.Errorf("invalid version %q", v)
+			}
+		}
+		f.Versions = append(f.Versions, vals...)
+	default:
+		if len(vals) != 1 {
+			return fmt.Errorf("expected a single date")
+		}
+		start, end, err := parseDateRange(vals[0])
+		if err != nil {
+			return err
+		}
+		switch key {
+		case "effective_from":
+			f.EffectiveFrom = start.Format(dateLayout)
+		case "effective_to":
+			f.EffectiveTo = end.Format(dateLayout)
+		case "effective_after": // setelah seluruh periode: "after 2024" → mulai 2025-01-01
+			f.EffectiveFrom = end.AddDate(0, 0, 1).Format(dateLayout)
+		case "effective_before":
+			f.EffectiveTo = start.AddDate(0, 0, -1).Format(dateLayout)
+		}
+	}
+	return nil
+}
+
+func lower(vals StringList) StringList {
+	out := make(StringList, len(vals))
+	for i, v := range vals {
+		out[i] = strings.ToLower(v)
+	}
+	return out
+}
+
+const dateLayout = "2006-01-02"
+
+// parseDateRange: "2024" → 2024-01-01..2024-12-31, "2024-06" → 2024-06-01..2024-06-30, tanggal penuh → hari itu.
+func parseDateRange(s string) (time.Time, time.Time, error) {
+	s = strings.TrimSpace(s)
+	if t, err := time.Parse(dateLayout, s); err == nil {
+		return t, t, nil
+	}
+	if t, err := time.Parse("2006-01", s); err == nil {
+		return t, t.AddDate(0, 1, -1), nil
+	}
+	if t, err := time.Parse("2006", s); err == nil {
+		return t, t.AddDate(1, 0, -1), nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		d := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+		return d, d, nil
+	}
+	return time.Time{}, time.Time{}, fmt.Errorf("invalid date %q (use YYYY, YYYY-MM or YYYY-MM-DD)", s)
+}
+
+// Empty melaporkan apakah filter tidak membatasi apa pun.
+func (f Filter) Empty() bool {
+	return len(f.DocIDs) == 0 && len(f.Versions) == 0 && !f.hasDocPredicates()
+}
+
+func (f Filter) hasDocPredicates() bool {
+	return len(f.Collections)+len(f.Types)+len(f.Assets)+len(f.Wells)+len(f.Areas)+
+		len(f.Revisions)+len(f.Langs)+len(f.Tags) > 0 || f.EffectiveFrom != "" || f.EffectiveTo != ""
+}
+
+// Where menghasilkan predikat SQL (tanpa "AND" di depan) atas kolom doc_id col,
+// mis. Where("c.doc_id"). String kosong bila filter kosong.
+func (f Filter) Where(col string) (string, []any) {
+	var (
+		parts []string
+		args  []any
+	)
+	in := func(expr string, vals []string) {
+		if len(vals) == 0 {
+			return
+		}
+		parts = append(parts, expr+" IN ("+strings.TrimSuffix(strings.Repeat("?,", len(vals)), ",")+")")
+		for _, v := range vals {
+			args = append(args, v)
+		}
+	}
+	in(col, f.DocIDs)
+	if f.hasDocPredicates() {
+		outer := parts
+		parts = nil
+		in("d.collection", f.Collections)
+		in("d.doc_type", f.Types)
+		in("d.asset_id", f.Assets)
+		in("d.well_id", f.Wells)
+		in("d.area", f.Areas)
+		in("d.revision", f.Revisions)
+		in("d.lang", f.Langs)
+		for _, t := range f.Tags {
+			parts = append(parts, "JSON_CONTAINS(d.tags, JSON_QUOTE(?))")
+			args = append(args, t)
+		}
+		if f.EffectiveFrom != "" {
+			parts = append(parts, "d.effective_date >= ?")
+			args = append(args, f.EffectiveFrom)
+		}
+		if f.EffectiveTo != "" {
+			parts = append(parts, "d.effective_date <= ?")
+			args = append(args, f.EffectiveTo)
+		}
+		sub := col + " IN (SELECT d.doc_id FROM documents d WHERE " + strings.Join(parts, " AND ") + ")"
+		parts = append(outer, sub)
+	}
+	return strings.Join(parts, " AND "), args
+}
+
+// ChunkWhere adalah Where untuk retrieval doc_chunks (col = kolom doc_id chunk, mis. "c.doc_id"):
+// chunk yang dihapus (soft delete) selalu dikecualikan dan hanya versi terbaru (superseded = 0)
+// yang dipakai, kecuali filter meminta version atau revision tertentu. Revision dicocokkan dengan
+// riwayat document_versions sehingga revisi lama tetap bisa dicari. Tidak pernah kosong.
+func (f Filter) ChunkWhere(col string) (string, []any) {
+	p := strings.TrimSuffix(col, "doc_id")
+	parts := []string{p + "deleted_at IS NULL"}
+	var args []any
+	if len(f.Versions) == 0 && len(f.Revisions) == 0 {
+		parts = append(parts, p+"superseded = 0")
+	}
+	if len(f.Versions) > 0 {
+		parts = append(parts, p+"doc_version IN ("+placeholders(len(f.Versions))+")")
+		for _, v := range f.Versions {
+			args = append(args, v)
+		}
+	}
+	if len(f.Revisions) > 0 {
+		parts = append(parts, "("+col+", "+p+"doc_version) IN (SELECT v.doc_id, v.version FROM document_versions v"+
+			" WHERE v.revision IN ("+placeholders(len(f.Revisions))+"))")
+		for _, v := range f.Revisions {
+			args = append(args, v)
+		}
+	}
+	rest := f
+	rest.Revisions = nil
+	if where, wargs := rest.Where(col); where != "" {
+		parts = append(parts, where)
+		args = append(args, wargs...)
+	}
+	return strings.Join(parts, " AND "), args
+}
+
+func placeholders(n int) string { return strings.TrimSuffix(strings.Repeat("?,", n), ",") }
+
+// Matches mengevaluasi filter terhadap metadata di memori (semantik sama dengan Where;
+// perbandingan string tidak peka huruf besar seperti collation MySQL).
+func (f Filter) Matches(d Document) bool {
+	if !anyEqual(f.DocIDs, d.DocID) || !anyEqual(f.Collections, d.Collection) || !anyEqual(f.Types, d.Type) ||
+		!anyEqual(f.Assets, d.Asset) || !anyEqual(f.Wells, d.Well) || !anyEqual(f.Areas, d.Area) ||
+		!anyEqual(f.Revisions, d.Revision) || !anyEqual(f.Langs, d.Lang) {
+		return false
+	}
+	for _, t := range f.Tags {
+		if !anyEqual(d.Tags, t) {
+			return false
+		}
+	}
+	if f.EffectiveFrom != "" && (d.EffectiveDate == "" || d.EffectiveDate < f.EffectiveFrom) {
+		return false
+	}
+	if f.EffectiveTo != "" && (d.EffectiveDate == "" || d.EffectiveDate > f.EffectiveTo) {
+		return false
+	}
+	return true
+}
+
+// anyEqual: true bila list kosong atau memuat v (case-insensitive).
+func anyEqual(list []string, v string) bool {
+	if len(list) == 0 {
+		return true
+	}
+	for _, s := range list {
+		if strings.EqualFold(s, v) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,311 @@
+// internal/repositories/documents/versions.go
+// Versi & penghapusan dokumen. Setiap upload yang isinya berbeda menjadi versi baru:
+// chunk versi lama ditandai superseded (tetap bisa dicari dengan filter version/revision) dan
+// dipangkas setelah keep versi. Soft delete menyembunyikan dokumen & chunk dari retrieval
+// (bisa di-restore); hard delete menghapus semuanya. Pemanggil bertanggung jawab mengeluarkan id
+// chunk yang dikembalikan dari indeks vektor.
+package documents
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ErrVersionNotFound dikembalikan Activate untuk versi tanpa chunk.
+var ErrVersionNotFound = errors.New("document version not found")
+
+// Version adalah satu baris document_versions.
+type Version struct {
+	DocID        string     `json:"doc_id"`
+	Version      int        `json:"version"`
+	ContentHash  string     `json:"content_hash,omitempty"`
+	Filename     string     `json:"filename,omitempty"`
+	Title        string     `json:"title,omitempty"`
+	Revision     string     `json:"revision,omitempty"`
+	Chunks       int        `json:"chunks"`
+	JobID        int64      `json:"job_id,omitempty"`
+	Current      bool       `json:"current"`
+	CreatedAt    time.Time  `json:"created_at"`
+	SupersededAt *time.Time `json:"superseded_at,omitempty"`
+}
+
+// HashFile mengembalikan sha256 (hex) isi file.
+func HashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// FindByHash mencari dokumen aktif (tidak dihapus) yang versi aktifnya ber-hash sama.
+// ErrNotFound bila tidak ada.
+func FindByHash(ctx context.Context, db *sql.DB, hash string) (docID string, version int, err error) {
+	err = db.QueryRowContext(ctx, `
+		SELECT doc_id, current_version FROM documents
+		 WHERE content_hash = ? AND deleted_at IS NULL
+		 ORDER BY updated_at DESC LIMIT 1`, hash).Scan(&docID, &version)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", 0, ErrNotFound
+	}
+	return docID, version, err
+}
+
+// NextVersion (dalam transaksi ingest) mengunci dokumen, menandai chunk versi berjalan superseded,
+// memangkas chunk versi yang melewati batas keep (termasuk versi baru; 0 = simpan semua) dan
+// mengembalikan nomor versi baru.
+func NextVersion(ctx context.Context, tx *sql.Tx, docID string, keep int) (int, error) {
+	var one int
+	if err := tx.QueryRowContext(ctx, `SELECT 1 FROM documents WHERE doc_id = ? FOR UPDATE`, docID).
+		Scan(&one); err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return 0, err
+	}
+	var last int // 0 = dokumen baru (atau hanya metadata tanpa upload)
+	if err := tx.QueryRowContext(ctx, `SELECT COALESCE(MAX(version), 0) FROM document_versions WHERE doc_id = ?`, docID).
+		Scan(&last); err != nil {
+		return 0, err
+	}
+	next := last + 1
+	if _, err := tx.ExecContext(ctx, `UPDATE doc_chunks SET superseded = 1 WHERE doc_id = ? AND superseded = 0`, docID); err != nil {
+		return 0, fmt.Errorf("supersede chunks: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE document_versions SET superseded_at = NOW() WHERE doc_id = ? AND superseded_at IS NULL`, docID); err != nil {
+		return 0, err
+	}
+	if keep > 0 && next-keep > 0 {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM doc_chunks WHERE doc_id = ? AND doc_version <= ?`, docID, next-keep); err != nil {
+			return 0, fmt.Errorf("prune old versions: %w", err)
+		}
+	}
+	return next, nil
+}
+
+// RecordVersion (dalam transaksi ingest, setelah Upsert) mencatat versi dan menjadikannya aktif;
+// dokumen yang sebelumnya di-soft-delete ikut dipulihkan.
+func RecordVersion(ctx context.Context, tx *sql.Tx, v Version) error {
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO document_versions (doc_id, version, content_hash, filename, title, revision, chunks, job_id)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE content_hash = VALUES(content_hash), filename = VALUES(filename),
+		  title = VALUES(title), revision = VALUES(revision), chunks = VALUES(chunks), job_id = VALUES(job_id),
+		  superseded_at = NULL`,
+		v.DocID, v.Version, nullable(v.ContentHash), v.Filename, v.Title, nullable(v.Revision), v.Chunks, nullableID(v.JobID)); err != nil {
+		return fmt.Errorf("record version: %w", err)
+	}
+	_, err := tx.ExecContext(ctx, `UPDATE documents SET content_hash = ?, current_version = ?, deleted_at = NULL WHERE doc_id = ?`,
+		nullable(v.ContentHash), v.Version, v.DocID)
+	return err
+}
+
+func nullableID(id int64) any {
+	if id <= 0 {
+		return nil
+	}
+	return id
+}
+
+// Versions mengembalikan riwayat versi dokumen, terbaru lebih dulu.
+func (r *Repo) Versions(ctx context.Context, docID string) ([]Version, error) {
+	rows, err := r.DB.QueryContext(ctx, `
+		SELECT v.doc_id, v.version, COALESCE(v.content_hash,''), v.filename, v.title, COALESCE(v.revision,''),
+		       v.chunks, COALESCE(v.job_id, 0), v.version = d.current_version, v.created_at, v.superseded_at
+		  FROM document_versions v JOIN documents d ON d.doc_id = v.doc_id
+		 WHERE v.doc_id = ?
+		 ORDER BY v.version DESC`, docID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []Version{}
+	for rows.Next() {
+		var (
+			v   Version
+			sup sql.NullTime
+		)
+		if err := rows.Scan(&v.DocID, &v.Version, &v.ContentHash, &v.Filename, &v.Title, &v.Revision,
+			&v.Chunks, &v.JobID, &v.Current, &v.CreatedAt, &sup); err != nil {
+			return nil, err
+		}
+		if sup.Valid {
+			v.SupersededAt = &sup.Time
+		}
+		out = append(out, v)
+	}
+	return out, rows.Err()
+}
+
+// chunkIDs mengembalikan id chunk dokumen yang cocok dengan predikat tambahan cond.
+func chunkIDs(ctx context.Context, tx *sql.Tx, docID, cond string, args ...any) ([]int64, error) {
+	q := `SELECT id FROM doc_chunks WHERE doc_id = ?`
+	if cond != "" {
+		q += ` AND ` + cond
+	}
+	rows, err := tx.QueryContext(ctx, q, append([]any{docID}, args...)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+func (r *Repo) exists(ctx context.Context, tx *sql.Tx, docID string) error {
+	var one int
+	err := tx.QueryRowContext(ctx, `SELECT 1 FROM documents WHERE doc_id = ? FOR UPDATE`, docID).Scan(&one)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	}
+	return err
+}
+
+// SoftDelete menyembunyikan dokumen & semua chunk-nya dari retrieval. Mengembalikan id chunk
+// yang baru disembunyikan (kosong bila sudah terhapus sebelumnya).
+func (r *Repo) SoftDelete(ctx context.Context, docID string) ([]int64, error) {
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	if err := r.exists(ctx, tx, docID); err != nil {
+		return nil, err
+	}
+	ids, err := chunkIDs(ctx, tx, docID, `deleted_at IS NULL`)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE documents SET deleted_at = COALESCE(deleted_at, NOW()) WHERE doc_id = ?`, docID); err != nil {
+		return nil, err
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE doc_chunks SET deleted_at = NOW() WHERE doc_id = ? AND deleted_at IS NULL`, docID); err != nil {
+		return nil, err
+	}
+	return ids, tx.Commit()
+}
+
+// Restore membatalkan soft delete; chunk kembali masuk indeks vektor pada sinkronisasi berikutnya.
+func (r *Repo) Restore(ctx context.Context, docID string) error {
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if err := r.exists(ctx, tx, docID); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE documents SET deleted_at = NULL WHERE doc_id = ?`, docID); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE doc_chunks SET deleted_at = NULL WHERE doc_id = ? AND deleted_at IS NOT NULL`, docID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// HardDelete menghapus dokumen, semua versi & chunk-nya secara permanen. Mengembalikan id chunk
+// yang dihapus dan metadata dokumen (mis. URL file upload) sebelum dihapus.
+func (r *Repo) HardDelete(ctx context.Context, docID string) ([]int64, *Document, error) {
+	d, err := r.Get(ctx, docID)
+	if err != nil {
+		return nil, nil, err
+	}
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer tx.Rollback()
+	if err := r.exists(ctx, tx, docID); err != nil {
+		return nil, nil, err
+	}
+	ids, err := chunkIDs(ctx, tx, docID, "")
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, q := range []string{
+		`DELETE FROM doc_chunks WHERE doc_id = ?`,
+		`DELETE FROM document_versions WHERE doc_id = ?`,
+		`DELETE FROM documents WHERE doc_id = ?`,
+	} {
+		if _, err := tx.ExecContext(ctx, q, docID); err != nil {
+			return nil, nil, fmt.Errorf("hard delete %s: %w", docID, err)
+		}
+	}
+	return ids, d, tx.Commit()
+}
+
+// Activate menjadikan versi tersimpan sebagai versi aktif (rollback ke revisi lama atau kembali
+// ke yang terbaru). Mengembalikan id chunk yang tidak lagi aktif.
+func (r *Repo) Activate(ctx context.Context, docID string, version int) ([]int64, error) {
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	if err := r.exists(ctx, tx, docID); err != nil {
+		return nil, err
+	}
+	var n int
+	if err := tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM doc_chunks WHERE doc_id = ? AND doc_version = ?`, docID, version).
+		Scan(&n); err != nil {
+		return nil, err
+	}
+	if n == 0 {
+		return nil, ErrVersionNotFound
+	}
+	ids, err := chunkIDs(ctx, tx, docID, `superseded = 0 AND doc_version <> ?`, version)
+	if err != nil {
+		return nil, err
+	}
+	stmts := []struct {
+		q    string
+		args []any
+	}{
+		{`UPDATE doc_chunks SET superseded = (doc_version <> ?) WHERE doc_id = ?`, []any{version, docID}},
+		{`UPDATE document_versions SET superseded_at = IF(version = ?, NULL, COALESCE(superseded_at, NOW())) WHERE doc_id = ?`, []any{version, docID}},
+		{`UPDATE documents d LEFT JOIN document_versions v ON v.doc_id = d.doc_id AND v.version = ?
+		     SET d.current_version = ?, d.content_hash = v.content_hash, d.revision = COALESCE(v.revision, d.revision)
+		   WHERE d.doc_id = ?`, []any{version, version, docID}},
+	}
+	for _, s := range stmts {
+		if _, err := tx.ExecContext(ctx, s.q, s.args...); err != nil {
+			return nil, fmt.Errorf("activate %s v%d: %w", docID, version, err)
+		}
+	}
+	return ids, tx.Commit()
+}
+
+// UploadPath mengembalikan path file upload dokumen di dir (URL "uploads/<hash>/<file>" atau
+// "uploads/<file>" untuk upload lama), kosong bila dokumen tidak berasal dari upload.
+func UploadPath(d *Document, dir string) string {
+	name, ok := strings.CutPrefix(d.URL, "uploads/")
+	parts := strings.Split(name, "/")
+	if !ok || len(parts) > 2 || strings.Contains(name, `\`) {
+		return ""
+	}
+	for _, p := range parts {
+		if p == "" || p == "." || p == ".." {
+			return ""
+		}
+	}
+	return filepath.Join(dir, filepath.Join(parts...))
+}
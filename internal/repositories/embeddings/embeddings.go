@@ -0,0 +1,163 @@
+// internal/repositories/embeddings/embeddings.go
+// Kolom embedding doc_chunks selama transisi JSON → BLOB dan antar model embedding:
+//
+//	embedding          JSON   (legacy, array float)
+//	embedding_bin      BLOB   (pkg/vector: f32 atau i8 terkuantisasi)
+//	embedding_model    model embedding yang menghasilkan vektor
+//	embedding_version  label versi embedder (EMBED_VERSION)
+//	embedding_dim      dimensi vektor
+//	embedding_next_*   kolom bayangan untuk model baru (diisi cmd/reembed, dipindah saat cutover)
+//
+// Pembaca memakai embedding_bin bila terisi dan jatuh ke JSON bila belum dimigrasi
+// (cmd/migrate-embeddings); penulis memakai format EMBED_STORAGE. Dengan EMBED_READ=next pembaca
+// memakai vektor bayangan bila ada (dual-read selama migrasi model); vektor yang dimensinya
+// berbeda dari EMBED_DIM tidak pernah dibandingkan dengan query (lihat ExpectedDim).
+package embeddings
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"mcp-oilgas/internal/llm"
+	"mcp-oilgas/pkg/vector"
+)
+
+// HasEmbedding adalah predikat SQL untuk chunk yang punya embedding aktif (format apa pun).
+const HasEmbedding = `(embedding_bin IS NOT NULL OR embedding IS NOT NULL)`
+
+// Columns adalah kolom aktif yang dibaca Decode (urutan: bin, json).
+const Columns = `embedding_bin, embedding`
+
+// Sumber baca embedding (env EMBED_READ).
+const (
+	ReadCurrent = "current" // kolom aktif saja (default)
+	ReadNext    = "next"    // kolom bayangan bila terisi, selain itu kolom aktif
+)
+
+// ReadFromEnv: EMBED_READ = current (default) | next.
+func ReadFromEnv() string {
+	if strings.ToLower(strings.TrimSpace(os.Getenv("EMBED_READ"))) == ReadNext {
+		return ReadNext
+	}
+	return ReadCurrent
+}
+
+// ReadColumns adalah ekspresi kolom (bin, json) untuk pembaca query sesuai EMBED_READ.
+func ReadColumns() string {
+	if ReadFromEnv() == ReadNext {
+		return `COALESCE(embedding_next_bin, embedding_bin), IF(embedding_next_bin IS NULL, embedding, NULL)`
+	}
+	return Columns
+}
+
+// ReadHasEmbedding adalah predikat HasEmbedding sesuai EMBED_READ.
+func ReadHasEmbedding() string {
+	if ReadFromEnv() == ReadNext {
+		return `(embedding_next_bin IS NOT NULL OR embedding_bin IS NOT NULL OR embedding IS NOT NULL)`
+	}
+	return HasEmbedding
+}
+
+// ExpectedDim: EMBED_DIM (default 1536 untuk text-embedding-3-small, 512 untuk EMBED_PROVIDER=local).
+// Query & vektor korpus dengan dimensi lain tidak dipakai untuk skor cosine.
+func ExpectedDim() int { return llm.ConfigFromEnv().EmbedDim }
+
+// Model mengidentifikasi embedder yang menghasilkan vektor.
+type Model struct {
+	Name    string
+	Version string // opsional, mis. "v2" atau tanggal rilis model
+}
+
+// ModelFromEnv: model embedder aktif (llm.Config.EmbedModel) + EMBED_VERSION.
+func ModelFromEnv() Model {
+	return Model{
+		Name:    llm.ConfigFromEnv().EmbedModel,
+		Version: strings.TrimSpace(os.Getenv("EMBED_VERSION")),
+	}
+}
+
+// String: "name" atau "name@version".
+func (m Model) String() string {
+	if m.Version == "" {
+		return m.Name
+	}
+	return m.Name + "@" + m.Version
+}
+
+// Decode membaca vektor dari nilai kolom Columns; bin diutamakan.
+func Decode(bin, js []byte) ([]float32, error) {
+	if len(bin) > 0 {
+		return vector.Decode(bin)
+	}
+	if len(js) == 0 {
+		return nil, fmt.Errorf("no embedding")
+	}
+	return ParseJSON(js)
+}
+
+// ParseJSON mem-parse array JSON float (format legacy).
+func ParseJSON(raw []byte) ([]float32, error) {
+	var v []float32
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return nil, err
+	}
+	if len(v) == 0 {
+		return nil, fmt.Errorf("empty embedding")
+	}
+	return v, nil
+}
+
+// StorageFromEnv: EMBED_STORAGE = f32 (default) | i8 | json (legacy, tanpa BLOB).
+func StorageFromEnv() string {
+	f := strings.ToLower(strings.TrimSpace(os.Getenv("EMBED_STORAGE")))
+	if !vector.ValidFormat(f) {
+		return vector.FormatF32
+	}
+	return f
+}
+
+// Values adalah argumen SQL untuk kolom
+// (embedding, embedding_bin, embedding_model, embedding_dim, embedding_version).
+type Values struct {
+	JSON, Bin, Model, Dim, Version any
+}
+
+// InsertColumns & SetColumns menyusun kolom aktif untuk INSERT/UPDATE (urutan = Values).
+// NextSetColumns mengisi kolom bayangan (argumen: Bin, Model, Version, Dim).
+const (
+	InsertColumns  = `embedding, embedding_bin, embedding_model, embedding_dim, embedding_version`
+	SetColumns     = `embedding = ?, embedding_bin = ?, embedding_model = ?, embedding_dim = ?, embedding_version = ?`
+	NextSetColumns = `embedding_next_bin = ?, embedding_next_model = ?, embedding_next_version = ?, embedding_next_dim = ?`
+)
+
+// Encode menyiapkan nilai kolom untuk vec dalam format storage. vec kosong → semua NULL.
+// Format biner mengosongkan kolom JSON sehingga tidak ada dua sumber yang bisa berbeda.
+func Encode(vec []float32, m Model, storage string) (Values, error) {
+	if len(vec) == 0 {
+		return Values{}, nil
+	}
+	v := Values{Dim: len(vec)}
+	if m.Name != "" {
+		v.Model = m.Name
+	}
+	if m.Version != "" {
+		v.Version = m.Version
+	}
+	switch storage {
+	case vector.FormatJSON:
+		b, err := json.Marshal(vec)
+		if err != nil {
+			return Values{}, err
+		}
+		v.JSON = string(b)
+	default:
+		b, err := vector.Encode(vec, storage)
+		if err != nil {
+			return Values{}, err
+		}
+		v.Bin = b
+	}
+	return v, nil
+}
@@ -0,0 +1,128 @@
+// internal/repositories/mysql/fusion.go
+// Strategi fusi skor BM25 + cosine untuk SearchHybrid.
+//
+//	linear:       alpha·bm25_norm + (1-alpha)·(cos+1)/2       (bm25_norm = bm25 / max bm25 kandidat)
+//	rrf:          Σ 1/(k + rank) atas sinyal yang ada         (Reciprocal Rank Fusion, k default 60)
+//	weighted_rrf: alpha/(k + rank_bm25) + (1-alpha)/(k + rank_cos)
+//
+// Rank 1-based per sinyal; kandidat tanpa sinyal tertentu tidak mendapat kontribusi dari sinyal itu.
+package mysql
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Nama strategi fusi.
+const (
+	FusionLinear      = "linear"
+	FusionRRF         = "rrf"
+	FusionWeightedRRF = "weighted_rrf"
+)
+
+// DefaultRRFK adalah konstanta k pada RRF (Cormack dkk., 2009).
+const DefaultRRFK = 60
+
+// DefaultAlpha adalah bobot BM25 default (linear & weighted_rrf); 1 = BM25 murni, 0 = cosine murni.
+const DefaultAlpha = 0.6
+
+// AlphaFromEnv: RAG_ALPHA (0..1), default DefaultAlpha. Dipakai pemanggil /rag/search_v2 in-process
+// (answer_with_docs, route RAG planner & SSE); nilai terbaik bisa dicari dengan cmd/rag-eval.
+func AlphaFromEnv() float64 {
+	if a, err := strconv.ParseFloat(strings.TrimSpace(os.Getenv("RAG_ALPHA")), 64); err == nil && a >= 0 && a <= 1 {
+		return a
+	}
+	return DefaultAlpha
+}
+
+// ParseFusion menormalkan nama strategi; "" → linear.
+func ParseFusion(s string) (string, error) {
+	switch f := strings.ToLower(strings.TrimSpace(s)); f {
+	case "":
+		return FusionLinear, nil
+	case FusionLinear, FusionRRF, FusionWeightedRRF:
+		return f, nil
+	case "weighted-rrf", "wrrf":
+		return FusionWeightedRRF, nil
+	}
+	return "", fmt.Errorf("unknown fusion %q (linear|rrf|weighted_rrf)", s)
+}
+
+// HybridOptions mengatur cara SearchHybridWith menggabungkan sinyal.
+type HybridOptions struct {
+	Fusion string // FusionLinear (default) | FusionRRF | FusionWeightedRRF
+	RRFK   int    // k untuk RRF; <= 0 → DefaultRRFK
+	Debug  bool   // isi Chunk.Debug dengan skor per sinyal
+}
+
+// ScoreDebug merinci skor per sinyal satu kandidat.
+type ScoreDebug struct {
+	BM25Raw    *float64 `json:"bm25_raw,omitempty"` // skor MATCH ... AGAINST; nil = bukan kandidat BM25
+	BM25Norm   float64  `json:"bm25_norm"`
+	Cosine     *float64 `json:"cosine,omitempty"` // cosine mentah [-1,1]; nil = tanpa embedding
+	BM25Rank   int      `json:"bm25_rank,omitempty"`
+	CosineRank int      `json:"cosine_rank,omitempty"`
+	Fused      float64  `json:"fused"`
+}
+
+// Fuse mengisi BM25Norm, rank, dan Fused untuk seluruh kandidat (BM25Raw/Cosine harus sudah terisi).
+// Urutan ds tidak berubah; seri diputus oleh urutan masukan.
+func Fuse(ds []ScoreDebug, fusion string, alpha float64, k int) {
+	if k <= 0 {
+		k = DefaultRRFK
+	}
+	var maxBM25 float64
+	for _, d := range ds {
+		if d.BM25Raw != nil && *d.BM25Raw > maxBM25 {
+			maxBM25 = *d.BM25Raw
+		}
+	}
+	for i := range ds {
+		ds[i].BM25Norm, ds[i].BM25Rank, ds[i].CosineRank = 0, 0, 0
+		if maxBM25 > 0 && ds[i].BM25Raw != nil {
+			ds[i].BM25Norm = min(*ds[i].BM25Raw/maxBM25, 1)
+		}
+	}
+	assignRanks(ds, func(d *ScoreDebug) *float64 { return d.BM25Raw }, func(d *ScoreDebug, r int) { d.BM25Rank = r })
+	assignRanks(ds, func(d *ScoreDebug) *float64 { return d.Cosine }, func(d *ScoreDebug, r int) { d.CosineRank = r })
+
+	for i := range ds {
+		d := &ds[i]
+		switch fusion {
+		case FusionRRF:
+			d.Fused = rrf(d.BM25Rank, k) + rrf(d.CosineRank, k)
+		case FusionWeightedRRF:
+			d.Fused = alpha*rrf(d.BM25Rank, k) + (1-alpha)*rrf(d.CosineRank, k)
+		default:
+			cos := 0.0
+			if d.Cosine != nil {
+				cos = (max(-1, min(*d.Cosine, 1)) + 1) / 2 // ke [0,1]
+			}
+			d.Fused = alpha*d.BM25Norm + (1-alpha)*cos
+		}
+	}
+}
+
+func rrf(rank, k int) float64 {
+	if rank <= 0 {
+		return 0
+	}
+	return 1 / float64(k+rank)
+}
+
+// assignRanks memberi rank 1.. (skor menurun) kepada kandidat yang punya sinyal get.
+func assignRanks(ds []ScoreDebug, get func(*ScoreDebug) *float64, set func(*ScoreDebug, int)) {
+	idx := make([]int, 0, len(ds))
+	for i := range ds {
+		if get(&ds[i]) != nil {
+			idx = append(idx, i)
+		}
+	}
+	sort.SliceStable(idx, func(a, b int) bool { return *get(&ds[idx[a]]) > *get(&ds[idx[b]]) })
+	for r, i := range idx {
+		set(&ds[i], r+1)
+	}
+}
@@ -0,0 +1,64 @@
+package mysql_test
+
+import (
+	"math"
+	"testing"
+
+	"mcp-oilgas/internal/repositories/mysql"
+)
+
+func f64(v float64) *float64 { return &v }
+
+func TestFuseStrategies(t *testing.T) {
+	// a: BM25 terbaik, cosine sedang; b: hanya cosine (kandidat ANN); c: BM25 lemah, cosine terbaik
+	base := func() []mysql.ScoreDebug {
+		return []mysql.ScoreDebug{
+			{BM25Raw: f64(8), Cosine: f64(0.2)},
+			{Cosine: f64(0.5)},
+			{BM25Raw: f64(2), Cosine: f64(0.9)},
+		}
+	}
+
+	ds := base()
+	mysql.Fuse(ds, mysql.FusionLinear, 0.5, 0)
+	if ds[0].BM25Norm != 1 || ds[2].BM25Norm != 0.25 || ds[1].BM25Norm != 0 {
+		t.Fatalf("bm25 norm: %+v", ds)
+	}
+	if want := 0.5*1 + 0.5*0.6; math.Abs(ds[0].Fused-want) > 1e-9 {
+		t.Fatalf("linear a = %v, want %v", ds[0].Fused, want)
+	}
+	if ds[0].BM25Rank != 1 || ds[2].BM25Rank != 2 || ds[1].BM25Rank != 0 {
+		t.Fatalf("bm25 ranks: %+v", ds)
+	}
+	if ds[2].CosineRank != 1 || ds[1].CosineRank != 2 || ds[0].CosineRank != 3 {
+		t.Fatalf("cosine ranks: %+v", ds)
+	}
+
+	ds = base()
+	mysql.Fuse(ds, mysql.FusionRRF, 0.5, 60)
+	if want := 1.0/62 + 1.0/61; math.Abs(ds[2].Fused-want) > 1e-12 {
+		t.Fatalf("rrf c = %v, want %v", ds[2].Fused, want)
+	}
+	if want := 1.0 / 62; math.Abs(ds[1].Fused-want) > 1e-12 {
+		t.Fatalf("rrf b = %v, want %v", ds[1].Fused, want)
+	}
+
+	// alpha=1 → weighted_rrf hanya mengikuti rank BM25
+	ds = base()
+	mysql.Fuse(ds, mysql.FusionWeightedRRF, 1, 60)
+	if !(ds[0].Fused > ds[2].Fused && ds[1].Fused == 0) {
+		t.Fatalf("weighted_rrf alpha=1: %+v", ds)
+	}
+}
+
+func TestParseFusion(t *testing.T) {
+	if f, err := mysql.ParseFusion(""); err != nil || f != mysql.FusionLinear {
+		t.Fatalf("default = %q, %v", f, err)
+	}
+	if f, err := mysql.ParseFusion(" RRF "); err != nil || f != mysql.FusionRRF {
+		t.Fatalf("rrf = %q, %v", f, err)
+	}
+	if _, err := mysql.ParseFusion("borda"); err == nil {
+		t.Fatalf("expected error for unknown fusion")
+	}
+}
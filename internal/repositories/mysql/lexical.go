@@ -0,0 +1,157 @@
+// internal/repositories/mysql/lexical.go
+// Sumber skor leksikal ("BM25") untuk SearchBM25Filtered & hybrid:
+//
+//	fulltext: MATCH ... AGAINST (NATURAL LANGUAGE MODE) MySQL — tanpa stemming/stopword Indonesia
+//	go:       indeks BM25 in-process (internal/lexindex) dengan analyzer Indonesia/Inggris + sinonim
+//	both:     kedua daftar dinormalisasi (÷ skor maksimum masing-masing) lalu dirata-rata
+//
+// Selama indeks Go belum siap (atau RAGRepo.Lexical nil) semua mode jatuh ke fulltext.
+package mysql
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"mcp-oilgas/internal/repositories/documents"
+	"mcp-oilgas/pkg/vector"
+)
+
+// Mode pencarian leksikal.
+const (
+	LexicalFullText = "fulltext"
+	LexicalGo       = "go"
+	LexicalBoth     = "both"
+)
+
+// LexicalSearcher adalah indeks leksikal in-process (mis. *lexindex.Index).
+type LexicalSearcher interface {
+	Search(query string, k int) []vector.Hit
+	Ready() bool
+}
+
+// ParseLexicalMode menormalkan nama mode; "" → fulltext.
+func ParseLexicalMode(s string) (string, error) {
+	switch m := strings.ToLower(strings.TrimSpace(s)); m {
+	case "", "mysql":
+		return LexicalFullText, nil
+	case LexicalFullText, LexicalGo, LexicalBoth:
+		return m, nil
+	}
+	return "", fmt.Errorf("unknown lexical mode %q (fulltext|go|both)", s)
+}
+
+// LexicalModeFromEnv: LEXICAL_SEARCH (fulltext|go|both), default fulltext; nilai tidak dikenal → fulltext.
+func LexicalModeFromEnv() string {
+	m, err := ParseLexicalMode(os.Getenv("LEXICAL_SEARCH"))
+	if err != nil {
+		return LexicalFullText
+	}
+	return m
+}
+
+// lexicalMode: mode efektif untuk satu query.
+func (r *RAGRepo) lexicalMode() string {
+	if r.Lexical == nil || !r.Lexical.Ready() {
+		return LexicalFullText
+	}
+	m, err := ParseLexicalMode(r.LexicalMode)
+	if err != nil {
+		return LexicalFullText
+	}
+	return m
+}
+
+// searchLexicalGo mengambil kandidat dari indeks Go lalu melengkapi baris chunk dari MySQL
+// (sekaligus menerapkan filter metadata & versi aktif); Score = skor BM25 indeks.
+func (r *RAGRepo) searchLexicalGo(ctx context.Context, query string, limit int, f documents.Filter) ([]Chunk, error) {
+	k := limit
+	if !f.Empty() {
+		k *= 4 // sebagian kandidat gugur oleh filter metadata
+	}
+	hits := r.Lexical.Search(query, k)
+	if len(hits) == 0 {
+		return nil, nil
+	}
+	score := make(map[int64]float64, len(hits))
+	args := make([]any, 0, len(hits))
+	for _, h := range hits {
+		score[h.ID] = h.Score
+		args = append(args, h.ID)
+	}
+	q := `SELECT id, doc_id, title, url, snippet, page_no, char_start, char_end FROM doc_chunks WHERE id IN (` +
+		strings.TrimSuffix(strings.Repeat("?,", len(hits)), ",") + `)`
+	if where, fargs := f.ChunkWhere("doc_id"); where != "" {
+		q += ` AND ` + where
+		args = append(args, fargs...)
+	}
+	rows, err := r.DB.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []Chunk
+	for rows.Next() {
+		var c Chunk
+		if err := rows.Scan(&c.ID, &c.DocID, &c.Title, &c.URL, &c.Snippet, &c.PageNo, &c.CharStart, &c.CharEnd); err != nil {
+			return nil, err
+		}
+		c.Score.Valid, c.Score.Float64 = true, score[c.ID]
+		out = append(out, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	sortByScore(out)
+	if len(out) > limit {
+		out = out[:limit]
+	}
+	return out, nil
+}
+
+// mergeLexical menggabungkan hasil FULLTEXT dan indeks Go: skor masing-masing dibagi skor
+// maksimumnya lalu dirata-rata (chunk yang hanya muncul di satu daftar mendapat setengah).
+func mergeLexical(ft, gobm []Chunk, limit int) []Chunk {
+	maxOf := func(cs []Chunk) float64 {
+		m := 0.0
+		for _, c := range cs {
+			m = max(m, c.Score.Float64)
+		}
+		return m
+	}
+	merged := map[int64]*Chunk{}
+	var order []int64
+	for _, list := range [][]Chunk{ft, gobm} {
+		m := maxOf(list)
+		for _, c := range list {
+			s := 0.0
+			if m > 0 {
+				s = c.Score.Float64 / m / 2
+			}
+			if p, ok := merged[c.ID]; ok {
+				p.Score.Float64 += s
+				continue
+			}
+			c := c
+			c.Score.Valid, c.Score.Float64 = true, s
+			merged[c.ID] = &c
+			order = append(order, c.ID)
+		}
+	}
+	out := make([]Chunk, 0, len(order))
+	for _, id := range order {
+		out = append(out, *merged[id])
+	}
+	sortByScore(out)
+	if len(out) > limit {
+		out = out[:limit]
+	}
+	return out
+}
+
+func sortByScore(cs []Chunk) {
+	sort.SliceStable(cs, func(i, j int) bool { return cs[i].Score.Float64 > cs[j].Score.Float64 })
+}
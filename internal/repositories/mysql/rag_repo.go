@@ -4,11 +4,14 @@ package mysql
 import (
 	"context"
 	"database/sql"
-	"encoding/json"
 	"errors"
 	"math"
 	"sort"
 	"strings"
+
+	"mcp-oilgas/internal/repositories/documents"
+	"mcp-oilgas/internal/repositories/embeddings"
+	"mcp-oilgas/pkg/vector"
 )
 
 type Chunk struct {
@@ -18,16 +21,40 @@ type Chunk struct {
 	URL     sql.NullString
 	Snippet sql.NullString
 	PageNo  sql.NullInt64
-	Score   sql.NullFloat64 // BM25 atau skor final hybrid
+	// CharStart/CharEnd: offset rune [start, end) chunk di teks dokumen (NULL untuk chunk lama)
+	CharStart sql.NullInt64
+	CharEnd   sql.NullInt64
+	Score     sql.NullFloat64     // BM25 atau skor final hybrid
+	Doc       *documents.Document // metadata dokumen (nil bila doc_id belum ada di tabel documents)
+	Debug     *ScoreDebug         // skor per sinyal (hanya bila HybridOptions.Debug)
+}
+
+// ANNSearcher adalah generator kandidat semantik atas seluruh korpus (mis. *vectorindex.Index).
+type ANNSearcher interface {
+	Search(q []float32, k int) []vector.Hit
+	Candidates() int
 }
 
 type RAGRepo struct {
 	DB *sql.DB
+	// ANN opsional; nil (atau indeks belum siap) → kandidat semantik hanya dari BM25,
+	// dengan fallback 200 chunk terbaru bila BM25 kosong.
+	ANN ANNSearcher
+	// Lexical opsional: indeks BM25 in-process (lihat lexical.go); LexicalMode memilih
+	// fulltext (default) | go | both.
+	Lexical     LexicalSearcher
+	LexicalMode string
 }
 
-// -------- BM25 (FULLTEXT) --------
+// -------- BM25 (FULLTEXT / indeks Go) --------
 
 func (r *RAGRepo) SearchBM25(ctx context.Context, query string, topK int) ([]Chunk, error) {
+	return r.SearchBM25Filtered(ctx, query, topK, documents.Filter{})
+}
+
+// SearchBM25Filtered: BM25 terbatas pada dokumen yang cocok dengan filter metadata. Sumber skor
+// mengikuti LexicalMode (FULLTEXT MySQL, indeks Go, atau gabungan keduanya).
+func (r *RAGRepo) SearchBM25Filtered(ctx context.Context, query string, topK int, f documents.Filter) ([]Chunk, error) {
 	if r == nil || r.DB == nil {
 		return nil, errors.New("rag repo: DB is nil")
 	}
@@ -38,16 +65,41 @@ func (r *RAGRepo) SearchBM25(ctx context.Context, query string, topK int) ([]Chu
 	if topK <= 0 || topK > 100 {
 		topK = 10
 	}
+	limit := topK * 5 // ambil lebih banyak utk hybrid
+
+	switch r.lexicalMode() {
+	case LexicalGo:
+		return r.searchLexicalGo(ctx, query, limit, f)
+	case LexicalBoth:
+		ft, err := r.searchFullText(ctx, query, limit, f)
+		if err != nil {
+			return nil, err
+		}
+		gobm, err := r.searchLexicalGo(ctx, query, limit, f)
+		if err != nil {
+			return nil, err
+		}
+		return mergeLexical(ft, gobm, limit), nil
+	}
+	return r.searchFullText(ctx, query, limit, f)
+}
 
-	const q = `
-		SELECT id, doc_id, title, url, snippet, page_no,
+func (r *RAGRepo) searchFullText(ctx context.Context, query string, limit int, f documents.Filter) ([]Chunk, error) {
+	q := `
+		SELECT id, doc_id, title, url, snippet, page_no, char_start, char_end,
 		       MATCH(title, snippet) AGAINST (? IN NATURAL LANGUAGE MODE) AS score
 		  FROM doc_chunks
-		 WHERE MATCH(title, snippet) AGAINST (? IN NATURAL LANGUAGE MODE)
+		 WHERE MATCH(title, snippet) AGAINST (? IN NATURAL LANGUAGE MODE)`
+	args := []any{query, query}
+	if where, fargs := f.ChunkWhere("doc_id"); where != "" {
+		q += ` AND ` + where
+		args = append(args, fargs...)
+	}
+	q += `
 		 ORDER BY score DESC
-		 LIMIT ?;
-	`
-	rows, err := r.DB.QueryContext(ctx, q, query, query, topK*5) // ambil lebih banyak utk hybrid
+		 LIMIT ?;`
+	args = append(args, limit)
+	rows, err := r.DB.QueryContext(ctx, q, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -56,7 +108,7 @@ func (r *RAGRepo) SearchBM25(ctx context.Context, query string, topK int) ([]Chu
 	var out []Chunk
 	for rows.Next() {
 		var c Chunk
-		if err := rows.Scan(&c.ID, &c.DocID, &c.Title, &c.URL, &c.Snippet, &c.PageNo, &c.Score); err != nil {
+		if err := rows.Scan(&c.ID, &c.DocID, &c.Title, &c.URL, &c.Snippet, &c.PageNo, &c.CharStart, &c.CharEnd, &c.Score); err != nil {
 			return nil, err
 		}
 		out = append(out, c)
@@ -85,25 +137,13 @@ func cosine(a, b []float32) float64 {
 	return dot / den
 }
 
-func parseEmbeddingJSON(raw []byte) ([]float32, error) {
-	var f64 []float64
-	if err := json.Unmarshal(raw, &f64); err != nil {
-		return nil, err
-	}
-	out := make([]float32, len(f64))
-	for i, v := range f64 {
-		out[i] = float32(v)
-	}
-	return out, nil
-}
-
 func (r *RAGRepo) loadEmbeddings(ctx context.Context, ids []int64) (map[int64][]float32, error) {
 	if len(ids) == 0 {
 		return map[int64][]float32{}, nil
 	}
 	var sb strings.Builder
 	args := make([]any, 0, len(ids))
-	sb.WriteString(`SELECT id, embedding FROM doc_chunks WHERE id IN (`)
+	sb.WriteString(`SELECT id, ` + embeddings.ReadColumns() + ` FROM doc_chunks WHERE id IN (`)
 	for i, id := range ids {
 		if i > 0 {
 			sb.WriteString(",")
@@ -123,14 +163,11 @@ func (r *RAGRepo) loadEmbeddings(ctx context.Context, ids []int64) (map[int64][]
 	m := make(map[int64][]float32, len(ids))
 	for rows.Next() {
 		var id int64
-		var raw []byte // kolom JSON -> []byte
-		if err := rows.Scan(&id, &raw); err != nil {
+		var bin, raw []byte // BLOB biner / JSON legacy (salah satu bisa NULL)
+		if err := rows.Scan(&id, &bin, &raw); err != nil {
 			return nil, err
 		}
-		if len(raw) == 0 {
-			continue
-		}
-		vec, err := parseEmbeddingJSON(raw)
+		vec, err := embeddings.Decode(bin, raw)
 		if err != nil {
 			continue
 		}
@@ -142,8 +179,41 @@ func (r *RAGRepo) loadEmbeddings(ctx context.Context, ids []int64) (map[int64][]
 // -------- Hybrid (BM25 + cosine) --------
 
 func (r *RAGRepo) SearchHybrid(ctx context.Context, query string, queryEmbedding []float32, alpha float64, topK int) ([]Chunk, error) {
+	return r.SearchHybridFiltered(ctx, query, queryEmbedding, alpha, topK, documents.Filter{})
+}
+
+// SearchHybridFiltered: SearchHybrid dengan filter metadata dokumen (kandidat BM25 maupun
+// kandidat embedding dibatasi ke dokumen yang cocok); hasil dilengkapi metadata dokumen.
+func (r *RAGRepo) SearchHybridFiltered(ctx context.Context, query string, queryEmbedding []float32, alpha float64, topK int, f documents.Filter) ([]Chunk, error) {
+	res, _, err := r.SearchHybridWithStats(ctx, query, queryEmbedding, alpha, topK, f)
+	return res, err
+}
+
+// HybridStats mencatat sinyal yang benar-benar ikut menilai kandidat.
+type HybridStats struct {
+	BM25Candidates   int `json:"bm25_candidates"`
+	ANNCandidates    int `json:"ann_candidates"`
+	RecentCandidates int `json:"recent_candidates,omitempty"` // fallback tanpa BM25 & ANN
+	DenseScored      int `json:"dense_scored"`                // kandidat yang punya skor cosine
+	DimSkipped       int `json:"dim_skipped,omitempty"`       // embedding berdimensi lain, tidak dinilai cosine
+}
+
+// SearchHybridWithStats sama dengan SearchHybridFiltered, ditambah statistik sinyal.
+func (r *RAGRepo) SearchHybridWithStats(ctx context.Context, query string, queryEmbedding []float32, alpha float64, topK int, f documents.Filter) ([]Chunk, HybridStats, error) {
+	return r.SearchHybridWith(ctx, query, queryEmbedding, alpha, topK, f, HybridOptions{})
+}
+
+// SearchHybridWith: SearchHybridWithStats dengan strategi fusi pilihan (lihat fusion.go).
+// Untuk rrf/weighted_rrf, alpha hanya dipakai sebagai bobot weighted_rrf.
+func (r *RAGRepo) SearchHybridWith(ctx context.Context, query string, queryEmbedding []float32, alpha float64, topK int, f documents.Filter, opt HybridOptions) ([]Chunk, HybridStats, error) {
+	var st HybridStats
+	fusion, err := ParseFusion(opt.Fusion)
+	if err != nil {
+		return nil, st, err
+	}
+	opt.Fusion = fusion
 	if r == nil || r.DB == nil {
-		return nil, errors.New("rag repo: DB is nil")
+		return nil, st, errors.New("rag repo: DB is nil")
 	}
 	if topK <= 0 || topK > 100 {
 		topK = 10
@@ -153,14 +223,14 @@ func (r *RAGRepo) SearchHybrid(ctx context.Context, query string, queryEmbedding
 	}
 
 	var bm25Results []Chunk
-	var err error
 	if strings.TrimSpace(query) != "" {
-		bm25Results, err = r.SearchBM25(ctx, query, topK)
+		bm25Results, err = r.SearchBM25Filtered(ctx, query, topK, f)
 		if err != nil {
-			return nil, err
+			return nil, st, err
 		}
 	}
 
+	st.BM25Candidates = len(bm25Results)
 	candidateIDs := make([]int64, 0, len(bm25Results))
 	idSet := map[int64]struct{}{}
 	for _, c := range bm25Results {
@@ -170,26 +240,46 @@ func (r *RAGRepo) SearchHybrid(ctx context.Context, query string, queryEmbedding
 		}
 	}
 
-	// Kalau tidak ada teks, ambil sample dokumen ber-embedding
+	// Kandidat semantik dari indeks ANN (seluruh korpus) di samping kandidat BM25.
+	// Skor cosine dari indeks dipakai langsung sehingga embedding-nya tidak perlu dimuat ulang.
+	annCos := map[int64]float64{}
+	if r.ANN != nil && len(queryEmbedding) > 0 {
+		k := r.ANN.Candidates()
+		if !f.Empty() {
+			k *= 4 // sebagian kandidat gugur oleh filter metadata
+		}
+		for _, h := range r.ANN.Search(queryEmbedding, k) {
+			annCos[h.ID] = h.Score
+			st.ANNCandidates++
+			if _, ok := idSet[h.ID]; !ok {
+				idSet[h.ID] = struct{}{}
+				candidateIDs = append(candidateIDs, h.ID)
+			}
+		}
+	}
+
+	// Kalau tidak ada teks (dan indeks ANN tidak tersedia), ambil sample dokumen ber-embedding
 	if len(candidateIDs) == 0 && len(queryEmbedding) > 0 {
-		rows, err := r.DB.QueryContext(ctx, `
-			SELECT id FROM doc_chunks
-			 WHERE embedding IS NOT NULL
-			 ORDER BY id DESC
-			 LIMIT 200
-		`)
+		q := `SELECT id FROM doc_chunks WHERE ` + embeddings.ReadHasEmbedding()
+		var args []any
+		if where, fargs := f.ChunkWhere("doc_id"); where != "" {
+			q += ` AND ` + where
+			args = fargs
+		}
+		rows, err := r.DB.QueryContext(ctx, q+` ORDER BY id DESC LIMIT 200`, args...)
 		if err != nil {
-			return nil, err
+			return nil, st, err
 		}
 		for rows.Next() {
 			var id int64
 			if err := rows.Scan(&id); err != nil {
 				rows.Close()
-				return nil, err
+				return nil, st, err
 			}
 			if _, ok := idSet[id]; !ok {
 				idSet[id] = struct{}{}
 				candidateIDs = append(candidateIDs, id)
+				st.RecentCandidates++
 			}
 		}
 		rows.Close()
@@ -204,7 +294,7 @@ func (r *RAGRepo) SearchHybrid(ctx context.Context, query string, queryEmbedding
 	if len(candidateIDs) > 0 {
 		var sb strings.Builder
 		args := make([]any, 0, len(candidateIDs))
-		sb.WriteString(`SELECT id, doc_id, title, url, snippet, page_no FROM doc_chunks WHERE id IN (`)
+		sb.WriteString(`SELECT id, doc_id, title, url, snippet, page_no, char_start, char_end FROM doc_chunks WHERE id IN (`)
 		for i, id := range candidateIDs {
 			if i > 0 {
 				sb.WriteString(",")
@@ -213,17 +303,22 @@ func (r *RAGRepo) SearchHybrid(ctx context.Context, query string, queryEmbedding
 			args = append(args, id)
 		}
 		sb.WriteString(")")
+		// kandidat ANN belum melewati filter metadata/versi (kandidat BM25 sudah)
+		if where, fargs := f.ChunkWhere("doc_id"); where != "" && len(annCos) > 0 {
+			sb.WriteString(" AND " + where)
+			args = append(args, fargs...)
+		}
 		q := sb.String()
 
 		rows, err := r.DB.QueryContext(ctx, q, args...)
 		if err != nil {
-			return nil, err
+			return nil, st, err
 		}
 		for rows.Next() {
 			var c Chunk
-			if err := rows.Scan(&c.ID, &c.DocID, &c.Title, &c.URL, &c.Snippet, &c.PageNo); err != nil {
+			if err := rows.Scan(&c.ID, &c.DocID, &c.Title, &c.URL, &c.Snippet, &c.PageNo, &c.CharStart, &c.CharEnd); err != nil {
 				rows.Close()
-				return nil, err
+				return nil, st, err
 			}
 			if _, exists := meta[c.ID]; !exists {
 				meta[c.ID] = c
@@ -232,60 +327,87 @@ func (r *RAGRepo) SearchHybrid(ctx context.Context, query string, queryEmbedding
 		rows.Close()
 	}
 
-	embMap, err := r.loadEmbeddings(ctx, candidateIDs)
-	if err != nil {
-		return nil, err
-	}
-
-	// Normalisasi BM25 ke [0,1]
-	var maxBM25 float64
-	for _, c := range meta {
-		if c.Score.Valid && c.Score.Float64 > maxBM25 {
-			maxBM25 = c.Score.Float64
+	need := candidateIDs
+	if len(annCos) > 0 {
+		need = make([]int64, 0, len(candidateIDs))
+		for _, id := range candidateIDs {
+			if _, ok := annCos[id]; !ok {
+				need = append(need, id)
+			}
 		}
 	}
+	embMap, err := r.loadEmbeddings(ctx, need)
+	if err != nil {
+		return nil, st, err
+	}
 
+	// Skor per sinyal (urutan kandidat tetap: BM25 dulu, lalu ANN/fallback), lalu fusi.
 	type scored struct {
 		Chunk
-		Final float64
+		ScoreDebug
 	}
 	out := make([]scored, 0, len(meta))
-	for id, c := range meta {
-		bm25Norm := 0.0
-		if maxBM25 > 0 && c.Score.Valid {
-			bm25Norm = c.Score.Float64 / maxBM25
-			if bm25Norm > 1 {
-				bm25Norm = 1
-			}
+	for _, id := range candidateIDs {
+		c, ok := meta[id]
+		if !ok {
+			continue
+		}
+		s := scored{Chunk: c}
+		if c.Score.Valid {
+			raw := c.Score.Float64
+			s.BM25Raw = &raw
 		}
-		cos := 0.0
 		if len(queryEmbedding) > 0 {
-			if vec, ok := embMap[id]; ok {
-				cos = cosine(queryEmbedding, vec)
-				if cos < -1 {
-					cos = -1
-				}
-				if cos > 1 {
-					cos = 1
+			if cos, ok := annCos[id]; ok {
+				s.Cosine = &cos
+				st.DenseScored++
+			} else if vec, ok := embMap[id]; ok {
+				if len(vec) != len(queryEmbedding) {
+					st.DimSkipped++ // model embedding lain (migrasi belum selesai)
+				} else {
+					cos := cosine(queryEmbedding, vec)
+					s.Cosine = &cos
+					st.DenseScored++
 				}
-				cos = (cos + 1) / 2 // ke [0,1]
 			}
 		}
-		final := alpha*bm25Norm + (1-alpha)*cos
-		out = append(out, scored{Chunk: c, Final: final})
+		out = append(out, s)
+	}
+	ds := make([]ScoreDebug, len(out))
+	for i := range out {
+		ds[i] = out[i].ScoreDebug
+	}
+	Fuse(ds, opt.Fusion, alpha, opt.RRFK)
+	for i := range out {
+		out[i].ScoreDebug = ds[i]
 	}
 
-	sort.Slice(out, func(i, j int) bool { return out[i].Final > out[j].Final })
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Fused > out[j].Fused })
 	if len(out) > topK {
 		out = out[:topK]
 	}
 
 	res := make([]Chunk, 0, len(out))
+	ids := make([]string, 0, len(out))
 	for _, s := range out {
 		c := s.Chunk
 		c.Score.Valid = true
-		c.Score.Float64 = s.Final
+		c.Score.Float64 = s.Fused
+		if opt.Debug {
+			d := s.ScoreDebug
+			c.Debug = &d
+		}
 		res = append(res, c)
+		ids = append(ids, c.DocID.String)
+	}
+
+	// Metadata dokumen (opsional: tabel documents mungkin belum dimigrasi)
+	if docs, err := (&documents.Repo{DB: r.DB}).GetMany(ctx, ids); err == nil {
+		for i := range res {
+			if d, ok := docs[res[i].DocID.String]; ok {
+				res[i].Doc = &d
+			}
+		}
 	}
-	return res, nil
+	return res, st, nil
 }
@@ -144,3 +144,23 @@ func (r *TimeseriesRepo) List(ctx context.Context, f TSFilter) ([]TSPoint, error
 	}
 	return points, nil
 }
+
+// SignalInfo: metadata ts_signal (nama tag & satuan) untuk label sumbu grafik.
+type SignalInfo struct {
+	TagID   string
+	TagName sql.NullString
+	Unit    sql.NullString
+}
+
+// GetSignal mengambil metadata satu tag dari ts_signal (sql.ErrNoRows bila tidak ada).
+func (r *TimeseriesRepo) GetSignal(ctx context.Context, tagID string) (SignalInfo, error) {
+	if r == nil || r.DB == nil {
+		return SignalInfo{}, errors.New("timeseries repo: DB is nil")
+	}
+	var s SignalInfo
+	const q = `SELECT tag_id, tag_name, unit FROM ts_signal WHERE tag_id = ? LIMIT 1`
+	if err := r.DB.QueryRowContext(ctx, q, strings.TrimSpace(tagID)).Scan(&s.TagID, &s.TagName, &s.Unit); err != nil {
+		return SignalInfo{}, err
+	}
+	return s, nil
+}
@@ -5,16 +5,21 @@ package search
 import (
 	"context"
 	"database/sql"
-	"encoding/json"
 	"errors"
-	"math"
+	"fmt"
+	"os"
+	"strconv"
 	"strings"
 	"time"
 
-	"mcp-oilgas/pkg/vector" // ganti dari your/module → ke module kamu
+	"mcp-oilgas/internal/llm"
+	"mcp-oilgas/internal/repositories/documents"
+	"mcp-oilgas/internal/repositories/embeddings"
+	"mcp-oilgas/pkg/vector"
 )
 
 type RAGHit struct {
+	ChunkID int64 // doc_chunks.id
 	DocID   string
 	Title   string
 	URL     string
@@ -25,32 +30,44 @@ type RAGHit struct {
 
 type RAGRepo interface {
 	Retrieve(ctx context.Context, query string, topK int) ([]RAGHit, error)
+	// RetrieveFiltered membatasi kandidat ke dokumen yang cocok dengan filter metadata.
+	RetrieveFiltered(ctx context.Context, query string, topK int, f documents.Filter) ([]RAGHit, error)
 }
 
 type ragRepo struct {
 	db           *sql.DB
-	embedClient  *vector.OpenAIClient
-	embedModel   string
+	embedder     llm.Embedder
 	prefilterTop int
 }
 
-func NewRAGRepo(db *sql.DB, embedClient *vector.OpenAIClient, embedModel string, prefilterTop int) RAGRepo {
+// DefaultPrefilter adalah jumlah kandidat BM25 yang dinilai cosine bila RAG_PREFILTER kosong.
+const DefaultPrefilter = 200
+
+// PrefilterFromEnv: RAG_PREFILTER (> 0), default DefaultPrefilter.
+func PrefilterFromEnv() int {
+	if n, err := strconv.Atoi(strings.TrimSpace(os.Getenv("RAG_PREFILTER"))); err == nil && n > 0 {
+		return n
+	}
+	return DefaultPrefilter
+}
+
+// NewRAGRepo: model embedding mengikuti konfigurasi embedder (llm.Config.EmbedModel).
+func NewRAGRepo(db *sql.DB, embedder llm.Embedder, prefilterTop int) RAGRepo {
 	if prefilterTop <= 0 {
 		prefilterTop = 100
 	}
-	if embedModel == "" {
-		embedModel = "text-embedding-3-small"
-	}
-	return &ragRepo{db: db, embedClient: embedClient, embedModel: embedModel, prefilterTop: prefilterTop}
+	return &ragRepo{db: db, embedder: embedder, prefilterTop: prefilterTop}
 }
 
 type chunkRow struct {
+	ID      int64
 	DocID   string
 	Title   string
 	URL     string
 	PageNo  int
 	Snippet string
-	EmbJSON string
+	EmbBin  []byte
+	EmbJSON []byte
 }
 
 // 🔑 pindahkan ke sini biar bisa dipakai di partialSortTopK
@@ -59,6 +76,10 @@ type scored struct {
 }
 
 func (r *ragRepo) Retrieve(ctx context.Context, query string, topK int) ([]RAGHit, error) {
+	return r.RetrieveFiltered(ctx, query, topK, documents.Filter{})
+}
+
+func (r *ragRepo) RetrieveFiltered(ctx context.Context, query string, topK int, f documents.Filter) ([]RAGHit, error) {
 	q := strings.TrimSpace(query)
 	if q == "" {
 		return nil, errors.New("empty query")
@@ -67,7 +88,7 @@ func (r *ragRepo) Retrieve(ctx context.Context, query string, topK int) ([]RAGHi
 		topK = 10
 	}
 
-	embs, err := r.embedClient.CreateEmbeddings(ctx, r.embedModel, []string{q})
+	embs, err := r.embedder.Embed(ctx, []string{q})
 	if err != nil {
 		return nil, err
 	}
@@ -75,19 +96,29 @@ func (r *ragRepo) Retrieve(ctx context.Context, query string, topK int) ([]RAGHi
 		return nil, errors.New("no embedding for query")
 	}
 	qv := embs[0]
-	norm(qv)
+	// jangan pernah membandingkan vektor beda dimensi (mis. selama migrasi model embedding)
+	if dim := embeddings.ExpectedDim(); len(qv) != dim {
+		return nil, fmt.Errorf("query embedding dimension %d != EMBED_DIM %d", len(qv), dim)
+	}
+	vector.Normalize(qv)
 
 	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
-	rows, err := r.db.QueryContext(ctx, `
-		SELECT doc_id, title, url, page_no, snippet, JSON_EXTRACT(embedding, '$') AS emb
+	sqlq := `
+		SELECT id, doc_id, title, url, page_no, snippet, ` + embeddings.ReadColumns() + `
 		FROM doc_chunks
-		WHERE MATCH(title, snippet) AGAINST (? IN NATURAL LANGUAGE MODE)
+		WHERE MATCH(title, snippet) AGAINST (? IN NATURAL LANGUAGE MODE)`
+	args := []any{q}
+	if where, fargs := f.ChunkWhere("doc_id"); where != "" {
+		sqlq += ` AND ` + where
+		args = append(args, fargs...)
+	}
+	sqlq += `
 		ORDER BY MATCH(title, snippet) AGAINST (? IN NATURAL LANGUAGE MODE) DESC
-		LIMIT ?`,
-		q, q, r.prefilterTop,
-	)
+		LIMIT ?`
+	args = append(args, q, r.prefilterTop)
+	rows, err := r.db.QueryContext(ctx, sqlq, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -96,7 +127,7 @@ func (r *ragRepo) Retrieve(ctx context.Context, query string, topK int) ([]RAGHi
 	var cands []chunkRow
 	for rows.Next() {
 		var cr chunkRow
-		if err := rows.Scan(&cr.DocID, &cr.Title, &cr.URL, &cr.PageNo, &cr.Snippet, &cr.EmbJSON); err != nil {
+		if err := rows.Scan(&cr.ID, &cr.DocID, &cr.Title, &cr.URL, &cr.PageNo, &cr.Snippet, &cr.EmbBin, &cr.EmbJSON); err != nil {
 			return nil, err
 		}
 		cands = append(cands, cr)
@@ -105,14 +136,15 @@ func (r *ragRepo) Retrieve(ctx context.Context, query string, topK int) ([]RAGHi
 
 	scoredHits := make([]scored, 0, len(cands))
 	for _, c := range cands {
-		var ev []float64
-		if err := json.Unmarshal([]byte(c.EmbJSON), &ev); err != nil || len(ev) == 0 {
+		ev, err := embeddings.Decode(c.EmbBin, c.EmbJSON)
+		if err != nil || len(ev) != len(qv) {
 			continue
 		}
-		norm(ev)
-		s := dot(qv, ev)
+		vector.Normalize(ev)
+		s := vector.Dot(qv, ev)
 		scoredHits = append(scoredHits, scored{
 			h: RAGHit{
+				ChunkID: c.ID,
 				DocID:   c.DocID,
 				Title:   c.Title,
 				URL:     c.URL,
@@ -132,32 +164,6 @@ func (r *ragRepo) Retrieve(ctx context.Context, query string, topK int) ([]RAGHi
 	return out, nil
 }
 
-func norm(v []float64) {
-	var s float64
-	for _, x := range v {
-		s += x * x
-	}
-	if s == 0 {
-		return
-	}
-	inv := 1.0 / math.Sqrt(s)
-	for i := range v {
-		v[i] *= inv
-	}
-}
-
-func dot(a, b []float64) float64 {
-	n := len(a)
-	if len(b) < n {
-		n = len(b)
-	}
-	var s float64
-	for i := 0; i < n; i++ {
-		s += a[i] * b[i]
-	}
-	return s
-}
-
 func partialSortTopK(arr []scored, k int) {
 	for i := 0; i < len(arr); i++ {
 		maxIdx := i
@@ -0,0 +1,66 @@
+// internal/repositories/search/reranked.go
+package search
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"mcp-oilgas/internal/repositories/documents"
+	"mcp-oilgas/internal/rerank"
+)
+
+// WithReranker membungkus repo: ambil `candidates` hit, rerank, potong ke topK.
+// Reranker gagal/timeout → urutan retrieval asli (tetap dipotong ke topK). rr nil = repo apa adanya.
+func WithReranker(repo RAGRepo, rr rerank.Reranker, cfg rerank.Config) RAGRepo {
+	if repo == nil || rr == nil {
+		return repo
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 3 * time.Second
+	}
+	return &rerankedRepo{next: repo, rr: rr, cfg: cfg}
+}
+
+type rerankedRepo struct {
+	next RAGRepo
+	rr   rerank.Reranker
+	cfg  rerank.Config
+}
+
+func (r *rerankedRepo) Retrieve(ctx context.Context, query string, topK int) ([]RAGHit, error) {
+	return r.RetrieveFiltered(ctx, query, topK, documents.Filter{})
+}
+
+func (r *rerankedRepo) RetrieveFiltered(ctx context.Context, query string, topK int, f documents.Filter) ([]RAGHit, error) {
+	if topK <= 0 || topK > 50 {
+		topK = 10
+	}
+	n := max(topK, min(r.cfg.Candidates, 50))
+	hits, err := r.next.RetrieveFiltered(ctx, query, n, f)
+	if err != nil || len(hits) <= 1 {
+		return hits, err
+	}
+
+	passages := make([]string, len(hits))
+	for i, h := range hits {
+		passages[i] = h.Title + "\n" + h.Snippet
+	}
+	rctx, cancel := context.WithTimeout(ctx, r.cfg.Timeout)
+	defer cancel()
+	res, err := rerank.Apply(rctx, r.rr, query, passages, topK)
+	if err != nil {
+		log.Printf("[rerank] %s failed, keeping retrieval order: %v", r.rr.Name(), err)
+		if len(hits) > topK {
+			hits = hits[:topK]
+		}
+		return hits, nil
+	}
+	out := make([]RAGHit, 0, len(res))
+	for _, x := range res {
+		h := hits[x.Index]
+		h.Score = x.Score
+		out = append(out, h)
+	}
+	return out, nil
+}
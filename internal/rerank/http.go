@@ -0,0 +1,119 @@
+// internal/rerank/http.go
+package rerank
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// HTTP memanggil cross-encoder yang dilayani lewat HTTP.
+//
+//	cohere (default): POST {"model","query","documents":[...]} → {"results":[{"index","relevance_score"}]}
+//	                  (Cohere, Jina, Voyage, vLLM /v1/rerank, infinity, LocalAI)
+//	tei:              POST {"query","texts":[...]} → [{"index","score"}] (HF text-embeddings-inference)
+type HTTP struct {
+	URL    string
+	Model  string
+	APIKey string
+	Format string       // cohere | tei
+	Client *http.Client // nil = http.DefaultClient
+}
+
+// Name mengembalikan "http".
+func (r *HTTP) Name() string { return KindHTTP }
+
+type httpResult struct {
+	Index          int      `json:"index"`
+	RelevanceScore *float64 `json:"relevance_score"`
+	Score          *float64 `json:"score"`
+}
+
+// Score mengirim seluruh passage dalam satu request; passage tanpa hasil mendapat skor minimum.
+func (r *HTTP) Score(ctx context.Context, query string, passages []string) ([]float64, error) {
+	var body any
+	if r.Format == "tei" {
+		body = map[string]any{"query": query, "texts": passages, "truncate": true}
+	} else {
+		m := map[string]any{"query": query, "documents": passages, "return_documents": false}
+		if r.Model != "" {
+			m["model"] = r.Model
+		}
+		body = m
+	}
+	b, _ := json.Marshal(body)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.URL, bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if r.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+r.APIKey)
+	}
+	cli := r.Client
+	if cli == nil {
+		cli = http.DefaultClient
+	}
+	resp, err := cli.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("rerank http: %w", err)
+	}
+	defer resp.Body.Close()
+	raw, err := io.ReadAll(io.LimitReader(resp.Body, 4<<20))
+	if err != nil {
+		return nil, fmt.Errorf("rerank http: %w", err)
+	}
+	if resp.StatusCode >= 300 {
+		msg := string(raw)
+		if len(msg) > 200 {
+			msg = msg[:200]
+		}
+		return nil, fmt.Errorf("rerank http: status %d: %s", resp.StatusCode, msg)
+	}
+
+	// terima {"results":[...]}, {"data":[...]}, atau array langsung
+	var results []httpResult
+	var wrapped struct {
+		Results []httpResult `json:"results"`
+		Data    []httpResult `json:"data"`
+	}
+	if err := json.Unmarshal(raw, &results); err != nil {
+		if err := json.Unmarshal(raw, &wrapped); err != nil {
+			return nil, fmt.Errorf("rerank http: invalid response: %w", err)
+		}
+		results = wrapped.Results
+		if len(results) == 0 {
+			results = wrapped.Data
+		}
+	}
+
+	out := make([]float64, len(passages))
+	seen := make([]bool, len(passages))
+	lowest, ok := 0.0, false
+	for _, res := range results {
+		s := res.Score
+		if res.RelevanceScore != nil {
+			s = res.RelevanceScore
+		}
+		if s == nil || res.Index < 0 || res.Index >= len(passages) {
+			continue
+		}
+		out[res.Index], seen[res.Index] = *s, true
+		if !ok || *s < lowest {
+			lowest, ok = *s, true
+		}
+	}
+	if !ok {
+		return nil, fmt.Errorf("rerank http: no usable scores")
+	}
+	// skor cross-encoder bisa negatif (logit): passage yang tidak dikembalikan ditaruh paling bawah
+	for i := range out {
+		if !seen[i] {
+			out[i] = lowest - 1
+		}
+	}
+	return out, nil
+}
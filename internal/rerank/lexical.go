@@ -0,0 +1,112 @@
+// internal/rerank/lexical.go
+package rerank
+
+import (
+	"context"
+	"math"
+	"strings"
+	"unicode"
+)
+
+// Lexical menilai cakupan term query di passage, dibobot IDF atas set kandidat
+// (term langka di antara kandidat lebih menentukan), ditambah bonus bigram query yang muncul utuh.
+// Skor berada di [0, 1.25].
+type Lexical struct{}
+
+// Name mengembalikan "lexical".
+func (Lexical) Name() string { return KindLexical }
+
+// Score tidak memakai jaringan; ctx diabaikan.
+func (Lexical) Score(_ context.Context, query string, passages []string) ([]float64, error) {
+	qTerms := uniqueTerms(tokenize(query))
+	out := make([]float64, len(passages))
+	if len(qTerms) == 0 {
+		return out, nil
+	}
+
+	docs := make([]map[string]struct{}, len(passages))
+	toks := make([][]string, len(passages))
+	df := map[string]int{}
+	for i, p := range passages {
+		toks[i] = tokenize(p)
+		docs[i] = map[string]struct{}{}
+		for _, t := range toks[i] {
+			docs[i][t] = struct{}{}
+		}
+		for _, t := range qTerms {
+			if _, ok := docs[i][t]; ok {
+				df[t]++
+			}
+		}
+	}
+	n := float64(len(passages))
+	idf := make(map[string]float64, len(qTerms))
+	var total float64
+	for _, t := range qTerms {
+		idf[t] = math.Log(1 + (n+1)/(float64(df[t])+0.5))
+		total += idf[t]
+	}
+
+	qBigrams := bigrams(tokenize(query))
+	for i := range passages {
+		var s float64
+		for _, t := range qTerms {
+			if _, ok := docs[i][t]; ok {
+				s += idf[t]
+			}
+		}
+		score := s / total
+		if len(qBigrams) > 0 {
+			pb := map[string]struct{}{}
+			for _, b := range bigrams(toks[i]) {
+				pb[b] = struct{}{}
+			}
+			hit := 0
+			for _, b := range qBigrams {
+				if _, ok := pb[b]; ok {
+					hit++
+				}
+			}
+			score += 0.25 * float64(hit) / float64(len(qBigrams))
+		}
+		out[i] = score
+	}
+	return out, nil
+}
+
+// tokenize: huruf kecil, pisah di non huruf/angka, buang token 1 karakter.
+func tokenize(s string) []string {
+	fs := strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	out := fs[:0]
+	for _, f := range fs {
+		if len([]rune(f)) > 1 {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+func uniqueTerms(ts []string) []string {
+	seen := map[string]struct{}{}
+	out := make([]string, 0, len(ts))
+	for _, t := range ts {
+		if _, ok := seen[t]; !ok {
+			seen[t] = struct{}{}
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+func bigrams(ts []string) []string {
+	if len(ts) < 2 {
+		return nil
+	}
+	out := make([]string, 0, len(ts)-1)
+	for i := 1; i < len(ts); i++ {
+		out = append(out, ts[i-1]+" "+ts[i])
+	}
+	return uniqueTerms(out)
+}
@@ -0,0 +1,96 @@
+// internal/rerank/llm.go
+package rerank
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"mcp-oilgas/internal/llm"
+	"mcp-oilgas/internal/prompts"
+	"mcp-oilgas/internal/redact"
+)
+
+// maxPassageChars membatasi teks per passage yang dikirim ke LLM.
+const maxPassageChars = 1000
+
+// LLM menilai passage secara pointwise (skor 0..10 per passage) dengan template "rerank".
+// Passage dikirim per batch dalam satu object JSON agar panggilan tetap sedikit;
+// passage yang tidak diberi skor oleh model mendapat 0. LLM nil = llm.Shared().
+type LLM struct {
+	LLM   llm.Client
+	Batch int // default 10
+}
+
+// Name mengembalikan "llm".
+func (r *LLM) Name() string { return KindLLM }
+
+func (r *LLM) client() (llm.Client, error) {
+	if r.LLM != nil {
+		return r.LLM, nil
+	}
+	cli, err := llm.Shared()
+	if err != nil {
+		return nil, err
+	}
+	if redact.LLMEnabled() {
+		cli = redact.WrapClient(cli, redact.Default().NewSession())
+	}
+	return cli, nil
+}
+
+type llmPassage struct {
+	ID   int    `json:"id"`
+	Text string `json:"text"`
+}
+
+// Score memanggil LLM per batch; error satu batch menggagalkan seluruh reranking.
+func (r *LLM) Score(ctx context.Context, query string, passages []string) ([]float64, error) {
+	cli, err := r.client()
+	if err != nil {
+		return nil, err
+	}
+	rp, err := prompts.Default().Render(prompts.Rerank, prompts.Vars{})
+	if err != nil {
+		return nil, err
+	}
+	batch := r.Batch
+	if batch <= 0 {
+		batch = 10
+	}
+
+	out := make([]float64, len(passages))
+	for start := 0; start < len(passages); start += batch {
+		end := min(start+batch, len(passages))
+		ps := make([]llmPassage, 0, end-start)
+		for i := start; i < end; i++ {
+			t := passages[i]
+			if len(t) > maxPassageChars {
+				t = t[:maxPassageChars]
+			}
+			ps = append(ps, llmPassage{ID: i - start, Text: t})
+		}
+		// query & passage dikirim sebagai string JSON agar tidak bercampur dengan instruksi
+		ub, _ := json.Marshal(map[string]any{"query": query, "passages": ps})
+		raw, err := cli.AnswerJSON(ctx, string(ub), rp.Text)
+		if err != nil {
+			return nil, fmt.Errorf("rerank llm: %w", err)
+		}
+		var resp struct {
+			Scores []struct {
+				ID    int     `json:"id"`
+				Score float64 `json:"score"`
+			} `json:"scores"`
+		}
+		if err := json.Unmarshal([]byte(raw), &resp); err != nil {
+			return nil, fmt.Errorf("rerank llm: invalid json: %w", err)
+		}
+		for _, s := range resp.Scores {
+			if s.ID < 0 || s.ID >= len(ps) {
+				continue
+			}
+			out[start+s.ID] = max(0, min(s.Score, 10))
+		}
+	}
+	return out, nil
+}
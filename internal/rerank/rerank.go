@@ -0,0 +1,125 @@
+// internal/rerank/rerank.go
+// Tahap reranking setelah retrieval hybrid: tahap pertama mengambil kandidat lebih banyak
+// (RERANK_CANDIDATES), reranker menilai ulang pasangan (query, passage), lalu dipotong ke top_k.
+//
+// Reranker yang tersedia:
+//   - lexical: overlap term berbobot IDF atas set kandidat (offline, tanpa jaringan)
+//   - llm:     penilaian pointwise lewat LLM (template "rerank", JSON mode)
+//   - http:    cross-encoder lokal via endpoint /rerank (format Cohere/Jina/vLLM atau HF TEI)
+package rerank
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Reranker menilai relevansi setiap passage terhadap query; skor lebih tinggi = lebih relevan.
+// Panjang hasil harus sama dengan passages.
+type Reranker interface {
+	Name() string
+	Score(ctx context.Context, query string, passages []string) ([]float64, error)
+}
+
+// Nama reranker (env RERANK).
+const (
+	KindOff     = "off"
+	KindLexical = "lexical"
+	KindLLM     = "llm"
+	KindHTTP    = "http"
+)
+
+// Config dibaca dari env RERANK_*.
+type Config struct {
+	Kind       string
+	Candidates int           // jumlah kandidat tahap pertama
+	Timeout    time.Duration // batas waktu reranking; lewat → urutan hybrid dipertahankan
+
+	URL    string // http: endpoint rerank, mis. http://reranker:8080/rerank
+	Model  string // http: nama model (format cohere)
+	APIKey string // http: Bearer token opsional
+	Format string // http: cohere (default) | tei
+
+	Batch int // llm: passage per panggilan
+}
+
+// ConfigFromEnv: RERANK (off|lexical|llm|http, default off), RERANK_CANDIDATES (50),
+// RERANK_TIMEOUT_MS (3000), RERANK_URL, RERANK_MODEL, RERANK_API_KEY, RERANK_FORMAT (cohere|tei),
+// RERANK_BATCH (10).
+func ConfigFromEnv() Config {
+	cfg := Config{
+		Kind:       strings.ToLower(strings.TrimSpace(os.Getenv("RERANK"))),
+		Candidates: 50,
+		Timeout:    3 * time.Second,
+		URL:        strings.TrimSpace(os.Getenv("RERANK_URL")),
+		Model:      strings.TrimSpace(os.Getenv("RERANK_MODEL")),
+		APIKey:     strings.TrimSpace(os.Getenv("RERANK_API_KEY")),
+		Format:     strings.ToLower(strings.TrimSpace(os.Getenv("RERANK_FORMAT"))),
+		Batch:      10,
+	}
+	if cfg.Kind == "" {
+		cfg.Kind = KindOff
+	}
+	if n, err := strconv.Atoi(os.Getenv("RERANK_CANDIDATES")); err == nil && n > 0 {
+		cfg.Candidates = n
+	}
+	if n, err := strconv.Atoi(os.Getenv("RERANK_TIMEOUT_MS")); err == nil && n > 0 {
+		cfg.Timeout = time.Duration(n) * time.Millisecond
+	}
+	if n, err := strconv.Atoi(os.Getenv("RERANK_BATCH")); err == nil && n > 0 {
+		cfg.Batch = n
+	}
+	return cfg
+}
+
+// New membuat reranker sesuai cfg.Kind; (nil, nil) bila off.
+func New(cfg Config) (Reranker, error) {
+	switch cfg.Kind {
+	case "", KindOff, "none", "false", "0":
+		return nil, nil
+	case KindLexical:
+		return Lexical{}, nil
+	case KindLLM:
+		return &LLM{Batch: cfg.Batch}, nil
+	case KindHTTP:
+		if cfg.URL == "" {
+			return nil, fmt.Errorf("rerank: RERANK_URL is required for RERANK=http")
+		}
+		return &HTTP{URL: cfg.URL, Model: cfg.Model, APIKey: cfg.APIKey, Format: cfg.Format}, nil
+	}
+	return nil, fmt.Errorf("rerank: unknown RERANK %q (off|lexical|llm|http)", cfg.Kind)
+}
+
+// Result adalah posisi passage (indeks masukan) beserta skor reranker.
+type Result struct {
+	Index int
+	Score float64
+}
+
+// Apply menilai passages dan mengembalikan maksimal topK hasil, terurut skor menurun
+// (seri dipertahankan sesuai urutan retrieval).
+func Apply(ctx context.Context, r Reranker, query string, passages []string, topK int) ([]Result, error) {
+	if len(passages) == 0 {
+		return nil, nil
+	}
+	scores, err := r.Score(ctx, query, passages)
+	if err != nil {
+		return nil, err
+	}
+	if len(scores) != len(passages) {
+		return nil, fmt.Errorf("rerank %s: got %d scores for %d passages", r.Name(), len(scores), len(passages))
+	}
+	out := make([]Result, len(passages))
+	for i, s := range scores {
+		out[i] = Result{Index: i, Score: s}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Score > out[j].Score })
+	if topK > 0 && len(out) > topK {
+		out = out[:topK]
+	}
+	return out, nil
+}
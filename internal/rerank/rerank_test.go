@@ -0,0 +1,83 @@
+package rerank_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"mcp-oilgas/internal/llm"
+	"mcp-oilgas/internal/rerank"
+)
+
+var passages = []string{
+	"Laporan produksi harian lapangan North",
+	"Prosedur gas testing sebelum confined space entry: ukur O2, LEL dan H2S",
+	"Gas testing wajib dicatat di permit to work",
+}
+
+func TestLexicalPrefersCoverage(t *testing.T) {
+	res, err := rerank.Apply(context.Background(), rerank.Lexical{}, "prosedur gas testing H2S", passages, 2)
+	if err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+	if len(res) != 2 || res[0].Index != 1 || res[1].Index != 2 {
+		t.Fatalf("unexpected order: %+v", res)
+	}
+}
+
+func TestLLMPointwiseScores(t *testing.T) {
+	fake := &llm.Fake{JSONReplies: []string{`{"scores":[{"id":0,"score":1},{"id":1,"score":9},{"id":2,"score":6}]}`}}
+	res, err := rerank.Apply(context.Background(), &rerank.LLM{LLM: fake}, "gas testing", passages, 3)
+	if err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+	if res[0].Index != 1 || res[0].Score != 9 || res[2].Index != 0 {
+		t.Fatalf("unexpected order: %+v", res)
+	}
+	if n := len(fake.Calls()); n != 1 {
+		t.Fatalf("expected 1 batched call, got %d", n)
+	}
+}
+
+func TestHTTPCohereFormat(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Model     string   `json:"model"`
+			Query     string   `json:"query"`
+			Documents []string `json:"documents"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || len(req.Documents) != 3 || req.Model != "bge-reranker" {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		// sebagian hasil saja (top_n), skor logit negatif
+		_, _ = w.Write([]byte(`{"results":[{"index":2,"relevance_score":1.5},{"index":0,"relevance_score":-3}]}`))
+	}))
+	defer srv.Close()
+
+	r := &rerank.HTTP{URL: srv.URL, Model: "bge-reranker"}
+	scores, err := r.Score(context.Background(), "gas testing", passages)
+	if err != nil {
+		t.Fatalf("score: %v", err)
+	}
+	if scores[2] != 1.5 || scores[0] != -3 || scores[1] >= -3 {
+		t.Fatalf("unexpected scores: %v", scores)
+	}
+}
+
+func TestHTTPTEIFormat(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`[{"index":1,"score":0.9},{"index":0,"score":0.1},{"index":2,"score":0.4}]`))
+	}))
+	defer srv.Close()
+
+	res, err := rerank.Apply(context.Background(), &rerank.HTTP{URL: srv.URL, Format: "tei"}, "q", passages, 1)
+	if err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+	if len(res) != 1 || res[0].Index != 1 {
+		t.Fatalf("unexpected result: %+v", res)
+	}
+}
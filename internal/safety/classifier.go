@@ -0,0 +1,61 @@
+// internal/safety/classifier.go
+// Klasifier prompt injection berbasis LLM (JSON mode) untuk kasus yang skor heuristiknya abu-abu.
+package safety
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"mcp-oilgas/internal/llm"
+	"mcp-oilgas/internal/prompts"
+	"mcp-oilgas/internal/redact"
+)
+
+// maxClassifyChars membatasi teks yang dikirim ke klasifier.
+const maxClassifyChars = 2000
+
+// LLMClassifier memakai template "injection_check". LLM nil = llm.Shared().
+type LLMClassifier struct {
+	LLM llm.Client
+}
+
+// Classify mengembalikan skor 0..1 dari field "score" balasan model.
+func (c *LLMClassifier) Classify(ctx context.Context, text string) (float64, error) {
+	cli := c.LLM
+	if cli == nil {
+		var err error
+		if cli, err = llm.Shared(); err != nil {
+			return 0, err
+		}
+		if redact.LLMEnabled() {
+			cli = redact.WrapClient(cli, redact.Default().NewSession())
+		}
+	}
+	rp, err := prompts.Default().Render(prompts.InjectionCheck, prompts.Vars{})
+	if err != nil {
+		return 0, err
+	}
+	if len(text) > maxClassifyChars {
+		text = text[:maxClassifyChars]
+	}
+	// teks dikirim sebagai string JSON agar tidak bercampur dengan instruksi klasifier
+	ub, _ := json.Marshal(map[string]string{"text": text})
+	raw, err := cli.AnswerJSON(ctx, string(ub), rp.Text)
+	if err != nil {
+		return 0, fmt.Errorf("injection classifier: %w", err)
+	}
+	var out struct {
+		Score float64 `json:"score"`
+	}
+	if err := json.Unmarshal([]byte(raw), &out); err != nil {
+		return 0, fmt.Errorf("injection classifier: invalid json: %w", err)
+	}
+	if out.Score < 0 {
+		out.Score = 0
+	}
+	if out.Score > 1 {
+		out.Score = 1
+	}
+	return out.Score, nil
+}
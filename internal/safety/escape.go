@@ -0,0 +1,51 @@
+// internal/safety/escape.go
+// Delimiting & escaping konten tidak tepercaya saat menyusun prompt.
+//
+// Konten dibungkus tag <untrusted_data source="..."> ... </untrusted_data>. Template system
+// prompt menyatakan isi tag tersebut adalah DATA, bukan instruksi. Escape memastikan konten
+// tidak bisa menutup tag lebih awal atau menyisipkan penanda peran (mis. <|im_start|>).
+package safety
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+)
+
+var (
+	reDelimTag  = regexp.MustCompile(`(?i)<(/?\s*untrusted_data)`)
+	reSpecialTk = regexp.MustCompile(`<\|([^|<>]{0,40})\|>`)
+	attrCleaner = strings.NewReplacer(`"`, "'", "\n", " ", "\r", " ", "<", "(", ">", ")")
+)
+
+// Escape membuang karakter tersembunyi dan menjinakkan tag pembatas / token khusus model.
+func Escape(text string) string {
+	text, _ = stripInvisible(text)
+	text = reDelimTag.ReplaceAllString(text, "&lt;$1")
+	text = reSpecialTk.ReplaceAllString(text, "‹|$1|›")
+	return text
+}
+
+// Quote membungkus teks tidak tepercaya dengan tag pembatas; source = label sumber (mis. citation key).
+func Quote(source, text string) string {
+	var b strings.Builder
+	b.WriteString(`<untrusted_data source="`)
+	b.WriteString(attrCleaner.Replace(source))
+	b.WriteString("\">\n")
+	b.WriteString(Escape(text))
+	b.WriteString("\n</untrusted_data>")
+	return b.String()
+}
+
+// QuoteJSON meng-encode v sebagai JSON lalu membungkusnya dengan tag pembatas.
+// encoding/json meng-escape <, > dan & sehingga tag tidak bisa ditutup dari dalam data.
+func QuoteJSON(source string, v any) string {
+	b, _ := json.Marshal(v)
+	var sb strings.Builder
+	sb.WriteString(`<untrusted_data source="`)
+	sb.WriteString(attrCleaner.Replace(source))
+	sb.WriteString("\">\n")
+	sb.Write(b)
+	sb.WriteString("\n</untrusted_data>")
+	return sb.String()
+}
@@ -0,0 +1,96 @@
+// internal/safety/heuristics.go
+// Aturan heuristik deteksi prompt injection (Inggris + Indonesia).
+package safety
+
+import (
+	"regexp"
+	"strings"
+)
+
+// rule adalah satu pola heuristik beserta bobotnya (0..1).
+type rule struct {
+	id     string
+	weight float64
+	re     *regexp.Regexp
+}
+
+// Bobot digabung sebagai 1 - Π(1-w) per aturan yang cocok, sehingga satu pola kuat
+// (mis. "ignore previous instructions") cukup untuk ditandai, sedangkan pola lemah
+// perlu muncul bersamaan.
+var rules = []rule{
+	{"ignore_instructions", 0.7, regexp.MustCompile(`(?i)\b(ignore|disregard|forget|override|bypass)\b[^.\n]{0,40}\b(previous|prior|above|earlier|all|any|your|the|system)\b[^.\n]{0,30}\b(instructions?|rules?|prompts?|guidelines?|directions?)\b`)},
+	{"ignore_instructions", 0.7, regexp.MustCompile(`(?i)\b(abaikan|lupakan|hiraukan|acuhkan|langgar)\b[^.\n]{0,40}\b(instruksi|perintah|aturan|arahan|prompt)`)},
+	{"role_override", 0.5, regexp.MustCompile(`(?i)\b(you are now|from now on,? you|act as|pretend to be|roleplay as)\b`)},
+	{"role_override", 0.5, regexp.MustCompile(`(?i)\b(kamu sekarang adalah|anda sekarang adalah|mulai sekarang,? (kamu|anda)|berperanlah sebagai|bertindaklah sebagai|berpura-puralah)\b`)},
+	{"role_marker", 0.5, regexp.MustCompile(`(?im)^\s*(system|assistant|developer)\s*:`)},
+	{"role_marker", 0.5, regexp.MustCompile(`(?i)<\|?(im_start|im_end|system|endoftext)\|?>|\[/?INST\]|</?(system|instructions?|untrusted_data)\b[^>]*>`)},
+	{"role_marker", 0.5, regexp.MustCompile(`(?im)^\s*#{2,}\s*(system|instructions?|instruksi)\b`)},
+	{"prompt_leak", 0.5, regexp.MustCompile(`(?i)\b(reveal|print|show|repeat|output|leak)\b[^.\n]{0,30}\b(system prompt|hidden prompt|initial prompt|your instructions)\b`)},
+	{"prompt_leak", 0.5, regexp.MustCompile(`(?i)\b(tampilkan|bocorkan|ulangi|sebutkan)\b[^.\n]{0,30}\b(system prompt|prompt sistem|instruksi (sistem|awal))\b`)},
+	{"new_instructions", 0.4, regexp.MustCompile(`(?i)\b(new|updated|real|actual) instructions?\b|\binstruksi (baru|sebenarnya)\b|\bIMPORTANT:\s*(ignore|you must)\b|\bPENTING:\s*(abaikan|kamu harus|anda harus)\b`)},
+	{"secrecy", 0.4, regexp.MustCompile(`(?i)\b(do not|don't|never) (tell|inform|mention|reveal)\b[^.\n]{0,20}\buser\b|\bjangan (beritahu|beri tahu|memberi tahu|kasih tahu|sebutkan)\b[^.\n]{0,20}\b(pengguna|user)\b`)},
+	{"exfiltration", 0.6, regexp.MustCompile(`(?i)\b(send|post|upload|forward|kirim(kan)?)\b[^.\n]{0,40}\b(to|ke)\b[^.\n]{0,20}https?://`)},
+	{"exfiltration", 0.6, regexp.MustCompile(`(?i)!\[[^\]]*\]\(https?://[^)\s]*[?&][^)\s]*=[^)\s]*\)`)},
+	{"tool_invocation", 0.3, regexp.MustCompile(`(?i)\b(call|invoke|execute|panggil|jalankan)\b[^.\n]{0,20}\b(tool|function|fungsi)\b`)},
+}
+
+// invisible: karakter tak terlihat / kontrol arah teks yang sering dipakai menyembunyikan instruksi.
+func invisible(r rune) bool {
+	switch r {
+	case '\u200b', '\u200c', '\u200d', '\u2060', '\ufeff', '\u00ad',
+		'\u202a', '\u202b', '\u202c', '\u202d', '\u202e',
+		'\u2066', '\u2067', '\u2068', '\u2069':
+		return true
+	}
+	return r >= 0xE0000 && r <= 0xE007F // tag characters ("ASCII smuggling")
+}
+
+// stripInvisible menghapus karakter tak terlihat dan mengembalikan jumlah yang dihapus.
+func stripInvisible(s string) (string, int) {
+	n := 0
+	out := strings.Map(func(r rune) rune {
+		if invisible(r) {
+			n++
+			return -1
+		}
+		return r
+	}, s)
+	return out, n
+}
+
+// span adalah rentang byte yang cocok dengan aturan (pada teks tanpa karakter tak terlihat).
+type span struct{ start, end int }
+
+// heuristic menilai teks; mengembalikan skor gabungan, aturan yang cocok, dan span cocokan.
+func heuristic(text string) (float64, []string, []span) {
+	clean, hidden := stripInvisible(text)
+	matched := map[string]float64{}
+	var order []string
+	var spans []span
+	add := func(id string, w float64) {
+		if prev, ok := matched[id]; !ok {
+			order = append(order, id)
+			matched[id] = w
+		} else if w > prev {
+			matched[id] = w
+		}
+	}
+	for _, r := range rules {
+		locs := r.re.FindAllStringIndex(clean, -1)
+		if len(locs) == 0 {
+			continue
+		}
+		add(r.id, r.weight)
+		for _, l := range locs {
+			spans = append(spans, span{l[0], l[1]})
+		}
+	}
+	if hidden >= 3 {
+		add("invisible_chars", 0.3)
+	}
+	keep := 1.0
+	for _, id := range order {
+		keep *= 1 - matched[id]
+	}
+	return 1 - keep, order, spans
+}
@@ -0,0 +1,126 @@
+// internal/safety/results.go
+// Penyaringan hasil eksekusi route (snippet RAG & output tool MCP) sebelum dikirim ke LLM.
+package safety
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	mcps "mcp-oilgas/internal/mcp"
+)
+
+// minScanChars: string lebih pendek (angka, ID, tanggal, nama tag) tidak dinilai.
+const minScanChars = 24
+
+// Finding adalah satu nilai yang ditandai.
+type Finding struct {
+	Source string `json:"source"` // mis. "rag:<query>#<doc_id>" atau "tool:get_po_status"
+	Path   string `json:"path"`   // mis. "data.retrieved_chunks[2].snippet"
+	Verdict
+}
+
+// Report merangkum penyaringan satu set hasil.
+type Report struct {
+	Mode    Mode      `json:"mode"`
+	Checked int       `json:"checked"`
+	Dropped int       `json:"dropped"`
+	Flagged []Finding `json:"flagged,omitempty"`
+}
+
+// ScreenResults menyaring semua string di Data setiap hasil. Slice input tidak diubah;
+// hasil yang dikembalikan adalah salinan yang sudah dibersihkan sesuai mode.
+// Pada mode drop, item list (mis. chunk RAG) yang berisi string bertanda dibuang seluruhnya.
+func (s *Screener) ScreenResults(ctx context.Context, results []mcps.ExecResult) ([]mcps.ExecResult, Report) {
+	if s == nil || s.cfg.Mode == ModeOff {
+		return results, Report{Mode: ModeOff}
+	}
+	rep := Report{Mode: s.cfg.Mode}
+	out := make([]mcps.ExecResult, len(results))
+	for i, r := range results {
+		out[i] = r
+		if r.Data == nil {
+			continue
+		}
+		src := "tool:" + r.Route.Tool
+		if r.Route.Kind == mcps.RouteRAG {
+			src = "rag:" + r.Route.Query
+		}
+		out[i].Data, _ = s.walk(ctx, r.Data, src, "data", &rep)
+	}
+	return out, rep
+}
+
+// walk menyalin v sambil membersihkan string; drop=true bila v (atau isinya) harus dibuang.
+func (s *Screener) walk(ctx context.Context, v any, src, path string, rep *Report) (any, bool) {
+	switch t := v.(type) {
+	case string:
+		if len(t) < minScanChars {
+			clean, _ := stripInvisible(t)
+			return clean, false
+		}
+		rep.Checked++
+		out, verdict, keep := s.Clean(ctx, src, t)
+		if verdict.Flagged {
+			rep.Flagged = append(rep.Flagged, Finding{Source: src, Path: path, Verdict: verdict})
+		}
+		if keep {
+			out, _ = stripInvisible(out)
+		}
+		return out, !keep
+	case map[string]any:
+		if id, ok := t["doc_id"].(string); ok && id != "" {
+			src = src + "#" + id
+		}
+		keys := make([]string, 0, len(t))
+		for k := range t {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		out := make(map[string]any, len(t))
+		drop := false
+		for _, k := range keys {
+			nv, d := s.walk(ctx, t[k], src, path+"."+k, rep)
+			out[k] = nv
+			drop = drop || d
+		}
+		return out, drop
+	case []map[string]any:
+		out := make([]map[string]any, 0, len(t))
+		for i, m := range t {
+			nv, d := s.walk(ctx, m, src, fmt.Sprintf("%s[%d]", path, i), rep)
+			if d {
+				rep.Dropped++
+				continue
+			}
+			out = append(out, nv.(map[string]any))
+		}
+		return out, false
+	case []any:
+		out := make([]any, 0, len(t))
+		for i, e := range t {
+			nv, d := s.walk(ctx, e, src, fmt.Sprintf("%s[%d]", path, i), rep)
+			if _, isMap := e.(map[string]any); d && isMap {
+				rep.Dropped++
+				continue
+			}
+			out = append(out, nv)
+		}
+		return out, false
+	default:
+		return v, false
+	}
+}
+
+// Merge menggabungkan laporan lain (mis. per langkah mode agen) ke r.
+func (r *Report) Merge(o Report) {
+	if r.Mode == "" {
+		r.Mode = o.Mode
+	}
+	r.Checked += o.Checked
+	r.Dropped += o.Dropped
+	r.Flagged = append(r.Flagged, o.Flagged...)
+}
+
+// Any bernilai true bila ada konten yang ditandai atau dibuang.
+func (r Report) Any() bool { return len(r.Flagged) > 0 || r.Dropped > 0 }
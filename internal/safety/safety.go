@@ -0,0 +1,243 @@
+// internal/safety/safety.go
+// Penyaringan prompt injection untuk konten tidak tepercaya (snippet dokumen RAG & output tool)
+// sebelum masuk ke prompt LLM.
+//
+// Alur: heuristik (regex EN/ID + karakter tersembunyi) → klasifier LLM opsional untuk kasus
+// abu-abu → tindakan sesuai mode → catat ke audit log (audit.Security()).
+//
+// Konfigurasi (env):
+//   - SAFETY_MODE           off | flag | neutralize (default) | drop
+//   - SAFETY_THRESHOLD      skor minimal untuk ditandai (default 0.6)
+//   - SAFETY_CLASSIFIER     off (default) | llm
+//   - SAFETY_CLASSIFIER_MIN skor heuristik minimal agar klasifier ditanya (default 0.2)
+package safety
+
+import (
+	"context"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"mcp-oilgas/internal/audit"
+)
+
+// Mode menentukan tindakan terhadap konten yang ditandai.
+type Mode string
+
+const (
+	ModeOff        Mode = "off"        // tidak menyaring (delimiting tetap berlaku)
+	ModeFlag       Mode = "flag"       // hanya mencatat, konten tidak diubah
+	ModeNeutralize Mode = "neutralize" // kalimat yang cocok diganti placeholder
+	ModeDrop       Mode = "drop"       // chunk/nilai dibuang dari prompt
+)
+
+// Placeholder pengganti teks yang dinetralkan / dibuang.
+const (
+	NeutralizedText = "[dihapus: indikasi instruksi tertanam]"
+	DroppedText     = "[konten disembunyikan: indikasi prompt injection]"
+)
+
+// Config mengatur Screener.
+type Config struct {
+	Mode          Mode
+	Threshold     float64
+	Classifier    string  // "off" | "llm"
+	ClassifierMin float64 // klasifier hanya ditanya bila skor heuristik >= nilai ini
+}
+
+// ConfigFromEnv membaca SAFETY_* (lihat komentar paket).
+func ConfigFromEnv() Config {
+	cfg := Config{Mode: ModeNeutralize, Threshold: 0.6, Classifier: "off", ClassifierMin: 0.2}
+	switch m := Mode(strings.ToLower(strings.TrimSpace(os.Getenv("SAFETY_MODE")))); m {
+	case ModeOff, ModeFlag, ModeNeutralize, ModeDrop:
+		cfg.Mode = m
+	}
+	if f, err := strconv.ParseFloat(os.Getenv("SAFETY_THRESHOLD"), 64); err == nil && f > 0 && f <= 1 {
+		cfg.Threshold = f
+	}
+	if v := strings.ToLower(strings.TrimSpace(os.Getenv("SAFETY_CLASSIFIER"))); v != "" {
+		cfg.Classifier = v
+	}
+	if f, err := strconv.ParseFloat(os.Getenv("SAFETY_CLASSIFIER_MIN"), 64); err == nil && f >= 0 && f <= 1 {
+		cfg.ClassifierMin = f
+	}
+	return cfg
+}
+
+// Classifier menilai peluang sebuah teks berisi prompt injection (0..1).
+type Classifier interface {
+	Classify(ctx context.Context, text string) (float64, error)
+}
+
+// Verdict adalah hasil penilaian satu teks.
+type Verdict struct {
+	Score      float64  `json:"score"`
+	Rules      []string `json:"rules,omitempty"`
+	Classifier *float64 `json:"classifier,omitempty"` // skor klasifier bila ditanya
+	Flagged    bool     `json:"flagged"`
+
+	spans []span
+}
+
+// Screener menilai & membersihkan teks tidak tepercaya.
+type Screener struct {
+	cfg Config
+	clf Classifier
+	aud *audit.Logger
+}
+
+// New membuat Screener. clf boleh nil (hanya heuristik); aud nil = audit.Security().
+func New(cfg Config, clf Classifier, aud *audit.Logger) *Screener {
+	if cfg.Threshold <= 0 {
+		cfg.Threshold = 0.6
+	}
+	if cfg.Mode == "" {
+		cfg.Mode = ModeNeutralize
+	}
+	return &Screener{cfg: cfg, clf: clf, aud: aud}
+}
+
+// Mode mengembalikan mode aktif.
+func (s *Screener) Mode() Mode { return s.cfg.Mode }
+
+func (s *Screener) auditLog() *audit.Logger {
+	if s.aud != nil {
+		return s.aud
+	}
+	return audit.Security()
+}
+
+// Screen menilai teks tanpa mengubahnya.
+func (s *Screener) Screen(ctx context.Context, text string) Verdict {
+	if s == nil || s.cfg.Mode == ModeOff || strings.TrimSpace(text) == "" {
+		return Verdict{}
+	}
+	score, ids, spans := heuristic(text)
+	v := Verdict{Score: score, Rules: ids, spans: spans}
+	if s.clf != nil && score >= s.cfg.ClassifierMin && score < s.cfg.Threshold {
+		if c, err := s.clf.Classify(ctx, text); err == nil {
+			v.Classifier = &c
+			if c > v.Score {
+				v.Score = c
+			}
+		}
+	}
+	v.Flagged = v.Score >= s.cfg.Threshold
+	return v
+}
+
+// Clean menilai teks dari `source` lalu menerapkan mode. keep=false berarti konten
+// sebaiknya dibuang dari prompt (mode drop). Teks yang ditandai dicatat ke audit log.
+func (s *Screener) Clean(ctx context.Context, source, text string) (out string, v Verdict, keep bool) {
+	v = s.Screen(ctx, text)
+	if !v.Flagged {
+		return text, v, true
+	}
+	out, keep = text, true
+	switch s.cfg.Mode {
+	case ModeNeutralize:
+		out = neutralize(text, v.spans)
+	case ModeDrop:
+		out, keep = DroppedText, false
+	}
+	s.record(ctx, source, text, v)
+	return out, v, keep
+}
+
+func (s *Screener) record(ctx context.Context, source, text string, v Verdict) {
+	fields := map[string]any{
+		"source":  source,
+		"score":   v.Score,
+		"rules":   v.Rules,
+		"action":  string(s.cfg.Mode),
+		"excerpt": excerpt(text, v.spans),
+	}
+	if v.Classifier != nil {
+		fields["classifier"] = *v.Classifier
+	}
+	s.auditLog().Record(ctx, "prompt_injection", fields)
+}
+
+// neutralize mengganti setiap span cocokan dengan placeholder dan membuang karakter tersembunyi.
+func neutralize(text string, spans []span) string {
+	clean, _ := stripInvisible(text)
+	if len(spans) == 0 {
+		return clean
+	}
+	// gabungkan span yang tumpang tindih (urut berdasarkan start)
+	sorted := append([]span(nil), spans...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].start < sorted[j].start })
+	var b strings.Builder
+	pos := 0
+	for _, sp := range sorted {
+		if sp.end <= pos {
+			continue
+		}
+		if sp.start >= pos {
+			b.WriteString(clean[pos:sp.start])
+			b.WriteString(NeutralizedText)
+		}
+		pos = sp.end
+	}
+	b.WriteString(clean[pos:])
+	return b.String()
+}
+
+// excerpt mengambil potongan di sekitar cocokan pertama (maks ~200 byte) untuk audit.
+func excerpt(text string, spans []span) string {
+	clean, _ := stripInvisible(text)
+	start, end := 0, len(clean)
+	if len(spans) > 0 {
+		start, end = spans[0].start-60, spans[0].end+60
+	}
+	if start < 0 {
+		start = 0
+	}
+	if end > len(clean) {
+		end = len(clean)
+	}
+	if end-start > 200 {
+		end = start + 200
+	}
+	// jaga batas UTF-8
+	for start > 0 && start < len(clean) && !utf8Start(clean[start]) {
+		start--
+	}
+	for end < len(clean) && !utf8Start(clean[end]) {
+		end++
+	}
+	return clean[start:end]
+}
+
+func utf8Start(b byte) bool { return b&0xC0 != 0x80 }
+
+// ----------------- Instance bersama -----------------
+
+var (
+	defaultMu sync.Mutex
+	defaultS  *Screener
+)
+
+// Default mengembalikan Screener bersama dari env (klasifier LLM memakai llm.Shared()).
+func Default() *Screener {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	if defaultS == nil {
+		cfg := ConfigFromEnv()
+		var clf Classifier
+		if cfg.Classifier == "llm" {
+			clf = &LLMClassifier{}
+		}
+		defaultS = New(cfg, clf, nil)
+	}
+	return defaultS
+}
+
+// SetDefault mengganti Screener bersama (wiring/test).
+func SetDefault(s *Screener) {
+	defaultMu.Lock()
+	defaultS = s
+	defaultMu.Unlock()
+}
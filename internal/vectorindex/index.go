@@ -0,0 +1,487 @@
+// internal/vectorindex/index.go
+// Indeks ANN in-process atas seluruh doc_chunks.embedding, dipakai sebagai generator kandidat
+// semantik di samping BM25 (lihat mysql.RAGRepo.SearchHybridFiltered).
+//
+// Siklus hidup: snapshot di disk (VECTOR_INDEX_PATH) dimuat bila ada, lalu Sync menyamakan
+// indeks dengan MySQL (chunk baru/hilang/diubah); bila tidak ada snapshot, indeks dibangun
+// penuh dari tabel. Start menjalankan Sync berkala sehingga chunk hasil cmd/worker atau
+// cmd/ingest-docs (proses lain) ikut masuk indeks tanpa restart.
+package vectorindex
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/gob"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"mcp-oilgas/internal/repositories/embeddings"
+	"mcp-oilgas/pkg/vector"
+)
+
+// Config dibaca dari env VECTOR_INDEX_*.
+type Config struct {
+	Enabled      bool
+	Path         string        // snapshot; kosong = tanpa persistensi
+	SyncInterval time.Duration // 0 = tanpa sync berkala
+	HNSW         vector.HNSWConfig
+	Candidates   int // jumlah kandidat ANN per query hybrid
+	// Dim: hanya vektor berdimensi ini yang diindeks (EMBED_DIM); 0 = dimensi vektor pertama.
+	Dim int
+	// Source: sumber kolom embedding (EMBED_READ); snapshot dari sumber lain diabaikan.
+	Source string
+}
+
+// ConfigFromEnv: VECTOR_INDEX (on|off), VECTOR_INDEX_PATH, VECTOR_INDEX_SYNC_INTERVAL (detik),
+// VECTOR_INDEX_M, VECTOR_INDEX_EF_CONSTRUCTION, VECTOR_INDEX_EF_SEARCH, VECTOR_INDEX_CANDIDATES.
+func ConfigFromEnv() Config {
+	cfg := Config{
+		Enabled:      true,
+		SyncInterval: 15 * time.Second,
+		HNSW:         vector.HNSWConfig{M: 16, EfConstruction: 200, EfSearch: 128},
+		Candidates:   100,
+		Dim:          embeddings.ExpectedDim(),
+		Source:       embeddings.ReadFromEnv(),
+	}
+	switch strings.ToLower(strings.TrimSpace(os.Getenv("VECTOR_INDEX"))) {
+	case "off", "false", "0", "no":
+		cfg.Enabled = false
+	}
+	cfg.Path = strings.TrimSpace(os.Getenv("VECTOR_INDEX_PATH"))
+	if n, err := strconv.Atoi(os.Getenv("VECTOR_INDEX_SYNC_INTERVAL")); err == nil && n >= 0 {
+		cfg.SyncInterval = time.Duration(n) * time.Second
+	}
+	envInt("VECTOR_INDEX_M", &cfg.HNSW.M)
+	envInt("VECTOR_INDEX_EF_CONSTRUCTION", &cfg.HNSW.EfConstruction)
+	envInt("VECTOR_INDEX_EF_SEARCH", &cfg.HNSW.EfSearch)
+	envInt("VECTOR_INDEX_CANDIDATES", &cfg.Candidates)
+	return cfg
+}
+
+func envInt(key string, dst *int) {
+	if n, err := strconv.Atoi(os.Getenv(key)); err == nil && n > 0 {
+		*dst = n
+	}
+}
+
+// watermark meringkas isi doc_chunks ber-embedding; sama → tidak ada yang perlu disinkronkan.
+type watermark struct {
+	Count     int64
+	MaxID     int64
+	UpdatedAt int64 // UNIX_TIMESTAMP(MAX(updated_at)); tidak bergantung pada parseTime di DSN
+}
+
+// Stats untuk endpoint admin.
+type Stats struct {
+	Enabled    bool      `json:"enabled"`
+	Ready      bool      `json:"ready"`
+	Vectors    int       `json:"vectors"`
+	Deleted    int       `json:"deleted"`
+	Dim        int       `json:"dim"`
+	Skipped    int       `json:"skipped"` // embedding rusak/dimensi berbeda
+	LastSync   time.Time `json:"last_sync,omitempty"`
+	LastError  string    `json:"last_error,omitempty"`
+	Snapshot   string    `json:"snapshot,omitempty"`
+	SnapshotAt time.Time `json:"snapshot_at,omitempty"`
+}
+
+// Index membungkus vector.HNSW dengan kunci dan sinkronisasi ke MySQL.
+type Index struct {
+	db  *sql.DB
+	cfg Config
+
+	mu       sync.RWMutex // melindungi graph & status di bawahnya
+	graph    *vector.HNSW
+	ready    bool
+	dirty    bool // berubah sejak snapshot terakhir
+	skipped  int
+	lastSync time.Time
+	lastErr  string
+	snapAt   time.Time
+
+	syncMu     sync.Mutex // serialisasi Sync/Load/Save
+	mark       watermark
+	hasUpdated bool // doc_chunks.updated_at tersedia (migration 0010)
+}
+
+// New membuat indeks kosong (belum siap sampai Load/Sync pertama selesai).
+func New(db *sql.DB, cfg Config) *Index {
+	return &Index{db: db, cfg: cfg, graph: vector.NewHNSW(cfg.HNSW)}
+}
+
+// Ready melaporkan apakah indeks sudah memuat korpus.
+func (x *Index) Ready() bool {
+	if x == nil {
+		return false
+	}
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	return x.ready
+}
+
+// Candidates mengembalikan jumlah kandidat ANN per query yang dikonfigurasi.
+func (x *Index) Candidates() int { return x.cfg.Candidates }
+
+// Search mengembalikan k chunk id terdekat; nil bila indeks belum siap atau dimensi query berbeda.
+func (x *Index) Search(q []float32, k int) []vector.Hit {
+	if x == nil {
+		return nil
+	}
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	if !x.ready || len(q) != x.graph.Dim() {
+		return nil
+	}
+	ef := x.cfg.HNSW.EfSearch
+	if ef < k {
+		ef = k
+	}
+	return x.graph.Search(q, k, ef)
+}
+
+// Remove menghapus chunk dari indeks.
+func (x *Index) Remove(ids ...int64) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	for _, id := range ids {
+		if x.graph.Delete(id) {
+			x.dirty = true
+		}
+	}
+}
+
+// liveWhere: chunk yang boleh ada di indeks — ber-embedding, versi dokumen aktif, tidak dihapus.
+func liveWhere() string {
+	return embeddings.ReadHasEmbedding() + ` AND deleted_at IS NULL AND superseded = 0`
+}
+
+// Stats mengembalikan ringkasan kondisi indeks.
+func (x *Index) Stats() Stats {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	return Stats{
+		Enabled: true, Ready: x.ready, Vectors: x.graph.Len(), Deleted: x.graph.Deleted(), Dim: x.graph.Dim(),
+		Skipped: x.skipped, LastSync: x.lastSync, LastError: x.lastErr, Snapshot: x.cfg.Path, SnapshotAt: x.snapAt,
+	}
+}
+
+// ---- sinkronisasi dengan MySQL ----
+
+// Start memuat snapshot (bila ada), sinkron awal, lalu (bila SyncInterval > 0) sync berkala
+// sampai ctx selesai. Dijalankan di goroutine; pencarian sebelum siap mengembalikan nil.
+func (x *Index) Start(ctx context.Context) {
+	if err := x.Load(); err != nil {
+		log.Printf("[vectorindex] snapshot %s: %v; membangun ulang dari MySQL", x.cfg.Path, err)
+	}
+	t0 := time.Now()
+	if _, err := x.Sync(ctx); err != nil {
+		log.Printf("[vectorindex] initial sync: %v", err)
+	} else {
+		st := x.Stats()
+		log.Printf("[vectorindex] ready: %d vectors (dim %d) in %s", st.Vectors, st.Dim, time.Since(t0).Round(time.Millisecond))
+	}
+	x.saveIfDirty()
+	if x.cfg.SyncInterval <= 0 {
+		return
+	}
+	tk := time.NewTicker(x.cfg.SyncInterval)
+	defer tk.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			x.saveIfDirty()
+			return
+		case <-tk.C:
+			if _, err := x.Sync(ctx); err != nil {
+				log.Printf("[vectorindex] sync: %v", err)
+			}
+			if time.Since(x.snapshotTime()) >= 5*time.Minute {
+				x.saveIfDirty()
+			}
+		}
+	}
+}
+
+// SyncResult merangkum satu Sync.
+type SyncResult struct {
+	Added   int `json:"added"`
+	Removed int `json:"removed"`
+	Skipped int `json:"skipped"`
+}
+
+// Sync menyamakan indeks dengan doc_chunks: chunk ber-embedding yang belum ada (atau berubah
+// sejak sync terakhir) disisipkan, chunk yang hilang/embedding-nya dikosongkan dihapus.
+func (x *Index) Sync(ctx context.Context) (SyncResult, error) {
+	x.syncMu.Lock()
+	defer x.syncMu.Unlock()
+	res, err := x.sync(ctx)
+	x.mu.Lock()
+	x.lastSync = time.Now()
+	x.lastErr = ""
+	if err != nil {
+		x.lastErr = err.Error()
+	}
+	x.skipped += res.Skipped
+	x.mu.Unlock()
+	return res, err
+}
+
+func (x *Index) sync(ctx context.Context) (SyncResult, error) {
+	var res SyncResult
+	if x.db == nil {
+		return res, fmt.Errorf("vectorindex: DB is nil")
+	}
+	if !x.hasUpdated {
+		var n int
+		_ = x.db.QueryRowContext(ctx, `
+			SELECT COUNT(*) FROM information_schema.columns
+			 WHERE table_schema = DATABASE() AND table_name = 'doc_chunks' AND column_name = 'updated_at'`).Scan(&n)
+		x.hasUpdated = n > 0
+	}
+
+	var (
+		cur     watermark
+		updated sql.NullInt64
+	)
+	q := `SELECT COUNT(*), COALESCE(MAX(id),0), NULL FROM doc_chunks WHERE ` + liveWhere()
+	if x.hasUpdated {
+		q = `SELECT COUNT(*), COALESCE(MAX(id),0), UNIX_TIMESTAMP(MAX(updated_at)) FROM doc_chunks WHERE ` + liveWhere()
+	}
+	if err := x.db.QueryRowContext(ctx, q).Scan(&cur.Count, &cur.MaxID, &updated); err != nil {
+		return res, fmt.Errorf("watermark: %w", err)
+	}
+	cur.UpdatedAt = updated.Int64
+	if x.Ready() && cur == x.mark {
+		return res, nil
+	}
+
+	// id yang seharusnya ada di indeks
+	live := make(map[int64]bool, cur.Count)
+	rows, err := x.db.QueryContext(ctx, `SELECT id FROM doc_chunks WHERE `+liveWhere())
+	if err != nil {
+		return res, fmt.Errorf("list ids: %w", err)
+	}
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return res, err
+		}
+		live[id] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return res, err
+	}
+
+	x.mu.RLock()
+	var stale, missing []int64
+	for _, id := range x.graph.IDs() {
+		if !live[id] {
+			stale = append(stale, id)
+		}
+	}
+	for id := range live {
+		if !x.graph.Has(id) {
+			missing = append(missing, id)
+		}
+	}
+	x.mu.RUnlock()
+
+	// chunk yang embedding-nya diperbarui di tempat (mis. cmd/ingest-docs) sejak sync terakhir
+	if x.hasUpdated && x.Ready() && x.mark.UpdatedAt > 0 {
+		rows, err := x.db.QueryContext(ctx, `SELECT id FROM doc_chunks WHERE `+liveWhere()+` AND updated_at >= FROM_UNIXTIME(?)`, x.mark.UpdatedAt)
+		if err != nil {
+			return res, fmt.Errorf("list updated: %w", err)
+		}
+		for rows.Next() {
+			var id int64
+			if err := rows.Scan(&id); err == nil {
+				missing = append(missing, id)
+			}
+		}
+		rows.Close()
+	}
+
+	x.Remove(stale...)
+	res.Removed = len(stale)
+
+	added, skipped, err := x.load(ctx, missing)
+	res.Added, res.Skipped = added, skipped
+	if err != nil {
+		return res, err
+	}
+
+	x.mu.Lock()
+	// tombstone menumpuk (re-ingest dokumen) → bangun ulang graf
+	if n := x.graph.Len(); x.graph.Deleted() > 1000 && x.graph.Deleted() > n/2 {
+		x.graph.Compact()
+	}
+	x.ready = true
+	if res.Added > 0 || res.Removed > 0 {
+		x.dirty = true
+	}
+	x.mu.Unlock()
+	x.mark = cur
+	return res, nil
+}
+
+// load membaca embedding chunk ids (batch) dan menyisipkannya.
+func (x *Index) load(ctx context.Context, ids []int64) (added, skipped int, err error) {
+	const batch = 500
+	for len(ids) > 0 {
+		n := min(batch, len(ids))
+		part := ids[:n]
+		ids = ids[n:]
+
+		args := make([]any, len(part))
+		for i, id := range part {
+			args[i] = id
+		}
+		rows, err := x.db.QueryContext(ctx, `SELECT id, `+embeddings.ReadColumns()+` FROM doc_chunks WHERE `+liveWhere()+` AND id IN (`+
+			strings.TrimSuffix(strings.Repeat("?,", len(part)), ",")+`)`, args...)
+		if err != nil {
+			return added, skipped, fmt.Errorf("load embeddings: %w", err)
+		}
+		type item struct {
+			id  int64
+			vec []float32
+		}
+		items := make([]item, 0, len(part))
+		var drop []int64 // vektor lama yang kini berdimensi lain (re-embed) keluar dari indeks
+		for rows.Next() {
+			var (
+				id       int64
+				bin, raw []byte
+			)
+			if err := rows.Scan(&id, &bin, &raw); err != nil {
+				rows.Close()
+				return added, skipped, err
+			}
+			vec, err := embeddings.Decode(bin, raw)
+			if err != nil || (x.cfg.Dim > 0 && len(vec) != x.cfg.Dim) {
+				skipped++
+				drop = append(drop, id)
+				continue
+			}
+			items = append(items, item{id, vec})
+		}
+		rows.Close()
+
+		x.mu.Lock()
+		for _, id := range drop {
+			x.graph.Delete(id)
+		}
+		for _, it := range items {
+			if err := x.graph.Add(it.id, it.vec); err != nil {
+				skipped++
+				continue
+			}
+			added++
+		}
+		x.mu.Unlock()
+	}
+	return added, skipped, nil
+}
+
+// ---- snapshot ----
+
+type snapshot struct {
+	Mark   watermark
+	Graph  *vector.HNSW
+	Source string
+}
+
+func (x *Index) snapshotTime() time.Time {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	return x.snapAt
+}
+
+// Load memuat snapshot dari Path (indeks langsung siap dipakai). File tidak ada → nil
+// (indeks dibangun oleh Sync). Sync berikutnya hanya memproses selisih terhadap watermark snapshot.
+func (x *Index) Load() error {
+	if x.cfg.Path == "" {
+		return nil
+	}
+	b, err := os.ReadFile(x.cfg.Path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var s snapshot
+	if err := gob.NewDecoder(bytes.NewReader(b)).Decode(&s); err != nil {
+		return err
+	}
+	if s.Graph == nil {
+		return fmt.Errorf("empty snapshot")
+	}
+	// model/sumber embedding berganti (migrasi) → bangun ulang dari tabel
+	if s.Source != x.cfg.Source || (x.cfg.Dim > 0 && s.Graph.Dim() > 0 && s.Graph.Dim() != x.cfg.Dim) {
+		log.Printf("[vectorindex] snapshot %s ignored: source %q dim %d, want source %q dim %d",
+			x.cfg.Path, s.Source, s.Graph.Dim(), x.cfg.Source, x.cfg.Dim)
+		return nil
+	}
+	x.syncMu.Lock()
+	defer x.syncMu.Unlock()
+	x.mu.Lock()
+	x.graph, x.ready, x.snapAt = s.Graph, true, time.Now()
+	x.mu.Unlock()
+	x.mark = s.Mark
+	log.Printf("[vectorindex] snapshot loaded: %d vectors from %s", s.Graph.Len(), x.cfg.Path)
+	return nil
+}
+
+// Save menulis snapshot secara atomik (file sementara + rename).
+func (x *Index) Save() error {
+	if x.cfg.Path == "" {
+		return nil
+	}
+	x.syncMu.Lock()
+	defer x.syncMu.Unlock()
+	x.mu.Lock()
+	var buf bytes.Buffer
+	err := gob.NewEncoder(&buf).Encode(snapshot{Mark: x.mark, Graph: x.graph, Source: x.cfg.Source})
+	if err == nil {
+		x.dirty = false
+	}
+	x.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(x.cfg.Path), 0o755); err != nil {
+		return err
+	}
+	tmp := x.cfg.Path + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0o644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, x.cfg.Path); err != nil {
+		return err
+	}
+	x.mu.Lock()
+	x.snapAt = time.Now()
+	x.mu.Unlock()
+	return nil
+}
+
+func (x *Index) saveIfDirty() {
+	x.mu.RLock()
+	dirty := x.dirty
+	x.mu.RUnlock()
+	if !dirty || x.cfg.Path == "" {
+		return
+	}
+	if err := x.Save(); err != nil {
+		log.Printf("[vectorindex] save snapshot: %v", err)
+	}
+}
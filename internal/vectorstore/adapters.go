@@ -0,0 +1,123 @@
+// internal/vectorstore/adapters.go
+package vectorstore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"mcp-oilgas/internal/llm"
+	"mcp-oilgas/internal/repositories/documents"
+	mysqlrepo "mcp-oilgas/internal/repositories/mysql"
+	"mcp-oilgas/internal/repositories/search"
+)
+
+// RAG menyesuaikan Store ke bentuk mysql.RAGRepo.SearchHybridWith (dipakai handler /rag/search_v2).
+type RAG struct {
+	Store Store
+}
+
+// SearchHybridWith mengembalikan hasil Store.Hybrid sebagai mysql.Chunk.
+func (r RAG) SearchHybridWith(ctx context.Context, query string, queryEmbedding []float32, alpha float64, topK int, f documents.Filter, opt mysqlrepo.HybridOptions) ([]mysqlrepo.Chunk, mysqlrepo.HybridStats, error) {
+	hits, st, err := r.Store.Hybrid(ctx, HybridQuery{Text: query, Embedding: queryEmbedding, Alpha: alpha, TopK: topK, Filter: f, Options: opt})
+	if err != nil {
+		return nil, st, err
+	}
+	out := make([]mysqlrepo.Chunk, 0, len(hits))
+	for _, h := range hits {
+		c := mysqlrepo.Chunk{
+			ID:      h.ID,
+			DocID:   sql.NullString{String: h.DocID, Valid: h.DocID != ""},
+			Title:   sql.NullString{String: h.Title, Valid: h.Title != ""},
+			URL:     sql.NullString{String: h.URL, Valid: h.URL != ""},
+			Snippet: sql.NullString{String: h.Snippet, Valid: true},
+			PageNo:  sql.NullInt64{Int64: int64(h.PageNo), Valid: true},
+			Score:   sql.NullFloat64{Float64: h.Score, Valid: true},
+			Doc:     h.Doc,
+		}
+		if h.CharStart != nil && h.CharEnd != nil {
+			c.CharStart = sql.NullInt64{Int64: int64(*h.CharStart), Valid: true}
+			c.CharEnd = sql.NullInt64{Int64: int64(*h.CharEnd), Valid: true}
+		}
+		if opt.Debug {
+			d := h.Scores
+			c.Debug = &d
+		}
+		out = append(out, c)
+	}
+	return out, st, nil
+}
+
+// Retriever menyesuaikan Store ke search.RAGRepo (/ask & SSE): query di-embed lalu diurutkan
+// dengan cosine atas kandidat leksikal + dense (hybrid alpha 0), seperti prefilter search.RAGRepo.
+type Retriever struct {
+	Store    Store
+	Embedder llm.Embedder
+}
+
+var _ search.RAGRepo = Retriever{}
+
+func (r Retriever) Retrieve(ctx context.Context, query string, topK int) ([]search.RAGHit, error) {
+	return r.RetrieveFiltered(ctx, query, topK, documents.Filter{})
+}
+
+func (r Retriever) RetrieveFiltered(ctx context.Context, query string, topK int, f documents.Filter) ([]search.RAGHit, error) {
+	q := strings.TrimSpace(query)
+	if q == "" {
+		return nil, errors.New("empty query")
+	}
+	if topK <= 0 || topK > 50 {
+		topK = 10
+	}
+	if r.Embedder == nil {
+		return nil, errors.New("vectorstore: embedder is nil")
+	}
+	embs, err := r.Embedder.Embed(ctx, []string{q})
+	if err != nil {
+		return nil, err
+	}
+	if len(embs) == 0 {
+		return nil, errors.New("no embedding for query")
+	}
+	hits, _, err := r.Store.Hybrid(ctx, HybridQuery{Text: q, Embedding: embs[0], Alpha: 0, TopK: topK, Filter: f})
+	if err != nil {
+		return nil, err
+	}
+	out := make([]search.RAGHit, 0, len(hits))
+	for _, h := range hits {
+		if h.Scores.Cosine == nil {
+			continue // seperti search.RAGRepo: hanya chunk yang bisa dinilai cosine
+		}
+		out = append(out, search.RAGHit{ChunkID: h.ID, DocID: h.DocID, Title: h.Title, URL: h.URL,
+			Snippet: h.Snippet, Page: h.PageNo, Score: *h.Scores.Cosine})
+	}
+	return out, nil
+}
+
+// Copy memindahkan chunk aktif dari MySQL ke dst per batch (ID dipertahankan), mulai setelah
+// afterID. Mengembalikan jumlah chunk dan ID terakhir yang disalin (untuk sync inkremental).
+func Copy(ctx context.Context, src *MySQL, dst Store, afterID int64, batch int) (int, int64, error) {
+	if batch <= 0 {
+		batch = 500
+	}
+	n := 0
+	for {
+		recs, err := src.Scan(ctx, afterID, batch)
+		if err != nil {
+			return n, afterID, fmt.Errorf("scan after %d: %w", afterID, err)
+		}
+		if len(recs) == 0 {
+			return n, afterID, nil
+		}
+		if _, err := dst.Upsert(ctx, recs); err != nil {
+			return n, afterID, fmt.Errorf("upsert after %d: %w", afterID, err)
+		}
+		n += len(recs)
+		afterID = recs[len(recs)-1].ID
+		if err := ctx.Err(); err != nil {
+			return n, afterID, err
+		}
+	}
+}
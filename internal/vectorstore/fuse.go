@@ -0,0 +1,53 @@
+// internal/vectorstore/fuse.go
+package vectorstore
+
+import (
+	"sort"
+
+	mysqlrepo "mcp-oilgas/internal/repositories/mysql"
+)
+
+// candidate adalah satu kandidat hybrid sebelum fusi; bm25/cos nil = sinyal tidak ada.
+type candidate struct {
+	rec  Record
+	bm25 *float64
+	cos  *float64
+}
+
+// normalizeHybrid menerapkan default yang sama dengan mysql.RAGRepo.SearchHybridWith.
+func normalizeHybrid(q *HybridQuery) error {
+	f, err := mysqlrepo.ParseFusion(q.Options.Fusion)
+	if err != nil {
+		return err
+	}
+	q.Options.Fusion = f
+	if q.TopK <= 0 || q.TopK > 100 {
+		q.TopK = 10
+	}
+	if q.Alpha < 0 || q.Alpha > 1 {
+		q.Alpha = 0.5
+	}
+	return nil
+}
+
+// fuse menilai kandidat (urutan masukan = tie-break) dengan mysql.Fuse lalu memotong ke topK.
+func fuse(cands []candidate, q HybridQuery) []Hit {
+	ds := make([]mysqlrepo.ScoreDebug, len(cands))
+	for i, c := range cands {
+		ds[i] = mysqlrepo.ScoreDebug{BM25Raw: c.bm25, Cosine: c.cos}
+	}
+	mysqlrepo.Fuse(ds, q.Options.Fusion, q.Alpha, q.Options.RRFK)
+	hits := make([]Hit, len(cands))
+	for i, c := range cands {
+		r := c.rec
+		r.Embedding = nil
+		hits[i] = Hit{Record: r, Score: ds[i].Fused, Scores: ds[i]}
+	}
+	sort.SliceStable(hits, func(i, j int) bool { return hits[i].Score > hits[j].Score })
+	if len(hits) > q.TopK {
+		hits = hits[:q.TopK]
+	}
+	return hits
+}
+
+func ptr(f float64) *float64 { return &f }
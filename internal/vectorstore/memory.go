@@ -0,0 +1,187 @@
+// internal/vectorstore/memory.go
+package vectorstore
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"mcp-oilgas/internal/analysis"
+	"mcp-oilgas/internal/lexindex"
+	"mcp-oilgas/pkg/vector"
+)
+
+// Memory menyimpan chunk di memori: cosine brute force atas embedding ternormalisasi dan BM25
+// lewat lexindex (analyzer Indonesia/Inggris). Cocok untuk test dan korpus kecil; isi hilang
+// saat proses berhenti (lihat Copy untuk memuat dari MySQL).
+type Memory struct {
+	mu   sync.RWMutex
+	recs map[int64]*Record
+	next int64
+	lex  *lexindex.Index
+}
+
+// NewMemory membuat store kosong; an nil = analysis.Default().
+func NewMemory(an *analysis.Analyzer) *Memory {
+	lx := lexindex.New(nil, lexindex.Config{K1: 1.2, B: 0.75}, an)
+	lx.MarkReady()
+	return &Memory{recs: map[int64]*Record{}, lex: lx}
+}
+
+// Len mengembalikan jumlah chunk.
+func (m *Memory) Len() int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return len(m.recs)
+}
+
+// IDs mengembalikan id semua chunk yang tersimpan.
+func (m *Memory) IDs() []int64 {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	ids := make([]int64, 0, len(m.recs))
+	for id := range m.recs {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+func (m *Memory) Upsert(ctx context.Context, recs []Record) ([]int64, error) {
+	ids := make([]int64, len(recs))
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := range recs {
+		r := recs[i]
+		if r.ID == 0 {
+			m.next++
+			r.ID = m.next
+		} else if r.ID > m.next {
+			m.next = r.ID
+		}
+		if len(r.Embedding) > 0 {
+			r.Embedding = append([]float32(nil), r.Embedding...)
+			vector.Normalize(r.Embedding)
+		}
+		if r.Doc != nil {
+			d := *r.Doc
+			r.Doc = &d
+		}
+		m.recs[r.ID] = &r
+		m.lex.Add(r.ID, lexicalText(&r))
+		ids[i] = r.ID
+	}
+	return ids, nil
+}
+
+func (m *Memory) Delete(ctx context.Context, ids ...int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, id := range ids {
+		delete(m.recs, id)
+	}
+	m.lex.Remove(ids...)
+	return nil
+}
+
+func (m *Memory) DeleteDoc(ctx context.Context, docID string) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var ids []int64
+	for id, r := range m.recs {
+		if r.DocID == docID {
+			ids = append(ids, id)
+			delete(m.recs, id)
+		}
+	}
+	m.lex.Remove(ids...)
+	return len(ids), nil
+}
+
+func (m *Memory) Search(ctx context.Context, q SearchQuery) ([]Hit, error) {
+	if len(q.Embedding) == 0 {
+		return nil, fmt.Errorf("vectorstore: empty query embedding")
+	}
+	if q.TopK <= 0 {
+		q.TopK = 10
+	}
+	qv := append([]float32(nil), q.Embedding...)
+	vector.Normalize(qv)
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var hits []Hit
+	for _, r := range m.recs {
+		if len(r.Embedding) != len(qv) || !matches(q.Filter, r) {
+			continue
+		}
+		h := Hit{Record: *r, Score: vector.Dot(qv, r.Embedding)}
+		h.Embedding = nil
+		h.Scores.Cosine = ptr(h.Score)
+		hits = append(hits, h)
+	}
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].ID < hits[j].ID
+	})
+	if len(hits) > q.TopK {
+		hits = hits[:q.TopK]
+	}
+	return hits, nil
+}
+
+func (m *Memory) Hybrid(ctx context.Context, q HybridQuery) ([]Hit, Stats, error) {
+	var st Stats
+	if err := normalizeHybrid(&q); err != nil {
+		return nil, st, err
+	}
+	limit := q.TopK * 5 // sama dengan kandidat BM25 mysql.RAGRepo
+
+	var dense []Hit
+	if len(q.Embedding) > 0 {
+		var err error
+		if dense, err = m.Search(ctx, SearchQuery{Embedding: q.Embedding, TopK: limit, Filter: q.Filter}); err != nil {
+			return nil, st, err
+		}
+	}
+	qv := append([]float32(nil), q.Embedding...)
+	vector.Normalize(qv)
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var cands []candidate
+	seen := map[int64]int{}
+	k := limit
+	if !q.Filter.Empty() {
+		k *= 4 // sebagian kandidat gugur oleh filter metadata
+	}
+	for _, h := range m.lex.Search(q.Text, k) {
+		r, ok := m.recs[h.ID]
+		if !ok || !matches(q.Filter, r) || len(cands) >= limit {
+			continue
+		}
+		c := candidate{rec: *r, bm25: ptr(h.Score)}
+		if len(qv) > 0 {
+			if len(r.Embedding) == len(qv) {
+				c.cos = ptr(vector.Dot(qv, r.Embedding))
+				st.DenseScored++
+			} else if len(r.Embedding) > 0 {
+				st.DimSkipped++
+			}
+		}
+		seen[h.ID] = len(cands)
+		cands = append(cands, c)
+	}
+	st.BM25Candidates = len(cands)
+	for _, h := range dense {
+		st.ANNCandidates++
+		if _, ok := seen[h.ID]; ok {
+			continue
+		}
+		cands = append(cands, candidate{rec: h.Record, cos: ptr(h.Score)})
+		st.DenseScored++
+	}
+	return fuse(cands, q), st, nil
+}
@@ -0,0 +1,126 @@
+// internal/vectorstore/memory_test.go
+
+package vectorstore_test
+
+import (
+	"context"
+	"testing"
+
+	"mcp-oilgas/internal/repositories/documents"
+	mysqlrepo "mcp-oilgas/internal/repositories/mysql"
+	"mcp-oilgas/internal/vectorstore"
+)
+
+func seed(t *testing.T) *vectorstore.Memory {
+	t.Helper()
+	m := vectorstore.NewMemory(nil)
+	sop := &documents.Document{DocID: "sop-h2s", Collection: "hse", Type: "sop"}
+	man := &documents.Document{DocID: "man-valve", Collection: "ops", Type: "manual"}
+	_, err := m.Upsert(context.Background(), []vectorstore.Record{
+		{DocID: "sop-h2s", DocVersion: 2, Title: "SOP H2S", Snippet: "Prosedur penanganan kebocoran gas H2S di sumur", Embedding: []float32{1, 0, 0}, Doc: sop},
+		{DocID: "sop-h2s", DocVersion: 2, Title: "SOP H2S", Snippet: "Evakuasi personel ke titik kumpul", Embedding: []float32{0.8, 0.6, 0}, Doc: sop},
+		{DocID: "man-valve", DocVersion: 1, Title: "Valve manual", Snippet: "Pemeriksaan katup secara berkala oleh teknisi", Embedding: []float32{0, 1, 0}, Doc: man},
+		{DocID: "man-valve", DocVersion: 1, Title: "Valve manual", Snippet: "Pelumasan katup tanpa embedding"},
+	})
+	if err != nil {
+		t.Fatalf("upsert: %v", err)
+	}
+	return m
+}
+
+func TestMemorySearchAndFilter(t *testing.T) {
+	ctx := context.Background()
+	m := seed(t)
+
+	hits, err := m.Search(ctx, vectorstore.SearchQuery{Embedding: []float32{2, 0, 0}, TopK: 2})
+	if err != nil || len(hits) != 2 || hits[0].ID != 1 || hits[1].ID != 2 {
+		t.Fatalf("search: %+v %v", hits, err)
+	}
+	if hits[0].Score < 0.999 || hits[0].Embedding != nil {
+		t.Fatalf("want normalized cosine without embedding, got %+v", hits[0])
+	}
+
+	hits, _ = m.Search(ctx, vectorstore.SearchQuery{Embedding: []float32{1, 0, 0}, TopK: 10,
+		Filter: documents.Filter{Collections: documents.StringList{"OPS"}}})
+	if len(hits) != 1 || hits[0].ID != 3 {
+		t.Fatalf("collection filter: %+v", hits)
+	}
+	hits, _ = m.Search(ctx, vectorstore.SearchQuery{Embedding: []float32{1, 0, 0}, TopK: 10,
+		Filter: documents.Filter{Versions: documents.StringList{"1"}}})
+	if len(hits) != 1 || hits[0].DocID != "man-valve" {
+		t.Fatalf("version filter: %+v", hits)
+	}
+}
+
+func TestMemoryHybrid(t *testing.T) {
+	ctx := context.Background()
+	m := seed(t)
+
+	hits, st, err := m.Hybrid(ctx, vectorstore.HybridQuery{Text: "periksa katup", Embedding: []float32{0, 1, 0}, Alpha: 0.5, TopK: 3})
+	if err != nil || len(hits) == 0 || hits[0].ID != 3 {
+		t.Fatalf("hybrid: %+v %v", hits, err)
+	}
+	if hits[0].Scores.BM25Raw == nil || hits[0].Scores.Cosine == nil || st.BM25Candidates == 0 {
+		t.Fatalf("want both signals: %+v %+v", hits[0].Scores, st)
+	}
+
+	// leksikal saja: chunk tanpa embedding tetap ditemukan
+	hits, _, err = m.Hybrid(ctx, vectorstore.HybridQuery{Text: "pelumasan", TopK: 3})
+	if err != nil || len(hits) != 1 || hits[0].ID != 4 {
+		t.Fatalf("lexical only: %+v %v", hits, err)
+	}
+
+	// adapter RAG → mysql.Chunk
+	chunks, _, err := vectorstore.RAG{Store: m}.SearchHybridWith(ctx, "kebocoran gas", []float32{1, 0, 0}, 0.5, 2,
+		documents.Filter{Types: documents.StringList{"sop"}}, mysqlrepo.HybridOptions{Debug: true})
+	if err != nil || len(chunks) == 0 || chunks[0].ID != 1 || chunks[0].DocID.String != "sop-h2s" || chunks[0].Debug == nil {
+		t.Fatalf("rag adapter: %+v %v", chunks, err)
+	}
+}
+
+func TestMemoryDelete(t *testing.T) {
+	ctx := context.Background()
+	m := seed(t)
+
+	if err := m.Delete(ctx, 1); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	hits, _, _ := m.Hybrid(ctx, vectorstore.HybridQuery{Text: "kebocoran H2S", TopK: 5})
+	for _, h := range hits {
+		if h.ID == 1 {
+			t.Fatalf("deleted chunk still returned")
+		}
+	}
+	n, err := m.DeleteDoc(ctx, "man-valve")
+	if err != nil || n != 2 || m.Len() != 1 {
+		t.Fatalf("delete doc: n=%d len=%d %v", n, m.Len(), err)
+	}
+
+	// upsert dengan ID eksplisit mengganti record lama
+	if _, err := m.Upsert(ctx, []vectorstore.Record{{ID: 2, DocID: "sop-h2s", Snippet: "Titik kumpul darurat"}}); err != nil || m.Len() != 1 {
+		t.Fatalf("replace: len=%d %v", m.Len(), err)
+	}
+	if hits, _ := m.Search(ctx, vectorstore.SearchQuery{Embedding: []float32{1, 0, 0}}); len(hits) != 0 {
+		t.Fatalf("replaced record kept old embedding: %+v", hits)
+	}
+}
+
+// Purge dipanggil handler admin saat dokumen dihapus (docID) atau versinya digantikan (ids).
+func TestPurge(t *testing.T) {
+	ctx := context.Background()
+	m := seed(t)
+
+	if err := vectorstore.Purge(ctx, m, "", []int64{2}); err != nil || m.Len() != 3 {
+		t.Fatalf("purge ids: len=%d %v", m.Len(), err)
+	}
+	if err := vectorstore.Purge(ctx, m, "man-valve", []int64{3}); err != nil || m.Len() != 1 {
+		t.Fatalf("purge doc: len=%d %v", m.Len(), err)
+	}
+	hits, _, _ := m.Hybrid(ctx, vectorstore.HybridQuery{Text: "katup pelumasan evakuasi", TopK: 5})
+	if len(hits) != 0 {
+		t.Fatalf("purged chunks still retrievable: %+v", hits)
+	}
+	if err := vectorstore.Purge(ctx, nil, "sop-h2s", nil); err != nil {
+		t.Fatalf("nil store: %v", err)
+	}
+}
@@ -0,0 +1,414 @@
+// internal/vectorstore/mysql.go
+package vectorstore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"mcp-oilgas/internal/repositories/documents"
+	"mcp-oilgas/internal/repositories/embeddings"
+	mysqlrepo "mcp-oilgas/internal/repositories/mysql"
+	"mcp-oilgas/pkg/vector"
+)
+
+// MySQL adalah Store atas doc_chunks. Hybrid diteruskan ke mysql.RAGRepo (FULLTEXT/indeks leksikal
+// Go, kandidat ANN bila RAG.ANN diisi); Search memakai indeks ANN bila siap, selain itu memindai
+// embedding chunk yang lolos filter. Delete/DeleteDoc menghapus permanen (soft delete & versi tetap
+// lewat endpoint admin dokumen).
+type MySQL struct {
+	DB      *sql.DB
+	RAG     *mysqlrepo.RAGRepo
+	Model   embeddings.Model // dicatat per chunk saat Upsert
+	Storage string           // format embedding (EMBED_STORAGE)
+}
+
+// NewMySQL: rag nil = mysql.RAGRepo tanpa ANN/indeks leksikal.
+func NewMySQL(db *sql.DB, rag *mysqlrepo.RAGRepo) *MySQL {
+	if rag == nil {
+		rag = &mysqlrepo.RAGRepo{DB: db}
+	}
+	return &MySQL{DB: db, RAG: rag, Model: embeddings.ModelFromEnv(), Storage: embeddings.StorageFromEnv()}
+}
+
+const mysqlColumns = `id, doc_id, doc_version, title, url, snippet, section, page_no, chunk_index, char_start, char_end`
+
+func (s *MySQL) Upsert(ctx context.Context, recs []Record) ([]int64, error) {
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO doc_chunks (`+mysqlColumns+`, `+embeddings.InsertColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE
+		  doc_id = VALUES(doc_id), doc_version = VALUES(doc_version), title = VALUES(title), url = VALUES(url),
+		  snippet = VALUES(snippet), section = VALUES(section), page_no = VALUES(page_no),
+		  chunk_index = VALUES(chunk_index), char_start = VALUES(char_start), char_end = VALUES(char_end),
+		  embedding = VALUES(embedding), embedding_bin = VALUES(embedding_bin),
+		  embedding_model = VALUES(embedding_model), embedding_dim = VALUES(embedding_dim),
+		  embedding_version = VALUES(embedding_version)`)
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	docs := map[string]bool{}
+	ids := make([]int64, len(recs))
+	for i, r := range recs {
+		if r.Doc != nil && !docs[r.DocID] {
+			d := *r.Doc
+			d.DocID = r.DocID
+			if err := documents.Upsert(ctx, tx, d); err != nil {
+				return nil, err
+			}
+			docs[r.DocID] = true
+		}
+		emb, err := embeddings.Encode(r.Embedding, s.Model, s.Storage)
+		if err != nil {
+			return nil, fmt.Errorf("encode embedding %d: %w", i, err)
+		}
+		var id any
+		if r.ID > 0 {
+			id = r.ID
+		}
+		version := r.DocVersion
+		if version <= 0 {
+			version = 1
+		}
+		res, err := stmt.ExecContext(ctx, id, r.DocID, version, nullString(r.Title), nullString(r.URL), r.Snippet,
+			nullString(r.Section), r.PageNo, r.ChunkIndex, intPtr(r.CharStart), intPtr(r.CharEnd),
+			emb.JSON, emb.Bin, emb.Model, emb.Dim, emb.Version)
+		if err != nil {
+			return nil, fmt.Errorf("upsert chunk %d: %w", i, err)
+		}
+		if ids[i] = r.ID; r.ID == 0 {
+			if ids[i], err = res.LastInsertId(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return ids, tx.Commit()
+}
+
+func (s *MySQL) Delete(ctx context.Context, ids ...int64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	args := make([]any, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	_, err := s.DB.ExecContext(ctx, `DELETE FROM doc_chunks WHERE id IN (`+placeholders(len(ids))+`)`, args...)
+	return err
+}
+
+func (s *MySQL) DeleteDoc(ctx context.Context, docID string) (int, error) {
+	res, err := s.DB.ExecContext(ctx, `DELETE FROM doc_chunks WHERE doc_id = ?`, docID)
+	if err != nil {
+		return 0, err
+	}
+	n, _ := res.RowsAffected()
+	return int(n), nil
+}
+
+func (s *MySQL) Search(ctx context.Context, q SearchQuery) ([]Hit, error) {
+	if len(q.Embedding) == 0 {
+		return nil, errors.New("vectorstore: empty query embedding")
+	}
+	if q.TopK <= 0 {
+		q.TopK = 10
+	}
+	qv := append([]float32(nil), q.Embedding...)
+	vector.Normalize(qv)
+
+	var scored []vector.Hit
+	if ann, ok := s.RAG.ANN.(interface{ Ready() bool }); ok && ann.Ready() {
+		k := max(q.TopK, s.RAG.ANN.Candidates())
+		if !q.Filter.Empty() {
+			k *= 4
+		}
+		scored = s.RAG.ANN.Search(qv, k)
+	} else {
+		// tanpa indeks: pindai embedding chunk yang lolos filter
+		where, args := q.Filter.ChunkWhere("doc_id")
+		rows, err := s.DB.QueryContext(ctx, `SELECT id, `+embeddings.ReadColumns()+` FROM doc_chunks WHERE `+
+			embeddings.ReadHasEmbedding()+` AND `+where, args...)
+		if err != nil {
+			return nil, err
+		}
+		for rows.Next() {
+			var (
+				id       int64
+				bin, raw []byte
+			)
+			if err := rows.Scan(&id, &bin, &raw); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			vec, err := embeddings.Decode(bin, raw)
+			if err != nil || len(vec) != len(qv) {
+				continue
+			}
+			vector.Normalize(vec)
+			scored = append(scored, vector.Hit{ID: id, Score: vector.Dot(qv, vec)})
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+		sort.Slice(scored, func(i, j int) bool { return scored[i].Score > scored[j].Score })
+		if len(scored) > q.TopK {
+			scored = scored[:q.TopK]
+		}
+	}
+
+	ids := make([]int64, len(scored))
+	for i, h := range scored {
+		ids[i] = h.ID
+	}
+	recs, err := s.load(ctx, ids, q.Filter)
+	if err != nil {
+		return nil, err
+	}
+	var hits []Hit
+	for _, h := range scored {
+		r, ok := recs[h.ID]
+		if !ok {
+			continue // gugur oleh filter (kandidat ANN)
+		}
+		hit := Hit{Record: r, Score: h.Score}
+		hit.Scores.Cosine = ptr(h.Score)
+		hits = append(hits, hit)
+		if len(hits) == q.TopK {
+			break
+		}
+	}
+	return hits, nil
+}
+
+func (s *MySQL) Hybrid(ctx context.Context, q HybridQuery) ([]Hit, Stats, error) {
+	chunks, st, err := s.RAG.SearchHybridWith(ctx, q.Text, q.Embedding, q.Alpha, q.TopK, q.Filter,
+		mysqlrepo.HybridOptions{Fusion: q.Options.Fusion, RRFK: q.Options.RRFK, Debug: true})
+	if err != nil {
+		return nil, st, err
+	}
+	hits := make([]Hit, 0, len(chunks))
+	for _, c := range chunks {
+		h := Hit{Record: recordFromChunk(c), Score: c.Score.Float64}
+		if c.Debug != nil {
+			h.Scores = *c.Debug
+		}
+		hits = append(hits, h)
+	}
+	return hits, st, nil
+}
+
+// load membaca record (tanpa embedding) untuk ids yang lolos filter, beserta metadata dokumen.
+func (s *MySQL) load(ctx context.Context, ids []int64, f documents.Filter) (map[int64]Record, error) {
+	out := make(map[int64]Record, len(ids))
+	if len(ids) == 0 {
+		return out, nil
+	}
+	args := make([]any, 0, len(ids))
+	for _, id := range ids {
+		args = append(args, id)
+	}
+	where, fargs := f.ChunkWhere("doc_id")
+	rows, err := s.DB.QueryContext(ctx, `SELECT `+mysqlColumns+` FROM doc_chunks WHERE id IN (`+
+		placeholders(len(ids))+`) AND `+where, append(args, fargs...)...)
+	if err != nil {
+		return nil, err
+	}
+	recs, err := scanMySQL(rows, false)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.attachDocs(ctx, recs); err != nil {
+		return nil, err
+	}
+	for _, r := range recs {
+		out[r.ID] = r
+	}
+	return out, nil
+}
+
+// Scan membaca chunk aktif (versi terbaru, tidak dihapus) dengan id > afterID, urut id, paling
+// banyak limit, lengkap dengan embedding & metadata dokumen. Dipakai Copy untuk memindahkan korpus.
+func (s *MySQL) Scan(ctx context.Context, afterID int64, limit int) ([]Record, error) {
+	where, args := documents.Filter{}.ChunkWhere("doc_id")
+	rows, err := s.DB.QueryContext(ctx, `SELECT `+mysqlColumns+`, `+embeddings.ReadColumns()+` FROM doc_chunks WHERE id > ? AND `+
+		where+` ORDER BY id LIMIT ?`, append(append([]any{afterID}, args...), limit)...)
+	if err != nil {
+		return nil, err
+	}
+	recs, err := scanMySQL(rows, true)
+	if err != nil {
+		return nil, err
+	}
+	return recs, s.attachDocs(ctx, recs)
+}
+
+// ScanIDs membaca chunk aktif dengan id tertentu (lengkap seperti Scan); id yang tidak aktif dilewati.
+func (s *MySQL) ScanIDs(ctx context.Context, ids []int64) ([]Record, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	args := make([]any, 0, len(ids))
+	for _, id := range ids {
+		args = append(args, id)
+	}
+	where, fargs := documents.Filter{}.ChunkWhere("doc_id")
+	rows, err := s.DB.QueryContext(ctx, `SELECT `+mysqlColumns+`, `+embeddings.ReadColumns()+` FROM doc_chunks WHERE id IN (`+
+		placeholders(len(ids))+`) AND `+where+` ORDER BY id`, append(args, fargs...)...)
+	if err != nil {
+		return nil, err
+	}
+	recs, err := scanMySQL(rows, true)
+	if err != nil {
+		return nil, err
+	}
+	return recs, s.attachDocs(ctx, recs)
+}
+
+// UpdatedSince mengembalikan id chunk aktif dengan updated_at >= since (UNIX detik) beserta
+// UNIX_TIMESTAMP(MAX(updated_at)) sebagai watermark berikutnya (0 bila kosong).
+func (s *MySQL) UpdatedSince(ctx context.Context, since int64) ([]int64, int64, error) {
+	where, args := documents.Filter{}.ChunkWhere("doc_id")
+	rows, err := s.DB.QueryContext(ctx, `SELECT id, UNIX_TIMESTAMP(updated_at) FROM doc_chunks
+		WHERE updated_at >= FROM_UNIXTIME(?) AND `+where, append([]any{since}, args...)...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+	var (
+		ids  []int64
+		mark int64
+	)
+	for rows.Next() {
+		var (
+			id int64
+			ts sql.NullFloat64 // UNIX_TIMESTAMP(DATETIME(n)) bisa pecahan
+		)
+		if err := rows.Scan(&id, &ts); err != nil {
+			return nil, 0, err
+		}
+		ids = append(ids, id)
+		mark = max(mark, int64(ts.Float64))
+	}
+	return ids, mark, rows.Err()
+}
+
+// MaxUpdated mengembalikan UNIX_TIMESTAMP(MAX(updated_at)) chunk aktif (0 bila kosong).
+func (s *MySQL) MaxUpdated(ctx context.Context) (int64, error) {
+	where, args := documents.Filter{}.ChunkWhere("doc_id")
+	var ts sql.NullFloat64
+	err := s.DB.QueryRowContext(ctx, `SELECT UNIX_TIMESTAMP(MAX(updated_at)) FROM doc_chunks WHERE `+where, args...).Scan(&ts)
+	return int64(ts.Float64), err
+}
+
+// LiveIDs mengembalikan id semua chunk aktif (dipakai cmd/vectorstore-sync -prune).
+func (s *MySQL) LiveIDs(ctx context.Context) (map[int64]bool, error) {
+	where, args := documents.Filter{}.ChunkWhere("doc_id")
+	rows, err := s.DB.QueryContext(ctx, `SELECT id FROM doc_chunks WHERE `+where, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	live := map[int64]bool{}
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		live[id] = true
+	}
+	return live, rows.Err()
+}
+
+func scanMySQL(rows *sql.Rows, withEmbedding bool) ([]Record, error) {
+	defer rows.Close()
+	var out []Record
+	for rows.Next() {
+		var (
+			r                    Record
+			title, url, section  sql.NullString
+			version, page, index sql.NullInt64
+			charStart, charEnd   sql.NullInt64
+			bin, raw             []byte
+		)
+		dst := []any{&r.ID, &r.DocID, &version, &title, &url, &r.Snippet, &section, &page, &index, &charStart, &charEnd}
+		if withEmbedding {
+			dst = append(dst, &bin, &raw)
+		}
+		if err := rows.Scan(dst...); err != nil {
+			return nil, err
+		}
+		r.DocVersion, r.PageNo, r.ChunkIndex = int(version.Int64), int(page.Int64), int(index.Int64)
+		r.Title, r.URL, r.Section = title.String, url.String, section.String
+		r.CharStart, r.CharEnd = nullIntPtr(charStart), nullIntPtr(charEnd)
+		if withEmbedding && (bin != nil || raw != nil) {
+			if vec, err := embeddings.Decode(bin, raw); err == nil {
+				r.Embedding = vec
+			}
+		}
+		out = append(out, r)
+	}
+	return out, rows.Err()
+}
+
+// attachDocs mengisi Record.Doc (tabel documents opsional; gagal = tanpa metadata).
+func (s *MySQL) attachDocs(ctx context.Context, recs []Record) error {
+	ids := make([]string, 0, len(recs))
+	for _, r := range recs {
+		ids = append(ids, r.DocID)
+	}
+	docs, err := (&documents.Repo{DB: s.DB}).GetMany(ctx, ids)
+	if err != nil {
+		return nil
+	}
+	for i := range recs {
+		if d, ok := docs[recs[i].DocID]; ok {
+			d := d
+			recs[i].Doc = &d
+		}
+	}
+	return nil
+}
+
+// recordFromChunk mengubah hasil mysql.RAGRepo ke Record.
+func recordFromChunk(c mysqlrepo.Chunk) Record {
+	r := Record{ID: c.ID, DocID: c.DocID.String, Title: c.Title.String, URL: c.URL.String,
+		Snippet: c.Snippet.String, PageNo: int(c.PageNo.Int64), Doc: c.Doc}
+	r.CharStart, r.CharEnd = nullIntPtr(c.CharStart), nullIntPtr(c.CharEnd)
+	return r
+}
+
+func placeholders(n int) string { return strings.TrimSuffix(strings.Repeat("?,", n), ",") }
+
+func nullString(s string) any {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+func intPtr(p *int) any {
+	if p == nil {
+		return nil
+	}
+	return *p
+}
+
+func nullIntPtr(n sql.NullInt64) *int {
+	if !n.Valid {
+		return nil
+	}
+	v := int(n.Int64)
+	return &v
+}
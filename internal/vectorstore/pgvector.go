@@ -0,0 +1,381 @@
+// internal/vectorstore/pgvector.go
+package vectorstore
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"mcp-oilgas/internal/analysis"
+	"mcp-oilgas/internal/repositories/documents"
+)
+
+// PGVector adalah Store atas PostgreSQL + pgvector (skema: db/postgres/vectorstore.sql). Cosine
+// memakai operator <=> (indeks HNSW vector_cosine_ops); leksikal memakai tsvector 'simple' atas
+// kolom lex berisi term analyzer (stem Indonesia/Inggris, tanpa stopword) sehingga Postgres tidak
+// butuh konfigurasi text search bahasa Indonesia. Metadata dokumen disimpan di kolom JSONB meta.
+//
+// Paket ini tidak mengimpor driver Postgres sendiri: cmd/api dan cmd/vectorstore-sync mendaftarkan
+// github.com/jackc/pgx/v5/stdlib ("pgx", default PGVECTOR_DRIVER).
+type PGVector struct {
+	DB    *sql.DB
+	Table string
+	Dim   int // dimensi kolom vector(N); 0 = tidak diperiksa
+	an    *analysis.Analyzer
+}
+
+var reIdent = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.]*$`)
+
+// NewPGVector membungkus koneksi yang sudah dibuka; an nil = analysis.Default().
+func NewPGVector(db *sql.DB, table string, dim int, an *analysis.Analyzer) (*PGVector, error) {
+	if table == "" {
+		table = "rag_chunks"
+	}
+	if !reIdent.MatchString(table) {
+		return nil, fmt.Errorf("vectorstore: invalid table name %q", table)
+	}
+	if an == nil {
+		an = analysis.Default()
+	}
+	return &PGVector{DB: db, Table: table, Dim: dim, an: an}, nil
+}
+
+// OpenPGVector membuka koneksi dari cfg (PGVECTOR_DRIVER/PGVECTOR_DSN) dan memeriksanya.
+func OpenPGVector(ctx context.Context, cfg Config, an *analysis.Analyzer) (*PGVector, error) {
+	db, err := sql.Open(cfg.PGDriver, cfg.PGDSN)
+	if err != nil {
+		return nil, fmt.Errorf("vectorstore: open %s: %w (driver must be linked into the binary)", cfg.PGDriver, err)
+	}
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("vectorstore: ping postgres: %w", err)
+	}
+	s, err := NewPGVector(db, cfg.PGTable, cfg.PGDim, an)
+	if err != nil {
+		db.Close()
+	}
+	return s, err
+}
+
+const pgColumns = `id, doc_id, doc_version, title, url, snippet, section, page_no, chunk_index, char_start, char_end, meta`
+
+func (s *PGVector) Upsert(ctx context.Context, recs []Record) ([]int64, error) {
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	cols := `doc_id, doc_version, title, url, snippet, section, page_no, chunk_index, char_start, char_end, meta, lex, embedding`
+	vals := `$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13::vector`
+	update := ` ON CONFLICT (id) DO UPDATE SET doc_id = EXCLUDED.doc_id, doc_version = EXCLUDED.doc_version,
+		title = EXCLUDED.title, url = EXCLUDED.url, snippet = EXCLUDED.snippet, section = EXCLUDED.section,
+		page_no = EXCLUDED.page_no, chunk_index = EXCLUDED.chunk_index, char_start = EXCLUDED.char_start,
+		char_end = EXCLUDED.char_end, meta = EXCLUDED.meta, lex = EXCLUDED.lex, embedding = EXCLUDED.embedding`
+
+	ids := make([]int64, len(recs))
+	explicit := false
+	for i, r := range recs {
+		if s.Dim > 0 && len(r.Embedding) > 0 && len(r.Embedding) != s.Dim {
+			return nil, fmt.Errorf("chunk %d: embedding dimension %d != %d", i, len(r.Embedding), s.Dim)
+		}
+		var meta any
+		if r.Doc != nil {
+			b, err := json.Marshal(r.Doc)
+			if err != nil {
+				return nil, err
+			}
+			meta = string(b)
+		}
+		version := r.DocVersion
+		if version <= 0 {
+			version = 1
+		}
+		args := []any{r.DocID, version, r.Title, r.URL, r.Snippet, r.Section, r.PageNo, r.ChunkIndex,
+			intPtr(r.CharStart), intPtr(r.CharEnd), meta, strings.Join(s.an.Terms(lexicalText(&r)), " "), vectorLiteral(r.Embedding)}
+		q := `INSERT INTO ` + s.Table + ` (` + cols + `) VALUES (` + vals + `) RETURNING id`
+		if r.ID > 0 {
+			q = `INSERT INTO ` + s.Table + ` (id, ` + cols + `) VALUES ($14, ` + vals + `)` + update + ` RETURNING id`
+			args = append(args, r.ID)
+			explicit = true
+		}
+		if err := tx.QueryRowContext(ctx, q, args...).Scan(&ids[i]); err != nil {
+			return nil, fmt.Errorf("upsert chunk %d: %w", i, err)
+		}
+	}
+	if explicit {
+		// id dari sumber lain (mis. doc_chunks MySQL) → sequence jangan sampai bentrok
+		if _, err := tx.ExecContext(ctx, `SELECT setval(pg_get_serial_sequence('`+s.Table+`', 'id'),
+			GREATEST((SELECT COALESCE(MAX(id), 0) FROM `+s.Table+`), 1))`); err != nil {
+			return nil, err
+		}
+	}
+	return ids, tx.Commit()
+}
+
+func (s *PGVector) Delete(ctx context.Context, ids ...int64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	args := make([]any, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	_, err := s.DB.ExecContext(ctx, `DELETE FROM `+s.Table+` WHERE id IN (`+pgPlaceholders(1, len(ids))+`)`, args...)
+	return err
+}
+
+func (s *PGVector) DeleteDoc(ctx context.Context, docID string) (int, error) {
+	res, err := s.DB.ExecContext(ctx, `DELETE FROM `+s.Table+` WHERE doc_id = $1`, docID)
+	if err != nil {
+		return 0, err
+	}
+	n, _ := res.RowsAffected()
+	return int(n), nil
+}
+
+func (s *PGVector) Search(ctx context.Context, q SearchQuery) ([]Hit, error) {
+	if len(q.Embedding) == 0 {
+		return nil, errors.New("vectorstore: empty query embedding")
+	}
+	if s.Dim > 0 && len(q.Embedding) != s.Dim {
+		return nil, fmt.Errorf("query embedding dimension %d != %d", len(q.Embedding), s.Dim)
+	}
+	if q.TopK <= 0 {
+		q.TopK = 10
+	}
+	where, args := pgWhere(q.Filter, 2)
+	rows, err := s.DB.QueryContext(ctx, `SELECT `+pgColumns+`, NULL::float8, 1 - (embedding <=> $1::vector) FROM `+s.Table+
+		` WHERE embedding IS NOT NULL`+where+` ORDER BY embedding <=> $1::vector LIMIT `+strconv.Itoa(q.TopK),
+		append([]any{vectorLiteral(q.Embedding)}, args...)...)
+	if err != nil {
+		return nil, err
+	}
+	cands, err := scanPG(rows)
+	if err != nil {
+		return nil, err
+	}
+	hits := make([]Hit, 0, len(cands))
+	for _, c := range cands {
+		h := Hit{Record: c.rec, Score: *c.cos}
+		h.Scores.Cosine = c.cos
+		hits = append(hits, h)
+	}
+	return hits, nil
+}
+
+func (s *PGVector) Hybrid(ctx context.Context, q HybridQuery) ([]Hit, Stats, error) {
+	var st Stats
+	if err := normalizeHybrid(&q); err != nil {
+		return nil, st, err
+	}
+	if s.Dim > 0 && len(q.Embedding) > 0 && len(q.Embedding) != s.Dim {
+		return nil, st, fmt.Errorf("query embedding dimension %d != %d", len(q.Embedding), s.Dim)
+	}
+	limit := q.TopK * 5
+	vec := vectorLiteral(q.Embedding) // NULL bila tanpa embedding → cosine NULL
+
+	var cands []candidate
+	seen := map[int64]bool{}
+	if tsq := s.tsQuery(q.Text); tsq != "" {
+		where, args := pgWhere(q.Filter, 3)
+		rows, err := s.DB.QueryContext(ctx, `SELECT `+pgColumns+`, ts_rank_cd(tsv, to_tsquery('simple', $1)),
+			1 - (embedding <=> $2::vector) FROM `+s.Table+` WHERE tsv @@ to_tsquery('simple', $1)`+where+`
+			ORDER BY 13 DESC LIMIT `+strconv.Itoa(limit), append([]any{tsq, vec}, args...)...)
+		if err != nil {
+			return nil, st, err
+		}
+		lex, err := scanPG(rows)
+		if err != nil {
+			return nil, st, err
+		}
+		for _, c := range lex {
+			seen[c.rec.ID] = true
+			if c.cos != nil {
+				st.DenseScored++
+			}
+		}
+		cands = append(cands, lex...)
+		st.BM25Candidates = len(lex)
+	}
+	if len(q.Embedding) > 0 {
+		dense, err := s.Search(ctx, SearchQuery{Embedding: q.Embedding, TopK: limit, Filter: q.Filter})
+		if err != nil {
+			return nil, st, err
+		}
+		for _, h := range dense {
+			st.ANNCandidates++
+			if seen[h.ID] {
+				continue
+			}
+			cands = append(cands, candidate{rec: h.Record, cos: h.Scores.Cosine})
+			st.DenseScored++
+		}
+	}
+	return fuse(cands, q), st, nil
+}
+
+// IDs mengembalikan semua id di tabel (dipakai cmd/vectorstore-sync -prune).
+func (s *PGVector) IDs(ctx context.Context) ([]int64, error) {
+	rows, err := s.DB.QueryContext(ctx, `SELECT id FROM `+s.Table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		out = append(out, id)
+	}
+	return out, rows.Err()
+}
+
+// MaxID mengembalikan id terbesar (0 bila kosong); titik mulai sync inkremental.
+func (s *PGVector) MaxID(ctx context.Context) (int64, error) {
+	var id int64
+	err := s.DB.QueryRowContext(ctx, `SELECT COALESCE(MAX(id), 0) FROM `+s.Table).Scan(&id)
+	return id, err
+}
+
+// tsQuery menyusun tsquery OR atas term analyzer query (term hanya huruf/angka, aman disisipkan).
+// Bobot sinonim tidak terbawa: ts_rank_cd menilai semua term sama.
+func (s *PGVector) tsQuery(text string) string {
+	terms := s.an.QueryTerms(text)
+	parts := make([]string, 0, len(terms))
+	for _, t := range terms {
+		parts = append(parts, t.Text)
+	}
+	return strings.Join(parts, " | ")
+}
+
+// scanPG membaca pgColumns + skor leksikal + cosine (keduanya boleh NULL).
+func scanPG(rows *sql.Rows) ([]candidate, error) {
+	defer rows.Close()
+	var out []candidate
+	for rows.Next() {
+		var (
+			r                    Record
+			title, url, section  sql.NullString
+			version, page, index sql.NullInt64
+			charStart, charEnd   sql.NullInt64
+			meta                 []byte
+			lex, cos             sql.NullFloat64
+		)
+		if err := rows.Scan(&r.ID, &r.DocID, &version, &title, &url, &r.Snippet, &section, &page, &index,
+			&charStart, &charEnd, &meta, &lex, &cos); err != nil {
+			return nil, err
+		}
+		r.DocVersion, r.PageNo, r.ChunkIndex = int(version.Int64), int(page.Int64), int(index.Int64)
+		r.Title, r.URL, r.Section = title.String, url.String, section.String
+		r.CharStart, r.CharEnd = nullIntPtr(charStart), nullIntPtr(charEnd)
+		if len(meta) > 0 {
+			var d documents.Document
+			if err := json.Unmarshal(meta, &d); err == nil {
+				r.Doc = &d
+			}
+		}
+		c := candidate{rec: r}
+		if lex.Valid {
+			c.bm25 = ptr(lex.Float64)
+		}
+		if cos.Valid {
+			c.cos = ptr(cos.Float64)
+		}
+		out = append(out, c)
+	}
+	return out, rows.Err()
+}
+
+// pgWhere menerjemahkan documents.Filter ke predikat Postgres atas doc_id/doc_version dan meta
+// (diawali " AND "; placeholder mulai dari $start). Perbandingan string tidak peka huruf besar
+// seperti collation MySQL; tag dicocokkan persis.
+func pgWhere(f documents.Filter, start int) (string, []any) {
+	var (
+		parts []string
+		args  []any
+	)
+	next := func(v any) string {
+		args = append(args, v)
+		return "$" + strconv.Itoa(start+len(args)-1)
+	}
+	in := func(expr string, vals []string, lower bool) {
+		if len(vals) == 0 {
+			return
+		}
+		ph := make([]string, len(vals))
+		for i, v := range vals {
+			if lower {
+				v = strings.ToLower(v)
+			}
+			ph[i] = next(v)
+		}
+		parts = append(parts, expr+" IN ("+strings.Join(ph, ", ")+")")
+	}
+	in("doc_id", f.DocIDs, false)
+	in("lower(meta->>'collection')", f.Collections, true)
+	in("lower(meta->>'type')", f.Types, true)
+	in("lower(meta->>'asset')", f.Assets, true)
+	in("lower(meta->>'well')", f.Wells, true)
+	in("lower(meta->>'area')", f.Areas, true)
+	in("lower(meta->>'revision')", f.Revisions, true)
+	in("lower(meta->>'lang')", f.Langs, true)
+	if len(f.Versions) > 0 {
+		ph := make([]string, 0, len(f.Versions))
+		for _, v := range f.Versions {
+			if n, err := strconv.Atoi(strings.TrimSpace(v)); err == nil {
+				ph = append(ph, next(n))
+			}
+		}
+		if len(ph) == 0 {
+			parts = append(parts, "FALSE")
+		} else {
+			parts = append(parts, "doc_version IN ("+strings.Join(ph, ", ")+")")
+		}
+	}
+	for _, t := range f.Tags {
+		parts = append(parts, "meta->'tags' @> jsonb_build_array("+next(t)+"::text)")
+	}
+	if f.EffectiveFrom != "" {
+		parts = append(parts, "meta->>'effective_date' >= "+next(f.EffectiveFrom))
+	}
+	if f.EffectiveTo != "" {
+		parts = append(parts, "meta->>'effective_date' <= "+next(f.EffectiveTo))
+	}
+	if len(parts) == 0 {
+		return "", nil
+	}
+	return " AND " + strings.Join(parts, " AND "), args
+}
+
+func pgPlaceholders(start, n int) string {
+	ph := make([]string, n)
+	for i := range ph {
+		ph[i] = "$" + strconv.Itoa(start+i)
+	}
+	return strings.Join(ph, ",")
+}
+
+// vectorLiteral: format teks pgvector "[x,y,...]"; nil (NULL) bila vektor kosong.
+func vectorLiteral(v []float32) any {
+	if len(v) == 0 {
+		return nil
+	}
+	var sb strings.Builder
+	sb.WriteByte('[')
+	for i, x := range v {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteString(strconv.FormatFloat(float64(x), 'g', -1, 32))
+	}
+	sb.WriteByte(']')
+	return sb.String()
+}
@@ -0,0 +1,179 @@
+// internal/vectorstore/store.go
+// Antarmuka penyimpanan vektor untuk retrieval RAG, terlepas dari MySQL:
+//
+//	mysql:    doc_chunks (FULLTEXT + embedding JSON/BLOB) lewat mysql.RAGRepo — perilaku default
+//	memory:   indeks in-process (cosine brute force + BM25 internal/lexindex), untuk test & korpus kecil
+//	pgvector: PostgreSQL + ekstensi pgvector; leksikal memakai tsvector atas term analyzer Indonesia/Inggris
+//
+// Semua backend memakai fusi yang sama dengan mysql.RAGRepo (mysql.Fuse), sehingga skor hybrid
+// dapat dibandingkan antar backend. Handler memakai Store lewat adapter RAG (bentuk mysql.Chunk,
+// untuk /rag/search_v2) dan Retriever (bentuk search.RAGHit, untuk /ask & SSE).
+package vectorstore
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"mcp-oilgas/internal/repositories/documents"
+	mysqlrepo "mcp-oilgas/internal/repositories/mysql"
+)
+
+// Record adalah satu chunk beserta embedding dan metadata dokumennya.
+type Record struct {
+	ID         int64               `json:"id"` // 0 → diberikan store saat Upsert
+	DocID      string              `json:"doc_id"`
+	DocVersion int                 `json:"doc_version,omitempty"`
+	Title      string              `json:"title,omitempty"`
+	URL        string              `json:"url,omitempty"`
+	Snippet    string              `json:"snippet"`
+	Section    string              `json:"section,omitempty"`
+	PageNo     int                 `json:"page_no,omitempty"`
+	ChunkIndex int                 `json:"chunk_index,omitempty"`
+	CharStart  *int                `json:"char_start,omitempty"` // offset rune di teks dokumen (nil = tidak diketahui)
+	CharEnd    *int                `json:"char_end,omitempty"`
+	Embedding  []float32           `json:"embedding,omitempty"` // nil = tanpa sinyal cosine
+	Doc        *documents.Document `json:"doc,omitempty"`       // metadata untuk filter; nil = hanya doc_id/versi
+}
+
+// Hit adalah hasil pencarian (Record tanpa Embedding).
+type Hit struct {
+	Record
+	Score  float64              `json:"score"` // cosine (Search) atau skor fusi (Hybrid)
+	Scores mysqlrepo.ScoreDebug `json:"scores"`
+}
+
+// SearchQuery: pencarian dense (cosine) dengan filter metadata.
+type SearchQuery struct {
+	Embedding []float32
+	TopK      int
+	Filter    documents.Filter
+}
+
+// HybridQuery: leksikal + cosine, difusikan seperti mysql.RAGRepo.SearchHybridWith.
+type HybridQuery struct {
+	Text      string
+	Embedding []float32 // opsional; kosong = leksikal saja
+	Alpha     float64   // bobot leksikal (linear & weighted_rrf)
+	TopK      int
+	Filter    documents.Filter
+	Options   mysqlrepo.HybridOptions
+}
+
+// Stats = statistik sinyal hybrid (sama dengan mysql.HybridStats).
+type Stats = mysqlrepo.HybridStats
+
+// Store adalah backend penyimpanan & pencarian chunk.
+type Store interface {
+	// Upsert menyisipkan atau mengganti record (berdasarkan ID); mengembalikan ID per record.
+	Upsert(ctx context.Context, recs []Record) ([]int64, error)
+	// Delete menghapus chunk; ID yang tidak ada diabaikan.
+	Delete(ctx context.Context, ids ...int64) error
+	// DeleteDoc menghapus semua chunk satu dokumen; mengembalikan jumlah chunk yang dihapus.
+	DeleteDoc(ctx context.Context, docID string) (int, error)
+	// Search mengembalikan TopK chunk dengan cosine tertinggi yang lolos filter.
+	Search(ctx context.Context, q SearchQuery) ([]Hit, error)
+	// Hybrid menggabungkan kandidat leksikal & dense yang lolos filter.
+	Hybrid(ctx context.Context, q HybridQuery) ([]Hit, Stats, error)
+}
+
+// Nama backend.
+const (
+	BackendMySQL    = "mysql"
+	BackendMemory   = "memory"
+	BackendPGVector = "pgvector"
+)
+
+// Config dibaca dari env VECTOR_STORE & PGVECTOR_*.
+type Config struct {
+	Backend  string
+	PGDriver string // nama driver database/sql yang terdaftar di binary (mis. pgx, postgres)
+	PGDSN    string
+	PGTable  string
+	PGDim    int // dimensi kolom vector(N); 0 = tidak diperiksa
+	// SyncInterval: jeda sinkron backend memory dengan doc_chunks (0 = hanya dimuat saat start)
+	SyncInterval time.Duration
+}
+
+// ConfigFromEnv: VECTOR_STORE (mysql|memory|pgvector, default mysql), VECTOR_STORE_SYNC_INTERVAL
+// (detik, default 15), PGVECTOR_DSN, PGVECTOR_DRIVER (default pgx), PGVECTOR_TABLE (default
+// rag_chunks), EMBED_DIM sebagai dimensi kolom.
+func ConfigFromEnv() Config {
+	cfg := Config{Backend: BackendMySQL, PGDriver: "pgx", PGTable: "rag_chunks", SyncInterval: 15 * time.Second}
+	if b := strings.ToLower(strings.TrimSpace(os.Getenv("VECTOR_STORE"))); b != "" {
+		cfg.Backend = b
+	}
+	cfg.PGDSN = strings.TrimSpace(os.Getenv("PGVECTOR_DSN"))
+	if v := strings.TrimSpace(os.Getenv("PGVECTOR_DRIVER")); v != "" {
+		cfg.PGDriver = v
+	}
+	if v := strings.TrimSpace(os.Getenv("PGVECTOR_TABLE")); v != "" {
+		cfg.PGTable = v
+	}
+	if n, err := strconv.Atoi(os.Getenv("EMBED_DIM")); err == nil && n > 0 {
+		cfg.PGDim = n
+	}
+	if n, err := strconv.Atoi(os.Getenv("VECTOR_STORE_SYNC_INTERVAL")); err == nil && n >= 0 {
+		cfg.SyncInterval = time.Duration(n) * time.Second
+	}
+	return cfg
+}
+
+// Validate memeriksa nama backend dan parameter wajibnya.
+func (c Config) Validate() error {
+	switch c.Backend {
+	case BackendMySQL, BackendMemory:
+		return nil
+	case BackendPGVector:
+		if c.PGDSN == "" {
+			return fmt.Errorf("vectorstore: PGVECTOR_DSN is required for VECTOR_STORE=pgvector")
+		}
+		return nil
+	}
+	return fmt.Errorf("vectorstore: unknown backend %q (mysql|memory|pgvector)", c.Backend)
+}
+
+// Purge mengeluarkan chunk yang tidak lagi boleh di-retrieve dari s tanpa menunggu sinkronisasi:
+// docID != "" menghapus seluruh chunk dokumen (soft/hard delete), selain itu hanya chunk ids
+// (versi yang digantikan). s nil = no-op.
+func Purge(ctx context.Context, s Store, docID string, ids []int64) error {
+	if s == nil {
+		return nil
+	}
+	if docID != "" {
+		_, err := s.DeleteDoc(ctx, docID)
+		return err
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	return s.Delete(ctx, ids...)
+}
+
+// matches mengevaluasi filter terhadap record di memori. Store non-MySQL hanya menyimpan versi
+// yang di-upsert, jadi tanpa Versions semua record dianggap versi aktif.
+func matches(f documents.Filter, r *Record) bool {
+	d := documents.Document{DocID: r.DocID}
+	if r.Doc != nil {
+		d = *r.Doc
+		d.DocID = r.DocID
+	}
+	if !f.Matches(d) {
+		return false
+	}
+	if len(f.Versions) == 0 {
+		return true
+	}
+	for _, v := range f.Versions {
+		if strings.TrimSpace(v) == strconv.Itoa(r.DocVersion) {
+			return true
+		}
+	}
+	return false
+}
+
+// lexicalText: teks yang diindeks leksikal (sama dengan MATCH(title, snippet)).
+func lexicalText(r *Record) string { return r.Title + "\n" + r.Snippet }
@@ -0,0 +1,125 @@
+// internal/vectorstore/sync.go
+package vectorstore
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// MemorySync menjaga Memory tetap sama dengan chunk aktif doc_chunks, seperti vectorindex: chunk
+// baru atau yang diperbarui (updated_at, mis. re-embed) disalin, chunk yang dihapus atau versinya
+// digantikan (endpoint admin dokumen) dibuang.
+type MemorySync struct {
+	Src      *MySQL
+	Dst      *Memory
+	Interval time.Duration // 0 = hanya sync awal
+	Batch    int           // chunk per query (default 500)
+
+	mu   sync.Mutex
+	mark int64 // UNIX_TIMESTAMP(MAX(updated_at)) pada sync terakhir
+}
+
+// SyncResult merangkum satu Sync.
+type SyncResult struct {
+	Added   int `json:"added"`
+	Removed int `json:"removed"`
+}
+
+// Start menjalankan sync awal lalu sync berkala sampai ctx selesai (jalankan di goroutine).
+func (s *MemorySync) Start(ctx context.Context) {
+	t0 := time.Now()
+	if res, err := s.Sync(ctx); err != nil {
+		log.Printf("[vectorstore] memory: initial sync: %v", err)
+	} else {
+		log.Printf("[vectorstore] memory: %d chunks loaded in %s", res.Added, time.Since(t0).Round(time.Millisecond))
+	}
+	if s.Interval <= 0 {
+		return
+	}
+	tk := time.NewTicker(s.Interval)
+	defer tk.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-tk.C:
+			if _, err := s.Sync(ctx); err != nil {
+				log.Printf("[vectorstore] memory: sync: %v", err)
+			}
+		}
+	}
+}
+
+// Sync menyamakan isi Memory dengan chunk aktif di MySQL.
+func (s *MemorySync) Sync(ctx context.Context) (SyncResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var res SyncResult
+	batch := s.Batch
+	if batch <= 0 {
+		batch = 500
+	}
+
+	// watermark dibaca sebelum daftar chunk: update yang terjadi selama sync terbawa ke sync berikutnya
+	var (
+		updated []int64
+		mark    int64
+		err     error
+	)
+	if s.mark > 0 {
+		updated, mark, err = s.Src.UpdatedSince(ctx, s.mark)
+	} else {
+		mark, err = s.Src.MaxUpdated(ctx)
+	}
+	if err != nil {
+		return res, fmt.Errorf("updated chunks: %w", err)
+	}
+	mark = max(mark, s.mark)
+
+	live, err := s.Src.LiveIDs(ctx)
+	if err != nil {
+		return res, fmt.Errorf("list live chunks: %w", err)
+	}
+	have := map[int64]bool{}
+	var stale []int64
+	for _, id := range s.Dst.IDs() {
+		have[id] = true
+		if !live[id] {
+			stale = append(stale, id)
+		}
+	}
+	var missing []int64
+	for id := range live {
+		if !have[id] {
+			missing = append(missing, id)
+		}
+	}
+	// chunk yang diperbarui di tempat sejak sync terakhir (mis. re-embed)
+	for _, id := range updated {
+		if have[id] && live[id] {
+			missing = append(missing, id)
+		}
+	}
+
+	if err := s.Dst.Delete(ctx, stale...); err != nil {
+		return res, err
+	}
+	res.Removed = len(stale)
+	for len(missing) > 0 {
+		n := min(batch, len(missing))
+		recs, err := s.Src.ScanIDs(ctx, missing[:n])
+		if err != nil {
+			return res, fmt.Errorf("load chunks: %w", err)
+		}
+		if _, err := s.Dst.Upsert(ctx, recs); err != nil {
+			return res, err
+		}
+		res.Added += len(recs)
+		missing = missing[n:]
+	}
+	s.mark = mark
+	return res, nil
+}
@@ -0,0 +1,446 @@
+// internal/verify/verify.go
+// Verifier pasca-generasi: cek angka, tanggal, nomor PO/WO & ID sumur pada jawaban LLM
+// terhadap hasil tool (sources) dan potongan dokumen yang diambil.
+package verify
+
+import (
+	"encoding/json"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Jenis klaim yang diekstrak dari jawaban.
+const (
+	KindNumber = "number"
+	KindDate   = "date"
+	KindPO     = "po_number"
+	KindWO     = "work_order"
+	KindWell   = "well_id"
+)
+
+// Claim adalah satu fakta terukur di jawaban.
+type Claim struct {
+	Kind       string  `json:"kind"`
+	Text       string  `json:"text"`                 // potongan asli di jawaban
+	Normalized string  `json:"normalized"`           // bentuk kanonik (ID upper, tanggal YYYY-MM-DD, angka)
+	Value      float64 `json:"value,omitempty"`      // untuk KindNumber
+	Start      int     `json:"start"`                // offset byte di jawaban
+	End        int     `json:"end"`                  //
+	Supported  bool    `json:"supported"`            //
+	MatchedBy  string  `json:"matched_by,omitempty"` // exact|tolerance|question
+
+	alt []float64 // tafsiran lain untuk angka ambigu ("555.409" → 555409 atau 555.409)
+}
+
+// Report adalah hasil verifikasi satu jawaban.
+type Report struct {
+	Checked     int     `json:"checked"`
+	Supported   int     `json:"supported"`
+	Score       float64 `json:"score"`  // supported/checked (1 bila tidak ada klaim)
+	Passed      bool    `json:"passed"` // true bila tidak ada klaim tak berdasar
+	Claims      []Claim `json:"claims"`
+	Unsupported []Claim `json:"unsupported"`
+}
+
+// Options mengatur toleransi verifikasi.
+type Options struct {
+	// RelTolerance: selisih relatif yang masih dianggap cocok (default 0.005 = 0.5%),
+	// menampung pembulatan mis. "555.4 ribu" vs 555409.
+	RelTolerance float64
+	// IgnoreSmallInts: bilangan bulat <= nilai ini diabaikan (mis. "top 3"); default 10.
+	IgnoreSmallInts int
+}
+
+func (o Options) withDefaults() Options {
+	if o.RelTolerance <= 0 {
+		o.RelTolerance = 0.005
+	}
+	if o.IgnoreSmallInts == 0 {
+		o.IgnoreSmallInts = 10
+	}
+	return o
+}
+
+// ---------------- Evidence ----------------
+
+// Evidence adalah kumpulan fakta dari sources yang boleh dikutip jawaban.
+type Evidence struct {
+	numbers  []float64
+	ids      map[string]struct{}
+	dates    map[string]struct{}
+	question string
+	qNumbers []float64
+}
+
+// NewEvidence membangun evidence dari pertanyaan dan sembarang sources (ExecResult, chunk, map, dst).
+// Sources di-marshal ke JSON lalu seluruh leaf (angka & string) diindeks; panjang array ikut
+// dicatat agar klaim hitungan ("ada 5 PO") bisa diverifikasi.
+func NewEvidence(question string, sources ...any) *Evidence {
+	ev := &Evidence{
+		ids:      map[string]struct{}{},
+		dates:    map[string]struct{}{},
+		question: question,
+	}
+	for _, c := range extractClaims(question) {
+		switch c.Kind {
+		case KindNumber:
+			ev.qNumbers = append(ev.qNumbers, c.Value)
+		case KindDate:
+			ev.dates[c.Normalized] = struct{}{}
+		default:
+			ev.ids[c.Normalized] = struct{}{}
+		}
+	}
+	for _, s := range sources {
+		b, err := json.Marshal(s)
+		if err != nil {
+			continue
+		}
+		var v any
+		if err := json.Unmarshal(b, &v); err != nil {
+			continue
+		}
+		ev.walk(v)
+	}
+	sort.Float64s(ev.numbers)
+	return ev
+}
+
+// AddText menambahkan teks bebas (mis. snippet dokumen) sebagai evidence.
+func (ev *Evidence) AddText(s string) {
+	ev.addString(s)
+	sort.Float64s(ev.numbers)
+}
+
+func (ev *Evidence) walk(v any) {
+	switch t := v.(type) {
+	case map[string]any:
+		for _, x := range t {
+			ev.walk(x)
+		}
+	case []any:
+		ev.numbers = append(ev.numbers, float64(len(t)))
+		for _, x := range t {
+			ev.walk(x)
+		}
+	case float64:
+		ev.numbers = append(ev.numbers, t)
+	case string:
+		ev.addString(t)
+	}
+}
+
+func (ev *Evidence) addString(s string) {
+	if strings.TrimSpace(s) == "" {
+		return
+	}
+	// timestamp RFC3339 / "YYYY-MM-DD hh:mm:ss" → tanggal
+	for _, m := range reISODate.FindAllStringSubmatch(s, -1) {
+		ev.dates[m[1]+"-"+m[2]+"-"+m[3]] = struct{}{}
+	}
+	for _, c := range extractClaims(s) {
+		switch c.Kind {
+		case KindNumber:
+			ev.numbers = append(ev.numbers, c.Value)
+		case KindDate:
+			ev.dates[c.Normalized] = struct{}{}
+		default:
+			ev.ids[c.Normalized] = struct{}{}
+		}
+	}
+}
+
+// ---------------- Verify ----------------
+
+// Verify mengekstrak klaim dari answer lalu mencocokkannya dengan evidence.
+func Verify(answer string, ev *Evidence, opts Options) Report {
+	opts = opts.withDefaults()
+	claims := extractClaims(answer)
+
+	rep := Report{Claims: make([]Claim, 0, len(claims)), Unsupported: []Claim{}}
+	for _, c := range claims {
+		if c.Kind == KindNumber && isSmallInt(c.Value, opts.IgnoreSmallInts) {
+			continue
+		}
+		c.Supported, c.MatchedBy = ev.supports(c, opts)
+		rep.Claims = append(rep.Claims, c)
+		rep.Checked++
+		if c.Supported {
+			rep.Supported++
+		} else {
+			rep.Unsupported = append(rep.Unsupported, c)
+		}
+	}
+	rep.Score = 1
+	if rep.Checked > 0 {
+		rep.Score = float64(rep.Supported) / float64(rep.Checked)
+	}
+	rep.Passed = len(rep.Unsupported) == 0
+	return rep
+}
+
+func (ev *Evidence) supports(c Claim, opts Options) (bool, string) {
+	switch c.Kind {
+	case KindDate:
+		if _, ok := ev.dates[c.Normalized]; ok {
+			return true, "exact"
+		}
+		return false, ""
+	case KindNumber:
+		cands := append([]float64{c.Value}, c.alt...)
+		for _, v := range cands {
+			for _, q := range ev.qNumbers {
+				if q == v {
+					return true, "question"
+				}
+			}
+		}
+		for _, v := range cands {
+			if ok, by := ev.hasNumber(v, opts.RelTolerance); ok {
+				return true, by
+			}
+		}
+		return false, ""
+	default:
+		if _, ok := ev.ids[c.Normalized]; ok {
+			return true, "exact"
+		}
+		return false, ""
+	}
+}
+
+func (ev *Evidence) hasNumber(v, rel float64) (bool, string) {
+	// exact via binary search
+	i := sort.SearchFloat64s(ev.numbers, v)
+	if i < len(ev.numbers) && ev.numbers[i] == v {
+		return true, "exact"
+	}
+	tol := math.Abs(v) * rel
+	lo := sort.SearchFloat64s(ev.numbers, v-tol)
+	if lo < len(ev.numbers) && ev.numbers[lo] <= v+tol {
+		return true, "tolerance"
+	}
+	// pembulatan: "12.3" cocok dengan 12.34 (selisih < setengah digit terakhir)
+	for _, n := range ev.numbers[max(0, lo-1):min(len(ev.numbers), lo+2)] {
+		if roundTo(n, decimals(v)) == v {
+			return true, "tolerance"
+		}
+	}
+	return false, ""
+}
+
+// UnsupportedTexts mengembalikan teks klaim tak berdasar (unik, urut kemunculan).
+func (r Report) UnsupportedTexts() []string {
+	seen := map[string]struct{}{}
+	out := make([]string, 0, len(r.Unsupported))
+	for _, c := range r.Unsupported {
+		if _, ok := seen[c.Text]; ok {
+			continue
+		}
+		seen[c.Text] = struct{}{}
+		out = append(out, c.Text)
+	}
+	return out
+}
+
+// ---------------- Extraction ----------------
+
+var (
+	rePO   = regexp.MustCompile(`(?i)\bPO[-_ ]?(\d{3,})\b`)
+	reWO   = regexp.MustCompile(`(?i)\bWO[-_ ]?(\d{3,})\b`)
+	reWell = regexp.MustCompile(`(?i)\bWELL[-_ ]([A-Z])[-_ ]?(\d{1,3})\b`)
+
+	reISODate = regexp.MustCompile(`\b(\d{4})-(\d{2})-(\d{2})(?:[T ][0-9:.]+Z?)?\b`)
+	reDMYDate = regexp.MustCompile(`\b(\d{1,2})[/.](\d{1,2})[/.](\d{4})\b`)
+	reTxtDate = regexp.MustCompile(`(?i)\b(\d{1,2})\s+(jan(?:uari|uary)?|feb(?:ruari|ruary)?|mar(?:et|ch)?|apr(?:il)?|mei|may|jun(?:i|e)?|jul(?:i|y)?|agu(?:stus)?|aug(?:ust)?|sep(?:tember)?|okt(?:ober)?|oct(?:ober)?|nov(?:ember)?|des(?:ember)?|dec(?:ember)?)\s+(\d{4})\b`)
+
+	// angka dengan pemisah ribuan (1,234,567.8 atau 1.234.567,8) atau desimal biasa,
+	// opsional diikuti pengali (ribu/juta/miliar/k/mn/bn). "m" sengaja tidak dipakai (meter).
+	reNumber = regexp.MustCompile(`(?i)(?:^|[^\w.,])(-?\d{1,3}(?:[.,]\d{3})+(?:[.,]\d+)?|-?\d+(?:[.,]\d+)?)(\s*(?:ribu|rb|juta|jt|miliar|milyar|thousand|million|billion|k|mn|bn)\b)?`)
+)
+
+var monthIdx = map[string]int{
+	"jan": 1, "feb": 2, "mar": 3, "apr": 4, "mei": 5, "may": 5, "jun": 6, "jul": 7,
+	"agu": 8, "aug": 8, "sep": 9, "okt": 10, "oct": 10, "nov": 11, "des": 12, "dec": 12,
+}
+
+type span struct{ start, end int }
+
+func extractClaims(s string) []Claim {
+	var out []Claim
+	var taken []span
+	overlaps := func(a, b int) bool {
+		for _, t := range taken {
+			if a < t.end && b > t.start {
+				return true
+			}
+		}
+		return false
+	}
+	add := func(c Claim) {
+		if overlaps(c.Start, c.End) {
+			return
+		}
+		taken = append(taken, span{c.Start, c.End})
+		out = append(out, c)
+	}
+
+	for _, m := range rePO.FindAllStringSubmatchIndex(s, -1) {
+		add(Claim{Kind: KindPO, Text: s[m[0]:m[1]], Normalized: "PO-" + s[m[2]:m[3]], Start: m[0], End: m[1]})
+	}
+	for _, m := range reWO.FindAllStringSubmatchIndex(s, -1) {
+		add(Claim{Kind: KindWO, Text: s[m[0]:m[1]], Normalized: "WO-" + s[m[2]:m[3]], Start: m[0], End: m[1]})
+	}
+	for _, m := range reWell.FindAllStringSubmatchIndex(s, -1) {
+		n, _ := strconv.Atoi(s[m[4]:m[5]])
+		norm := "WELL_" + strings.ToUpper(s[m[2]:m[3]]) + pad2(n)
+		add(Claim{Kind: KindWell, Text: s[m[0]:m[1]], Normalized: norm, Start: m[0], End: m[1]})
+	}
+	for _, m := range reISODate.FindAllStringSubmatchIndex(s, -1) {
+		norm := s[m[2]:m[3]] + "-" + s[m[4]:m[5]] + "-" + s[m[6]:m[7]]
+		if validDate(norm) {
+			add(Claim{Kind: KindDate, Text: s[m[0]:m[1]], Normalized: norm, Start: m[0], End: m[1]})
+		}
+	}
+	for _, m := range reDMYDate.FindAllStringSubmatchIndex(s, -1) {
+		d, _ := strconv.Atoi(s[m[2]:m[3]])
+		mo, _ := strconv.Atoi(s[m[4]:m[5]])
+		norm := s[m[6]:m[7]] + "-" + pad2(mo) + "-" + pad2(d)
+		if validDate(norm) {
+			add(Claim{Kind: KindDate, Text: s[m[0]:m[1]], Normalized: norm, Start: m[0], End: m[1]})
+		}
+	}
+	for _, m := range reTxtDate.FindAllStringSubmatchIndex(s, -1) {
+		d, _ := strconv.Atoi(s[m[2]:m[3]])
+		mo := monthIdx[strings.ToLower(s[m[4]:m[4]+3])]
+		norm := s[m[6]:m[7]] + "-" + pad2(mo) + "-" + pad2(d)
+		if validDate(norm) {
+			add(Claim{Kind: KindDate, Text: s[m[0]:m[1]], Normalized: norm, Start: m[0], End: m[1]})
+		}
+	}
+	for _, m := range reNumber.FindAllStringSubmatchIndex(s, -1) {
+		start, end := m[2], m[3]
+		if m[4] >= 0 {
+			end = m[5]
+		}
+		if overlaps(start, end) {
+			continue
+		}
+		raw := s[m[2]:m[3]]
+		v, ok := parseNumber(raw)
+		if !ok {
+			continue
+		}
+		mul := 1.0
+		if m[4] >= 0 {
+			mul = multiplier(strings.ToLower(strings.TrimSpace(s[m[4]:m[5]])))
+		}
+		c := Claim{Kind: KindNumber, Text: s[start:end], Value: v * mul, Start: start, End: end}
+		c.Normalized = strconv.FormatFloat(c.Value, 'f', -1, 64)
+		if a, ok := ambiguousThousands(raw); ok {
+			c.alt = []float64{a * mul}
+		}
+		add(c)
+	}
+
+	sort.Slice(out, func(i, j int) bool { return out[i].Start < out[j].Start })
+	return out
+}
+
+// parseNumber menerima format en (1,234.5) maupun id (1.234,5).
+func parseNumber(s string) (float64, bool) {
+	s = strings.TrimSpace(s)
+	lastDot := strings.LastIndex(s, ".")
+	lastComma := strings.LastIndex(s, ",")
+	switch {
+	case lastDot >= 0 && lastComma >= 0:
+		if lastComma > lastDot { // id: 1.234,5
+			s = strings.ReplaceAll(s, ".", "")
+			s = strings.Replace(s, ",", ".", 1)
+		} else { // en: 1,234.5
+			s = strings.ReplaceAll(s, ",", "")
+		}
+	case lastComma >= 0:
+		// "1,234" (ribuan) vs "12,5" (desimal id)
+		if strings.Count(s, ",") > 1 || len(s)-lastComma-1 == 3 {
+			s = strings.ReplaceAll(s, ",", "")
+		} else {
+			s = strings.Replace(s, ",", ".", 1)
+		}
+	case lastDot >= 0:
+		// "1.234.567" (ribuan id) vs "12.5" (desimal)
+		if strings.Count(s, ".") > 1 {
+			s = strings.ReplaceAll(s, ".", "")
+		}
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	return v, err == nil
+}
+
+// ambiguousThousands: "555.409" / "12,500" bisa berarti ribuan (id/en) atau desimal.
+// Mengembalikan tafsiran ribuan bila parseNumber memilih tafsiran desimal, dan sebaliknya.
+func ambiguousThousands(s string) (float64, bool) {
+	s = strings.TrimSpace(s)
+	if strings.Count(s, ".")+strings.Count(s, ",") != 1 {
+		return 0, false
+	}
+	i := strings.IndexAny(s, ".,")
+	if len(s)-i-1 != 3 {
+		return 0, false
+	}
+	primary, _ := parseNumber(s)
+	thousands, err := strconv.ParseFloat(s[:i]+s[i+1:], 64)
+	if err != nil {
+		return 0, false
+	}
+	if primary == thousands {
+		decimal, err := strconv.ParseFloat(s[:i]+"."+s[i+1:], 64)
+		return decimal, err == nil
+	}
+	return thousands, true
+}
+
+func multiplier(w string) float64 {
+	switch w {
+	case "ribu", "rb", "thousand", "k":
+		return 1e3
+	case "juta", "jt", "million", "mn":
+		return 1e6
+	case "miliar", "milyar", "billion", "bn":
+		return 1e9
+	}
+	return 1
+}
+
+func validDate(s string) bool {
+	_, err := time.Parse("2006-01-02", s)
+	return err == nil
+}
+
+func pad2(n int) string {
+	if n < 10 {
+		return "0" + strconv.Itoa(n)
+	}
+	return strconv.Itoa(n)
+}
+
+func isSmallInt(v float64, limit int) bool {
+	return v == math.Trunc(v) && math.Abs(v) <= float64(limit)
+}
+
+func decimals(v float64) int {
+	s := strconv.FormatFloat(v, 'f', -1, 64)
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		return len(s) - i - 1
+	}
+	return 0
+}
+
+func roundTo(v float64, d int) float64 {
+	p := math.Pow(10, float64(d))
+	return math.Round(v*p) / p
+}
@@ -0,0 +1,61 @@
+// internal/verify/verify_test.go
+
+package verify_test
+
+import (
+	"testing"
+
+	"mcp-oilgas/internal/verify"
+)
+
+func sources() []any {
+	return []any{
+		map[string]any{
+			"route": map[string]any{"kind": "mcp", "tool": "get_po_top_amount"},
+			"data": map[string]any{
+				"items": []any{
+					map[string]any{"po_number": "PO-20250000", "vendor": "NOV", "amount": 555409.0, "eta": "2025-10-07"},
+					map[string]any{"po_number": "PO-20250001", "vendor": "Baker", "amount": 43316.0, "eta": "2025-10-11T00:00:00Z"},
+				},
+			},
+		},
+		map[string]any{
+			"data": map[string]any{"breakdown": []any{
+				map[string]any{"sub_cause": "Rig Power Failure", "hours": 7.37, "well_id": "WELL_F10"},
+			}},
+		},
+	}
+}
+
+// Jawaban yang semua angkanya ada di sources harus lolos (format en & id).
+func TestVerifySupportedClaims(t *testing.T) {
+	ev := verify.NewEvidence("Sebutkan 2 PO dengan amount tertinggi", sources()...)
+	answer := "PO-20250000 dari NOV bernilai USD 555.409 (ETA 7 Oktober 2025), disusul PO 20250001 senilai 43,316 " +
+		"dengan ETA 11/10/2025. NPT terbesar di Well F-10 sekitar 7.4 jam."
+	rep := verify.Verify(answer, ev, verify.Options{})
+	if !rep.Passed {
+		t.Fatalf("expected pass, unsupported=%+v", rep.Unsupported)
+	}
+	if rep.Checked < 7 {
+		t.Fatalf("expected >=7 claims checked, got %d: %+v", rep.Checked, rep.Claims)
+	}
+}
+
+// Angka, PO, tanggal & sumur karangan harus ditandai.
+func TestVerifyFlagsUnsupportedClaims(t *testing.T) {
+	ev := verify.NewEvidence("PO tertinggi?", sources()...)
+	answer := "PO-20259999 senilai USD 1.2 juta dengan ETA 2025-12-01 untuk WELL_Z99."
+	rep := verify.Verify(answer, ev, verify.Options{})
+	if rep.Passed {
+		t.Fatalf("expected failure, got %+v", rep)
+	}
+	kinds := map[string]bool{}
+	for _, c := range rep.Unsupported {
+		kinds[c.Kind] = true
+	}
+	for _, k := range []string{verify.KindPO, verify.KindNumber, verify.KindDate, verify.KindWell} {
+		if !kinds[k] {
+			t.Fatalf("expected unsupported %s claim, got %+v", k, rep.Unsupported)
+		}
+	}
+}
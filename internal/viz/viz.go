@@ -0,0 +1,388 @@
+// internal/viz/viz.go
+// Payload visualisasi terstruktur dari hasil tool MCP (line/bar/table) agar UI bisa
+// langsung menggambar grafik tanpa mem-parsing teks jawaban.
+//
+// Pemetaan tool → jenis grafik:
+//
+//	get_timeseries                              → line (sumbu Y = ts_signal.unit)
+//	get_production                              → line per sumur (MMSCFD)
+//	get_po_vendor_summary, get_po_vendor_compare → bar per vendor
+//	summarize_npt_events                        → bar per sub_cause (jam + biaya)
+//	search_work_orders                          → table
+package viz
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"mcp-oilgas/internal/mcp"
+)
+
+// Jenis visualisasi.
+const (
+	TypeLine  = "line"
+	TypeBar   = "bar"
+	TypeTable = "table"
+)
+
+// Axis mendeskripsikan satu sumbu grafik.
+type Axis struct {
+	Label string `json:"label"`
+	Type  string `json:"type"` // time | category | value
+	Unit  string `json:"unit,omitempty"`
+}
+
+// Point adalah satu titik data; X berupa waktu RFC3339/tanggal atau kategori.
+type Point struct {
+	X string  `json:"x"`
+	Y float64 `json:"y"`
+}
+
+// Series adalah satu deret data pada grafik line/bar.
+type Series struct {
+	Name   string  `json:"name"`
+	Unit   string  `json:"unit,omitempty"`
+	Axis   string  `json:"axis,omitempty"` // "y" (default) | "y2"
+	Points []Point `json:"points"`
+}
+
+// Column adalah satu kolom tabel.
+type Column struct {
+	Key   string `json:"key"`
+	Label string `json:"label"`
+	Unit  string `json:"unit,omitempty"`
+}
+
+// Spec adalah payload satu visualisasi (di-stream sebagai event SSE "visualization").
+type Spec struct {
+	ID        string   `json:"id"`
+	Type      string   `json:"type"`
+	Title     string   `json:"title"`
+	Tool      string   `json:"tool"`
+	X         *Axis    `json:"x,omitempty"`
+	Y         *Axis    `json:"y,omitempty"`
+	Y2        *Axis    `json:"y2,omitempty"`
+	Series    []Series `json:"series,omitempty"`
+	Columns   []Column `json:"columns,omitempty"`
+	Rows      [][]any  `json:"rows,omitempty"`
+	Truncated bool     `json:"truncated,omitempty"`
+}
+
+// Options membatasi ukuran payload.
+type Options struct {
+	MaxPoints int // per series (downsample merata bila lebih); default 1000
+	MaxRows   int // per tabel; default 200
+}
+
+func (o Options) withDefaults() Options {
+	if o.MaxPoints <= 0 {
+		o.MaxPoints = 1000
+	}
+	if o.MaxRows <= 0 {
+		o.MaxRows = 200
+	}
+	return o
+}
+
+type builder func(data any, o Options) []Spec
+
+var builders = map[string]builder{
+	"get_timeseries":        buildTimeseries,
+	"get_production":        buildProduction,
+	"get_po_vendor_summary": buildVendorBar,
+	"get_po_vendor_compare": buildVendorBar,
+	"summarize_npt_events":  buildNPT,
+	"search_work_orders":    buildWorkOrders,
+}
+
+// Supported melaporkan apakah tool punya pemetaan visualisasi.
+func Supported(tool string) bool {
+	_, ok := builders[tool]
+	return ok
+}
+
+// Build membuat visualisasi dari satu hasil tool. Data yang bentuknya tidak dikenali
+// atau kosong menghasilkan nil (bukan error) karena visualisasi bersifat opsional.
+func Build(tool string, data any, o Options) []Spec {
+	b, ok := builders[tool]
+	if !ok || data == nil {
+		return nil
+	}
+	specs := b(normalize(data), o.withDefaults())
+	for i := range specs {
+		specs[i].Tool = tool
+	}
+	return specs
+}
+
+// FromResults membuat visualisasi dari seluruh ExecResult yang sukses (route MCP saja).
+func FromResults(results []mcp.ExecResult, o Options) []Spec {
+	var out []Spec
+	for _, r := range results {
+		if r.Error != "" || r.Route.Kind == mcp.RouteRAG {
+			continue
+		}
+		out = append(out, Build(r.Route.Tool, r.Data, o)...)
+	}
+	for i := range out {
+		out[i].ID = fmt.Sprintf("viz-%d", i+1)
+	}
+	return out
+}
+
+// ---- builders ----
+
+func buildTimeseries(data any, o Options) []Spec {
+	m := asMap(data)
+	pts := asSlice(m["points"])
+	if len(pts) == 0 {
+		return nil
+	}
+	unit := str(m["unit"])
+	name := str(m["tag_name"])
+	if name == "" {
+		name = str(m["tag_id"])
+	}
+	s := Series{Name: name, Unit: unit}
+	for _, p := range pts {
+		pm := asMap(p)
+		y, ok := num(pm["value"])
+		if !ok {
+			continue
+		}
+		s.Points = append(s.Points, Point{X: str(pm["ts_utc"]), Y: y})
+	}
+	if len(s.Points) == 0 {
+		return nil
+	}
+	spec := Spec{
+		Type:  TypeLine,
+		Title: titleWithUnit(name, unit),
+		X:     &Axis{Label: "Time (UTC)", Type: "time"},
+		Y:     &Axis{Label: name, Type: "value", Unit: unit},
+	}
+	s.Points, spec.Truncated = downsample(s.Points, o.MaxPoints)
+	spec.Series = []Series{s}
+	return []Spec{spec}
+}
+
+func buildProduction(data any, o Options) []Spec {
+	rows := asSlice(data)
+	if len(rows) == 0 {
+		return nil
+	}
+	const unit = "MMSCFD"
+	byWell := map[string][]Point{}
+	for _, r := range rows {
+		rm := asMap(r)
+		y, ok := num(rm["gas_mmscfd"])
+		if !ok {
+			y = 0 // omitempty: nilai 0 tidak ikut di-encode
+		}
+		well := str(rm["well_id"])
+		byWell[well] = append(byWell[well], Point{X: str(rm["date"]), Y: y})
+	}
+	wells := make([]string, 0, len(byWell))
+	for w := range byWell {
+		wells = append(wells, w)
+	}
+	sort.Strings(wells)
+
+	spec := Spec{
+		Type:  TypeLine,
+		Title: titleWithUnit("Gas production", unit),
+		X:     &Axis{Label: "Date", Type: "time"},
+		Y:     &Axis{Label: "Gas", Type: "value", Unit: unit},
+	}
+	for _, w := range wells {
+		pts := byWell[w]
+		sort.SliceStable(pts, func(i, j int) bool { return pts[i].X < pts[j].X })
+		var cut bool
+		pts, cut = downsample(pts, o.MaxPoints)
+		spec.Truncated = spec.Truncated || cut
+		spec.Series = append(spec.Series, Series{Name: w, Unit: unit, Points: pts})
+	}
+	return []Spec{spec}
+}
+
+func buildVendorBar(data any, o Options) []Spec {
+	m := asMap(data)
+	vendors := asSlice(m["vendors"])
+	if len(vendors) == 0 {
+		return nil
+	}
+	currency := strings.ToUpper(str(m["currency"]))
+	total := Series{Name: "total", Unit: currency}
+	count := Series{Name: "count", Axis: "y2"}
+	for _, v := range vendors {
+		vm := asMap(v)
+		name := str(vm["vendor"])
+		if t, ok := num(vm["total"]); ok {
+			total.Points = append(total.Points, Point{X: name, Y: t})
+		}
+		if c, ok := num(vm["count"]); ok {
+			count.Points = append(count.Points, Point{X: name, Y: c})
+		}
+	}
+	if len(total.Points) == 0 {
+		return nil
+	}
+	title := "PO total per vendor"
+	if st := str(m["status"]); st != "" {
+		title += " (" + st + ")"
+	}
+	spec := Spec{
+		Type:   TypeBar,
+		Title:  title,
+		X:      &Axis{Label: "Vendor", Type: "category"},
+		Y:      &Axis{Label: "Total", Type: "value", Unit: currency},
+		Series: []Series{total},
+	}
+	if len(count.Points) > 0 {
+		spec.Y2 = &Axis{Label: "PO count", Type: "value"}
+		spec.Series = append(spec.Series, count)
+	}
+	return []Spec{spec}
+}
+
+func buildNPT(data any, o Options) []Spec {
+	m := asMap(data)
+	rows := asSlice(m["breakdown"])
+	if len(rows) == 0 {
+		return nil
+	}
+	hours := Series{Name: "hours", Unit: "h"}
+	cost := Series{Name: "cost_usd", Unit: "USD", Axis: "y2"}
+	for _, r := range rows {
+		rm := asMap(r)
+		cat := str(rm["sub_cause"])
+		h, _ := num(rm["hours"])
+		c, _ := num(rm["cost_usd"])
+		hours.Points = append(hours.Points, Point{X: cat, Y: h})
+		cost.Points = append(cost.Points, Point{X: cat, Y: c})
+	}
+	return []Spec{{
+		Type:   TypeBar,
+		Title:  "NPT breakdown by sub-cause",
+		X:      &Axis{Label: "Sub-cause", Type: "category"},
+		Y:      &Axis{Label: "Duration", Type: "value", Unit: "h"},
+		Y2:     &Axis{Label: "Cost", Type: "value", Unit: "USD"},
+		Series: []Series{hours, cost},
+	}}
+}
+
+var workOrderColumns = []Column{
+	{Key: "wo_id", Label: "WO"},
+	{Key: "asset_id", Label: "Asset"},
+	{Key: "area", Label: "Area"},
+	{Key: "priority", Label: "Priority"},
+	{Key: "status", Label: "Status"},
+	{Key: "due_date", Label: "Due date"},
+}
+
+func buildWorkOrders(data any, o Options) []Spec {
+	rows := asSlice(data)
+	if len(rows) == 0 {
+		return nil
+	}
+	spec := Spec{
+		Type:    TypeTable,
+		Title:   fmt.Sprintf("Work orders (%d)", len(rows)),
+		Columns: workOrderColumns,
+	}
+	if len(rows) > o.MaxRows {
+		rows = rows[:o.MaxRows]
+		spec.Truncated = true
+	}
+	for _, r := range rows {
+		rm := asMap(r)
+		row := make([]any, len(workOrderColumns))
+		for i, c := range workOrderColumns {
+			row[i] = rm[c.Key]
+		}
+		spec.Rows = append(spec.Rows, row)
+	}
+	return []Spec{spec}
+}
+
+// ---- helpers ----
+
+// normalize mengubah data bertipe (struct) menjadi bentuk generik hasil json.Unmarshal,
+// sehingga builder cukup menangani map[string]any / []any.
+func normalize(v any) any {
+	switch v.(type) {
+	case map[string]any, []any:
+		return v
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	var out any
+	if err := json.Unmarshal(b, &out); err != nil {
+		return nil
+	}
+	return out
+}
+
+// downsample mengambil titik dengan langkah merata; titik terakhir selalu dipertahankan.
+func downsample(pts []Point, max int) ([]Point, bool) {
+	if max <= 0 || len(pts) <= max {
+		return pts, false
+	}
+	out := make([]Point, 0, max)
+	step := float64(len(pts)-1) / float64(max-1)
+	for i := 0; i < max; i++ {
+		out = append(out, pts[int(float64(i)*step+0.5)])
+	}
+	return out, true
+}
+
+func titleWithUnit(name, unit string) string {
+	if unit == "" {
+		return name
+	}
+	return name + " (" + unit + ")"
+}
+
+func asMap(v any) map[string]any {
+	m, _ := v.(map[string]any)
+	return m
+}
+
+func asSlice(v any) []any {
+	s, _ := v.([]any)
+	return s
+}
+
+func str(v any) string {
+	switch t := v.(type) {
+	case string:
+		return strings.TrimSpace(t)
+	case nil:
+		return ""
+	default:
+		return fmt.Sprint(t)
+	}
+}
+
+func num(v any) (float64, bool) {
+	switch t := v.(type) {
+	case float64:
+		return t, true
+	case int:
+		return float64(t), true
+	case int64:
+		return float64(t), true
+	case json.Number:
+		f, err := t.Float64()
+		return f, err == nil
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(t), 64)
+		return f, err == nil
+	}
+	return 0, false
+}
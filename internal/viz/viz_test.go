@@ -0,0 +1,63 @@
+package viz_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"mcp-oilgas/internal/mcp"
+	"mcp-oilgas/internal/viz"
+)
+
+func decode(t *testing.T, s string) any {
+	t.Helper()
+	var v any
+	if err := json.Unmarshal([]byte(s), &v); err != nil {
+		t.Fatalf("bad fixture: %v", err)
+	}
+	return v
+}
+
+func TestTimeseriesUsesSignalUnit(t *testing.T) {
+	data := decode(t, `{"tag_id":"T1","tag_name":"OIL_D01","unit":"BOPD","count":2,
+		"points":[{"ts_utc":"2025-09-01T00:00:00Z","value":1200.5},{"ts_utc":"2025-09-02T00:00:00Z","value":1180}]}`)
+	specs := viz.Build("get_timeseries", data, viz.Options{})
+	if len(specs) != 1 || specs[0].Type != viz.TypeLine {
+		t.Fatalf("want 1 line spec, got %+v", specs)
+	}
+	if specs[0].Y.Unit != "BOPD" || len(specs[0].Series[0].Points) != 2 {
+		t.Fatalf("unexpected spec: %+v", specs[0])
+	}
+}
+
+func TestFromResultsSkipsErrorsAndRAG(t *testing.T) {
+	res := []mcp.ExecResult{
+		{Route: mcp.Route{Kind: mcp.RouteMCP, Tool: "summarize_npt_events"},
+			Data: decode(t, `{"breakdown":[{"sub_cause":"stuck pipe","hours":12.5,"cost_usd":40000}],"top_3_levers":["stuck pipe"]}`)},
+		{Route: mcp.Route{Kind: mcp.RouteMCP, Tool: "search_work_orders"},
+			Data: decode(t, `[{"wo_id":"WO-1000","asset_id":"A1","priority":1,"status":"open"}]`)},
+		{Route: mcp.Route{Kind: mcp.RouteMCP, Tool: "get_production"}, Error: "db error"},
+		{Route: mcp.Route{Kind: mcp.RouteRAG, Query: "x"}, Data: map[string]any{"retrieved_chunks": []any{}}},
+	}
+	specs := viz.FromResults(res, viz.Options{})
+	if len(specs) != 2 {
+		t.Fatalf("want 2 specs, got %d", len(specs))
+	}
+	if specs[0].Type != viz.TypeBar || specs[0].ID != "viz-1" {
+		t.Fatalf("unexpected first spec: %+v", specs[0])
+	}
+	if specs[1].Type != viz.TypeTable || specs[1].Rows[0][0] != "WO-1000" {
+		t.Fatalf("unexpected table: %+v", specs[1])
+	}
+}
+
+func TestDownsample(t *testing.T) {
+	pts := make([]any, 0, 50)
+	for i := 0; i < 50; i++ {
+		pts = append(pts, map[string]any{"ts_utc": "t", "value": float64(i)})
+	}
+	specs := viz.Build("get_timeseries", map[string]any{"tag_id": "T", "points": pts}, viz.Options{MaxPoints: 10})
+	s := specs[0]
+	if !s.Truncated || len(s.Series[0].Points) != 10 || s.Series[0].Points[9].Y != 49 {
+		t.Fatalf("downsample wrong: %+v", s.Series[0].Points)
+	}
+}
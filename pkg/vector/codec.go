@@ -0,0 +1,105 @@
+// pkg/vector/codec.go
+// Encoding biner embedding untuk kolom BLOB (doc_chunks.embedding_bin).
+//
+//	f32: [0x01][dim × float32 little-endian]
+//	i8:  [0x02][scale float32 LE][dim × int8]   nilai ≈ int8 × scale (kuantisasi simetris per vektor)
+//
+// Byte pertama menandai format sehingga baris f32 dan i8 bisa bercampur di satu tabel.
+
+package vector
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+// Format penyimpanan embedding.
+const (
+	FormatJSON = "json" // legacy: array JSON di kolom embedding
+	FormatF32  = "f32"
+	FormatI8   = "i8"
+)
+
+const (
+	tagF32 byte = 0x01
+	tagI8  byte = 0x02
+)
+
+// ValidFormat melaporkan apakah f dikenal.
+func ValidFormat(f string) bool {
+	return f == FormatJSON || f == FormatF32 || f == FormatI8
+}
+
+// EncodeF32 mengemas v sebagai float32 little-endian (4 byte/dimensi).
+func EncodeF32(v []float32) []byte {
+	b := make([]byte, 1+4*len(v))
+	b[0] = tagF32
+	for i, x := range v {
+		binary.LittleEndian.PutUint32(b[1+4*i:], math.Float32bits(x))
+	}
+	return b
+}
+
+// EncodeI8 mengkuantisasi v ke int8 dengan skala max|v|/127 (1 byte/dimensi).
+// Galat per komponen ≤ scale/2; cosine praktis tidak berubah untuk embedding teks.
+func EncodeI8(v []float32) []byte {
+	var maxAbs float64
+	for _, x := range v {
+		if a := math.Abs(float64(x)); a > maxAbs {
+			maxAbs = a
+		}
+	}
+	scale := float32(maxAbs / 127)
+	b := make([]byte, 5+len(v))
+	b[0] = tagI8
+	binary.LittleEndian.PutUint32(b[1:], math.Float32bits(scale))
+	for i, x := range v {
+		q := 0.0
+		if scale > 0 {
+			q = math.Round(float64(x / scale))
+		}
+		b[5+i] = byte(int8(math.Max(-127, math.Min(127, q))))
+	}
+	return b
+}
+
+// Encode mengemas v dalam format f (FormatF32 atau FormatI8).
+func Encode(v []float32, f string) ([]byte, error) {
+	switch f {
+	case FormatF32:
+		return EncodeF32(v), nil
+	case FormatI8:
+		return EncodeI8(v), nil
+	}
+	return nil, fmt.Errorf("vector: unsupported binary format %q", f)
+}
+
+// Decode membuka hasil EncodeF32/EncodeI8.
+func Decode(b []byte) ([]float32, error) {
+	if len(b) == 0 {
+		return nil, fmt.Errorf("vector: empty blob")
+	}
+	switch b[0] {
+	case tagF32:
+		if (len(b)-1)%4 != 0 {
+			return nil, fmt.Errorf("vector: corrupt f32 blob (%d bytes)", len(b))
+		}
+		out := make([]float32, (len(b)-1)/4)
+		for i := range out {
+			out[i] = math.Float32frombits(binary.LittleEndian.Uint32(b[1+4*i:]))
+		}
+		return out, nil
+	case tagI8:
+		if len(b) < 5 {
+			return nil, fmt.Errorf("vector: corrupt i8 blob (%d bytes)", len(b))
+		}
+		scale := math.Float32frombits(binary.LittleEndian.Uint32(b[1:]))
+		out := make([]float32, len(b)-5)
+		for i := range out {
+			out[i] = float32(int8(b[5+i])) * scale
+		}
+		return out, nil
+	}
+	return nil, fmt.Errorf("vector: unknown blob format 0x%02x", b[0])
+}
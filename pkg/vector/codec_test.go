@@ -0,0 +1,53 @@
+package vector_test
+
+import (
+	"math"
+	"testing"
+
+	"mcp-oilgas/pkg/vector"
+)
+
+func TestCodecRoundTrip(t *testing.T) {
+	v := []float32{0.5, -0.25, 0.125, 0, -1, 0.333}
+
+	f32, err := vector.Encode(v, vector.FormatF32)
+	if err != nil || len(f32) != 1+4*len(v) {
+		t.Fatalf("f32 encode: len=%d err=%v", len(f32), err)
+	}
+	got, err := vector.Decode(f32)
+	if err != nil {
+		t.Fatalf("f32 decode: %v", err)
+	}
+	for i := range v {
+		if got[i] != v[i] {
+			t.Fatalf("f32 mismatch at %d: %v != %v", i, got[i], v[i])
+		}
+	}
+
+	i8, err := vector.Encode(v, vector.FormatI8)
+	if err != nil || len(i8) != 5+len(v) {
+		t.Fatalf("i8 encode: len=%d err=%v", len(i8), err)
+	}
+	got, err = vector.Decode(i8)
+	if err != nil {
+		t.Fatalf("i8 decode: %v", err)
+	}
+	for i := range v {
+		if math.Abs(float64(got[i]-v[i])) > 1.0/127 {
+			t.Fatalf("i8 error too large at %d: %v vs %v", i, got[i], v[i])
+		}
+	}
+	if c := vector.Cosine(v, got); c < 0.999 {
+		t.Fatalf("i8 cosine drift: %v", c)
+	}
+
+	if _, err := vector.Decode([]byte{0x07, 1, 2}); err == nil {
+		t.Fatalf("expected unknown format error")
+	}
+	if _, err := vector.Decode(f32[:len(f32)-1]); err == nil {
+		t.Fatalf("expected corrupt f32 error")
+	}
+	if _, err := vector.Encode(v, vector.FormatJSON); err == nil {
+		t.Fatalf("json is not a binary format")
+	}
+}
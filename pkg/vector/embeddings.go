@@ -1,95 +0,0 @@
-// pkg/vector/embeddings.go
-// Client sederhana untuk generate embedding via OpenAI API
-
-package vector
-
-import (
-	"bytes"
-	"context"
-	"encoding/json"
-	"fmt"
-	"io"
-	"net/http"
-	"time"
-)
-
-type EmbeddingRequest struct {
-	Model string   `json:"model"`
-	Input []string `json:"input"`
-}
-
-type EmbeddingResponse struct {
-	Object string `json:"object"`
-	Data   []struct {
-		Index     int       `json:"index"`
-		Embedding []float64 `json:"embedding"`
-	} `json:"data"`
-	Model string `json:"model"`
-	Usage struct {
-		PromptTokens int `json:"prompt_tokens"`
-		TotalTokens  int `json:"total_tokens"`
-	} `json:"usage"`
-}
-
-type OpenAIClient struct {
-	APIKey  string
-	BaseURL string
-	Client  *http.Client
-}
-
-// NewOpenAIClient buat client baru untuk panggil API OpenAI
-func NewOpenAIClient(apiKey, baseURL string) *OpenAIClient {
-	if baseURL == "" {
-		baseURL = "https://api.openai.com/v1"
-	}
-	return &OpenAIClient{
-		APIKey:  apiKey,
-		BaseURL: baseURL,
-		Client: &http.Client{
-			Timeout: 30 * time.Second,
-		},
-	}
-}
-
-// CreateEmbeddings kirim request ke OpenAI untuk dapatkan embedding
-func (c *OpenAIClient) CreateEmbeddings(ctx context.Context, model string, texts []string) ([][]float64, error) {
-	reqBody := EmbeddingRequest{
-		Model: model,
-		Input: texts,
-	}
-	bodyBytes, err := json.Marshal(reqBody)
-	if err != nil {
-		return nil, fmt.Errorf("marshal request: %w", err)
-	}
-
-	url := fmt.Sprintf("%s/embeddings", c.BaseURL)
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(bodyBytes))
-	if err != nil {
-		return nil, fmt.Errorf("new request: %w", err)
-	}
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+c.APIKey)
-
-	resp, err := c.Client.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("http do: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		b, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("openai error: %s (%s)", resp.Status, string(b))
-	}
-
-	var out EmbeddingResponse
-	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
-		return nil, fmt.Errorf("decode response: %w", err)
-	}
-
-	vectors := make([][]float64, len(out.Data))
-	for i, d := range out.Data {
-		vectors[i] = d.Embedding
-	}
-
-	return vectors, nil
-}
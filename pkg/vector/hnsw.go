@@ -0,0 +1,455 @@
+// pkg/vector/hnsw.go
+// Indeks ANN HNSW (Hierarchical Navigable Small World) in-memory dengan metrik cosine.
+// Vektor dinormalisasi saat disisipkan sehingga skor = dot product = cosine.
+// Tidak aman dipakai konkuren tanpa kunci dari pemanggil (lihat internal/vectorindex).
+
+package vector
+
+import (
+	"bytes"
+	"container/heap"
+	"encoding/gob"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// HNSWConfig mengatur graf. Nilai nol → default (M=16, EfConstruction=200, EfSearch=64).
+type HNSWConfig struct {
+	M              int   // jumlah tetangga per node per layer (layer 0: 2*M)
+	EfConstruction int   // lebar pencarian saat insert
+	EfSearch       int   // lebar pencarian default saat query
+	Seed           int64 // seed pemilihan level (0 = 1)
+}
+
+func (c HNSWConfig) normalized() HNSWConfig {
+	if c.M <= 1 {
+		c.M = 16
+	}
+	if c.EfConstruction <= 0 {
+		c.EfConstruction = 200
+	}
+	if c.EfSearch <= 0 {
+		c.EfSearch = 64
+	}
+	if c.Seed == 0 {
+		c.Seed = 1
+	}
+	return c
+}
+
+// Hit adalah satu hasil pencarian.
+type Hit struct {
+	ID    int64
+	Score float64 // cosine similarity
+}
+
+type hnswNode struct {
+	id      int64
+	vec     []float32
+	links   [][]int32 // links[layer]
+	deleted bool
+}
+
+// HNSW adalah graf ANN. ID bebas (mis. doc_chunks.id); Add dengan ID yang sama menggantikan vektor lama.
+type HNSW struct {
+	cfg      HNSWConfig
+	dim      int
+	nodes    []hnswNode
+	ids      map[int64]int32
+	entry    int32
+	maxLevel int
+	deleted  int
+	rng      *rand.Rand
+	levelMul float64
+}
+
+// NewHNSW membuat indeks kosong; dimensi ditetapkan oleh vektor pertama.
+func NewHNSW(cfg HNSWConfig) *HNSW {
+	cfg = cfg.normalized()
+	return &HNSW{
+		cfg:      cfg,
+		ids:      map[int64]int32{},
+		entry:    -1,
+		rng:      rand.New(rand.NewSource(cfg.Seed)),
+		levelMul: 1 / math.Log(float64(cfg.M)),
+	}
+}
+
+// Len mengembalikan jumlah vektor aktif.
+func (h *HNSW) Len() int { return len(h.ids) }
+
+// Dim mengembalikan dimensi vektor (0 bila kosong).
+func (h *HNSW) Dim() int { return h.dim }
+
+// Deleted mengembalikan jumlah node terhapus yang masih ada di graf (lihat Compact).
+func (h *HNSW) Deleted() int { return h.deleted }
+
+// Config mengembalikan konfigurasi efektif.
+func (h *HNSW) Config() HNSWConfig { return h.cfg }
+
+// Has melaporkan apakah id ada di indeks.
+func (h *HNSW) Has(id int64) bool {
+	_, ok := h.ids[id]
+	return ok
+}
+
+// IDs mengembalikan semua id aktif (urutan tidak ditentukan).
+func (h *HNSW) IDs() []int64 {
+	out := make([]int64, 0, len(h.ids))
+	for id := range h.ids {
+		out = append(out, id)
+	}
+	return out
+}
+
+func (h *HNSW) sim(a []float32, n int32) float32 {
+	b := h.nodes[n].vec
+	var s float32
+	for i := range a {
+		s += a[i] * b[i]
+	}
+	return s
+}
+
+// Add menyisipkan/menggantikan vektor id. Vektor identik dengan yang sudah ada tidak mengubah graf.
+func (h *HNSW) Add(id int64, v []float32) error {
+	if len(v) == 0 {
+		return fmt.Errorf("hnsw: empty vector for id %d", id)
+	}
+	if h.dim == 0 {
+		h.dim = len(v)
+	}
+	if len(v) != h.dim {
+		return fmt.Errorf("hnsw: dimension mismatch for id %d: got %d, want %d", id, len(v), h.dim)
+	}
+	vec := make([]float32, len(v))
+	copy(vec, v)
+	Normalize(vec)
+	if Norm(vec) == 0 {
+		return fmt.Errorf("hnsw: zero vector for id %d", id)
+	}
+
+	if old, ok := h.ids[id]; ok {
+		if equalVec(h.nodes[old].vec, vec) {
+			return nil
+		}
+		h.markDeleted(old)
+	}
+
+	n := int32(len(h.nodes))
+	level := int(math.Floor(-math.Log(1-h.rng.Float64()) * h.levelMul))
+	h.nodes = append(h.nodes, hnswNode{id: id, vec: vec, links: make([][]int32, level+1)})
+	h.ids[id] = n
+
+	if h.entry < 0 {
+		h.entry, h.maxLevel = n, level
+		return nil
+	}
+
+	ep := h.entry
+	for l := h.maxLevel; l > level; l-- {
+		ep = h.greedy(vec, ep, l)
+	}
+	eps := []int32{ep}
+	for l := min(level, h.maxLevel); l >= 0; l-- {
+		cands := h.searchLayer(vec, eps, h.cfg.EfConstruction, l)
+		neigh := h.selectNeighbors(cands, h.cfg.M)
+		h.nodes[n].links[l] = neigh
+		maxConn := h.cfg.M
+		if l == 0 {
+			maxConn = 2 * h.cfg.M
+		}
+		for _, nb := range neigh {
+			links := append(h.nodes[nb].links[l], n)
+			if len(links) > maxConn {
+				links = h.prune(nb, links, maxConn)
+			}
+			h.nodes[nb].links[l] = links
+		}
+		eps = eps[:0]
+		for _, c := range cands {
+			eps = append(eps, c.n)
+		}
+	}
+	if level > h.maxLevel {
+		h.entry, h.maxLevel = n, level
+	}
+	return nil
+}
+
+// Delete menandai id sebagai terhapus (tombstone); node tetap dipakai untuk navigasi
+// sampai Compact dipanggil. Mengembalikan false bila id tidak ada.
+func (h *HNSW) Delete(id int64) bool {
+	n, ok := h.ids[id]
+	if !ok {
+		return false
+	}
+	h.markDeleted(n)
+	return true
+}
+
+func (h *HNSW) markDeleted(n int32) {
+	delete(h.ids, h.nodes[n].id)
+	h.nodes[n].deleted = true
+	h.deleted++
+}
+
+// Compact membangun ulang graf hanya dari node aktif (membuang tombstone).
+func (h *HNSW) Compact() {
+	fresh := NewHNSW(h.cfg)
+	for i := range h.nodes {
+		if nd := h.nodes[i]; !nd.deleted {
+			_ = fresh.Add(nd.id, nd.vec)
+		}
+	}
+	*h = *fresh
+}
+
+// Search mengembalikan k tetangga terdekat q (cosine tertinggi dulu). ef <= 0 → EfSearch.
+func (h *HNSW) Search(q []float32, k, ef int) []Hit {
+	if h.entry < 0 || k <= 0 || len(q) != h.dim {
+		return nil
+	}
+	qv := make([]float32, len(q))
+	copy(qv, q)
+	Normalize(qv)
+	if ef <= 0 {
+		ef = h.cfg.EfSearch
+	}
+	// node terhapus ikut mengisi ef, jadi lebarkan pencarian sebanding porsinya
+	if h.deleted > 0 && len(h.ids) > 0 {
+		ef += ef * h.deleted / len(h.ids)
+	}
+	if ef < k {
+		ef = k
+	}
+
+	ep := h.entry
+	for l := h.maxLevel; l > 0; l-- {
+		ep = h.greedy(qv, ep, l)
+	}
+	cands := h.searchLayer(qv, []int32{ep}, ef, 0)
+	out := make([]Hit, 0, k)
+	for _, c := range cands {
+		if h.nodes[c.n].deleted {
+			continue
+		}
+		out = append(out, Hit{ID: h.nodes[c.n].id, Score: float64(c.s)})
+		if len(out) == k {
+			break
+		}
+	}
+	return out
+}
+
+// greedy turun ke tetangga paling mirip di layer l sampai tidak ada perbaikan.
+func (h *HNSW) greedy(q []float32, ep int32, l int) int32 {
+	best := h.sim(q, ep)
+	for changed := true; changed; {
+		changed = false
+		for _, nb := range h.nodes[ep].links[l] {
+			if s := h.sim(q, nb); s > best {
+				best, ep, changed = s, nb, true
+			}
+		}
+	}
+	return ep
+}
+
+type cand struct {
+	n int32
+	s float32
+}
+
+// candHeap: max-heap (similarity tertinggi di atas) bila max=true, selain itu min-heap.
+type candHeap struct {
+	items []cand
+	max   bool
+}
+
+func (c candHeap) Len() int { return len(c.items) }
+func (c candHeap) Less(i, j int) bool {
+	if c.max {
+		return c.items[i].s > c.items[j].s
+	}
+	return c.items[i].s < c.items[j].s
+}
+func (c candHeap) Swap(i, j int) { c.items[i], c.items[j] = c.items[j], c.items[i] }
+func (c *candHeap) Push(x any)   { c.items = append(c.items, x.(cand)) }
+func (c *candHeap) Pop() any {
+	old := c.items
+	x := old[len(old)-1]
+	c.items = old[:len(old)-1]
+	return x
+}
+
+// searchLayer: beam search di layer l; hasil urut similarity menurun (maks. ef).
+func (h *HNSW) searchLayer(q []float32, eps []int32, ef, l int) []cand {
+	visited := make(map[int32]struct{}, ef*4)
+	frontier := &candHeap{max: true}
+	results := &candHeap{}
+	for _, ep := range eps {
+		if _, ok := visited[ep]; ok {
+			continue
+		}
+		visited[ep] = struct{}{}
+		c := cand{ep, h.sim(q, ep)}
+		heap.Push(frontier, c)
+		heap.Push(results, c)
+		if results.Len() > ef {
+			heap.Pop(results)
+		}
+	}
+	for frontier.Len() > 0 {
+		c := heap.Pop(frontier).(cand)
+		if results.Len() >= ef && c.s < results.items[0].s {
+			break
+		}
+		for _, nb := range h.nodes[c.n].links[l] {
+			if _, ok := visited[nb]; ok {
+				continue
+			}
+			visited[nb] = struct{}{}
+			s := h.sim(q, nb)
+			if results.Len() < ef || s > results.items[0].s {
+				heap.Push(frontier, cand{nb, s})
+				heap.Push(results, cand{nb, s})
+				if results.Len() > ef {
+					heap.Pop(results)
+				}
+			}
+		}
+	}
+	out := results.items
+	sort.Slice(out, func(i, j int) bool { return out[i].s > out[j].s })
+	return out
+}
+
+// selectNeighbors: heuristik HNSW — kandidat dipilih bila lebih dekat ke q daripada ke
+// tetangga yang sudah terpilih (menjaga graf tetap menjangkau banyak arah), sisanya mengisi kuota.
+func (h *HNSW) selectNeighbors(cands []cand, m int) []int32 {
+	out := make([]int32, 0, m)
+	var pruned []int32
+	for _, c := range cands {
+		if len(out) >= m {
+			break
+		}
+		ok := true
+		for _, s := range out {
+			if h.sim(h.nodes[c.n].vec, s) > c.s {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			out = append(out, c.n)
+		} else {
+			pruned = append(pruned, c.n)
+		}
+	}
+	for _, p := range pruned {
+		if len(out) >= m {
+			break
+		}
+		out = append(out, p)
+	}
+	return out
+}
+
+// prune memangkas daftar tetangga node n menjadi maxConn dengan heuristik yang sama.
+func (h *HNSW) prune(n int32, links []int32, maxConn int) []int32 {
+	vec := h.nodes[n].vec
+	cands := make([]cand, len(links))
+	for i, nb := range links {
+		cands[i] = cand{nb, h.sim(vec, nb)}
+	}
+	sort.Slice(cands, func(i, j int) bool { return cands[i].s > cands[j].s })
+	return h.selectNeighbors(cands, maxConn)
+}
+
+func equalVec(a, b []float32) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// ---- snapshot ----
+
+const hnswSnapshotVersion = 1
+
+type hnswSnapshot struct {
+	Version  int
+	Cfg      HNSWConfig
+	Dim      int
+	Entry    int32
+	MaxLevel int
+	IDs      []int64
+	Vecs     []float32 // datar: len(IDs)*Dim
+	Links    [][][]int32
+	Deleted  []bool
+}
+
+// MarshalBinary menyimpan graf lengkap (dipakai gob untuk snapshot di disk).
+func (h *HNSW) MarshalBinary() ([]byte, error) {
+	s := hnswSnapshot{
+		Version: hnswSnapshotVersion, Cfg: h.cfg, Dim: h.dim, Entry: h.entry, MaxLevel: h.maxLevel,
+		IDs:     make([]int64, len(h.nodes)),
+		Vecs:    make([]float32, 0, len(h.nodes)*h.dim),
+		Links:   make([][][]int32, len(h.nodes)),
+		Deleted: make([]bool, len(h.nodes)),
+	}
+	for i, nd := range h.nodes {
+		s.IDs[i], s.Links[i], s.Deleted[i] = nd.id, nd.links, nd.deleted
+		s.Vecs = append(s.Vecs, nd.vec...)
+	}
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(s); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// UnmarshalBinary memuat graf dari MarshalBinary.
+func (h *HNSW) UnmarshalBinary(b []byte) error {
+	var s hnswSnapshot
+	if err := gob.NewDecoder(bytes.NewReader(b)).Decode(&s); err != nil {
+		return err
+	}
+	if s.Version != hnswSnapshotVersion {
+		return fmt.Errorf("hnsw: unsupported snapshot version %d", s.Version)
+	}
+	n := len(s.IDs)
+	if len(s.Vecs) != n*s.Dim || len(s.Links) != n || len(s.Deleted) != n || s.Entry >= int32(n) {
+		return fmt.Errorf("hnsw: corrupt snapshot")
+	}
+	fresh := NewHNSW(s.Cfg)
+	fresh.dim, fresh.entry, fresh.maxLevel = s.Dim, s.Entry, s.MaxLevel
+	fresh.nodes = make([]hnswNode, n)
+	for i := 0; i < n; i++ {
+		for _, links := range s.Links[i] {
+			for _, nb := range links {
+				if nb < 0 || int(nb) >= n {
+					return fmt.Errorf("hnsw: corrupt snapshot (link %d)", nb)
+				}
+			}
+		}
+		fresh.nodes[i] = hnswNode{id: s.IDs[i], vec: s.Vecs[i*s.Dim : (i+1)*s.Dim], links: s.Links[i], deleted: s.Deleted[i]}
+		if s.Deleted[i] {
+			fresh.deleted++
+		} else {
+			fresh.ids[s.IDs[i]] = int32(i)
+		}
+	}
+	// lanjutkan urutan level acak agar tidak mengulang dari seed awal
+	fresh.rng = rand.New(rand.NewSource(s.Cfg.Seed + int64(n)))
+	*h = *fresh
+	return nil
+}
@@ -0,0 +1,133 @@
+package vector_test
+
+import (
+	"bytes"
+	"encoding/gob"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"mcp-oilgas/pkg/vector"
+)
+
+func randVecs(n, dim int, seed int64) [][]float32 {
+	rng := rand.New(rand.NewSource(seed))
+	out := make([][]float32, n)
+	for i := range out {
+		v := make([]float32, dim)
+		for j := range v {
+			v[j] = float32(rng.NormFloat64())
+		}
+		out[i] = v
+	}
+	return out
+}
+
+func bruteForce(vecs [][]float32, skip map[int64]bool, q []float32, k int) []int64 {
+	type s struct {
+		id    int64
+		score float64
+	}
+	all := make([]s, 0, len(vecs))
+	for i, v := range vecs {
+		if !skip[int64(i)] {
+			all = append(all, s{int64(i), vector.Cosine(q, v)})
+		}
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].score > all[j].score })
+	out := make([]int64, 0, k)
+	for i := 0; i < k && i < len(all); i++ {
+		out = append(out, all[i].id)
+	}
+	return out
+}
+
+func recall(h *vector.HNSW, vecs [][]float32, skip map[int64]bool, queries [][]float32, k int) float64 {
+	var hit, total int
+	for _, q := range queries {
+		want := map[int64]bool{}
+		for _, id := range bruteForce(vecs, skip, q, k) {
+			want[id] = true
+		}
+		for _, got := range h.Search(q, k, 100) {
+			if skip[got.ID] {
+				return -1
+			}
+			if want[got.ID] {
+				hit++
+			}
+		}
+		total += len(want)
+	}
+	return float64(hit) / float64(total)
+}
+
+func TestHNSWRecallDeleteAndSnapshot(t *testing.T) {
+	const n, dim, k = 2000, 32, 10
+	vecs := randVecs(n, dim, 1)
+	queries := randVecs(50, dim, 2)
+
+	h := vector.NewHNSW(vector.HNSWConfig{M: 12, EfConstruction: 100})
+	for i, v := range vecs {
+		if err := h.Add(int64(i), v); err != nil {
+			t.Fatalf("add %d: %v", i, err)
+		}
+	}
+	if h.Len() != n || h.Dim() != dim {
+		t.Fatalf("len/dim = %d/%d", h.Len(), h.Dim())
+	}
+	if r := recall(h, vecs, nil, queries, k); r < 0.9 {
+		t.Fatalf("recall@%d too low: %.3f", k, r)
+	}
+
+	// nearest neighbour of a stored vector is itself
+	if hits := h.Search(vecs[42], 1, 0); len(hits) != 1 || hits[0].ID != 42 || hits[0].Score < 0.999 {
+		t.Fatalf("self search: %+v", hits)
+	}
+
+	// delete & replace
+	skip := map[int64]bool{}
+	for i := int64(0); i < n; i += 3 {
+		h.Delete(i)
+		skip[i] = true
+	}
+	if r := recall(h, vecs, skip, queries, k); r < 0.85 {
+		t.Fatalf("recall after delete: %.3f", r)
+	}
+	if err := h.Add(7, vecs[42]); err != nil {
+		t.Fatalf("replace: %v", err)
+	}
+	if err := h.Add(8, make([]float32, dim+1)); err == nil {
+		t.Fatalf("expected dimension mismatch error")
+	}
+
+	// snapshot roundtrip via gob (BinaryMarshaler)
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(h); err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+	var loaded vector.HNSW
+	if err := gob.NewDecoder(&buf).Decode(&loaded); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if loaded.Len() != h.Len() || loaded.Deleted() != h.Deleted() {
+		t.Fatalf("loaded len/deleted = %d/%d, want %d/%d", loaded.Len(), loaded.Deleted(), h.Len(), h.Deleted())
+	}
+	a, b := h.Search(queries[0], k, 0), loaded.Search(queries[0], k, 0)
+	if len(a) != len(b) {
+		t.Fatalf("search mismatch after load: %v vs %v", a, b)
+	}
+	for i := range a {
+		if a[i].ID != b[i].ID {
+			t.Fatalf("search mismatch after load: %v vs %v", a, b)
+		}
+	}
+
+	loaded.Compact()
+	if loaded.Deleted() != 0 || loaded.Len() != h.Len() {
+		t.Fatalf("compact: len=%d deleted=%d", loaded.Len(), loaded.Deleted())
+	}
+	if hits := loaded.Search(vecs[42], 2, 0); len(hits) != 2 || (hits[0].ID != 42 && hits[0].ID != 7) {
+		t.Fatalf("search after compact: %+v", hits)
+	}
+}
@@ -0,0 +1,53 @@
+// pkg/vector/vector.go
+// Utilitas vektor embedding (float32): normalisasi, dot product, cosine.
+// Client embeddings ada di internal/llm (Embedder).
+
+package vector
+
+import "math"
+
+// Norm mengembalikan panjang (L2) vektor.
+func Norm(v []float32) float64 {
+	var s float64
+	for _, x := range v {
+		s += float64(x) * float64(x)
+	}
+	return math.Sqrt(s)
+}
+
+// Normalize menormalisasi v in-place ke panjang 1 (vektor nol dibiarkan).
+func Normalize(v []float32) {
+	n := Norm(v)
+	if n == 0 {
+		return
+	}
+	inv := float32(1 / n)
+	for i := range v {
+		v[i] *= inv
+	}
+}
+
+// Dot mengembalikan dot product; panjang berbeda → dihitung sampai yang terpendek.
+func Dot(a, b []float32) float64 {
+	n := len(a)
+	if len(b) < n {
+		n = len(b)
+	}
+	var s float64
+	for i := 0; i < n; i++ {
+		s += float64(a[i]) * float64(b[i])
+	}
+	return s
+}
+
+// Cosine mengembalikan cosine similarity; 0 bila salah satu vektor nol atau dimensi berbeda.
+func Cosine(a, b []float32) float64 {
+	if len(a) == 0 || len(a) != len(b) {
+		return 0
+	}
+	na, nb := Norm(a), Norm(b)
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return Dot(a, b) / (na * nb)
+}